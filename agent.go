@@ -0,0 +1,215 @@
+package ociaitoopenai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/zalbiraw/ociaitoopenai/internal/agents"
+	"github.com/zalbiraw/ociaitoopenai/pkg/types"
+)
+
+// agentModelPrefix marks models answered by OCI Generative AI Agents; the agent endpoint OCID follows it.
+const agentModelPrefix = "agent:"
+
+// agentSessionHeader carries the agent session of a response. Clients send it back as
+// conversation_id, or in the request header of the same name, to continue the conversation.
+const agentSessionHeader = "X-Oci-Conversation-Id"
+
+// agentEndpointID returns the agent endpoint OCID of an "agent:" model.
+func agentEndpointID(model string) (string, bool) {
+	if !strings.HasPrefix(model, agentModelPrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(model, agentModelPrefix), true
+}
+
+// serveAgent answers a chat completion for an "agent:" model with OCI Generative AI Agents.
+// Agent sessions keep the conversation history, so only the last user message is sent.
+func (p *Proxy) serveAgent(rw http.ResponseWriter, req *http.Request, exchange *chatExchange) {
+	message := lastUserMessage(exchange.request.Messages)
+	if message == "" {
+		exchange.status = http.StatusBadRequest
+		writeError(rw, exchange.status, "The last message must be a user message with text content", "messages", "invalid_request")
+		return
+	}
+
+	log.Printf("[%s] serveAgent: Sending message to agent endpoint %s", p.name, exchange.agentEndpoint)
+	answer, sessionID, err := p.agentChat(req.Context(), exchange, message)
+	if err != nil {
+		log.Printf("[%s] ERROR: Failed to chat with agent endpoint %s: %v", p.name, exchange.agentEndpoint, err)
+		var statusErr *ociStatusError
+		switch {
+		case errors.As(err, &statusErr) && statusErr.status == http.StatusNotFound && exchange.request.ConversationID != "":
+			exchange.status = http.StatusNotFound
+			writeError(rw, exchange.status, fmt.Sprintf("The agent session `%s` does not exist or has expired.", exchange.request.ConversationID),
+				"conversation_id", "session_not_found")
+		case errors.As(err, &statusErr) && statusErr.status == http.StatusNotFound:
+			exchange.status = http.StatusNotFound
+			writeError(rw, exchange.status, fmt.Sprintf("The model `%s` does not exist or you do not have access to it.", exchange.model),
+				"model", "model_not_found")
+		default:
+			exchange.status = http.StatusBadGateway
+			writeError(rw, exchange.status, fmt.Sprintf("The agent request failed: %v", err), "", "agent_request_failed")
+		}
+		return
+	}
+	rw.Header().Set(agentSessionHeader, sessionID)
+
+	if exchange.stream {
+		p.writeAgentStream(rw, exchange, answer.Content.Text)
+		return
+	}
+
+	resp := p.transformer.ToOpenAIResponse(types.OracleCloudResponse{
+		ChatResponse: types.OracleCloudChatResponse{Text: answer.Content.Text, FinishReason: "COMPLETE"},
+	}, exchange.responseModel)
+	resp.Citations = answer.Content.Citations
+	body, err := json.Marshal(resp)
+	if err != nil {
+		log.Printf("[%s] ERROR: Failed to marshal agent response: %v", p.name, err)
+		p.recordFailure(metricMarshalFailures, exchange.model, http.StatusInternalServerError)
+		exchange.status = http.StatusInternalServerError
+		writeFailure(rw, err)
+		return
+	}
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	_, _ = rw.Write(body)
+	exchange.status = http.StatusOK
+	exchange.responseBody = body
+}
+
+// writeAgentStream sends an agent answer as a stream of chunks, for clients that requested one.
+func (p *Proxy) writeAgentStream(rw http.ResponseWriter, exchange *chatExchange, text string) {
+	stream := p.transformer.NewStream(exchange.responseModel, exchange.includeUsage)
+	sw := newStreamWriter(rw, stream, func(err error) {
+		log.Printf("[%s] ERROR: Failed to stream agent response: %v", p.name, err)
+	})
+	p.declareUsageTrailers(sw.Header())
+	sw.WriteHeader(http.StatusOK)
+	exchange.status = http.StatusOK
+	defer func() { exchange.responseBody = sw.output.Bytes() }()
+
+	for _, chunk := range stream.Convert(types.OracleCloudStreamEvent{Text: text, FinishReason: "COMPLETE"}) {
+		if err := sw.writeEvent(chunk); err != nil {
+			log.Printf("[%s] ERROR: Failed to stream agent response: %v", p.name, err)
+			return
+		}
+	}
+	if err := sw.finish(); err != nil {
+		log.Printf("[%s] ERROR: Failed to stream agent response: %v", p.name, err)
+	}
+	var usage *types.ChatCompletionUsage
+	if reported, ok := stream.Usage(); ok {
+		usage = &reported
+	}
+	p.setUsageTrailers(rw.Header(), usage, stream.FinishReason())
+}
+
+// agentChat sends a message to the exchange's agent endpoint and returns the answer and the
+// session it belongs to. The client's conversation_id names the session; otherwise, a session
+// is reused for requests with the same tenant and user, or created. The user field is chosen by
+// the client, so sessions are only reused by user within an identified tenant.
+func (p *Proxy) agentChat(ctx context.Context, exchange *chatExchange, message string) (*types.OCIAgentMessage, string, error) {
+	user := exchange.request.User
+	if exchange.tenant == "" {
+		user = ""
+	}
+	key := agents.Key(exchange.tenant, user, exchange.agentEndpoint)
+
+	sessionID, cached := exchange.request.ConversationID, false
+	if sessionID == "" && user != "" {
+		sessionID, cached = p.sessions.Get(key)
+	}
+	if sessionID == "" {
+		var err error
+		if sessionID, err = p.createAgentSession(ctx, exchange.agentEndpoint, key, user); err != nil {
+			return nil, "", err
+		}
+	}
+
+	answer, err := p.sendAgentMessage(ctx, exchange.agentEndpoint, sessionID, message)
+	var statusErr *ociStatusError
+	if cached && errors.As(err, &statusErr) && statusErr.status == http.StatusNotFound {
+		// The endpoint expired the session before it expired here
+		log.Printf("[%s] serveAgent: Agent session %s expired, creating a new one", p.name, sessionID)
+		p.sessions.Delete(key)
+		if sessionID, err = p.createAgentSession(ctx, exchange.agentEndpoint, key, user); err != nil {
+			return nil, "", err
+		}
+		answer, err = p.sendAgentMessage(ctx, exchange.agentEndpoint, sessionID, message)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return answer, sessionID, nil
+}
+
+// createAgentSession creates a session on an agent endpoint, remembering it for the user, if any.
+func (p *Proxy) createAgentSession(ctx context.Context, endpointID, key, user string) (string, error) {
+	var session types.OCIAgentSession
+	if err := p.agentJSON(ctx, "/20240531/agentEndpoints/"+url.PathEscape(endpointID)+"/sessions",
+		types.OCIAgentSessionRequest{DisplayName: "ociai-" + randomID()}, &session); err != nil {
+		return "", fmt.Errorf("failed to create agent session: %w", err)
+	}
+	if user != "" {
+		p.sessions.Put(key, session.ID)
+	}
+	return session.ID, nil
+}
+
+// sendAgentMessage sends a user message to an agent session.
+func (p *Proxy) sendAgentMessage(ctx context.Context, endpointID, sessionID, message string) (*types.OCIAgentMessage, error) {
+	var resp types.OCIAgentChatResponse
+	if err := p.agentJSON(ctx, "/20240531/agentEndpoints/"+url.PathEscape(endpointID)+"/actions/chat",
+		types.OCIAgentChatRequest{UserMessage: message, SessionID: sessionID}, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Message == nil {
+		return nil, fmt.Errorf("agent endpoint %s returned no message", endpointID)
+	}
+	return resp.Message, nil
+}
+
+// agentJSON posts in to the agent runtime API as JSON and decodes the response into out.
+func (p *Proxy) agentJSON(ctx context.Context, apiPath string, in, out interface{}) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	capture, err := p.serviceCall(ctx, http.MethodPost, "https://"+p.agentHost()+apiPath, body, "application/json")
+	if err != nil {
+		return err
+	}
+	return p.decodeResponse(capture.body.Bytes(), capture.header, out)
+}
+
+// agentHost returns the OCI Generative AI Agents runtime endpoint host.
+func (p *Proxy) agentHost() string {
+	if p.config.Agents.Host != "" {
+		return p.config.Agents.Host
+	}
+	region := p.config.Agents.Region
+	if region == "" {
+		region = p.config.Region
+	}
+	return fmt.Sprintf("agent-runtime.generativeai.%s.oci.oraclecloud.com", region)
+}
+
+// lastUserMessage returns the text of the last message, if it is the user's.
+func lastUserMessage(messages []types.ChatCompletionMessage) string {
+	if len(messages) == 0 {
+		return ""
+	}
+	last := messages[len(messages)-1]
+	if last.Role != "user" {
+		return ""
+	}
+	return last.Content
+}
@@ -0,0 +1,675 @@
+package ociaitoopenai
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zalbiraw/ociaitoopenai/internal/assistants"
+	"github.com/zalbiraw/ociaitoopenai/pkg/types"
+)
+
+// Page sizes of the emulated Assistants API list endpoints, as OpenAI's.
+const (
+	defaultListLimit = 20
+	maxListLimit     = 100
+)
+
+// assistantsPath returns the segments of an Assistants API route from "assistants" or
+// "threads" on, with those two lowercased, or nil for other routes.
+func assistantsPath(route string) []string {
+	segments := strings.Split(strings.Trim(route, "/"), "/")
+	for i, segment := range segments {
+		if strings.EqualFold(segment, "assistants") || strings.EqualFold(segment, "threads") {
+			path := segments[i:]
+			path[0] = strings.ToLower(segment)
+			return path
+		}
+	}
+	return nil
+}
+
+// assistantsOwner returns the owner of the Assistants API objects of a request: a hash of its
+// tenant, as tenant values may be virtual API keys, or nothing when the request has no tenant.
+func (p *Proxy) assistantsOwner(req *http.Request) string {
+	tenant := p.tenant(req)
+	if tenant == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(tenant))
+	return hex.EncodeToString(sum[:])
+}
+
+// serveAssistants serves the emulated Assistants API. Objects are kept by the plugin, scoped to
+// the tenant that created them, and runs send the thread through the chat completion flow in the
+// background.
+func (p *Proxy) serveAssistants(rw http.ResponseWriter, req *http.Request, path []string) {
+	resource := path[0]
+	owner := p.assistantsOwner(req)
+	if owner == "" {
+		// Requests without a tenant would otherwise share their objects with each other
+		writeError(rw, http.StatusBadRequest, fmt.Sprintf("The %s header is required for the Assistants API.", p.config.TenantHeader), "", "missing_tenant")
+		return
+	}
+	segment := func(i int) string {
+		if i < len(path) {
+			return strings.ToLower(path[i])
+		}
+		return ""
+	}
+
+	switch {
+	case resource == "assistants" && len(path) == 1:
+		p.serveAssistantCollection(rw, req, owner)
+	case resource == "assistants" && len(path) == 2:
+		p.serveAssistant(rw, req, owner, path[1])
+	case resource == "threads" && len(path) == 1:
+		if !allowMethod(rw, req, http.MethodPost) {
+			return
+		}
+		p.createThread(rw, req, owner)
+	case resource == "threads" && len(path) == 2 && segment(1) == "runs":
+		if !allowMethod(rw, req, http.MethodPost) {
+			return
+		}
+		p.createRun(rw, req, owner, "")
+	case resource == "threads" && len(path) == 2:
+		p.serveThread(rw, req, owner, path[1])
+	case resource == "threads" && len(path) == 3 && segment(2) == "messages":
+		p.serveMessages(rw, req, owner, path[1])
+	case resource == "threads" && len(path) == 3 && segment(2) == "runs":
+		p.serveRuns(rw, req, owner, path[1])
+	case resource == "threads" && len(path) == 4 && segment(2) == "runs":
+		if !allowMethod(rw, req, http.MethodGet) {
+			return
+		}
+		run, ok := p.assistants.Run(owner, path[1], path[3])
+		if !ok {
+			writeNotFound(rw, "run", path[3])
+			return
+		}
+		writeJSON(rw, http.StatusOK, run)
+	default:
+		writeError(rw, http.StatusNotFound, fmt.Sprintf("Unknown Assistants API endpoint %s %s.", req.Method, req.URL.Path), "", "unknown_url")
+	}
+}
+
+func (p *Proxy) serveAssistantCollection(rw http.ResponseWriter, req *http.Request, owner string) {
+	switch req.Method {
+	case http.MethodGet:
+		list := p.assistants.Assistants(owner)
+		indexes, hasMore, ok := paginate(rw, req, len(list), func(i int) string { return list[i].ID })
+		if !ok {
+			return
+		}
+		page := make([]types.Assistant, 0, len(indexes))
+		for _, i := range indexes {
+			page = append(page, list[i])
+		}
+		writeList(rw, page, len(page), func(i int) string { return page[i].ID }, hasMore)
+	case http.MethodPost:
+		var assistantReq types.AssistantRequest
+		if !decodeAssistantsRequest(rw, req, &assistantReq) {
+			return
+		}
+		if assistantReq.Model == "" {
+			writeError(rw, http.StatusBadRequest, "model is required", "model", "invalid_request")
+			return
+		}
+		if len(assistantReq.Tools) > 0 {
+			writeError(rw, http.StatusBadRequest, "Tools are not supported by the Assistants API emulation", "tools", "unsupported_parameter")
+			return
+		}
+		assistant, err := p.assistants.CreateAssistant(owner, types.Assistant{
+			Name:         assistantReq.Name,
+			Description:  assistantReq.Description,
+			Model:        assistantReq.Model,
+			Instructions: assistantReq.Instructions,
+			Tools:        []json.RawMessage{},
+			Metadata:     metadataOrEmpty(assistantReq.Metadata),
+			Temperature:  assistantReq.Temperature,
+			TopP:         assistantReq.TopP,
+		})
+		p.writeStored(rw, assistant, err)
+	default:
+		allowMethod(rw, req, http.MethodGet, http.MethodPost)
+	}
+}
+
+func (p *Proxy) serveAssistant(rw http.ResponseWriter, req *http.Request, owner, id string) {
+	switch req.Method {
+	case http.MethodGet:
+		assistant, ok := p.assistants.Assistant(owner, id)
+		if !ok {
+			writeNotFound(rw, "assistant", id)
+			return
+		}
+		writeJSON(rw, http.StatusOK, assistant)
+	case http.MethodDelete:
+		p.writeDeleted(rw, id, "assistant", p.assistants.DeleteAssistant(owner, id))
+	default:
+		allowMethod(rw, req, http.MethodGet, http.MethodDelete)
+	}
+}
+
+func (p *Proxy) createThread(rw http.ResponseWriter, req *http.Request, owner string) {
+	var threadReq types.ThreadRequest
+	if !decodeAssistantsRequest(rw, req, &threadReq) {
+		return
+	}
+	thread, ok := p.storeThread(rw, owner, threadReq)
+	if !ok {
+		return
+	}
+	writeJSON(rw, http.StatusOK, thread)
+}
+
+// storeThread creates a thread with its initial messages, writing an error response on failure.
+func (p *Proxy) storeThread(rw http.ResponseWriter, owner string, threadReq types.ThreadRequest) (types.Thread, bool) {
+	messages := make([]types.ThreadMessage, 0, len(threadReq.Messages))
+	for i, messageReq := range threadReq.Messages {
+		message, ok := threadMessage(rw, messageReq, fmt.Sprintf("messages[%d]", i))
+		if !ok {
+			return types.Thread{}, false
+		}
+		messages = append(messages, message)
+	}
+	thread, err := p.assistants.CreateThread(owner, types.Thread{Metadata: metadataOrEmpty(threadReq.Metadata)}, messages)
+	if err != nil {
+		p.writeStoreError(rw, err)
+		return types.Thread{}, false
+	}
+	return thread, true
+}
+
+func (p *Proxy) serveThread(rw http.ResponseWriter, req *http.Request, owner, id string) {
+	switch req.Method {
+	case http.MethodGet:
+		thread, ok := p.assistants.Thread(owner, id)
+		if !ok {
+			writeNotFound(rw, "thread", id)
+			return
+		}
+		writeJSON(rw, http.StatusOK, thread)
+	case http.MethodDelete:
+		p.writeDeleted(rw, id, "thread", p.assistants.DeleteThread(owner, id))
+	default:
+		allowMethod(rw, req, http.MethodGet, http.MethodDelete)
+	}
+}
+
+func (p *Proxy) serveMessages(rw http.ResponseWriter, req *http.Request, owner, threadID string) {
+	switch req.Method {
+	case http.MethodGet:
+		list, err := p.assistants.Messages(owner, threadID)
+		if err != nil {
+			writeNotFound(rw, "thread", threadID)
+			return
+		}
+		runID := req.URL.Query().Get("run_id")
+		if runID != "" {
+			var filtered []types.ThreadMessage
+			for _, message := range list {
+				if message.RunID != nil && *message.RunID == runID {
+					filtered = append(filtered, message)
+				}
+			}
+			list = filtered
+		}
+		indexes, hasMore, ok := paginate(rw, req, len(list), func(i int) string { return list[i].ID })
+		if !ok {
+			return
+		}
+		page := make([]types.ThreadMessage, 0, len(indexes))
+		for _, i := range indexes {
+			page = append(page, list[i])
+		}
+		writeList(rw, page, len(page), func(i int) string { return page[i].ID }, hasMore)
+	case http.MethodPost:
+		var messageReq types.ThreadMessageRequest
+		if !decodeAssistantsRequest(rw, req, &messageReq) {
+			return
+		}
+		message, ok := threadMessage(rw, messageReq, "")
+		if !ok {
+			return
+		}
+		message.ThreadID = threadID
+		message, err := p.assistants.AddMessage(owner, message)
+		if errors.Is(err, assistants.ErrNotFound) {
+			writeNotFound(rw, "thread", threadID)
+			return
+		}
+		p.writeStored(rw, message, err)
+	default:
+		allowMethod(rw, req, http.MethodGet, http.MethodPost)
+	}
+}
+
+func (p *Proxy) serveRuns(rw http.ResponseWriter, req *http.Request, owner, threadID string) {
+	switch req.Method {
+	case http.MethodGet:
+		list, err := p.assistants.Runs(owner, threadID)
+		if err != nil {
+			writeNotFound(rw, "thread", threadID)
+			return
+		}
+		indexes, hasMore, ok := paginate(rw, req, len(list), func(i int) string { return list[i].ID })
+		if !ok {
+			return
+		}
+		page := make([]types.Run, 0, len(indexes))
+		for _, i := range indexes {
+			page = append(page, list[i])
+		}
+		writeList(rw, page, len(page), func(i int) string { return page[i].ID }, hasMore)
+	case http.MethodPost:
+		p.createRun(rw, req, owner, threadID)
+	default:
+		allowMethod(rw, req, http.MethodGet, http.MethodPost)
+	}
+}
+
+// createRun creates a run on a thread and executes it in the background. Without a thread ID,
+// the thread is created from the request first.
+func (p *Proxy) createRun(rw http.ResponseWriter, req *http.Request, owner, threadID string) {
+	var runReq types.RunRequest
+	if !decodeAssistantsRequest(rw, req, &runReq) {
+		return
+	}
+	if runReq.Stream {
+		writeError(rw, http.StatusBadRequest, "Streaming runs are not supported by the Assistants API emulation", "stream", "unsupported_parameter")
+		return
+	}
+	assistant, ok := p.assistants.Assistant(owner, runReq.AssistantID)
+	if !ok {
+		writeNotFound(rw, "assistant", runReq.AssistantID)
+		return
+	}
+	additional := make([]types.ThreadMessage, 0, len(runReq.AdditionalMessages))
+	for i, messageReq := range runReq.AdditionalMessages {
+		message, ok := threadMessage(rw, messageReq, fmt.Sprintf("additional_messages[%d]", i))
+		if !ok {
+			return
+		}
+		additional = append(additional, message)
+	}
+
+	if threadID == "" {
+		threadReq := types.ThreadRequest{}
+		if runReq.Thread != nil {
+			threadReq = *runReq.Thread
+		}
+		thread, ok := p.storeThread(rw, owner, threadReq)
+		if !ok {
+			return
+		}
+		threadID = thread.ID
+	}
+	for _, message := range additional {
+		message.ThreadID = threadID
+		if _, err := p.assistants.AddMessage(owner, message); err != nil {
+			p.writeRunStoreError(rw, err, threadID)
+			return
+		}
+	}
+
+	run := types.Run{
+		ThreadID:     threadID,
+		AssistantID:  assistant.ID,
+		Model:        assistant.Model,
+		Instructions: assistant.Instructions,
+		Tools:        []json.RawMessage{},
+		Metadata:     metadataOrEmpty(runReq.Metadata),
+		Temperature:  assistant.Temperature,
+		TopP:         assistant.TopP,
+	}
+	if runReq.Model != "" {
+		run.Model = runReq.Model
+	}
+	if runReq.Instructions != "" {
+		run.Instructions = runReq.Instructions
+	}
+	if runReq.AdditionalInstructions != "" {
+		run.Instructions = strings.TrimSpace(run.Instructions + "\n\n" + runReq.AdditionalInstructions)
+	}
+	if runReq.Temperature != nil {
+		run.Temperature = runReq.Temperature
+	}
+	if runReq.TopP != nil {
+		run.TopP = runReq.TopP
+	}
+
+	run, err := p.assistants.CreateRun(owner, run)
+	if err != nil {
+		p.writeRunStoreError(rw, err, threadID)
+		return
+	}
+	log.Printf("[%s] createRun: Starting run %s on thread %s with %s", p.name, run.ID, threadID, run.Model)
+	go p.executeRun(owner, run, req.Header.Clone())
+	writeJSON(rw, http.StatusOK, run)
+}
+
+// executeRun sends the thread to the run's model as a chat completion, with the headers of the
+// request that created the run, and records the answer or the failure. Runs still going when the
+// plugin shuts down fail.
+func (p *Proxy) executeRun(owner string, run types.Run, header http.Header) {
+	timeout, _ := time.ParseDuration(p.config.Assistants.RunTimeout)
+	ctx, cancel := context.WithTimeout(p.ctx, timeout)
+	defer cancel()
+
+	started := time.Now().Unix()
+	run.Status = assistants.RunInProgress
+	run.StartedAt = &started
+	if err := p.assistants.UpdateRun(run); err != nil {
+		log.Printf("[%s] ERROR: Failed to start run %s: %v", p.name, run.ID, err)
+		return
+	}
+
+	answer, usage, err := p.runChat(ctx, owner, run, header)
+	if err != nil {
+		log.Printf("[%s] ERROR: Run %s failed: %v", p.name, run.ID, err)
+		failed := time.Now().Unix()
+		run.Status = assistants.RunFailed
+		run.FailedAt = &failed
+		run.LastError = &types.RunError{Code: "server_error", Message: err.Error()}
+		var chatErr *runChatError
+		if errors.As(err, &chatErr) && chatErr.status == http.StatusTooManyRequests {
+			run.LastError.Code = "rate_limit_exceeded"
+		}
+		if err := p.assistants.UpdateRun(run); err != nil {
+			log.Printf("[%s] ERROR: Failed to record failure of run %s: %v", p.name, run.ID, err)
+		}
+		return
+	}
+
+	completed := time.Now().Unix()
+	run.Status = assistants.RunCompleted
+	run.CompletedAt = &completed
+	run.Usage = usage
+	if err := p.assistants.CompleteRun(run, types.ThreadMessage{
+		Role:        "assistant",
+		Content:     []types.ThreadMessageContent{textContent(answer)},
+		AssistantID: &run.AssistantID,
+		RunID:       &run.ID,
+		Metadata:    map[string]string{},
+	}); err != nil {
+		log.Printf("[%s] ERROR: Failed to record answer of run %s: %v", p.name, run.ID, err)
+	}
+}
+
+// runChatError reports a chat completion of a run that failed with an error response.
+type runChatError struct {
+	status  int
+	message string
+}
+
+func (e *runChatError) Error() string {
+	if e.message == "" {
+		return fmt.Sprintf("the chat completion failed with status %d", e.status)
+	}
+	return e.message
+}
+
+// runChat answers the run's thread through the chat completion flow.
+func (p *Proxy) runChat(ctx context.Context, owner string, run types.Run, header http.Header) (string, *types.ChatCompletionUsage, error) {
+	messages, err := p.assistants.Messages(owner, run.ThreadID)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read thread messages: %w", err)
+	}
+	chatReq := types.ChatCompletionRequest{Model: run.Model}
+	if run.Instructions != "" {
+		chatReq.Messages = append(chatReq.Messages, types.ChatCompletionMessage{Role: "system", Content: run.Instructions})
+	}
+	for _, message := range messages {
+		chatReq.Messages = append(chatReq.Messages, types.ChatCompletionMessage{Role: message.Role, Content: message.Text()})
+	}
+	chatReq.Temperature = run.Temperature
+	if run.TopP != nil {
+		chatReq.TopP = *run.TopP
+	}
+	body, err := json.Marshal(chatReq)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to marshal chat request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "/v1/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return "", nil, err
+	}
+	req.Header = header
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Del("Content-Length")
+	req.Header.Del("Accept-Encoding")
+
+	capture := newCaptureWriter()
+	p.serveChat(capture, req)
+	if capture.statusCode != http.StatusOK {
+		var errResp types.ErrorResponse
+		_ = p.decodeResponse(capture.body.Bytes(), capture.header, &errResp)
+		return "", nil, &runChatError{status: capture.statusCode, message: errResp.Error.Message}
+	}
+	var resp types.ChatCompletionResponse
+	if err := p.decodeResponse(capture.body.Bytes(), capture.header, &resp); err != nil {
+		return "", nil, fmt.Errorf("failed to parse chat response: %w", err)
+	}
+	if len(resp.Choices) == 0 {
+		return "", nil, fmt.Errorf("the chat completion returned no choices")
+	}
+	return resp.Choices[0].Message.Content, &resp.Usage, nil
+}
+
+// threadMessage converts a create message request, writing an error response when it is invalid.
+// param prefixes the parameter names in errors.
+func threadMessage(rw http.ResponseWriter, messageReq types.ThreadMessageRequest, param string) (types.ThreadMessage, bool) {
+	field := func(name string) string {
+		if param == "" {
+			return name
+		}
+		return param + "." + name
+	}
+	if messageReq.Role != "user" && messageReq.Role != "assistant" {
+		writeError(rw, http.StatusBadRequest, "role must be user or assistant", field("role"), "invalid_request")
+		return types.ThreadMessage{}, false
+	}
+
+	var text string
+	if err := json.Unmarshal(messageReq.Content, &text); err != nil {
+		var parts []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		}
+		if err := json.Unmarshal(messageReq.Content, &parts); err != nil {
+			writeError(rw, http.StatusBadRequest, "content must be a string or an array of content parts", field("content"), "invalid_request")
+			return types.ThreadMessage{}, false
+		}
+		for _, part := range parts {
+			if part.Type != "text" {
+				writeError(rw, http.StatusBadRequest,
+					fmt.Sprintf("Content parts of type %q are not supported by the Assistants API emulation", part.Type),
+					field("content"), "unsupported_parameter")
+				return types.ThreadMessage{}, false
+			}
+			text += part.Text
+		}
+	}
+
+	return types.ThreadMessage{
+		Role:     messageReq.Role,
+		Content:  []types.ThreadMessageContent{textContent(text)},
+		Metadata: metadataOrEmpty(messageReq.Metadata),
+	}, true
+}
+
+func textContent(text string) types.ThreadMessageContent {
+	return types.ThreadMessageContent{Type: "text", Text: types.ThreadMessageText{Value: text, Annotations: []json.RawMessage{}}}
+}
+
+// metadataOrEmpty returns the metadata, or an empty map, which OpenAI returns rather than null.
+func metadataOrEmpty(metadata map[string]string) map[string]string {
+	if metadata == nil {
+		return map[string]string{}
+	}
+	return metadata
+}
+
+// decodeAssistantsRequest reads a JSON request body into v, writing an error response on
+// failure. An empty body leaves v unchanged.
+func decodeAssistantsRequest(rw http.ResponseWriter, req *http.Request, v interface{}) bool {
+	body, err := readRequestBody(req)
+	if err != nil {
+		writeBodyError(rw, err)
+		return false
+	}
+	_ = req.Body.Close()
+	body, err = jsonRequestBody(req.Header.Get("Content-Type"), body)
+	if err != nil {
+		writeError(rw, http.StatusUnsupportedMediaType, err.Error(), "", "unsupported_media_type")
+		return false
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		return true
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		writeError(rw, http.StatusBadRequest, fmt.Sprintf("Failed to parse request: %v", err), "", "invalid_request")
+		return false
+	}
+	return true
+}
+
+// paginate selects the page of a list requested with the limit, order and after query
+// parameters, writing an error response when they are invalid. The list is oldest first, and
+// pages are newest first by default, as OpenAI's.
+func paginate(rw http.ResponseWriter, req *http.Request, n int, id func(i int) string) ([]int, bool, bool) {
+	query := req.URL.Query()
+	limit := defaultListLimit
+	if value := query.Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxListLimit {
+			writeError(rw, http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", maxListLimit), "limit", "invalid_request")
+			return nil, false, false
+		}
+		limit = parsed
+	}
+	order := query.Get("order")
+	if order == "" {
+		order = "desc"
+	}
+	if order != "asc" && order != "desc" {
+		writeError(rw, http.StatusBadRequest, "order must be asc or desc", "order", "invalid_request")
+		return nil, false, false
+	}
+
+	indexes := make([]int, 0, n)
+	for i := 0; i < n; i++ {
+		if order == "asc" {
+			indexes = append(indexes, i)
+		} else {
+			indexes = append(indexes, n-1-i)
+		}
+	}
+	if after := query.Get("after"); after != "" {
+		for i, index := range indexes {
+			if id(index) == after {
+				indexes = indexes[i+1:]
+				break
+			}
+		}
+	}
+	if len(indexes) > limit {
+		return indexes[:limit], true, true
+	}
+	return indexes, false, true
+}
+
+// writeList writes a page of objects as an OpenAI list.
+func writeList(rw http.ResponseWriter, data interface{}, n int, id func(i int) string, hasMore bool) {
+	list := types.ListResponse{Object: "list", Data: data, HasMore: hasMore}
+	if n > 0 {
+		first, last := id(0), id(n-1)
+		list.FirstID, list.LastID = &first, &last
+	}
+	writeJSON(rw, http.StatusOK, list)
+}
+
+// writeStored writes a newly stored object, or the error that kept it from being stored.
+func (p *Proxy) writeStored(rw http.ResponseWriter, v interface{}, err error) {
+	if err != nil {
+		p.writeStoreError(rw, err)
+		return
+	}
+	writeJSON(rw, http.StatusOK, v)
+}
+
+func (p *Proxy) writeDeleted(rw http.ResponseWriter, id, object string, err error) {
+	if errors.Is(err, assistants.ErrNotFound) {
+		writeNotFound(rw, object, id)
+		return
+	}
+	if err != nil {
+		p.writeStoreError(rw, err)
+		return
+	}
+	writeJSON(rw, http.StatusOK, types.DeletedResponse{ID: id, Object: object + ".deleted", Deleted: true})
+}
+
+// writeRunStoreError writes the error that kept a run or its messages from being stored.
+func (p *Proxy) writeRunStoreError(rw http.ResponseWriter, err error, threadID string) {
+	switch {
+	case errors.Is(err, assistants.ErrNotFound):
+		writeNotFound(rw, "thread", threadID)
+	case errors.Is(err, assistants.ErrRunActive):
+		writeError(rw, http.StatusBadRequest, fmt.Sprintf("Thread %s already has an active run.", threadID), "", "run_active")
+	default:
+		p.writeStoreError(rw, err)
+	}
+}
+
+func (p *Proxy) writeStoreError(rw http.ResponseWriter, err error) {
+	if errors.Is(err, assistants.ErrRunActive) {
+		writeError(rw, http.StatusBadRequest, "Messages cannot be added to a thread while a run is active.", "", "run_active")
+		return
+	}
+	if errors.Is(err, assistants.ErrLimitReached) {
+		writeError(rw, http.StatusBadRequest, fmt.Sprintf(
+			"Storage limit reached: at most %d assistants and threads per tenant, and %d messages per thread. Delete objects you no longer need.",
+			p.config.Assistants.MaxObjects, p.config.Assistants.MaxMessages), "", "limit_reached")
+		return
+	}
+	log.Printf("[%s] ERROR: Failed to store Assistants API object: %v", p.name, err)
+	writeError(rw, http.StatusInternalServerError, "Failed to store the object", "", "internal_error")
+}
+
+func writeNotFound(rw http.ResponseWriter, object, id string) {
+	writeError(rw, http.StatusNotFound, fmt.Sprintf("No %s found with id '%s'.", object, url.PathEscape(id)), "", "not_found")
+}
+
+// allowMethod reports whether the request uses one of the allowed methods, writing a 405
+// response when it does not.
+func allowMethod(rw http.ResponseWriter, req *http.Request, methods ...string) bool {
+	for _, method := range methods {
+		if req.Method == method {
+			return true
+		}
+	}
+	rw.Header().Set("Allow", strings.Join(methods, ", "))
+	writeError(rw, http.StatusMethodNotAllowed, fmt.Sprintf("Method %s is not allowed here", req.Method), "", "method_not_allowed")
+	return false
+}
+
+func writeJSON(rw http.ResponseWriter, status int, v interface{}) {
+	body, _ := json.Marshal(v)
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(status)
+	_, _ = rw.Write(body)
+}
@@ -0,0 +1,58 @@
+package ociaitoopenai
+
+import (
+	"fmt"
+
+	"github.com/zalbiraw/ociaitoopenai/pkg/types"
+)
+
+// unsupportedField locates a request field OCI cannot honour, with guidance for the client.
+type unsupportedField struct {
+	param   string
+	message string
+}
+
+// unsupportedModality returns the first requested output modality other than text and audio,
+// which is reported by unsupportedAudio, or nil. Newer SDKs send "modalities": ["text"] by default.
+func unsupportedModality(req types.ChatCompletionRequest) *unsupportedField {
+	for i, modality := range req.Modalities {
+		if modality != "text" && modality != "audio" {
+			return &unsupportedField{
+				param:   "modalities",
+				message: fmt.Sprintf(`The modality %q at modalities[%d] is not supported by OCI Generative AI; request "modalities": ["text"].`, modality, i),
+			}
+		}
+	}
+	return nil
+}
+
+// unsupportedAudio returns the first audio input or output request of an OpenAI request, or nil.
+// OCI GenAI chat models only accept text and images and only produce text.
+func unsupportedAudio(req types.ChatCompletionRequest) *unsupportedField {
+	for _, modality := range req.Modalities {
+		if modality == "audio" {
+			return &unsupportedField{
+				param:   "modalities",
+				message: `Audio output is not supported by OCI Generative AI; request "modalities": ["text"] and convert the text to speech separately.`,
+			}
+		}
+	}
+	if len(req.Audio) > 0 && string(req.Audio) != "null" {
+		return &unsupportedField{
+			param:   "audio",
+			message: "Audio output is not supported by OCI Generative AI; remove the audio parameter and convert the text to speech separately.",
+		}
+	}
+
+	for i, msg := range req.Messages {
+		for j, part := range msg.Parts {
+			if part.Type == "input_audio" {
+				return &unsupportedField{
+					param:   fmt.Sprintf("messages[%d].content[%d]", i, j),
+					message: "Audio input is not supported by OCI Generative AI; transcribe the audio and send the transcript as a text content part.",
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package ociaitoopenai
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/zalbiraw/ociaitoopenai/internal/metrics"
+)
+
+// transformBypassedHeader explains why a chat response was returned in OCI format.
+const transformBypassedHeader = "X-Transform-Bypassed"
+
+// transformBypassed reports whether a successful OCI response is too large to transform.
+func (p *Proxy) transformBypassed(captured *responseWriter) bool {
+	limit := p.config.TransformMaxBytes
+	return limit > 0 && captured.statusCode == http.StatusOK && captured.body.Len() > limit
+}
+
+// bypassTransform returns an OCI response untransformed, decompressed if the client does not
+// accept its encoding, with a header telling the client why.
+func (p *Proxy) bypassTransform(rw http.ResponseWriter, captured *responseWriter, exchange *chatExchange) {
+	size := captured.body.Len()
+	log.Printf("[%s] processResponse: Returning %d byte response from model %s untransformed, above transformMaxBytes %d",
+		p.name, size, exchange.model, p.config.TransformMaxBytes)
+	p.metrics.Inc(metricTransformBypasses, metrics.Labels{"model": p.modelLabel(exchange.model)})
+
+	body := p.clientEncodedBody(captured.body.Bytes(), captured.Header(), exchange.acceptEncoding)
+	rw.Header().Set(transformBypassedHeader, fmt.Sprintf("response of %d bytes exceeds transformMaxBytes %d", size, p.config.TransformMaxBytes))
+	p.setAccessLogHeaders(rw.Header(), exchange, nil)
+	setAdjustedHeader(rw.Header(), exchange)
+	rw.WriteHeader(http.StatusOK)
+	_, _ = rw.Write(body)
+
+	exchange.status = http.StatusOK
+	exchange.responseBody = body
+	if decompressed, err := p.decompressResponse(body, captured.Header()); err == nil {
+		exchange.responseBody = decompressed
+	}
+}
@@ -0,0 +1,75 @@
+package ociaitoopenai
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/zalbiraw/ociaitoopenai/pkg/types"
+)
+
+// maxCatalogPages bounds the number of ListModels pages fetched for a single catalog refresh.
+const maxCatalogPages = 20
+
+// captureWriter captures the response to a request issued by the plugin itself.
+type captureWriter struct {
+	header     http.Header
+	statusCode int
+	body       bytes.Buffer
+}
+
+func newCaptureWriter() *captureWriter {
+	return &captureWriter{header: make(http.Header), statusCode: http.StatusOK}
+}
+
+func (cw *captureWriter) Header() http.Header { return cw.header }
+
+func (cw *captureWriter) WriteHeader(code int) { cw.statusCode = code }
+
+func (cw *captureWriter) Write(b []byte) (int, error) { return cw.body.Write(b) }
+
+// listModels lists the chat models in a compartment. The ListModels calls go through the next
+// handler, like client requests, so downstream authentication applies.
+func (p *Proxy) listModels(ctx context.Context, compartmentID string) ([]types.OCIModel, error) {
+	var models []types.OCIModel
+	page := ""
+	for i := 0; i < maxCatalogPages; i++ {
+		query := url.Values{}
+		query.Set("compartmentId", compartmentID)
+		query.Set("capability", "CHAT")
+		if page != "" {
+			query.Set("page", page)
+		}
+
+		endpoint := fmt.Sprintf("https://generativeai.%s.oci.oraclecloud.com/20231130/models?%s", p.config.Region, query.Encode())
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create catalog request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept-Encoding", upstreamAcceptEncoding)
+		if err := p.sign(req, nil); err != nil {
+			return nil, err
+		}
+
+		capture := newCaptureWriter()
+		p.serveNext(capture, req)
+		if capture.statusCode != http.StatusOK {
+			return nil, fmt.Errorf("catalog request failed with status %d", capture.statusCode)
+		}
+
+		var resp types.OCIModelsResponse
+		if err := p.decodeResponse(capture.body.Bytes(), capture.header, &resp); err != nil {
+			return nil, fmt.Errorf("failed to parse catalog response: %w", err)
+		}
+		models = append(models, resp.Items...)
+
+		page = capture.header.Get("opc-next-page")
+		if page == "" {
+			break
+		}
+	}
+	return models, nil
+}
@@ -0,0 +1,79 @@
+package ociaitoopenai
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zalbiraw/ociaitoopenai/internal/chaos"
+	"github.com/zalbiraw/ociaitoopenai/internal/metrics"
+)
+
+// chaosHeader names the faults injected into a response, so test clients can tell them from real failures.
+const chaosHeader = "X-Ociai-Chaos"
+
+// serveChaos handles a chat completion with the faults drawn by the chaos injector. Responses
+// to truncate or corrupt are buffered, so their streams are not delivered incrementally.
+func (p *Proxy) serveChaos(rw http.ResponseWriter, req *http.Request) {
+	faults := p.chaos.Draw(req)
+	names := faults.Names()
+	if len(names) == 0 {
+		p.serveChat(rw, req)
+		return
+	}
+	log.Printf("[%s] serveChaos: Injecting %s", p.name, strings.Join(names, ", "))
+	for _, name := range names {
+		p.metrics.Inc(metricChaosFaults, metrics.Labels{"fault": name})
+	}
+	rw.Header().Set(chaosHeader, strings.Join(names, ","))
+
+	if faults.Delay > 0 {
+		timer := time.NewTimer(faults.Delay)
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			timer.Stop()
+			return
+		}
+	}
+
+	if faults.RateLimit {
+		rw.Header().Set("Retry-After", fmt.Sprintf("%d", int(p.chaos.RetryAfter().Seconds()+0.5)))
+		writeError(rw, http.StatusTooManyRequests, "Rate limit reached, please retry later (injected by chaos mode)", "", "rate_limit_exceeded")
+		return
+	}
+	if !faults.Truncate && !faults.Malformed {
+		p.serveChat(rw, req)
+		return
+	}
+
+	capture := newCaptureWriter()
+	p.serveChat(capture, req)
+	body := capture.body.Bytes()
+	if capture.statusCode == http.StatusOK {
+		if faults.Malformed {
+			body = chaos.Malform(body)
+		}
+		length := len(body)
+		if faults.Truncate {
+			body = chaos.Truncate(body)
+		}
+		// A truncated JSON response still declares its full length, so the client sees the
+		// connection close early, as when an upstream fails mid-response
+		capture.header.Del("Content-Length")
+		if !strings.HasPrefix(capture.header.Get("Content-Type"), "text/event-stream") {
+			capture.header.Set("Content-Length", strconv.Itoa(length))
+		}
+	}
+
+	for key, values := range capture.header {
+		for _, value := range values {
+			rw.Header().Add(key, value)
+		}
+	}
+	rw.WriteHeader(capture.statusCode)
+	_, _ = rw.Write(body)
+}
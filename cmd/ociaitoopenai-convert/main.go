@@ -0,0 +1,133 @@
+// Command ociaitoopenai-convert runs the plugin's payload conversion offline. It reads an
+// OpenAI chat completion request, or an OCI GenAI chat response, on stdin and prints the
+// converted payload, so mappings can be debugged without deploying Traefik.
+//
+// Usage:
+//
+//	ociaitoopenai-convert [-config plugin.json] [-direction request|response] [-model name] < payload.json
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/zalbiraw/ociaitoopenai/internal/config"
+	"github.com/zalbiraw/ociaitoopenai/internal/transform"
+	"github.com/zalbiraw/ociaitoopenai/pkg/types"
+)
+
+const (
+	directionRequest  = "request"
+	directionResponse = "response"
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr); err != nil {
+		fmt.Fprintf(os.Stderr, "ociaitoopenai-convert: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// run converts the payload read from stdin and writes it to stdout. Parameter adjustments
+// made while normalising a request are reported on stderr.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("ociaitoopenai-convert", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	configPath := flags.String("config", "", "plugin configuration as JSON, using the same keys as the Traefik configuration")
+	direction := flags.String("direction", "", "request (OpenAI to OCI) or response (OCI to OpenAI); detected from the payload when empty")
+	model := flags.String("model", "", "model name to report in converted responses; defaults to the response's modelId")
+	compartmentID := flags.String("compartment", "", "compartment OCID to place in converted requests")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	cfg := config.New()
+	if *configPath != "" {
+		raw, err := os.ReadFile(*configPath)
+		if err != nil {
+			return fmt.Errorf("failed to read config: %w", err)
+		}
+		if err := json.Unmarshal(raw, cfg); err != nil {
+			return fmt.Errorf("failed to parse config: %w", err)
+		}
+	}
+	if *compartmentID != "" {
+		cfg.CompartmentID = *compartmentID
+	}
+
+	payload, err := io.ReadAll(stdin)
+	if err != nil {
+		return fmt.Errorf("failed to read payload: %w", err)
+	}
+	if *direction == "" {
+		*direction = detectDirection(payload)
+	}
+
+	transformer := transform.New(cfg)
+	var converted interface{}
+	switch *direction {
+	case directionRequest:
+		converted, err = convertRequest(transformer, payload, stderr)
+	case directionResponse:
+		converted, err = convertResponse(transformer, payload, *model)
+	default:
+		return fmt.Errorf("unknown direction %q, must be %s or %s", *direction, directionRequest, directionResponse)
+	}
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(converted)
+}
+
+// detectDirection treats payloads carrying an OCI chatResponse as responses and anything
+// else as an OpenAI request.
+func detectDirection(payload []byte) string {
+	var probe struct {
+		ChatResponse json.RawMessage `json:"chatResponse"`
+	}
+	if json.Unmarshal(payload, &probe) == nil && len(probe.ChatResponse) > 0 {
+		return directionResponse
+	}
+	return directionRequest
+}
+
+// convertRequest applies the same request pipeline as the plugin, short of network access
+// such as image inlining and catalog checks.
+func convertRequest(transformer *transform.Transformer, payload []byte, stderr io.Writer) (types.OracleCloudRequest, error) {
+	var req types.ChatCompletionRequest
+	if err := json.Unmarshal(payload, &req); err != nil {
+		return types.OracleCloudRequest{}, fmt.Errorf("failed to parse OpenAI request: %w", err)
+	}
+	if len(req.Messages) == 0 {
+		return types.OracleCloudRequest{}, errors.New("OpenAI request has no messages")
+	}
+
+	transform.FromLegacyFunctions(&req)
+	if !req.Stream {
+		transformer.EmulateTools(&req)
+	}
+	if adjustments := transformer.Normalize(&req); len(adjustments) > 0 {
+		fmt.Fprintf(stderr, "adjusted parameters: %s\n", strings.Join(adjustments, "; "))
+	}
+	return transformer.ToOracleCloudRequest(req), nil
+}
+
+// convertResponse converts a non-streaming OCI chat response.
+func convertResponse(transformer *transform.Transformer, payload []byte, model string) (types.ChatCompletionResponse, error) {
+	var resp types.OracleCloudResponse
+	if err := json.Unmarshal(payload, &resp); err != nil {
+		return types.ChatCompletionResponse{}, fmt.Errorf("failed to parse OCI response: %w", err)
+	}
+	if model == "" {
+		model = resp.ModelID
+	}
+	return transformer.ToOpenAIResponse(resp, model), nil
+}
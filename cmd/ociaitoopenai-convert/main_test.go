@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/zalbiraw/ociaitoopenai/pkg/types"
+)
+
+func TestRun_Request(t *testing.T) {
+	stdin := strings.NewReader(`{"model":"cohere.command-r-plus","temperature":3,"messages":[{"role":"user","content":"Hello"}]}`)
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"-compartment", "ocid1.compartment.test"}, stdin, &stdout, &stderr); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	var req types.OracleCloudRequest
+	if err := json.Unmarshal(stdout.Bytes(), &req); err != nil {
+		t.Fatalf("failed to decode output: %v", err)
+	}
+	if req.CompartmentID != "ocid1.compartment.test" || req.ServingMode.ModelID != "cohere.command-r-plus" {
+		t.Errorf("unexpected OCI request: %+v", req)
+	}
+	if !strings.Contains(stderr.String(), "temperature=") {
+		t.Errorf("expected the temperature adjustment on stderr, got %q", stderr.String())
+	}
+}
+
+func TestRun_Response(t *testing.T) {
+	stdin := strings.NewReader(`{"modelId":"meta.llama-3.3-70b-instruct","chatResponse":{"apiFormat":"GENERIC","choices":[{"finishReason":"stop","message":{"content":[{"type":"TEXT","text":"Hi"}]}}]}}`)
+	var stdout, stderr bytes.Buffer
+	if err := run(nil, stdin, &stdout, &stderr); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	var resp types.ChatCompletionResponse
+	if err := json.Unmarshal(stdout.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode output: %v", err)
+	}
+	if resp.Model != "meta.llama-3.3-70b-instruct" || len(resp.Choices) != 1 || resp.Choices[0].Message.Content != "Hi" {
+		t.Errorf("unexpected OpenAI response: %+v", resp)
+	}
+}
+
+func TestRun_UnknownDirection(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"-direction", "sideways"}, strings.NewReader(`{}`), &stdout, &stderr); err == nil {
+		t.Error("expected an error for an unknown direction")
+	}
+}
@@ -0,0 +1,361 @@
+package ociaitoopenai
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf16"
+)
+
+// upstreamAcceptEncoding lists the encodings the plugin can decode. It is requested from OCI in
+// place of the client's Accept-Encoding, which may name encodings the plugin cannot transform.
+const upstreamAcceptEncoding = "gzip, deflate"
+
+// upstreamEntityHeaders describe the bytes of an upstream body, so they no longer hold once the
+// body is transformed, and are never copied to transformed responses.
+var upstreamEntityHeaders = []string{
+	"Content-Length",
+	"Content-Encoding",
+	"Content-Md5",
+	"Content-Range",
+	"Accept-Ranges",
+	"Digest",
+	"Content-Digest",
+	"Repr-Digest",
+	"Etag",
+	"Transfer-Encoding",
+}
+
+// upstreamEntityHeader reports whether name is one of the upstreamEntityHeaders.
+func upstreamEntityHeader(name string) bool {
+	for _, entityHeader := range upstreamEntityHeaders {
+		if strings.EqualFold(name, entityHeader) {
+			return true
+		}
+	}
+	return false
+}
+
+// setTransformedEntityHeaders replaces the upstream entity headers of a response with those of
+// its transformed body, compressed with encoding, or uncompressed when encoding is empty. Since
+// the encoding depends on the client's Accept-Encoding, Vary names it for caches.
+func setTransformedEntityHeaders(header http.Header, body []byte, encoding string) {
+	for _, name := range upstreamEntityHeaders {
+		header.Del(name)
+	}
+	if encoding != "" {
+		header.Set("Content-Encoding", encoding)
+	}
+	header.Set("Content-Length", strconv.Itoa(len(body)))
+
+	for _, value := range header.Values("Vary") {
+		for _, field := range strings.Split(value, ",") {
+			if field = strings.TrimSpace(field); field == "*" || strings.EqualFold(field, "Accept-Encoding") {
+				return
+			}
+		}
+	}
+	header.Add("Vary", "Accept-Encoding")
+}
+
+// maxPooledBuffer is the largest buffer returned to the pool; larger ones are left to the GC
+// so a single huge response does not pin memory.
+const maxPooledBuffer = 1 << 20
+
+var (
+	bufferPool     = sync.Pool{New: func() interface{} { return new(bytes.Buffer) }}
+	gzipReaderPool sync.Pool
+
+	// gzipWriterPools and flateWriterPools hold a pool per compression level, indexed by
+	// level - gzip.HuffmanOnly, since a reset writer keeps the level it was created with.
+	gzipWriterPools  [gzip.BestCompression - gzip.HuffmanOnly + 1]sync.Pool
+	flateWriterPools [flate.BestCompression - flate.HuffmanOnly + 1]sync.Pool
+)
+
+// getBuffer returns an empty buffer from the pool.
+func getBuffer() *bytes.Buffer {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	return buf
+}
+
+// putBuffer returns a buffer to the pool.
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() <= maxPooledBuffer {
+		bufferPool.Put(buf)
+	}
+}
+
+// jsonRequestBody checks that a request body with the given Content-Type is JSON, and returns
+// it as UTF-8 without a byte order mark. A missing Content-Type is accepted, since many clients
+// omit it. UTF-16 bodies are recognised by their byte order mark, as some Windows clients send them.
+func jsonRequestBody(contentType string, body []byte) ([]byte, error) {
+	if contentType != "" {
+		mediaType, params, err := mime.ParseMediaType(contentType)
+		if err != nil || (mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json")) {
+			return nil, fmt.Errorf("unsupported Content-Type %q, requests must be application/json", contentType)
+		}
+		if !supportedCharset(params["charset"], body) {
+			return nil, fmt.Errorf("unsupported charset %q, requests must be UTF-8", params["charset"])
+		}
+	}
+
+	switch {
+	case bytes.HasPrefix(body, []byte{0xEF, 0xBB, 0xBF}):
+		return body[3:], nil
+	case hasUTF16ByteOrderMark(body):
+		return decodeUTF16(body), nil
+	}
+	return body, nil
+}
+
+// supportedCharset reports whether a body in the given charset can be decoded. UTF-16 is only
+// supported with a byte order mark.
+func supportedCharset(charset string, body []byte) bool {
+	switch strings.ToLower(charset) {
+	case "", "utf-8", "utf8", "us-ascii":
+		return true
+	case "utf-16", "utf-16le", "utf-16be":
+		return hasUTF16ByteOrderMark(body)
+	}
+	return false
+}
+
+// hasUTF16ByteOrderMark reports whether body starts with a UTF-16 byte order mark.
+func hasUTF16ByteOrderMark(body []byte) bool {
+	return bytes.HasPrefix(body, []byte{0xFF, 0xFE}) || bytes.HasPrefix(body, []byte{0xFE, 0xFF})
+}
+
+// decodeUTF16 converts a UTF-16 body starting with a byte order mark to UTF-8.
+func decodeUTF16(body []byte) []byte {
+	littleEndian := body[0] == 0xFF
+	units := make([]uint16, 0, len(body)/2)
+	for i := 2; i+1 < len(body); i += 2 {
+		if littleEndian {
+			units = append(units, uint16(body[i])|uint16(body[i+1])<<8)
+		} else {
+			units = append(units, uint16(body[i])<<8|uint16(body[i+1]))
+		}
+	}
+	return []byte(string(utf16.Decode(units)))
+}
+
+// maxBodyPrealloc caps the buffer allocated up front from a request's Content-Length, which
+// the client controls; larger bodies grow the buffer as they are actually read.
+const maxBodyPrealloc = 64 << 10
+
+// readRequestBody reads a request body in a single allocation when Content-Length is known and
+// small, avoiding the repeated growth of io.ReadAll for typical conversations.
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.ContentLength <= 0 {
+		return io.ReadAll(req.Body)
+	}
+
+	size := req.ContentLength
+	if size > maxBodyPrealloc {
+		size = maxBodyPrealloc
+	}
+	body := make([]byte, size)
+	n, err := io.ReadFull(req.Body, body)
+	if err == io.ErrUnexpectedEOF {
+		return body[:n], nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Keep reading in case the body is larger than the buffer
+	var probe [1]byte
+	if n, _ := req.Body.Read(probe[:]); n == 0 {
+		return body, nil
+	}
+	rest, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	return append(append(body, probe[0]), rest...), nil
+}
+
+// decodeResponse decodes a JSON response body into v, decompressing gzip and deflate bodies
+// with pooled readers into a pooled buffer. json.Decoder buffers the whole value before decoding
+// anyway, so unmarshalling from a reused buffer allocates less than decoding from the reader.
+func (p *Proxy) decodeResponse(body []byte, headers http.Header, v interface{}) error {
+	var reader io.Reader
+
+	switch headers.Get("Content-Encoding") {
+	case "gzip":
+		if len(body) < 2 {
+			return json.Unmarshal(body, v)
+		}
+		gzipReader, err := getGzipReader(bytes.NewReader(body))
+		if err != nil {
+			return fmt.Errorf("failed to create gzip reader: %w", err)
+		}
+		defer gzipReaderPool.Put(gzipReader)
+		reader = gzipReader
+	case "deflate":
+		deflateReader := flate.NewReader(bytes.NewReader(body))
+		defer deflateReader.Close()
+		reader = deflateReader
+	default:
+		return json.Unmarshal(body, v)
+	}
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+	if _, err := buf.ReadFrom(reader); err != nil {
+		return fmt.Errorf("failed to decompress response: %w", err)
+	}
+	return json.Unmarshal(buf.Bytes(), v)
+}
+
+// getGzipReader returns a pooled gzip reader reset to read from r.
+func getGzipReader(r io.Reader) (*gzip.Reader, error) {
+	if pooled, ok := gzipReaderPool.Get().(*gzip.Reader); ok {
+		if err := pooled.Reset(r); err != nil {
+			gzipReaderPool.Put(pooled)
+			return nil, err
+		}
+		return pooled, nil
+	}
+	return gzip.NewReader(r)
+}
+
+// readAllPooled reads r to the end through a pooled buffer, returning a copy of exactly the
+// bytes read, so the result does not carry the spare capacity io.ReadAll grows into.
+func readAllPooled(r io.Reader) ([]byte, error) {
+	buf := getBuffer()
+	defer putBuffer(buf)
+	if _, err := buf.ReadFrom(r); err != nil {
+		return nil, err
+	}
+	data := make([]byte, buf.Len())
+	copy(data, buf.Bytes())
+	return data, nil
+}
+
+// gzipCompress compresses body at the given level with a pooled gzip writer.
+func gzipCompress(body []byte, level int) ([]byte, error) {
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	pool := &gzipWriterPools[level-gzip.HuffmanOnly]
+	gzipWriter, ok := pool.Get().(*gzip.Writer)
+	if ok {
+		gzipWriter.Reset(buf)
+	} else {
+		var err error
+		if gzipWriter, err = gzip.NewWriterLevel(buf, level); err != nil {
+			return nil, fmt.Errorf("failed to create gzip writer: %w", err)
+		}
+	}
+	defer pool.Put(gzipWriter)
+
+	if _, err := gzipWriter.Write(body); err != nil {
+		return nil, fmt.Errorf("failed to write gzip compressed data: %w", err)
+	}
+	if err := gzipWriter.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close gzip writer: %w", err)
+	}
+
+	compressed := make([]byte, buf.Len())
+	copy(compressed, buf.Bytes())
+	return compressed, nil
+}
+
+// deflateCompress compresses body at the given level with a pooled deflate writer.
+func deflateCompress(body []byte, level int) ([]byte, error) {
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	pool := &flateWriterPools[level-flate.HuffmanOnly]
+	deflateWriter, ok := pool.Get().(*flate.Writer)
+	if ok {
+		deflateWriter.Reset(buf)
+	} else {
+		var err error
+		if deflateWriter, err = flate.NewWriter(buf, level); err != nil {
+			return nil, fmt.Errorf("failed to create deflate writer: %w", err)
+		}
+	}
+	defer pool.Put(deflateWriter)
+
+	if _, err := deflateWriter.Write(body); err != nil {
+		return nil, fmt.Errorf("failed to write deflate compressed data: %w", err)
+	}
+	if err := deflateWriter.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close deflate writer: %w", err)
+	}
+
+	compressed := make([]byte, buf.Len())
+	copy(compressed, buf.Bytes())
+	return compressed, nil
+}
+
+// copyUpstreamHeader copies the headers of an upstream response to a transformed response,
+// except the upstreamEntityHeaders. Values are copied per header rather than set one by one.
+func copyUpstreamHeader(dst, src http.Header) {
+	for key, values := range src {
+		if upstreamEntityHeader(key) {
+			continue
+		}
+		dst[key] = append([]string(nil), values...)
+	}
+}
+
+// acceptsEncoding reports whether a client's Accept-Encoding header accepts a content encoding.
+// The identity encoding is always accepted. A missing header accepts nothing else, since that is
+// how clients were served before the plugin chose the upstream encoding.
+func acceptsEncoding(acceptEncoding, encoding string) bool {
+	if encoding == "" || strings.EqualFold(encoding, "identity") {
+		return true
+	}
+
+	wildcard := false
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		fields := strings.Split(part, ";")
+		name := strings.TrimSpace(fields[0])
+		quality := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if q, err := strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64); err == nil {
+					quality = q
+				}
+			}
+		}
+		if strings.EqualFold(name, encoding) {
+			return quality > 0
+		}
+		if name == "*" {
+			wildcard = quality > 0
+		}
+	}
+	return wildcard
+}
+
+// clientEncodedBody returns an upstream body passed through to the client, decompressed with
+// Content-Encoding removed if the client does not accept the upstream's encoding.
+func (p *Proxy) clientEncodedBody(body []byte, header http.Header, acceptEncoding string) []byte {
+	if acceptsEncoding(acceptEncoding, header.Get("Content-Encoding")) {
+		return body
+	}
+
+	decompressed, err := p.decompressResponse(body, header)
+	if err != nil {
+		log.Printf("[%s] ERROR: Failed to decompress response for the client: %v", p.name, err)
+		return body
+	}
+	header.Del("Content-Encoding")
+	header.Del("Content-Length")
+	return decompressed
+}
@@ -0,0 +1,391 @@
+package ociaitoopenai
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/zalbiraw/ociaitoopenai/internal/config"
+	"github.com/zalbiraw/ociaitoopenai/pkg/types"
+)
+
+// benchmarkResponse builds a gzip-compressed OCI chat response of roughly the given text size.
+func benchmarkResponse(b *testing.B, size int) ([]byte, http.Header) {
+	b.Helper()
+	body, err := json.Marshal(types.OracleCloudResponse{
+		ModelID: "meta.llama-3.3-70b-instruct",
+		ChatResponse: types.OracleCloudChatResponse{
+			APIFormat: "GENERIC",
+			Choices: []types.OracleGenericChoice{{
+				Message: types.OracleGenericMessage{
+					Role:    "ASSISTANT",
+					Content: []types.OracleGenericContent{{Type: "TEXT", Text: strings.Repeat("lorem ipsum ", size/12)}},
+				},
+				FinishReason: "stop",
+			}},
+		},
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	gzipWriter := gzip.NewWriter(&buf)
+	_, _ = gzipWriter.Write(body)
+	_ = gzipWriter.Close()
+
+	headers := http.Header{}
+	headers.Set("Content-Encoding", "gzip")
+	return buf.Bytes(), headers
+}
+
+func newBenchmarkProxy() *Proxy {
+	return &Proxy{config: config.New(), name: "bench"}
+}
+
+func TestDecodeResponse(t *testing.T) {
+	p := newBenchmarkProxy()
+	for _, encoding := range []string{"", "gzip", "deflate"} {
+		body := []byte(`{"modelId":"test-model","chatResponse":{"text":"Hello"}}`)
+		headers := http.Header{}
+		if encoding != "" {
+			headers.Set("Content-Encoding", encoding)
+			compressed, err := p.compressResponse(body, headers)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body = compressed
+		}
+
+		var resp types.OracleCloudResponse
+		if err := p.decodeResponse(body, headers, &resp); err != nil {
+			t.Fatalf("%q: expected no error, got: %v", encoding, err)
+		}
+		if resp.ChatResponse.Text != "Hello" {
+			t.Errorf("%q: expected text Hello, got %q", encoding, resp.ChatResponse.Text)
+		}
+	}
+}
+
+func BenchmarkDecodeResponse(b *testing.B) {
+	for _, size := range []int{1 << 10, 64 << 10} {
+		body, headers := benchmarkResponse(b, size)
+		p := newBenchmarkProxy()
+
+		b.Run(fmt.Sprintf("ReadAllUnmarshal/%dKiB", size>>10), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				decompressed, err := p.decompressResponse(body, headers)
+				if err != nil {
+					b.Fatal(err)
+				}
+				var resp types.OracleCloudResponse
+				if err := json.Unmarshal(decompressed, &resp); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+
+		b.Run(fmt.Sprintf("Pooled/%dKiB", size>>10), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				var resp types.OracleCloudResponse
+				if err := p.decodeResponse(body, headers, &resp); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkReadRequestBody(b *testing.B) {
+	body := bytes.Repeat([]byte(`{"role":"user","content":"lorem ipsum dolor sit amet"},`), 2000)
+
+	b.Run("ReadAll", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := io.ReadAll(bytes.NewReader(body)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("Presized", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			req := &http.Request{Body: io.NopCloser(bytes.NewReader(body)), ContentLength: int64(len(body))}
+			if _, err := readRequestBody(req); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkCompressResponse(b *testing.B) {
+	body := bytes.Repeat([]byte("lorem ipsum "), 1000)
+
+	b.Run("NewWriter", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var buf bytes.Buffer
+			gzipWriter := gzip.NewWriter(&buf)
+			_, _ = gzipWriter.Write(body)
+			_ = gzipWriter.Close()
+		}
+	})
+
+	b.Run("Pooled", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := gzipCompress(body, gzip.DefaultCompression); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkDecompressResponse(b *testing.B) {
+	body, headers := benchmarkResponse(b, 64<<10)
+	p := newBenchmarkProxy()
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := p.decompressResponse(body, headers); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkCompressResponseDeflate(b *testing.B) {
+	body := bytes.Repeat([]byte("lorem ipsum "), 1000)
+	p := newBenchmarkProxy()
+	headers := http.Header{}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		headers.Set("Content-Encoding", "deflate")
+		if _, err := p.compressResponse(body, headers); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkServeHTTP_ChatCompletion measures the whole chat pipeline, from the OpenAI request
+// to the compressed OpenAI response, with concurrent requests.
+func BenchmarkServeHTTP_ChatCompletion(b *testing.B) {
+	upstream, headers := benchmarkResponse(b, 4<<10)
+	cfg := config.New()
+	cfg.CompartmentID = "ocid1.compartment.oc1..testcompartment"
+	cfg.Region = "us-ashburn-1"
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		_, _ = io.Copy(io.Discard, req.Body)
+		for key, values := range headers {
+			rw.Header()[key] = values
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		rw.Header().Set("Opc-Request-Id", "bench")
+		_, _ = rw.Write(upstream)
+	})
+	handler, err := New(context.Background(), next, cfg, "bench")
+	if err != nil {
+		b.Fatal(err)
+	}
+	body := []byte(`{"model":"meta.llama-3.3-70b-instruct","messages":[{"role":"system","content":"Be brief."},{"role":"user","content":"Hello"}]}`)
+
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			req := httptest.NewRequest(http.MethodPost, "/chat/completions", bytes.NewReader(body))
+			req.Header.Set("Accept-Encoding", "gzip")
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, req)
+			if recorder.Code != http.StatusOK {
+				b.Fatalf("expected status 200, got %d: %s", recorder.Code, recorder.Body)
+			}
+		}
+	})
+}
+
+func TestCopyUpstreamHeader(t *testing.T) {
+	src := http.Header{}
+	src.Add("Vary", "Origin")
+	src.Add("Vary", "Accept")
+	src.Set("Content-Length", "42")
+	src.Set("Etag", `"abc"`)
+	src.Set("Opc-Request-Id", "req-1")
+
+	dst := http.Header{}
+	copyUpstreamHeader(dst, src)
+	if got := dst.Values("Vary"); len(got) != 2 || got[0] != "Origin" || got[1] != "Accept" {
+		t.Errorf("expected both Vary values, got %v", got)
+	}
+	if dst.Get("Opc-Request-Id") != "req-1" {
+		t.Errorf("expected Opc-Request-Id to be copied, got %v", dst)
+	}
+	if dst.Get("Content-Length") != "" || dst.Get("Etag") != "" {
+		t.Errorf("expected entity headers to be dropped, got %v", dst)
+	}
+
+	dst.Add("Vary", "Accept-Encoding")
+	if len(src.Values("Vary")) != 2 {
+		t.Errorf("expected the source header to be unchanged, got %v", src)
+	}
+}
+
+func TestReadRequestBody(t *testing.T) {
+	for _, contentLength := range []int64{-1, 3, 11, 20} {
+		req := &http.Request{Body: io.NopCloser(strings.NewReader("hello world")), ContentLength: contentLength}
+		body, err := readRequestBody(req)
+		if err != nil {
+			t.Fatalf("content length %d: expected no error, got: %v", contentLength, err)
+		}
+		if string(body) != "hello world" {
+			t.Errorf("content length %d: expected full body, got %q", contentLength, body)
+		}
+	}
+}
+
+func TestReadRequestBody_OverstatedContentLength(t *testing.T) {
+	// A claimed 16 TiB must not be allocated before the two byte body is read
+	req := &http.Request{Body: io.NopCloser(strings.NewReader("{}")), ContentLength: 1 << 44}
+	body, err := readRequestBody(req)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if string(body) != "{}" || cap(body) > maxBodyPrealloc {
+		t.Errorf("expected the body in a capped buffer, got %q with capacity %d", body, cap(body))
+	}
+
+	large := strings.Repeat("a", 3*maxBodyPrealloc)
+	req = &http.Request{Body: io.NopCloser(strings.NewReader(large)), ContentLength: int64(len(large))}
+	if body, err := readRequestBody(req); err != nil || string(body) != large {
+		t.Errorf("expected a body above the preallocation cap to be read whole, got %d bytes, err %v", len(body), err)
+	}
+}
+
+func TestCompressResponse_MinBytesAndLevel(t *testing.T) {
+	p := newBenchmarkProxy()
+	p.config.Compression.MinBytes = 100
+	body := []byte(`{"object":"list","data":[]}`)
+
+	headers := http.Header{}
+	headers.Set("Content-Encoding", "gzip")
+	result, err := p.compressResponse(body, headers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(result, body) || headers.Get("Content-Encoding") != "" {
+		t.Errorf("expected small body to be sent uncompressed without Content-Encoding")
+	}
+
+	large := bytes.Repeat([]byte("lorem ipsum "), 100)
+	sizes := map[int]int{}
+	for _, level := range []int{gzip.NoCompression, gzip.BestCompression} {
+		p.config.Compression.Level = level
+		headers.Set("Content-Encoding", "gzip")
+		compressed, err := p.compressResponse(large, headers)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sizes[level] = len(compressed)
+	}
+	if sizes[gzip.BestCompression] >= sizes[gzip.NoCompression] {
+		t.Errorf("expected best compression to be smaller than no compression, got %v", sizes)
+	}
+}
+
+func TestAcceptsEncoding(t *testing.T) {
+	tests := []struct {
+		acceptEncoding string
+		encoding       string
+		expected       bool
+	}{
+		{"gzip, deflate, br", "gzip", true},
+		{"br, zstd", "gzip", false},
+		{"", "gzip", false},
+		{"", "", true},
+		{"gzip;q=0, *", "gzip", false},
+		{"*;q=0.5", "deflate", true},
+		{"GZIP", "gzip", true},
+	}
+	for _, tt := range tests {
+		if got := acceptsEncoding(tt.acceptEncoding, tt.encoding); got != tt.expected {
+			t.Errorf("acceptsEncoding(%q, %q) = %v, expected %v", tt.acceptEncoding, tt.encoding, got, tt.expected)
+		}
+	}
+}
+
+func TestSetTransformedEntityHeaders(t *testing.T) {
+	header := http.Header{}
+	header.Set("Content-Length", "999")
+	header.Set("Content-Encoding", "gzip")
+	header.Set("Etag", `"upstream"`)
+	header.Set("Content-Md5", "Q2hlY2sgSW50ZWdyaXR5IQ==")
+	header.Set("Digest", "sha-256=abc")
+	header.Set("Vary", "Origin")
+	header.Set("Opc-Request-Id", "ABC123")
+
+	setTransformedEntityHeaders(header, []byte(`{"id":"x"}`), "")
+	for _, name := range []string{"Content-Encoding", "Etag", "Content-Md5", "Digest"} {
+		if value := header.Get(name); value != "" {
+			t.Errorf("expected %s removed, got %q", name, value)
+		}
+	}
+	if header.Get("Content-Length") != "10" || header.Get("Opc-Request-Id") != "ABC123" {
+		t.Errorf("expected the length recomputed and other headers kept, got %v", header)
+	}
+	if vary := header.Values("Vary"); len(vary) != 2 || vary[1] != "Accept-Encoding" {
+		t.Errorf("expected Vary to name Accept-Encoding, got %v", vary)
+	}
+
+	setTransformedEntityHeaders(header, []byte("compressed"), "gzip")
+	if header.Get("Content-Encoding") != "gzip" || len(header.Values("Vary")) != 2 {
+		t.Errorf("expected the encoding set and Vary unchanged, got %v", header)
+	}
+}
+
+func TestJSONRequestBody(t *testing.T) {
+	utf16LE := []byte{0xFF, 0xFE}
+	for _, r := range `{"a":"é"}` {
+		utf16LE = append(utf16LE, byte(r), byte(r>>8))
+	}
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        []byte
+		expected    string
+		wantErr     bool
+	}{
+		{"json", "application/json", []byte(`{"a":1}`), `{"a":1}`, false},
+		{"charset", "application/json; charset=UTF-8", []byte(`{"a":1}`), `{"a":1}`, false},
+		{"no content type", "", []byte(`{"a":1}`), `{"a":1}`, false},
+		{"utf-8 bom", "application/json", append([]byte{0xEF, 0xBB, 0xBF}, `{"a":1}`...), `{"a":1}`, false},
+		{"utf-16 bom", "application/json; charset=utf-16", utf16LE, `{"a":"é"}`, false},
+		{"form", "application/x-www-form-urlencoded", []byte(`a=1`), "", true},
+		{"latin1", "application/json; charset=iso-8859-1", []byte(`{"a":1}`), "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := jsonRequestBody(tt.contentType, tt.body)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got: %v", tt.wantErr, err)
+			}
+			if string(body) != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, body)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package ociaitoopenai
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// rangeHeaders request part of a representation, which a generated completion does not have.
+var rangeHeaders = []string{"Range", "If-Range"}
+
+// conditionalHeaders make a request depend on the state of a representation, which OCI
+// Generative AI does not track for generated completions.
+var conditionalHeaders = []string{"If-Match", "If-None-Match", "If-Modified-Since", "If-Unmodified-Since"}
+
+// rejectPreconditions answers POST requests to AI endpoints that carry a Range or conditional
+// header, which would otherwise be forwarded to OCI with undefined results. It reports whether
+// the request was rejected.
+func rejectPreconditions(rw http.ResponseWriter, req *http.Request) bool {
+	if req.Method != http.MethodPost {
+		return false
+	}
+	for _, name := range rangeHeaders {
+		if req.Header.Get(name) != "" {
+			writeError(rw, http.StatusNotImplemented,
+				fmt.Sprintf("The %s header is not supported on this endpoint; request the whole response.", name),
+				"", "range_not_supported")
+			return true
+		}
+	}
+	for _, name := range conditionalHeaders {
+		if req.Header.Get(name) != "" {
+			writeError(rw, http.StatusBadRequest,
+				fmt.Sprintf("The %s header is not supported on this endpoint; completions are generated for every request.", name),
+				"", "conditional_request_not_supported")
+			return true
+		}
+	}
+	return false
+}
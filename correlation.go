@@ -0,0 +1,49 @@
+package ociaitoopenai
+
+import (
+	"net/http"
+	"strings"
+)
+
+// opcRequestIDHeader carries the client's request ID to OCI, which records it in its service logs.
+const opcRequestIDHeader = "Opc-Request-Id"
+
+// maxRequestIDPart bounds each part of the opc-request-id.
+const maxRequestIDPart = 64
+
+// requestID returns the gateway request ID from the configured header, or a new one.
+func (p *Proxy) requestID(req *http.Request) string {
+	if id := req.Header.Get(p.config.RequestMetadata.RequestIDHeader); id != "" {
+		return id
+	}
+	return randomID()
+}
+
+// opcRequestID joins the request ID and the configured metadata with colons. Parts keep their
+// position when empty, so the tenant and user can be told apart.
+func (p *Proxy) opcRequestID(requestID, tenant, user string) string {
+	parts := []string{requestIDPart(requestID)}
+	if p.config.RequestMetadata.IncludeTenant {
+		parts = append(parts, requestIDPart(tenant))
+	}
+	if p.config.RequestMetadata.IncludeUser {
+		parts = append(parts, requestIDPart(user))
+	}
+	return strings.Join(parts, ":")
+}
+
+// requestIDPart replaces characters other than letters, digits, '.', '_' and '-' with '_', and
+// truncates the value, so client-supplied values cannot forge parts or break the header.
+func requestIDPart(value string) string {
+	if len(value) > maxRequestIDPart {
+		value = value[:maxRequestIDPart]
+	}
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '_', r == '-':
+			return r
+		default:
+			return '_'
+		}
+	}, value)
+}
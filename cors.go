@@ -0,0 +1,125 @@
+package ociaitoopenai
+
+import (
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/zalbiraw/ociaitoopenai/internal/config"
+)
+
+// setCORSHeaders adds the cross-origin headers of the request's policy to its response.
+func (p *Proxy) setCORSHeaders(header http.Header, req *http.Request) {
+	policy := p.config.CORS.Policy(p.tenant(req), requestHost(req))
+	allowOrigin(header, policy, req.Header.Get("Origin"))
+}
+
+// servePreflight answers the CORS preflight of a request to an endpoint the plugin serves. It
+// reports whether the request was a preflight it answered.
+func (p *Proxy) servePreflight(rw http.ResponseWriter, req *http.Request, route string) bool {
+	method := req.Header.Get("Access-Control-Request-Method")
+	if req.Method != http.MethodOptions || method == "" {
+		return false
+	}
+	probe := req.Clone(req.Context())
+	probe.Method = method
+	if p.aiHandler(probe, route) == nil {
+		return false
+	}
+
+	origin := req.Header.Get("Origin")
+	header := rw.Header()
+	if policy := p.preflightPolicy(req, origin); allowOrigin(header, policy, origin) {
+		header.Set("Access-Control-Allow-Methods", method)
+		allowHeaders := strings.Join(policy.AllowHeaders, ", ")
+		if allowHeaders == "" {
+			allowHeaders = req.Header.Get("Access-Control-Request-Headers")
+		}
+		if allowHeaders != "" {
+			header.Set("Access-Control-Allow-Headers", allowHeaders)
+		}
+		if policy.MaxAge > 0 {
+			header.Set("Access-Control-Max-Age", strconv.Itoa(policy.MaxAge))
+		}
+	}
+	rw.WriteHeader(http.StatusNoContent)
+	return true
+}
+
+// preflightPolicy returns the policy answering a preflight. Browsers send preflights without the
+// tenant header, so an origin allowed by any tenant's policy passes the preflight; the request
+// itself is then checked against its own tenant's policy.
+func (p *Proxy) preflightPolicy(req *http.Request, origin string) config.CORSPolicy {
+	policy := p.config.CORS.Policy(p.tenant(req), requestHost(req))
+	if originAllowed(policy.AllowOrigins, origin) {
+		return policy
+	}
+	tenants := make([]string, 0, len(p.config.CORS.Tenants))
+	for tenant := range p.config.CORS.Tenants {
+		tenants = append(tenants, tenant)
+	}
+	sort.Strings(tenants)
+	for _, tenant := range tenants {
+		if tenantPolicy := p.config.CORS.Tenants[tenant]; originAllowed(tenantPolicy.AllowOrigins, origin) {
+			return tenantPolicy
+		}
+	}
+	return policy
+}
+
+// allowOrigin sets the headers allowing an origin to read a response, if the policy allows it,
+// and reports whether it does.
+func allowOrigin(header http.Header, policy config.CORSPolicy, origin string) bool {
+	wildcard := false
+	for _, allowed := range policy.AllowOrigins {
+		wildcard = wildcard || allowed == "*"
+	}
+	if wildcard && !policy.AllowCredentials {
+		header.Set("Access-Control-Allow-Origin", "*")
+		return true
+	}
+
+	// The response depends on the origin, so caches must not share it across origins
+	header.Add("Vary", "Origin")
+	if origin == "" || !originAllowed(policy.AllowOrigins, origin) {
+		return false
+	}
+	header.Set("Access-Control-Allow-Origin", origin)
+	if policy.AllowCredentials {
+		header.Set("Access-Control-Allow-Credentials", "true")
+	}
+	return true
+}
+
+// originAllowed reports whether an origin matches one of the allowed origins. "https://*.example.com"
+// matches the subdomains of example.com over https.
+func originAllowed(allowed []string, origin string) bool {
+	if origin == "" {
+		return false
+	}
+	origin = strings.ToLower(origin)
+	for _, pattern := range allowed {
+		pattern = strings.ToLower(pattern)
+		if pattern == "*" || pattern == origin {
+			return true
+		}
+		if i := strings.Index(pattern, "://*."); i >= 0 {
+			scheme, suffix := pattern[:i+3], pattern[i+4:]
+			if strings.HasPrefix(origin, scheme) && strings.HasSuffix(origin, suffix) &&
+				len(origin) > len(scheme)+len(suffix) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// requestHost returns the host a request was sent to, without the port.
+func requestHost(req *http.Request) string {
+	if host, _, err := net.SplitHostPort(req.Host); err == nil {
+		return host
+	}
+	return req.Host
+}
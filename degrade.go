@@ -0,0 +1,27 @@
+package ociaitoopenai
+
+import "github.com/zalbiraw/ociaitoopenai/internal/degrade"
+
+// Optional features turned off by the degradation guard while they keep failing.
+const (
+	featureModelValidation = "modelValidation"
+	featureMirror          = "mirror"
+	featureAudit           = "audit"
+	featureUsage           = "usage"
+)
+
+// featureEnabled reports whether an optional feature should be used for a request.
+func (p *Proxy) featureEnabled(feature string) bool {
+	return p.degrade == nil || p.degrade.Enabled(feature)
+}
+
+// featureFailed returns an error callback that also counts the failure against the feature's
+// error budget, when degradation is configured.
+func featureFailed(guard *degrade.Guard, feature string, onError func(error)) func(error) {
+	return func(err error) {
+		onError(err)
+		if guard != nil {
+			guard.Failure(feature, err)
+		}
+	}
+}
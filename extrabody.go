@@ -0,0 +1,49 @@
+package ociaitoopenai
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"github.com/zalbiraw/ociaitoopenai/pkg/types"
+)
+
+// chatRequestFields are the top-level fields of an OpenAI chat completion request the plugin recognizes.
+var chatRequestFields = jsonFields(reflect.TypeOf(types.ChatCompletionRequest{}))
+
+// jsonFields returns the JSON names of the fields of a struct type.
+func jsonFields(t reflect.Type) map[string]bool {
+	fields := make(map[string]bool, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name != "" && name != "-" {
+			fields[name] = true
+		}
+	}
+	return fields
+}
+
+// extraBody returns the top-level fields of a request body the plugin does not recognize and that
+// are listed in allowed, to be forwarded verbatim. Recognized fields are always transformed instead.
+func extraBody(body []byte, allowed []string) map[string]json.RawMessage {
+	if len(allowed) == 0 {
+		return nil
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil
+	}
+
+	var extra map[string]json.RawMessage
+	for _, name := range allowed {
+		value, sent := fields[name]
+		if !sent || chatRequestFields[name] {
+			continue
+		}
+		if extra == nil {
+			extra = make(map[string]json.RawMessage)
+		}
+		extra[name] = value
+	}
+	return extra
+}
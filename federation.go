@@ -0,0 +1,89 @@
+package ociaitoopenai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/zalbiraw/ociaitoopenai/pkg/types"
+)
+
+// federatedModel returns the compartment of a model and the name OCI knows it by. Models of the
+// compartments in modelCompartments are namespaced as "<namespace>/<model>"; any other model is
+// in the configured compartment.
+func (p *Proxy) federatedModel(model string) (compartmentID, name string) {
+	if namespace, name, ok := strings.Cut(model, "/"); ok {
+		if compartmentID, federated := p.config.ModelCompartments[namespace]; federated {
+			return compartmentID, name
+		}
+	}
+	return p.config.CompartmentID, model
+}
+
+// federatedModels lists the chat models of the configured compartment, followed by those of each
+// compartment in modelCompartments, namespaced. Models listed by an earlier compartment, such as
+// the base models every compartment lists, are not repeated.
+func (p *Proxy) federatedModels(ctx context.Context) ([]types.OCIModel, error) {
+	models, err := p.listModels(ctx, p.config.CompartmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	namespaces := make([]string, 0, len(p.config.ModelCompartments))
+	for namespace := range p.config.ModelCompartments {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+
+	seen := make(map[string]bool, len(models))
+	for _, model := range models {
+		seen[model.ID] = true
+	}
+	for _, namespace := range namespaces {
+		federated, err := p.listModels(ctx, p.config.ModelCompartments[namespace])
+		if err != nil {
+			return nil, fmt.Errorf("failed to list models of %s: %w", namespace, err)
+		}
+		for _, model := range federated {
+			if seen[model.ID] {
+				continue
+			}
+			seen[model.ID] = true
+			model.DisplayName = namespace + "/" + model.DisplayName
+			models = append(models, model)
+		}
+	}
+	return models, nil
+}
+
+// serveFederatedModels answers a models request with the merged models of every configured
+// compartment. The merged list is returned in one page, cut at the requested limit.
+func (p *Proxy) serveFederatedModels(rw http.ResponseWriter, req *http.Request, query modelsQuery) error {
+	models, err := p.federatedModels(req.Context())
+	if err != nil {
+		log.Printf("[%s] ERROR: Failed to list federated models: %v", p.name, err)
+		p.recordFailure(metricUpstreamFailures, "", http.StatusBadGateway)
+		writeError(rw, http.StatusBadGateway, fmt.Sprintf("Failed to list models: %v", err), "", "models_unavailable")
+		return nil
+	}
+
+	resp := p.transformer.ToOpenAIModelsResponse(types.OCIModelsResponse{Items: models})
+	query.filter(&resp)
+	body, err := json.Marshal(resp)
+	if err != nil {
+		log.Printf("[%s] ERROR: Failed to marshal OpenAI models response: %v", p.name, err)
+		p.recordFailure(metricMarshalFailures, "", http.StatusInternalServerError)
+		return fmt.Errorf("failed to marshal OpenAI models response: %w", err)
+	}
+
+	setTransformedEntityHeaders(rw.Header(), body, "")
+	rw.Header().Set("Content-Type", "application/json")
+	log.Printf("[%s] serveFederatedModels: Writing %d models, length=%d", p.name, len(models), len(body))
+	rw.WriteHeader(http.StatusOK)
+	_, _ = rw.Write(body)
+	return nil
+}
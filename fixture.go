@@ -0,0 +1,105 @@
+package ociaitoopenai
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/zalbiraw/ociaitoopenai/internal/config"
+	"github.com/zalbiraw/ociaitoopenai/internal/fixture"
+)
+
+// ociAPIPrefix prefixes the paths of requests rewritten for OCI, the only requests fixtures apply to.
+const ociAPIPrefix = "/20231130/"
+
+// fixtureRecorder passes a response through while keeping a copy of it for recording.
+type fixtureRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (fr *fixtureRecorder) WriteHeader(code int) {
+	if fr.status == 0 {
+		fr.status = code
+	}
+	fr.ResponseWriter.WriteHeader(code)
+}
+
+func (fr *fixtureRecorder) Write(b []byte) (int, error) {
+	if fr.status == 0 {
+		fr.status = http.StatusOK
+	}
+	fr.body.Write(b)
+	return fr.ResponseWriter.Write(b)
+}
+
+// Flush flushes the wrapped writer, so streamed responses are still sent as they arrive.
+func (fr *fixtureRecorder) Flush() {
+	if flusher, ok := fr.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// serveFixture handles an OCI request in fixture mode: in replay mode it is answered from the
+// recorded fixture, in record mode it is forwarded and its response saved.
+func (p *Proxy) serveFixture(rw http.ResponseWriter, req *http.Request) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		if err != nil {
+			log.Printf("[%s] ERROR: Failed to read request body for fixture: %v", p.name, err)
+			writeError(rw, http.StatusInternalServerError, "Failed to read request body", "", "internal_error")
+			return
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	path := req.URL.RequestURI()
+
+	if p.config.Fixtures.Mode == config.FixtureModeReplay {
+		recorded, err := p.fixtures.Load(req.Method, path, body)
+		if err != nil {
+			if os.IsNotExist(err) {
+				log.Printf("[%s] ERROR: No fixture recorded for %s %s", p.name, req.Method, req.URL.Path)
+				writeError(rw, http.StatusBadGateway, "No recorded response matches this request", "", "fixture_not_found")
+				return
+			}
+			log.Printf("[%s] ERROR: Failed to load fixture: %v", p.name, err)
+			writeError(rw, http.StatusInternalServerError, "Failed to load the recorded response", "", "internal_error")
+			return
+		}
+		if recorded.Response.ContentType != "" {
+			rw.Header().Set("Content-Type", recorded.Response.ContentType)
+		}
+		rw.WriteHeader(recorded.Response.Status)
+		_, _ = rw.Write([]byte(recorded.Response.Body))
+		return
+	}
+
+	recorder := &fixtureRecorder{ResponseWriter: rw}
+	p.next.ServeHTTP(recorder, req)
+
+	responseBody, err := p.decompressResponse(recorder.body.Bytes(), recorder.Header())
+	if err != nil {
+		log.Printf("[%s] ERROR: Failed to decompress response for fixture: %v", p.name, err)
+		return
+	}
+	// Only the content type is kept, since other headers may identify the caller
+	response := fixture.Response{
+		Status:      recorder.status,
+		ContentType: recorder.Header().Get("Content-Type"),
+		Body:        string(responseBody),
+	}
+	if err := p.fixtures.Save(req.Method, path, body, response); err != nil {
+		log.Printf("[%s] ERROR: Failed to record fixture: %v", p.name, err)
+	}
+}
+
+// isOCIRequest reports whether a request has been rewritten for OCI.
+func isOCIRequest(req *http.Request) bool {
+	return strings.HasPrefix(req.URL.Path, ociAPIPrefix)
+}
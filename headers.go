@@ -0,0 +1,79 @@
+package ociaitoopenai
+
+import (
+	"net/http"
+	"strings"
+)
+
+// strippedRequestHeaders are client request headers never forwarded to OCI: credentials meant
+// for the gateway, and hop-by-hop headers, which only apply to the client's connection.
+var strippedRequestHeaders = []string{
+	"Authorization",
+	"Cookie",
+	"X-Api-Key",
+	"Connection",
+	"Keep-Alive",
+	"Proxy-Authorization",
+	"Proxy-Connection",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// stripRequestHeaders removes the strippedRequestHeaders, and the headers the client's
+// Connection header names as hop-by-hop, from a request rewritten toward OCI, except those
+// listed in forward. Headers the plugin sets for OCI are never removed as hop-by-hop. It
+// returns the names of the headers removed.
+func stripRequestHeaders(header http.Header, forward []string) []string {
+	names := append([]string(nil), strippedRequestHeaders...)
+	for _, value := range header.Values("Connection") {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" && !ociRequestHeader(name) {
+				names = append(names, name)
+			}
+		}
+	}
+
+	var stripped []string
+	for _, name := range names {
+		name = http.CanonicalHeaderKey(name)
+		if _, ok := header[name]; !ok || forwardedHeader(name, forward) {
+			continue
+		}
+		header.Del(name)
+		stripped = append(stripped, name)
+	}
+	return stripped
+}
+
+// ociRequestHeader reports whether the plugin may set a header on requests to OCI.
+func ociRequestHeader(name string) bool {
+	name = http.CanonicalHeaderKey(name)
+	return name == "Content-Type" || name == "Content-Length" || name == "Accept-Encoding" || strings.HasPrefix(name, "Opc-")
+}
+
+// forwardedHeader reports whether name is listed in forward, ignoring case.
+func forwardedHeader(name string, forward []string) bool {
+	for _, allowed := range forward {
+		if strings.EqualFold(name, allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// credentialHeaders are request headers whose values are never logged. The signer's
+// Authorization header embeds session tokens in its keyId.
+var credentialHeaders = []string{"Authorization", "Cookie", "X-Api-Key", "Proxy-Authorization"}
+
+// redactedHeaders returns a copy of header, for logging, with credential values replaced.
+func redactedHeaders(header http.Header) http.Header {
+	redacted := header.Clone()
+	for _, name := range credentialHeaders {
+		if _, ok := redacted[name]; ok {
+			redacted[name] = []string{"[REDACTED]"}
+		}
+	}
+	return redacted
+}
@@ -0,0 +1,145 @@
+// Package admission sheds low-priority load when the upstream is saturated.
+// A request is shed when the number of requests in flight or the p95 upstream latency of recent
+// requests exceeds its threshold, so it fails fast instead of queueing into a timeout. Latency
+// samples expire with age, so shedding stops once the upstream has been idle for a while.
+package admission
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/zalbiraw/ociaitoopenai/internal/config"
+)
+
+// Controller tracks in-flight requests and recent latencies. It is safe for concurrent use.
+type Controller struct {
+	maxInFlight      int
+	latencyThreshold time.Duration
+	maxAge           time.Duration
+	retryAfter       time.Duration
+	now              func() time.Time
+
+	mu        sync.Mutex
+	inFlight  int
+	latencies []sample // Ring buffer of recent upstream latencies
+	next      int
+	filled    bool
+}
+
+// sample is an upstream latency and when it was observed.
+type sample struct {
+	latency time.Duration
+	at      time.Time
+}
+
+// New creates an admission controller. It returns nil when admission control is disabled.
+func New(cfg config.Admission) *Controller {
+	if !cfg.Enabled {
+		return nil
+	}
+
+	latencyThreshold, _ := time.ParseDuration(cfg.P95Latency)
+	maxAge, _ := time.ParseDuration(cfg.LatencyMaxAge)
+	retryAfter, _ := time.ParseDuration(cfg.RetryAfter)
+	return &Controller{
+		maxInFlight:      cfg.MaxInFlight,
+		latencyThreshold: latencyThreshold,
+		maxAge:           maxAge,
+		retryAfter:       retryAfter,
+		now:              time.Now,
+		latencies:        make([]sample, cfg.LatencyWindow),
+	}
+}
+
+// Admit reports whether a request of the given priority may proceed. Admitted requests
+// must call Done when they complete. Only low-priority requests are shed.
+func (c *Controller) Admit(priority string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if priority == config.PriorityLow && c.overloaded() {
+		return false
+	}
+	c.inFlight++
+	return true
+}
+
+// Done releases the in-flight slot of an admitted request.
+func (c *Controller) Done() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.inFlight--
+}
+
+// Observe records the upstream latency of a request: the time until the upstream response
+// headers arrived. Requests rejected before reaching the upstream are not observed.
+func (c *Controller) Observe(latency time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.latencies) == 0 {
+		return
+	}
+	c.latencies[c.next] = sample{latency: latency, at: c.now()}
+	c.next = (c.next + 1) % len(c.latencies)
+	if c.next == 0 {
+		c.filled = true
+	}
+}
+
+// Stats is a snapshot of the controller state.
+type Stats struct {
+	InFlight   int
+	P95Latency time.Duration
+	Overloaded bool
+}
+
+// Stats returns the current in-flight count, recent p95 latency and whether low-priority
+// requests are being shed.
+func (c *Controller) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return Stats{
+		InFlight:   c.inFlight,
+		P95Latency: c.p95(),
+		Overloaded: c.overloaded(),
+	}
+}
+
+// RetryAfter is the delay suggested to shed clients.
+func (c *Controller) RetryAfter() time.Duration {
+	return c.retryAfter
+}
+
+// overloaded reports whether either threshold is exceeded. The caller must hold the lock.
+func (c *Controller) overloaded() bool {
+	if c.maxInFlight > 0 && c.inFlight >= c.maxInFlight {
+		return true
+	}
+	return c.latencyThreshold > 0 && c.p95() > c.latencyThreshold
+}
+
+// p95 returns the 95th percentile of the recorded latencies younger than the maximum age.
+// The caller must hold the lock.
+func (c *Controller) p95() time.Duration {
+	n := c.next
+	if c.filled {
+		n = len(c.latencies)
+	}
+
+	sorted := make([]time.Duration, 0, n)
+	now := c.now()
+	for _, s := range c.latencies[:n] {
+		if c.maxAge <= 0 || now.Sub(s.at) <= c.maxAge {
+			sorted = append(sorted, s.latency)
+		}
+	}
+	if len(sorted) == 0 {
+		return 0
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted[(len(sorted)*95+99)/100-1]
+}
@@ -0,0 +1,79 @@
+package admission
+
+import (
+	"testing"
+	"time"
+
+	"github.com/zalbiraw/ociaitoopenai/internal/config"
+)
+
+func TestNew_Disabled(t *testing.T) {
+	if c := New(config.Admission{}); c != nil {
+		t.Error("expected no controller when admission control is disabled")
+	}
+}
+
+func TestAdmit_ShedsLowPriorityOverInFlightLimit(t *testing.T) {
+	c := New(config.Admission{Enabled: true, MaxInFlight: 1, LatencyWindow: 10, RetryAfter: "2s"})
+
+	if !c.Admit(config.PriorityNormal) {
+		t.Fatal("expected first request to be admitted")
+	}
+	if c.Admit(config.PriorityLow) {
+		t.Error("expected low-priority request to be shed at the in-flight limit")
+	}
+	if !c.Admit(config.PriorityHigh) {
+		t.Error("expected high-priority request to be admitted at the in-flight limit")
+	}
+
+	c.Done()
+	c.Done()
+	if !c.Admit(config.PriorityLow) {
+		t.Error("expected low-priority request to be admitted once load drops")
+	}
+	if c.RetryAfter() != 2*time.Second {
+		t.Errorf("expected retry after 2s, got %v", c.RetryAfter())
+	}
+}
+
+func TestAdmit_ShedsLowPriorityOverLatencyThreshold(t *testing.T) {
+	c := New(config.Admission{Enabled: true, P95Latency: "1s", LatencyWindow: 20, LatencyMaxAge: "1m"})
+
+	for i := 0; i < 18; i++ {
+		c.Observe(100 * time.Millisecond)
+	}
+	if !c.Admit(config.PriorityLow) {
+		t.Fatal("expected low-priority request to be admitted while latency is low")
+	}
+	c.Observe(100 * time.Millisecond)
+	c.Done()
+
+	for i := 0; i < 2; i++ {
+		c.Observe(5 * time.Second)
+	}
+	if c.Admit(config.PriorityLow) {
+		t.Error("expected low-priority request to be shed once p95 latency exceeds the threshold")
+	}
+}
+
+func TestAdmit_RecoversOnceLatenciesExpire(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	c := New(config.Admission{Enabled: true, P95Latency: "1s", LatencyWindow: 10, LatencyMaxAge: "1m"})
+	c.now = func() time.Time { return now }
+
+	for i := 0; i < 10; i++ {
+		c.Observe(5 * time.Second)
+	}
+	if c.Admit(config.PriorityLow) {
+		t.Fatal("expected low-priority request to be shed while the upstream is slow")
+	}
+
+	// Only low-priority traffic is left, so no new latency is observed; the old samples expire
+	now = now.Add(61 * time.Second)
+	if !c.Admit(config.PriorityLow) {
+		t.Error("expected low-priority request to be admitted once the slow samples expired")
+	}
+	if stats := c.Stats(); stats.P95Latency != 0 || stats.Overloaded {
+		t.Errorf("expected no recent latency and no overload, got %+v", stats)
+	}
+}
@@ -0,0 +1,77 @@
+// Package agents keeps track of OCI Generative AI Agents sessions, so consecutive chat
+// completions of a conversation continue the same agent session.
+package agents
+
+import (
+	"sync"
+	"time"
+)
+
+// Sessions maps conversation keys to agent session IDs. Sessions idle for longer than the TTL
+// are forgotten, since the agent endpoint expires them too. It is safe for concurrent use.
+type Sessions struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu       sync.Mutex
+	sessions map[string]session
+}
+
+type session struct {
+	id       string
+	lastUsed time.Time
+}
+
+// NewSessions creates a session store reusing sessions idle for at most ttl.
+func NewSessions(ttl time.Duration) *Sessions {
+	return &Sessions{
+		ttl:      ttl,
+		now:      time.Now,
+		sessions: make(map[string]session),
+	}
+}
+
+// Key identifies a conversation by tenant, user and agent endpoint.
+func Key(tenant, user, endpointID string) string {
+	return tenant + "\x00" + user + "\x00" + endpointID
+}
+
+// Get returns the session of a conversation, if it has been used within the TTL, and marks it used.
+func (s *Sessions) Get(key string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	entry, ok := s.sessions[key]
+	if !ok {
+		return "", false
+	}
+	if now.Sub(entry.lastUsed) > s.ttl {
+		delete(s.sessions, key)
+		return "", false
+	}
+	entry.lastUsed = now
+	s.sessions[key] = entry
+	return entry.id, true
+}
+
+// Put records the session of a conversation. Expired sessions are pruned at the same time.
+func (s *Sessions) Put(key, id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for k, entry := range s.sessions {
+		if now.Sub(entry.lastUsed) > s.ttl {
+			delete(s.sessions, k)
+		}
+	}
+	s.sessions[key] = session{id: id, lastUsed: now}
+}
+
+// Delete forgets the session of a conversation, such as one the agent endpoint no longer knows.
+func (s *Sessions) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, key)
+}
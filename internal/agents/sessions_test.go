@@ -0,0 +1,44 @@
+package agents
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSessions_ReuseWithinTTL(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := NewSessions(10 * time.Minute)
+	s.now = func() time.Time { return now }
+
+	key := Key("acme", "user-1", "ocid1.genaiagentendpoint.oc1..agent")
+	if _, ok := s.Get(key); ok {
+		t.Fatal("expected no session before one is recorded")
+	}
+	s.Put(key, "session-1")
+
+	// Each use extends the session
+	for i := 0; i < 3; i++ {
+		now = now.Add(8 * time.Minute)
+		if id, ok := s.Get(key); !ok || id != "session-1" {
+			t.Fatalf("expected session-1 to be reused, got %q, %v", id, ok)
+		}
+	}
+
+	if _, ok := s.Get(Key("acme", "user-2", "ocid1.genaiagentendpoint.oc1..agent")); ok {
+		t.Error("expected sessions not to be shared between users")
+	}
+
+	now = now.Add(11 * time.Minute)
+	if _, ok := s.Get(key); ok {
+		t.Error("expected the idle session to expire")
+	}
+}
+
+func TestSessions_Delete(t *testing.T) {
+	s := NewSessions(time.Hour)
+	s.Put("key", "session-1")
+	s.Delete("key")
+	if _, ok := s.Get("key"); ok {
+		t.Error("expected the deleted session to be forgotten")
+	}
+}
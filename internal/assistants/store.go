@@ -0,0 +1,499 @@
+// Package assistants stores the assistants, threads, messages and runs of the emulated OpenAI
+// Assistants API, in memory or in a local JSON file.
+package assistants
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/zalbiraw/ociaitoopenai/pkg/types"
+)
+
+// Run statuses.
+const (
+	RunQueued     = "queued"
+	RunInProgress = "in_progress"
+	RunCompleted  = "completed"
+	RunFailed     = "failed"
+)
+
+// Errors returned by the store.
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrRunActive    = errors.New("thread has an active run")
+	ErrLimitReached = errors.New("limit reached")
+)
+
+// Limits caps what a single owner may store. Zero fields are unlimited.
+type Limits struct {
+	Objects  int // Assistants and threads per owner
+	Messages int // Messages per thread
+}
+
+// Store holds the Assistants API objects. With a file, every change rewrites the file
+// atomically, so objects survive restarts. It is safe for concurrent use.
+//
+// Assistants and threads belong to an owner, and the messages and runs of a thread to the owner
+// of the thread. Objects of other owners are reported as not found.
+//
+// The file is written outside the lock guarding the objects, so reads and changes are not held
+// up by disk writes, and changes made while a write is in progress are saved together.
+type Store struct {
+	file   string
+	limits Limits
+	now    func() time.Time
+
+	writeMu sync.Mutex // Serializes file writes
+	written uint64     // Version last written to the file, guarded by writeMu
+
+	mu         sync.Mutex
+	version    uint64 // Incremented on every change
+	assistants map[string]types.Assistant
+	threads    map[string]types.Thread
+	messages   map[string][]types.ThreadMessage // By thread, oldest first
+	runs       map[string][]types.Run           // By thread, oldest first
+	owners     map[string]string                // Owner of each assistant and thread, by ID
+	counts     map[string]int                   // Assistants and threads of each owner
+}
+
+// snapshot is the file format of a store.
+type snapshot struct {
+	Assistants []types.Assistant     `json:"assistants"`
+	Threads    []types.Thread        `json:"threads"`
+	Messages   []types.ThreadMessage `json:"messages"`
+	Runs       []types.Run           `json:"runs"`
+	Owners     map[string]string     `json:"owners,omitempty"`
+}
+
+// New creates a store, loading the objects saved in file. An empty file keeps objects in memory only.
+func New(file string, limits Limits) (*Store, error) {
+	s := &Store{
+		file:       file,
+		limits:     limits,
+		now:        time.Now,
+		assistants: make(map[string]types.Assistant),
+		threads:    make(map[string]types.Thread),
+		messages:   make(map[string][]types.ThreadMessage),
+		runs:       make(map[string][]types.Run),
+		owners:     make(map[string]string),
+		counts:     make(map[string]int),
+	}
+	if file == "" {
+		return s, nil
+	}
+
+	data, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read assistants file: %w", err)
+	}
+	var saved snapshot
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, fmt.Errorf("failed to parse assistants file: %w", err)
+	}
+	for _, assistant := range saved.Assistants {
+		s.assistants[assistant.ID] = assistant
+	}
+	for _, thread := range saved.Threads {
+		s.threads[thread.ID] = thread
+	}
+	for _, message := range saved.Messages {
+		s.messages[message.ThreadID] = append(s.messages[message.ThreadID], message)
+	}
+	for _, run := range saved.Runs {
+		// Runs do not resume after a restart
+		if run.Status == RunQueued || run.Status == RunInProgress {
+			failedAt := s.now().Unix()
+			run.Status = RunFailed
+			run.FailedAt = &failedAt
+			run.LastError = &types.RunError{Code: "server_error", Message: "The run was interrupted by a restart."}
+		}
+		s.runs[run.ThreadID] = append(s.runs[run.ThreadID], run)
+	}
+	for id, owner := range saved.Owners {
+		s.owners[id] = owner
+		s.counts[owner]++
+	}
+	return s, nil
+}
+
+// CreateAssistant stores a new assistant of an owner, assigning its ID and creation time.
+func (s *Store) CreateAssistant(owner string, assistant types.Assistant) (types.Assistant, error) {
+	s.mu.Lock()
+	if s.limits.Objects > 0 && s.counts[owner] >= s.limits.Objects {
+		s.mu.Unlock()
+		return types.Assistant{}, ErrLimitReached
+	}
+	assistant.ID = newID("asst_")
+	assistant.Object = "assistant"
+	assistant.CreatedAt = s.now().Unix()
+	s.assistants[assistant.ID] = assistant
+	s.setOwner(assistant.ID, owner)
+	s.version++
+	s.mu.Unlock()
+
+	return assistant, s.save()
+}
+
+// Assistant returns an assistant of an owner by ID.
+func (s *Store) Assistant(owner, id string) (types.Assistant, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	assistant, ok := s.assistants[id]
+	if !ok || s.owners[id] != owner {
+		return types.Assistant{}, false
+	}
+	return assistant, true
+}
+
+// Assistants returns the assistants of an owner, oldest first.
+func (s *Store) Assistants(owner string) []types.Assistant {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	assistants := make([]types.Assistant, 0, len(s.assistants))
+	for _, assistant := range s.assistants {
+		if s.owners[assistant.ID] == owner {
+			assistants = append(assistants, assistant)
+		}
+	}
+	sort.Slice(assistants, func(i, j int) bool {
+		if assistants[i].CreatedAt != assistants[j].CreatedAt {
+			return assistants[i].CreatedAt < assistants[j].CreatedAt
+		}
+		return assistants[i].ID < assistants[j].ID
+	})
+	return assistants
+}
+
+// DeleteAssistant deletes an assistant of an owner. Its runs are kept, like OpenAI's.
+func (s *Store) DeleteAssistant(owner, id string) error {
+	s.mu.Lock()
+	if _, ok := s.assistants[id]; !ok || s.owners[id] != owner {
+		s.mu.Unlock()
+		return ErrNotFound
+	}
+	delete(s.assistants, id)
+	s.deleteOwner(id)
+	s.version++
+	s.mu.Unlock()
+
+	return s.save()
+}
+
+// CreateThread stores a new thread of an owner with its initial messages, assigning their IDs
+// and creation times.
+func (s *Store) CreateThread(owner string, thread types.Thread, messages []types.ThreadMessage) (types.Thread, error) {
+	s.mu.Lock()
+	if (s.limits.Objects > 0 && s.counts[owner] >= s.limits.Objects) || (s.limits.Messages > 0 && len(messages) > s.limits.Messages) {
+		s.mu.Unlock()
+		return types.Thread{}, ErrLimitReached
+	}
+	thread.ID = newID("thread_")
+	thread.Object = "thread"
+	thread.CreatedAt = s.now().Unix()
+	s.threads[thread.ID] = thread
+	s.setOwner(thread.ID, owner)
+	for _, message := range messages {
+		message.ThreadID = thread.ID
+		s.addMessage(message)
+	}
+	s.version++
+	s.mu.Unlock()
+
+	return thread, s.save()
+}
+
+// Thread returns a thread of an owner by ID.
+func (s *Store) Thread(owner, id string) (types.Thread, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.ownsThread(owner, id) {
+		return types.Thread{}, false
+	}
+	return s.threads[id], true
+}
+
+// DeleteThread deletes a thread of an owner with its messages and runs.
+func (s *Store) DeleteThread(owner, id string) error {
+	s.mu.Lock()
+	if !s.ownsThread(owner, id) {
+		s.mu.Unlock()
+		return ErrNotFound
+	}
+	delete(s.threads, id)
+	delete(s.messages, id)
+	delete(s.runs, id)
+	s.deleteOwner(id)
+	s.version++
+	s.mu.Unlock()
+
+	return s.save()
+}
+
+// AddMessage adds a message to its thread of an owner, assigning its ID and creation time. Like
+// OpenAI, messages cannot be added while a run is active.
+func (s *Store) AddMessage(owner string, message types.ThreadMessage) (types.ThreadMessage, error) {
+	s.mu.Lock()
+	if !s.ownsThread(owner, message.ThreadID) {
+		s.mu.Unlock()
+		return types.ThreadMessage{}, ErrNotFound
+	}
+	if s.activeRun(message.ThreadID) {
+		s.mu.Unlock()
+		return types.ThreadMessage{}, ErrRunActive
+	}
+	if s.threadFull(message.ThreadID) {
+		s.mu.Unlock()
+		return types.ThreadMessage{}, ErrLimitReached
+	}
+	message = s.addMessage(message)
+	s.version++
+	s.mu.Unlock()
+
+	return message, s.save()
+}
+
+// addMessage appends a message to its thread. The caller must hold the lock.
+func (s *Store) addMessage(message types.ThreadMessage) types.ThreadMessage {
+	message.ID = newID("msg_")
+	message.Object = "thread.message"
+	message.CreatedAt = s.now().Unix()
+	message.Status = "completed"
+	s.messages[message.ThreadID] = append(s.messages[message.ThreadID], message)
+	return message
+}
+
+// Messages returns the messages of a thread of an owner, oldest first.
+func (s *Store) Messages(owner, threadID string) ([]types.ThreadMessage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.ownsThread(owner, threadID) {
+		return nil, ErrNotFound
+	}
+	return append([]types.ThreadMessage(nil), s.messages[threadID]...), nil
+}
+
+// CreateRun stores a new queued run on its thread of an owner, assigning its ID and creation
+// time. A thread has at most one active run, and a run needs room in its thread for the answer.
+func (s *Store) CreateRun(owner string, run types.Run) (types.Run, error) {
+	s.mu.Lock()
+	if !s.ownsThread(owner, run.ThreadID) {
+		s.mu.Unlock()
+		return types.Run{}, ErrNotFound
+	}
+	if s.activeRun(run.ThreadID) {
+		s.mu.Unlock()
+		return types.Run{}, ErrRunActive
+	}
+	if s.threadFull(run.ThreadID) {
+		s.mu.Unlock()
+		return types.Run{}, ErrLimitReached
+	}
+	run.ID = newID("run_")
+	run.Object = "thread.run"
+	run.CreatedAt = s.now().Unix()
+	run.Status = RunQueued
+	s.runs[run.ThreadID] = append(s.runs[run.ThreadID], run)
+	s.version++
+	s.mu.Unlock()
+
+	return run, s.save()
+}
+
+// UpdateRun replaces a stored run.
+func (s *Store) UpdateRun(run types.Run) error {
+	s.mu.Lock()
+	if !s.replaceRun(run) {
+		s.mu.Unlock()
+		return ErrNotFound
+	}
+	s.version++
+	s.mu.Unlock()
+
+	return s.save()
+}
+
+// CompleteRun adds the assistant's answer to the thread and marks the run completed.
+func (s *Store) CompleteRun(run types.Run, answer types.ThreadMessage) error {
+	s.mu.Lock()
+	if !s.replaceRun(run) {
+		s.mu.Unlock()
+		return ErrNotFound
+	}
+	answer.ThreadID = run.ThreadID
+	s.addMessage(answer)
+	s.version++
+	s.mu.Unlock()
+
+	return s.save()
+}
+
+// replaceRun replaces a stored run, reporting whether it was found. The caller must hold the lock.
+func (s *Store) replaceRun(run types.Run) bool {
+	runs := s.runs[run.ThreadID]
+	for i := range runs {
+		if runs[i].ID == run.ID {
+			runs[i] = run
+			return true
+		}
+	}
+	return false
+}
+
+// threadFull reports whether a thread holds as many messages as allowed. The caller must hold the lock.
+func (s *Store) threadFull(threadID string) bool {
+	return s.limits.Messages > 0 && len(s.messages[threadID]) >= s.limits.Messages
+}
+
+// activeRun reports whether a run of the thread is queued or in progress. The caller must hold the lock.
+func (s *Store) activeRun(threadID string) bool {
+	for _, run := range s.runs[threadID] {
+		if run.Status == RunQueued || run.Status == RunInProgress {
+			return true
+		}
+	}
+	return false
+}
+
+// Run returns a run of a thread of an owner by ID.
+func (s *Store) Run(owner, threadID, runID string) (types.Run, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.ownsThread(owner, threadID) {
+		return types.Run{}, false
+	}
+	for _, run := range s.runs[threadID] {
+		if run.ID == runID {
+			return run, true
+		}
+	}
+	return types.Run{}, false
+}
+
+// Runs returns the runs of a thread of an owner, oldest first.
+func (s *Store) Runs(owner, threadID string) ([]types.Run, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.ownsThread(owner, threadID) {
+		return nil, ErrNotFound
+	}
+	return append([]types.Run(nil), s.runs[threadID]...), nil
+}
+
+// setOwner records the owner of an assistant or thread. The caller must hold the lock.
+func (s *Store) setOwner(id, owner string) {
+	if owner != "" {
+		s.owners[id] = owner
+	}
+	s.counts[owner]++
+}
+
+// deleteOwner forgets the owner of a deleted assistant or thread. The caller must hold the lock.
+func (s *Store) deleteOwner(id string) {
+	owner := s.owners[id]
+	delete(s.owners, id)
+	if s.counts[owner]--; s.counts[owner] <= 0 {
+		delete(s.counts, owner)
+	}
+}
+
+// ownsThread reports whether a thread exists and belongs to the owner. The caller must hold the lock.
+func (s *Store) ownsThread(owner, threadID string) bool {
+	_, ok := s.threads[threadID]
+	return ok && s.owners[threadID] == owner
+}
+
+// save writes the store to its file, if it has one. It must be called without the lock, after a
+// change. When a write started after the change has already saved it, nothing is written.
+func (s *Store) save() error {
+	if s.file == "" {
+		return nil
+	}
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	s.mu.Lock()
+	version := s.version
+	if version == s.written {
+		s.mu.Unlock()
+		return nil
+	}
+	saved := s.snapshot()
+	s.mu.Unlock()
+
+	if err := writeSnapshot(s.file, saved); err != nil {
+		return err
+	}
+	s.written = version
+	return nil
+}
+
+// snapshot copies the objects to save, so they can be written after the lock is released. The
+// caller must hold the lock.
+func (s *Store) snapshot() snapshot {
+	saved := snapshot{
+		Assistants: make([]types.Assistant, 0, len(s.assistants)),
+		Threads:    make([]types.Thread, 0, len(s.threads)),
+		Messages:   []types.ThreadMessage{},
+		Runs:       []types.Run{},
+		Owners:     make(map[string]string, len(s.owners)),
+	}
+	for _, assistant := range s.assistants {
+		saved.Assistants = append(saved.Assistants, assistant)
+	}
+	for _, thread := range s.threads {
+		saved.Threads = append(saved.Threads, thread)
+		saved.Messages = append(saved.Messages, s.messages[thread.ID]...)
+		saved.Runs = append(saved.Runs, s.runs[thread.ID]...)
+	}
+	for id, owner := range s.owners {
+		saved.Owners[id] = owner
+	}
+	return saved
+}
+
+// writeSnapshot replaces file with the snapshot atomically.
+func writeSnapshot(file string, saved snapshot) error {
+	data, err := json.Marshal(saved)
+	if err != nil {
+		return fmt.Errorf("failed to marshal assistants: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(file), filepath.Base(file)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("failed to create assistants file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write assistants file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write assistants file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), file); err != nil {
+		return fmt.Errorf("failed to replace assistants file: %w", err)
+	}
+	return nil
+}
+
+// newID returns a random object ID with the given prefix, like OpenAI's.
+func newID(prefix string) string {
+	b := make([]byte, 12)
+	_, _ = rand.Read(b)
+	return prefix + hex.EncodeToString(b)
+}
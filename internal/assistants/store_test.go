@@ -0,0 +1,172 @@
+package assistants
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/zalbiraw/ociaitoopenai/pkg/types"
+)
+
+func TestStore_File(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "assistants.json")
+	s, err := New(file, Limits{})
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	assistant, err := s.CreateAssistant("owner", types.Assistant{Model: "meta.llama-3.3-70b-instruct", Instructions: "Be brief"})
+	if err != nil {
+		t.Fatalf("failed to create assistant: %v", err)
+	}
+	thread, err := s.CreateThread("owner", types.Thread{}, []types.ThreadMessage{{Role: "user", Content: []types.ThreadMessageContent{
+		{Type: "text", Text: types.ThreadMessageText{Value: "Hello"}},
+	}}})
+	if err != nil {
+		t.Fatalf("failed to create thread: %v", err)
+	}
+	completed, _ := s.CreateRun("owner", types.Run{ThreadID: thread.ID, AssistantID: assistant.ID})
+	completed.Status = RunCompleted
+	if err := s.CompleteRun(completed, types.ThreadMessage{Role: "assistant"}); err != nil {
+		t.Fatalf("failed to complete run: %v", err)
+	}
+	running, _ := s.CreateRun("owner", types.Run{ThreadID: thread.ID, AssistantID: assistant.ID})
+	if _, err := s.CreateRun("owner", types.Run{ThreadID: thread.ID, AssistantID: assistant.ID}); !errors.Is(err, ErrRunActive) {
+		t.Errorf("expected a second active run to be rejected, got %v", err)
+	}
+	if _, err := s.AddMessage("owner", types.ThreadMessage{ThreadID: thread.ID, Role: "user"}); !errors.Is(err, ErrRunActive) {
+		t.Errorf("expected messages to be rejected during a run, got %v", err)
+	}
+
+	reloaded, err := New(file, Limits{})
+	if err != nil {
+		t.Fatalf("failed to reload store: %v", err)
+	}
+	if got, ok := reloaded.Assistant("owner", assistant.ID); !ok || got.Instructions != "Be brief" {
+		t.Errorf("expected the assistant to be reloaded, got %+v", got)
+	}
+	messages, err := reloaded.Messages("owner", thread.ID)
+	if err != nil || len(messages) != 2 || messages[0].Text() != "Hello" || messages[1].Role != "assistant" {
+		t.Errorf("expected both messages in order, got %+v, %v", messages, err)
+	}
+	if run, _ := reloaded.Run("owner", thread.ID, completed.ID); run.Status != RunCompleted {
+		t.Errorf("expected the completed run to be kept, got %s", run.Status)
+	}
+	if run, _ := reloaded.Run("owner", thread.ID, running.ID); run.Status != RunFailed || run.LastError == nil {
+		t.Errorf("expected the interrupted run to fail, got %+v", run)
+	}
+}
+
+func TestStore_DeleteThread(t *testing.T) {
+	s, _ := New("", Limits{})
+	thread, _ := s.CreateThread("owner", types.Thread{}, nil)
+	if err := s.DeleteThread("owner", thread.ID); err != nil {
+		t.Fatalf("failed to delete thread: %v", err)
+	}
+	if _, err := s.Messages("owner", thread.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected messages of a deleted thread not to be found, got %v", err)
+	}
+	if _, err := s.AddMessage("owner", types.ThreadMessage{ThreadID: thread.ID}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected adding to a deleted thread to fail, got %v", err)
+	}
+}
+
+func TestStore_Owners(t *testing.T) {
+	s, _ := New("", Limits{})
+	assistant, _ := s.CreateAssistant("tenant-a", types.Assistant{Model: "meta.llama-3.3-70b-instruct"})
+	thread, _ := s.CreateThread("tenant-a", types.Thread{}, nil)
+	_, _ = s.CreateAssistant("", types.Assistant{Model: "meta.llama-3.3-70b-instruct"})
+
+	if list := s.Assistants("tenant-a"); len(list) != 1 || list[0].ID != assistant.ID {
+		t.Errorf("expected only the owner's assistant to be listed, got %+v", list)
+	}
+	for _, owner := range []string{"tenant-b", ""} {
+		if _, ok := s.Assistant(owner, assistant.ID); ok {
+			t.Errorf("expected the assistant to be hidden from %q", owner)
+		}
+		if _, ok := s.Thread(owner, thread.ID); ok {
+			t.Errorf("expected the thread to be hidden from %q", owner)
+		}
+		if _, err := s.Messages(owner, thread.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("expected the messages to be hidden from %q, got %v", owner, err)
+		}
+		if _, err := s.CreateRun(owner, types.Run{ThreadID: thread.ID, AssistantID: assistant.ID}); !errors.Is(err, ErrNotFound) {
+			t.Errorf("expected %q not to run the thread, got %v", owner, err)
+		}
+		if err := s.DeleteAssistant(owner, assistant.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("expected %q not to delete the assistant, got %v", owner, err)
+		}
+	}
+	if _, err := s.CreateRun("tenant-a", types.Run{ThreadID: thread.ID, AssistantID: assistant.ID}); err != nil {
+		t.Errorf("expected the owner to run the thread, got %v", err)
+	}
+}
+
+func TestStore_Limits(t *testing.T) {
+	s, _ := New("", Limits{Objects: 2, Messages: 2})
+	message := types.ThreadMessage{Role: "user"}
+
+	thread, err := s.CreateThread("owner", types.Thread{}, []types.ThreadMessage{message})
+	if err != nil {
+		t.Fatalf("failed to create thread: %v", err)
+	}
+	if _, err := s.CreateThread("owner", types.Thread{}, []types.ThreadMessage{message, message, message}); !errors.Is(err, ErrLimitReached) {
+		t.Errorf("expected a thread with too many messages to be rejected, got %v", err)
+	}
+	assistant, _ := s.CreateAssistant("owner", types.Assistant{Model: "meta.llama-3.3-70b-instruct"})
+	if _, err := s.CreateAssistant("owner", types.Assistant{Model: "meta.llama-3.3-70b-instruct"}); !errors.Is(err, ErrLimitReached) {
+		t.Errorf("expected a third object to be rejected, got %v", err)
+	}
+	if _, err := s.CreateAssistant("other", types.Assistant{Model: "meta.llama-3.3-70b-instruct"}); err != nil {
+		t.Errorf("expected other owners to have their own limit, got %v", err)
+	}
+	if err := s.DeleteAssistant("owner", assistant.ID); err != nil {
+		t.Fatalf("failed to delete assistant: %v", err)
+	}
+	if _, err := s.CreateAssistant("owner", types.Assistant{Model: "meta.llama-3.3-70b-instruct"}); err != nil {
+		t.Errorf("expected deleting to free a slot, got %v", err)
+	}
+
+	// A run needs room in the thread for its answer
+	if _, err := s.AddMessage("owner", types.ThreadMessage{ThreadID: thread.ID, Role: "user"}); err != nil {
+		t.Fatalf("failed to add message: %v", err)
+	}
+	if _, err := s.AddMessage("owner", types.ThreadMessage{ThreadID: thread.ID, Role: "user"}); !errors.Is(err, ErrLimitReached) {
+		t.Errorf("expected a message over the limit to be rejected, got %v", err)
+	}
+	if _, err := s.CreateRun("owner", types.Run{ThreadID: thread.ID}); !errors.Is(err, ErrLimitReached) {
+		t.Errorf("expected a run on a full thread to be rejected, got %v", err)
+	}
+}
+
+func TestStore_ConcurrentSaves(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "assistants.json")
+	s, err := New(file, Limits{})
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(owner string) {
+			defer wg.Done()
+			if _, err := s.CreateThread(owner, types.Thread{}, []types.ThreadMessage{{Role: "user"}}); err != nil {
+				t.Errorf("failed to create thread: %v", err)
+			}
+			_ = s.Assistants(owner)
+		}(fmt.Sprintf("owner-%d", i%4))
+	}
+	wg.Wait()
+
+	// Every change is in the file once its call returns
+	reloaded, err := New(file, Limits{})
+	if err != nil {
+		t.Fatalf("failed to reload store: %v", err)
+	}
+	if got := len(reloaded.threads); got != 20 {
+		t.Errorf("expected 20 threads to be saved, got %d", got)
+	}
+}
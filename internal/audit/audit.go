@@ -0,0 +1,80 @@
+// Package audit writes a structured record of every chat completion handled by the plugin.
+// Records carry request metadata (model, status, client-supplied metadata, service tier) but never
+// message content, and are written asynchronously to the Traefik log, a file, or an HTTP endpoint.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/zalbiraw/ociaitoopenai/internal/config"
+	"github.com/zalbiraw/ociaitoopenai/internal/sink"
+)
+
+// queueSize is the number of records buffered for the background writer.
+const queueSize = 1024
+
+// Record is a single audit log entry.
+type Record struct {
+	Time        time.Time         `json:"time"`
+	RequestID   string            `json:"requestId,omitempty"`
+	Model       string            `json:"model"`
+	Tenant      string            `json:"tenant,omitempty"`
+	ClientIP    string            `json:"clientIp,omitempty"`
+	Status      int               `json:"status"`
+	DurationMs  int64             `json:"durationMs"`
+	ServiceTier string            `json:"serviceTier,omitempty"`
+	Priority    string            `json:"priority,omitempty"`
+	Store       *bool             `json:"store,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+}
+
+// Logger writes audit records to the configured sink.
+type Logger struct {
+	writer  *sink.Async
+	onError func(error)
+}
+
+// New creates an audit logger. It returns nil when auditing is disabled.
+// Without a file or URL, records are written to the Traefik log prefixed with the plugin name.
+func New(ctx context.Context, cfg config.Audit, name string, onError func(error)) *Logger {
+	if !cfg.Enabled {
+		return nil
+	}
+
+	var s sink.Sink
+	switch {
+	case cfg.File != "" && cfg.Rotation.Enabled():
+		s = sink.NewRotatingFile(cfg.File, cfg.Rotation)
+	case cfg.File != "":
+		s = sink.NewFile(cfg.File, 0)
+	case cfg.URL != "":
+		s = sink.NewHTTP(cfg.URL)
+	default:
+		s = sink.NewLog(fmt.Sprintf("[%s] audit: ", name))
+	}
+
+	return &Logger{
+		writer:  sink.NewAsync(ctx, s, queueSize, onError),
+		onError: onError,
+	}
+}
+
+// QueueDepth returns the number of records waiting to be written.
+func (l *Logger) QueueDepth() int {
+	return l.writer.Depth()
+}
+
+// Log queues a record for writing.
+func (l *Logger) Log(record Record) {
+	line, err := json.Marshal(record)
+	if err != nil {
+		if l.onError != nil {
+			l.onError(fmt.Errorf("failed to marshal audit record: %w", err))
+		}
+		return
+	}
+	l.writer.Write(line)
+}
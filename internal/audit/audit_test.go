@@ -0,0 +1,54 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zalbiraw/ociaitoopenai/internal/config"
+)
+
+func TestNew_Disabled(t *testing.T) {
+	if logger := New(context.Background(), config.Audit{}, "test", nil); logger != nil {
+		t.Error("expected no logger when auditing is disabled")
+	}
+}
+
+func TestLogger_WritesRecordsToFile(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	logger := New(ctx, config.Audit{Enabled: true, File: path}, "test", func(err error) {
+		t.Errorf("unexpected audit error: %v", err)
+	})
+
+	store := true
+	logger.Log(Record{
+		Model:       "cohere.command-r",
+		Status:      200,
+		ServiceTier: "flex",
+		Priority:    "low",
+		Store:       &store,
+		Metadata:    map[string]string{"team": "search"},
+	})
+
+	var data []byte
+	deadline := time.Now().Add(2 * time.Second)
+	for len(data) == 0 && time.Now().Before(deadline) {
+		data, _ = os.ReadFile(path)
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	var record Record
+	if err := json.Unmarshal([]byte(strings.TrimSpace(string(data))), &record); err != nil {
+		t.Fatalf("expected a JSON audit record, got %q: %v", data, err)
+	}
+	if record.Metadata["team"] != "search" || record.Priority != "low" || record.Store == nil || !*record.Store {
+		t.Errorf("unexpected audit record: %+v", record)
+	}
+}
@@ -0,0 +1,497 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/zalbiraw/ociaitoopenai/internal/config"
+)
+
+// staticProvider is a KeyProvider returning fixed credentials.
+type staticProvider struct {
+	keyID string
+	key   *rsa.PrivateKey
+}
+
+func (p staticProvider) Credentials() (string, *rsa.PrivateKey, error) {
+	return p.keyID, p.key, nil
+}
+
+func generateKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func encodeKey(key *rsa.PrivateKey) string {
+	return string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}))
+}
+
+// makeToken builds an unsigned JWT expiring at the given time.
+func makeToken(expiry time.Time) string {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`))
+	payload := base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"exp":%d}`, expiry.Unix())))
+	return header + "." + payload + ".sig"
+}
+
+func TestNewSigner_NoneConfigured(t *testing.T) {
+	signer, err := NewSigner(context.Background(), config.New(), nil)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if signer != nil {
+		t.Error("expected no signer when authType is empty")
+	}
+}
+
+func TestSigner_Sign(t *testing.T) {
+	key := generateKey(t)
+	signer := NewSignerWithProvider(staticProvider{keyID: "ST$token", key: key})
+	signer.now = func() time.Time { return time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC) }
+
+	body := []byte(`{"compartmentId":"ocid1.compartment.oc1..example"}`)
+	req := httptest.NewRequest(http.MethodPost, "https://generativeai.us-chicago-1.oci.oraclecloud.com/20231130/actions/chat", nil)
+	req.Header.Set("Content-Type", "application/json")
+
+	if err := signer.Sign(req, body); err != nil {
+		t.Fatalf("failed to sign request: %v", err)
+	}
+
+	authorization := req.Header.Get("Authorization")
+	match := regexp.MustCompile(`headers="([^"]+)",keyId="([^"]+)",algorithm="rsa-sha256",signature="([^"]+)"`).FindStringSubmatch(authorization)
+	if match == nil {
+		t.Fatalf("unexpected Authorization header: %s", authorization)
+	}
+	if match[1] != "date (request-target) host content-length content-type x-content-sha256" {
+		t.Errorf("unexpected signed headers: %s", match[1])
+	}
+	if match[2] != "ST$token" {
+		t.Errorf("unexpected keyId: %s", match[2])
+	}
+
+	digest := sha256.Sum256(body)
+	signingString := strings.Join([]string{
+		"date: Tue, 02 Jan 2024 03:04:05 GMT",
+		"(request-target): post /20231130/actions/chat",
+		"host: generativeai.us-chicago-1.oci.oraclecloud.com",
+		fmt.Sprintf("content-length: %d", len(body)),
+		"content-type: application/json",
+		"x-content-sha256: " + base64.StdEncoding.EncodeToString(digest[:]),
+	}, "\n")
+
+	signature, err := base64.StdEncoding.DecodeString(match[3])
+	if err != nil {
+		t.Fatal(err)
+	}
+	hashed := sha256.Sum256([]byte(signingString))
+	if err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, hashed[:], signature); err != nil {
+		t.Errorf("signature does not verify: %v", err)
+	}
+
+	signedBody, err := io.ReadAll(req.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(signedBody) != string(body) {
+		t.Errorf("expected request body to be the signed payload, got: %s", signedBody)
+	}
+}
+
+func TestSigner_SignGetOmitsBodyHeaders(t *testing.T) {
+	signer := NewSignerWithProvider(staticProvider{keyID: "ST$token", key: generateKey(t)})
+
+	req := httptest.NewRequest(http.MethodGet, "https://generativeai.us-chicago-1.oci.oraclecloud.com/20231130/models?capability=CHAT", nil)
+	if err := signer.Sign(req, nil); err != nil {
+		t.Fatalf("failed to sign request: %v", err)
+	}
+
+	if !strings.Contains(req.Header.Get("Authorization"), `headers="date (request-target) host"`) {
+		t.Errorf("unexpected Authorization header: %s", req.Header.Get("Authorization"))
+	}
+	if req.Header.Get("X-Content-Sha256") != "" {
+		t.Error("expected no content digest for GET requests")
+	}
+}
+
+func TestResourcePrincipalProvider(t *testing.T) {
+	key := generateKey(t)
+	dir := t.TempDir()
+	tokenPath := filepath.Join(dir, "rpst")
+	if err := os.WriteFile(tokenPath, []byte(makeToken(time.Now().Add(time.Hour))), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("OCI_RESOURCE_PRINCIPAL_VERSION", "2.2")
+	t.Setenv("OCI_RESOURCE_PRINCIPAL_RPST", tokenPath)
+	t.Setenv("OCI_RESOURCE_PRINCIPAL_PRIVATE_PEM", encodeKey(key))
+
+	provider, err := NewResourcePrincipalProvider()
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	keyID, signingKey, err := provider.Credentials()
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if !strings.HasPrefix(keyID, "ST$") {
+		t.Errorf("expected session token key ID, got: %s", keyID)
+	}
+	if !signingKey.Equal(key) {
+		t.Error("expected the configured private key")
+	}
+}
+
+func TestResourcePrincipalProvider_UnsupportedVersion(t *testing.T) {
+	t.Setenv("OCI_RESOURCE_PRINCIPAL_VERSION", "1.1")
+
+	if _, err := NewResourcePrincipalProvider(); err == nil {
+		t.Error("expected error for unsupported resource principal version")
+	}
+}
+
+func TestOKEProvider_ExchangesAndRefreshesToken(t *testing.T) {
+	tokenPath := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenPath, []byte("k8s-sa-token\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var exchanges int32
+	server := httptest.NewTLSServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&exchanges, 1)
+		if req.Header.Get("Authorization") != "Bearer k8s-sa-token" {
+			t.Errorf("unexpected Authorization header: %s", req.Header.Get("Authorization"))
+		}
+
+		var payload map[string]string
+		if err := json.NewDecoder(req.Body).Decode(&payload); err != nil || payload["podKey"] == "" {
+			t.Errorf("expected podKey in token request, got: %v (%v)", payload, err)
+		}
+
+		// Tokens that are already inside the refresh window force a renewal on every use
+		token, _ := json.Marshal(map[string]string{"token": "ST$" + makeToken(time.Now().Add(time.Minute))})
+		_, _ = fmt.Fprintf(rw, "%q", base64.StdEncoding.EncodeToString(token))
+	}))
+	defer server.Close()
+
+	provider := newOKEProvider(server.URL+"/resourcePrincipalSessionTokens", tokenPath, server.Client())
+
+	for i := 0; i < 2; i++ {
+		keyID, key, err := provider.Credentials()
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if !strings.HasPrefix(keyID, "ST$") || strings.HasPrefix(keyID, "ST$ST$") {
+			t.Errorf("unexpected key ID: %s", keyID)
+		}
+		if key == nil {
+			t.Error("expected a session key")
+		}
+	}
+
+	if got := atomic.LoadInt32(&exchanges); got != 2 {
+		t.Errorf("expected token to be exchanged twice, got %d", got)
+	}
+}
+
+func TestOKEProvider_KeepsValidTokenOnRefreshFailure(t *testing.T) {
+	provider := &sessionProvider{
+		now: time.Now,
+		current: &sessionCredentials{
+			token:  "still-valid",
+			key:    generateKey(t),
+			expiry: time.Now().Add(time.Minute),
+		},
+		fetch: func() (*sessionCredentials, error) {
+			return nil, fmt.Errorf("proxymux unavailable")
+		},
+	}
+
+	keyID, _, err := provider.Credentials()
+	if err != nil {
+		t.Fatalf("expected current token to be used, got: %v", err)
+	}
+	if keyID != "ST$still-valid" {
+		t.Errorf("unexpected key ID: %s", keyID)
+	}
+}
+
+func TestSessionProvider_ServesCurrentTokenDuringRefresh(t *testing.T) {
+	release := make(chan struct{})
+	fetching := make(chan struct{})
+	key := generateKey(t)
+	provider := &sessionProvider{
+		now: time.Now,
+		current: &sessionCredentials{
+			token:  "still-valid",
+			key:    key,
+			expiry: time.Now().Add(time.Minute),
+		},
+		fetch: func() (*sessionCredentials, error) {
+			close(fetching)
+			<-release
+			return &sessionCredentials{token: "renewed", key: key, expiry: time.Now().Add(time.Hour)}, nil
+		},
+	}
+
+	renewed := make(chan string)
+	go func() {
+		keyID, _, _ := provider.Credentials()
+		renewed <- keyID
+	}()
+	<-fetching
+
+	// A slow token exchange does not hold up requests while the current token is valid
+	keyID, _, err := provider.Credentials()
+	if err != nil || keyID != "ST$still-valid" {
+		t.Errorf("expected the current token during the refresh, got %q, %v", keyID, err)
+	}
+
+	close(release)
+	if keyID := <-renewed; keyID != "ST$renewed" {
+		t.Errorf("expected the renewed token, got %q", keyID)
+	}
+	if keyID, _, _ := provider.Credentials(); keyID != "ST$renewed" {
+		t.Errorf("expected the renewed token to be cached, got %q", keyID)
+	}
+}
+
+func writeSessionFiles(t *testing.T, token string, key *rsa.PrivateKey) (string, *KeySource) {
+	t.Helper()
+	dir := t.TempDir()
+	tokenPath := filepath.Join(dir, "token")
+	keyPath := filepath.Join(dir, "oci_api_key.pem")
+	if err := os.WriteFile(tokenPath, []byte(token), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyPath, []byte(encodeKey(key)), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	keys, err := NewKeySource(config.PrivateKey{File: keyPath}, "us-chicago-1", http.DefaultClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tokenPath, keys
+}
+
+func TestSecurityTokenProvider_RenewsBeforeExpiry(t *testing.T) {
+	oldToken := makeToken(time.Now().Add(2 * time.Minute))
+	newToken := makeToken(time.Now().Add(time.Hour))
+	tokenPath, keys := writeSessionFiles(t, oldToken, generateKey(t))
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if !strings.Contains(req.Header.Get("Authorization"), `keyId="ST$`+oldToken+`"`) {
+			t.Errorf("expected refresh to be signed with the current token, got: %s", req.Header.Get("Authorization"))
+		}
+		var payload map[string]string
+		if err := json.NewDecoder(req.Body).Decode(&payload); err != nil || payload["currentToken"] != oldToken {
+			t.Errorf("expected currentToken in refresh request, got: %v (%v)", payload, err)
+		}
+		_ = json.NewEncoder(rw).Encode(map[string]string{"token": newToken})
+	}))
+	defer server.Close()
+
+	provider, err := NewSecurityTokenProvider(tokenPath, keys, "us-chicago-1", http.DefaultClient)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	provider.refreshURL = server.URL
+	provider.client = server.Client()
+
+	if err := provider.Refresh(); err != nil {
+		t.Fatalf("expected refresh to succeed, got: %v", err)
+	}
+
+	keyID, _, err := provider.Credentials()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keyID != "ST$"+newToken {
+		t.Errorf("expected renewed token to be swapped in, got: %s", keyID)
+	}
+
+	written, err := os.ReadFile(tokenPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(written) != newToken {
+		t.Error("expected renewed token to be written back to the token file")
+	}
+}
+
+func TestSecurityTokenProvider_PicksUpTokenFileChanges(t *testing.T) {
+	tokenPath, keys := writeSessionFiles(t, makeToken(time.Now().Add(time.Hour)), generateKey(t))
+
+	provider, err := NewSecurityTokenProvider(tokenPath, keys, "us-chicago-1", http.DefaultClient)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	reauthenticated := makeToken(time.Now().Add(2 * time.Hour))
+	if err := os.WriteFile(tokenPath, []byte(reauthenticated), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(tokenPath, later, later); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := provider.Refresh(); err != nil {
+		t.Fatalf("expected refresh to succeed, got: %v", err)
+	}
+	keyID, _, err := provider.Credentials()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keyID != "ST$"+reauthenticated {
+		t.Errorf("expected re-created token to be picked up, got: %s", keyID)
+	}
+}
+
+func TestSecurityTokenProvider_ExpiredToken(t *testing.T) {
+	tokenPath, keys := writeSessionFiles(t, makeToken(time.Now().Add(-time.Minute)), generateKey(t))
+
+	provider, err := NewSecurityTokenProvider(tokenPath, keys, "us-chicago-1", http.DefaultClient)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if _, _, err := provider.Credentials(); err == nil {
+		t.Error("expected error for expired security token")
+	}
+}
+
+func TestKeySource_InlineFileAndEnv(t *testing.T) {
+	key := generateKey(t)
+	keyPath := filepath.Join(t.TempDir(), "key.pem")
+	if err := os.WriteFile(keyPath, []byte(encodeKey(key)), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TEST_OCI_PRIVATE_KEY", encodeKey(key))
+
+	sources := map[string]config.PrivateKey{
+		"pem":  {PEM: encodeKey(key)},
+		"file": {File: keyPath},
+		"env":  {Env: "TEST_OCI_PRIVATE_KEY"},
+	}
+	for name, cfg := range sources {
+		keys, err := NewKeySource(cfg, "us-chicago-1", http.DefaultClient)
+		if err != nil {
+			t.Errorf("%s: expected no error, got: %v", name, err)
+			continue
+		}
+		if !keys.Key().Equal(key) {
+			t.Errorf("%s: expected the configured key", name)
+		}
+	}
+}
+
+func TestKeySource_ReloadKeepsKeyOnFailure(t *testing.T) {
+	key := generateKey(t)
+	keyPath := filepath.Join(t.TempDir(), "key.pem")
+	if err := os.WriteFile(keyPath, []byte(encodeKey(key)), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err := NewKeySource(config.PrivateKey{File: keyPath}, "us-chicago-1", http.DefaultClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(keyPath, []byte("not a key"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := keys.Reload(); err == nil {
+		t.Error("expected reload of an invalid key to fail")
+	}
+	if !keys.Key().Equal(key) {
+		t.Error("expected previous key to be kept after a failed reload")
+	}
+
+	rotated := generateKey(t)
+	if err := os.WriteFile(keyPath, []byte(encodeKey(rotated)), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := keys.Reload(); err != nil {
+		t.Fatalf("expected reload to succeed, got: %v", err)
+	}
+	if !keys.Key().Equal(rotated) {
+		t.Error("expected rotated key to be swapped in")
+	}
+}
+
+func TestFetchVaultSecret(t *testing.T) {
+	key := generateKey(t)
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if !strings.HasPrefix(req.Header.Get("Authorization"), "Signature ") {
+			t.Errorf("expected signed vault request, got: %s", req.Header.Get("Authorization"))
+		}
+		_ = json.NewEncoder(rw).Encode(map[string]interface{}{
+			"secretBundleContent": map[string]string{
+				"contentType": "BASE64",
+				"content":     base64.StdEncoding.EncodeToString([]byte(encodeKey(key))),
+			},
+		})
+	}))
+	defer server.Close()
+
+	signer := NewSignerWithProvider(staticProvider{keyID: "ST$token", key: generateKey(t)})
+	content, err := fetchVaultSecret(server.Client(), signer, server.URL+"/20190301/secretbundles/ocid1.vaultsecret.oc1..example")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	parsed, err := parsePrivateKey(content)
+	if err != nil {
+		t.Fatalf("expected vault content to be a private key, got: %v", err)
+	}
+	if !parsed.Equal(key) {
+		t.Error("expected the key stored in the vault secret")
+	}
+}
+
+func TestNewSigner_APIKey(t *testing.T) {
+	key := generateKey(t)
+	cfg := config.New()
+	cfg.AuthType = TypeAPIKey
+	cfg.TenancyID = "ocid1.tenancy.oc1..tenancy"
+	cfg.UserID = "ocid1.user.oc1..user"
+	cfg.Fingerprint = "aa:bb:cc"
+	cfg.PrivateKey.PEM = encodeKey(key)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	signer, err := NewSigner(ctx, cfg, nil)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "https://generativeai.us-chicago-1.oci.oraclecloud.com/20231130/models", nil)
+	if err := signer.Sign(req, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(req.Header.Get("Authorization"), `keyId="ocid1.tenancy.oc1..tenancy/ocid1.user.oc1..user/aa:bb:cc"`) {
+		t.Errorf("unexpected Authorization header: %s", req.Header.Get("Authorization"))
+	}
+}
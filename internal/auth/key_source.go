@@ -0,0 +1,162 @@
+package auth
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/zalbiraw/ociaitoopenai/internal/config"
+)
+
+// defaultKeyRefreshInterval is how often key material is reloaded when no interval is configured.
+const defaultKeyRefreshInterval = time.Hour
+
+// KeySource loads the signing private key from inline PEM, a file, an environment variable,
+// or an OCI Vault secret, and reloads it periodically so rotated keys are picked up.
+type KeySource struct {
+	mu   sync.RWMutex
+	key  *rsa.PrivateKey
+	load func() ([]byte, error)
+}
+
+// NewKeySource creates a key source for the configured private key and loads the key.
+// Vault secrets are fetched with client, using the credentials of the configured vaultAuthType.
+func NewKeySource(cfg config.PrivateKey, region string, client *http.Client) (*KeySource, error) {
+	source := &KeySource{}
+
+	switch {
+	case cfg.PEM != "":
+		source.load = func() ([]byte, error) { return []byte(cfg.PEM), nil }
+	case cfg.File != "":
+		source.load = func() ([]byte, error) { return os.ReadFile(cfg.File) }
+	case cfg.Env != "":
+		source.load = func() ([]byte, error) {
+			value := os.Getenv(cfg.Env)
+			if value == "" {
+				return nil, fmt.Errorf("environment variable %s is empty", cfg.Env)
+			}
+			return []byte(value), nil
+		}
+	case cfg.VaultSecretID != "":
+		provider, err := newProvider(cfg.VaultAuthType)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize vault credentials: %w", err)
+		}
+		endpoint := fmt.Sprintf("https://secrets.vaults.%s.oci.oraclecloud.com/20190301/secretbundles/%s",
+			region, url.PathEscape(cfg.VaultSecretID))
+		source.load = func() ([]byte, error) {
+			return fetchVaultSecret(client, NewSignerWithProvider(provider), endpoint)
+		}
+	default:
+		return nil, fmt.Errorf("no private key source configured")
+	}
+
+	if err := source.Reload(); err != nil {
+		return nil, err
+	}
+	return source, nil
+}
+
+// Key returns the current private key.
+func (s *KeySource) Key() *rsa.PrivateKey {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.key
+}
+
+// Reload loads the key again and swaps it in. The previous key is kept on failure.
+func (s *KeySource) Reload() error {
+	data, err := s.load()
+	if err != nil {
+		return fmt.Errorf("failed to load private key: %w", err)
+	}
+	key, err := parsePrivateKey(data)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.key = key
+	s.mu.Unlock()
+	return nil
+}
+
+// Run reloads the key on the given interval until the context is cancelled.
+func (s *KeySource) Run(ctx context.Context, interval time.Duration, onError func(error)) {
+	if interval <= 0 {
+		interval = defaultKeyRefreshInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Reload(); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
+
+// fetchVaultSecret reads the current version of a secret from OCI Vault.
+func fetchVaultSecret(client *http.Client, signer *Signer, endpoint string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create vault request: %w", err)
+	}
+	if err := signer.Sign(req, nil); err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch vault secret: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read vault response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("vault secret fetch failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var bundle struct {
+		SecretBundleContent struct {
+			ContentType string `json:"contentType"`
+			Content     string `json:"content"`
+		} `json:"secretBundleContent"`
+	}
+	if err := json.Unmarshal(body, &bundle); err != nil {
+		return nil, fmt.Errorf("failed to parse vault secret bundle: %w", err)
+	}
+
+	content, err := base64.StdEncoding.DecodeString(bundle.SecretBundleContent.Content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode vault secret content: %w", err)
+	}
+	return content, nil
+}
+
+// apiKeyProvider signs as an IAM user with an API signing key.
+type apiKeyProvider struct {
+	keyID string
+	keys  *KeySource
+}
+
+// Credentials implements KeyProvider.
+func (p *apiKeyProvider) Credentials() (string, *rsa.PrivateKey, error) {
+	return p.keyID, p.keys.Key(), nil
+}
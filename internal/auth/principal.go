@@ -0,0 +1,263 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// refreshWindow is how long before a session token expires that it is renewed.
+const refreshWindow = 5 * time.Minute
+
+// Well-known locations of the Kubernetes service account credentials mounted into OKE pods.
+const (
+	okeServiceAccountTokenPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+	okeServiceAccountCAPath    = "/var/run/secrets/kubernetes.io/serviceaccount/ca.crt"
+	okeProxymuxPort            = "12250"
+)
+
+// sessionCredentials holds a resource principal session token and its private key.
+type sessionCredentials struct {
+	token  string
+	key    *rsa.PrivateKey
+	expiry time.Time
+}
+
+// sessionProvider caches session credentials and renews them shortly before they expire.
+// The fetch function obtains fresh credentials.
+type sessionProvider struct {
+	mu      sync.Mutex
+	current *sessionCredentials
+	refresh *sessionRefresh // Renewal in flight, nil when none
+	fetch   func() (*sessionCredentials, error)
+	now     func() time.Time
+}
+
+// sessionRefresh is a single renewal shared by the callers waiting on it.
+type sessionRefresh struct {
+	done chan struct{} // Closed once the renewal completes
+	err  error
+}
+
+// Credentials implements KeyProvider.
+// Only one caller renews the token, outside the lock; the others keep using the current token
+// while it is valid, and wait for the renewal otherwise. If renewal fails while the current
+// token is still valid, the current token is used.
+func (p *sessionProvider) Credentials() (string, *rsa.PrivateKey, error) {
+	p.mu.Lock()
+	current := p.current
+	if current != nil && !p.now().Add(refreshWindow).After(current.expiry) {
+		p.mu.Unlock()
+		return "ST$" + current.token, current.key, nil
+	}
+	leader := p.refresh == nil
+	if leader {
+		p.refresh = &sessionRefresh{done: make(chan struct{})}
+	}
+	refresh := p.refresh
+	p.mu.Unlock()
+
+	switch {
+	case leader:
+		fresh, err := p.fetch()
+		p.mu.Lock()
+		if err == nil {
+			p.current = fresh
+		}
+		refresh.err = err
+		p.refresh = nil
+		p.mu.Unlock()
+		close(refresh.done)
+	case current != nil && p.now().Before(current.expiry):
+		return "ST$" + current.token, current.key, nil
+	default:
+		<-refresh.done
+	}
+
+	p.mu.Lock()
+	current = p.current
+	p.mu.Unlock()
+	if current == nil || !p.now().Before(current.expiry) {
+		if refresh.err != nil {
+			return "", nil, refresh.err
+		}
+		return "", nil, fmt.Errorf("session token has expired")
+	}
+	return "ST$" + current.token, current.key, nil
+}
+
+// NewResourcePrincipalProvider creates a provider for Resource Principal v2.2 credentials.
+// The token and private key are read from the OCI_RESOURCE_PRINCIPAL_RPST and
+// OCI_RESOURCE_PRINCIPAL_PRIVATE_PEM environment variables, which hold either the value
+// itself or an absolute path to a file that is re-read when the token nears expiry.
+func NewResourcePrincipalProvider() (KeyProvider, error) {
+	if version := os.Getenv("OCI_RESOURCE_PRINCIPAL_VERSION"); version != "2.2" {
+		return nil, fmt.Errorf("unsupported OCI_RESOURCE_PRINCIPAL_VERSION %q, expected 2.2", version)
+	}
+
+	tokenSource := os.Getenv("OCI_RESOURCE_PRINCIPAL_RPST")
+	keySource := os.Getenv("OCI_RESOURCE_PRINCIPAL_PRIVATE_PEM")
+	if tokenSource == "" || keySource == "" {
+		return nil, fmt.Errorf("OCI_RESOURCE_PRINCIPAL_RPST and OCI_RESOURCE_PRINCIPAL_PRIVATE_PEM must be set")
+	}
+	if os.Getenv("OCI_RESOURCE_PRINCIPAL_PRIVATE_PEM_PASSPHRASE") != "" {
+		return nil, fmt.Errorf("encrypted resource principal private keys are not supported")
+	}
+
+	provider := &sessionProvider{
+		now: time.Now,
+		fetch: func() (*sessionCredentials, error) {
+			return loadResourcePrincipal(tokenSource, keySource)
+		},
+	}
+
+	// Fail fast on misconfiguration
+	if _, _, err := provider.Credentials(); err != nil {
+		return nil, err
+	}
+	return provider, nil
+}
+
+// loadResourcePrincipal reads the resource principal token and private key.
+func loadResourcePrincipal(tokenSource, keySource string) (*sessionCredentials, error) {
+	token, err := readValueOrFile(tokenSource)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load resource principal token: %w", err)
+	}
+
+	keyPEM, err := readValueOrFile(keySource)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load resource principal private key: %w", err)
+	}
+	key, err := parsePrivateKey(keyPEM)
+	if err != nil {
+		return nil, err
+	}
+
+	return newSessionCredentials(strings.TrimSpace(string(token)), key)
+}
+
+// NewOKEWorkloadIdentityProvider creates a provider that exchanges the pod's Kubernetes service
+// account token for a resource principal session token with the OKE proxymux service.
+// A fresh session key pair is generated for every exchange.
+func NewOKEWorkloadIdentityProvider() (KeyProvider, error) {
+	host := os.Getenv("KUBERNETES_SERVICE_HOST")
+	if host == "" {
+		return nil, fmt.Errorf("KUBERNETES_SERVICE_HOST is not set; OKE workload identity is only available in pods")
+	}
+
+	tokenPath := os.Getenv("OCI_KUBERNETES_SERVICE_ACCOUNT_TOKEN_PATH")
+	if tokenPath == "" {
+		tokenPath = okeServiceAccountTokenPath
+	}
+
+	caPEM, err := os.ReadFile(okeServiceAccountCAPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Kubernetes CA certificate: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("no certificates found in %s", okeServiceAccountCAPath)
+	}
+
+	client := &http.Client{
+		Timeout: 10 * time.Second,
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12},
+		},
+	}
+	endpoint := fmt.Sprintf("https://%s/resourcePrincipalSessionTokens", net.JoinHostPort(host, okeProxymuxPort))
+
+	return newOKEProvider(endpoint, tokenPath, client), nil
+}
+
+// newOKEProvider creates an OKE workload identity provider for the given proxymux endpoint.
+// Tokens are exchanged lazily on first use.
+func newOKEProvider(endpoint, tokenPath string, client *http.Client) *sessionProvider {
+	return &sessionProvider{
+		now: time.Now,
+		fetch: func() (*sessionCredentials, error) {
+			return exchangeServiceAccountToken(client, endpoint, tokenPath)
+		},
+	}
+}
+
+// exchangeServiceAccountToken requests a resource principal session token for a new session key.
+func exchangeServiceAccountToken(client *http.Client, endpoint, tokenPath string) (*sessionCredentials, error) {
+	saToken, err := os.ReadFile(tokenPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read service account token: %w", err)
+	}
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate session key: %w", err)
+	}
+	publicKey, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode session public key: %w", err)
+	}
+
+	payload, err := json.Marshal(map[string]string{
+		"podKey": base64.StdEncoding.EncodeToString(publicKey),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal token request: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(saToken)))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange service account token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("token exchange failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	// The proxymux returns a (possibly quoted) base64-encoded JSON document
+	decoded, err := base64.StdEncoding.DecodeString(strings.Trim(strings.TrimSpace(string(body)), `"`))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode token response: %w", err)
+	}
+	var tokenResp struct {
+		Token string `json:"token"`
+	}
+	if err := json.Unmarshal(decoded, &tokenResp); err != nil {
+		return nil, fmt.Errorf("failed to parse token response: %w", err)
+	}
+
+	return newSessionCredentials(strings.TrimPrefix(tokenResp.Token, "ST$"), key)
+}
+
+// newSessionCredentials pairs a token with its key, reading the expiry from the token.
+func newSessionCredentials(token string, key *rsa.PrivateKey) (*sessionCredentials, error) {
+	expiry, err := tokenExpiry(token)
+	if err != nil {
+		return nil, fmt.Errorf("invalid session token: %w", err)
+	}
+	return &sessionCredentials{token: token, key: key, expiry: expiry}, nil
+}
@@ -0,0 +1,185 @@
+package auth
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// securityTokenCheckInterval is how often the background refresher checks the session token.
+const securityTokenCheckInterval = time.Minute
+
+// SecurityTokenProvider supplies OCI session token credentials, as created by `oci session authenticate`.
+// A background refresher renews the token with the OCI auth service shortly before it expires and picks up
+// tokens re-created on disk, swapping the credentials in place so in-flight signers use them immediately.
+type SecurityTokenProvider struct {
+	mu      sync.RWMutex
+	current *sessionCredentials
+	modTime time.Time
+
+	tokenPath  string
+	keys       *KeySource
+	refreshURL string
+	client     *http.Client
+	now        func() time.Time
+}
+
+// NewSecurityTokenProvider creates a provider reading the session token from the given file
+// and the matching session key from the key source. Tokens are refreshed with client.
+func NewSecurityTokenProvider(tokenPath string, keys *KeySource, region string, client *http.Client) (*SecurityTokenProvider, error) {
+	provider := &SecurityTokenProvider{
+		tokenPath:  tokenPath,
+		keys:       keys,
+		refreshURL: fmt.Sprintf("https://auth.%s.oraclecloud.com/v1/authentication/refresh", region),
+		client:     client,
+		now:        time.Now,
+	}
+
+	if _, err := provider.reload(); err != nil {
+		return nil, err
+	}
+	return provider, nil
+}
+
+// Credentials implements KeyProvider.
+func (p *SecurityTokenProvider) Credentials() (string, *rsa.PrivateKey, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if !p.now().Before(p.current.expiry) {
+		return "", nil, fmt.Errorf("security token expired at %s", p.current.expiry.Format(time.RFC3339))
+	}
+	return "ST$" + p.current.token, p.keys.Key(), nil
+}
+
+// Run refreshes the session token in the background until the context is cancelled.
+// Refresh errors are passed to onError, if set, and retried on the next check.
+func (p *SecurityTokenProvider) Run(ctx context.Context, onError func(error)) {
+	ticker := time.NewTicker(securityTokenCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := p.Refresh(); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
+
+// Refresh reloads the token if it changed on disk and renews it if it is about to expire.
+func (p *SecurityTokenProvider) Refresh() error {
+	if _, err := p.reload(); err != nil {
+		return err
+	}
+
+	p.mu.RLock()
+	expiry := p.current.expiry
+	p.mu.RUnlock()
+
+	if p.now().Add(refreshWindow).Before(expiry) {
+		return nil
+	}
+	return p.renew()
+}
+
+// reload reads the token file if it changed since the last load.
+func (p *SecurityTokenProvider) reload() (bool, error) {
+	info, err := os.Stat(p.tokenPath)
+	if err != nil {
+		return false, fmt.Errorf("failed to stat security token file: %w", err)
+	}
+
+	p.mu.RLock()
+	unchanged := p.current != nil && info.ModTime().Equal(p.modTime)
+	p.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	token, err := os.ReadFile(p.tokenPath)
+	if err != nil {
+		return false, fmt.Errorf("failed to read security token file: %w", err)
+	}
+	creds, err := newSessionCredentials(strings.TrimSpace(string(token)), nil)
+	if err != nil {
+		return false, err
+	}
+
+	p.mu.Lock()
+	p.current = creds
+	p.modTime = info.ModTime()
+	p.mu.Unlock()
+	return true, nil
+}
+
+// renew exchanges the current token for a new one with the OCI auth service.
+// The new token is written back to the token file so other tools share it.
+func (p *SecurityTokenProvider) renew() error {
+	p.mu.RLock()
+	current := p.current
+	p.mu.RUnlock()
+
+	payload, err := json.Marshal(map[string]string{"currentToken": current.token})
+	if err != nil {
+		return fmt.Errorf("failed to marshal refresh request: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, p.refreshURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create refresh request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if err := NewSignerWithProvider(p).Sign(req, payload); err != nil {
+		return err
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to refresh security token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read refresh response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("security token refresh failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var tokenResp struct {
+		Token string `json:"token"`
+	}
+	if err := json.Unmarshal(body, &tokenResp); err != nil {
+		return fmt.Errorf("failed to parse refresh response: %w", err)
+	}
+	creds, err := newSessionCredentials(tokenResp.Token, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(p.tokenPath, []byte(creds.token), 0o600); err != nil {
+		return fmt.Errorf("failed to write refreshed security token: %w", err)
+	}
+	info, err := os.Stat(p.tokenPath)
+	if err != nil {
+		return fmt.Errorf("failed to stat security token file: %w", err)
+	}
+
+	p.mu.Lock()
+	p.current = creds
+	p.modTime = info.ModTime()
+	p.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,181 @@
+// Package auth provides the built-in OCI request signer for the OCI to OpenAI transformation plugin.
+//
+// By default the plugin relies on a separate authentication middleware (such as ociauth) placed after it
+// in the chain. When an authType is configured, requests are signed by the plugin itself using the
+// OCI HTTP signature scheme with credentials supplied by a KeyProvider.
+package auth
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/zalbiraw/ociaitoopenai/internal/config"
+	"github.com/zalbiraw/ociaitoopenai/internal/egress"
+)
+
+// Supported values for the authType configuration option.
+const (
+	// TypeNone leaves signing to a downstream middleware.
+	TypeNone = ""
+
+	// TypeResourcePrincipal signs with a Resource Principal Session Token (RPST).
+	TypeResourcePrincipal = "resource_principal"
+
+	// TypeOKEWorkloadIdentity exchanges the pod's Kubernetes service account token for an RPST.
+	TypeOKEWorkloadIdentity = "oke_workload_identity"
+
+	// TypeSecurityToken signs with an OCI CLI session token, refreshed in the background.
+	TypeSecurityToken = "security_token"
+
+	// TypeAPIKey signs as an IAM user with an API signing key.
+	TypeAPIKey = "api_key"
+)
+
+// KeyProvider supplies the credentials used to sign requests.
+// Implementations refresh their credentials as needed and must be safe for concurrent use.
+type KeyProvider interface {
+	// Credentials returns the key ID and the private key matching it.
+	Credentials() (keyID string, key *rsa.PrivateKey, err error)
+}
+
+// Signer signs outgoing OCI requests.
+type Signer struct {
+	provider KeyProvider
+	now      func() time.Time
+}
+
+// NewSigner creates a signer for the configured authType.
+// It returns nil when no built-in signing is configured.
+// Background credential refreshers run until ctx is cancelled and report failures to onError.
+func NewSigner(ctx context.Context, cfg *config.Config, onError func(error)) (*Signer, error) {
+	switch cfg.AuthType {
+	case TypeNone:
+		return nil, nil
+	case TypeSecurityToken, TypeAPIKey:
+		// Key-based auth types load the private key from the configured source
+	default:
+		provider, err := newProvider(cfg.AuthType)
+		if err != nil {
+			return nil, err
+		}
+		return NewSignerWithProvider(provider), nil
+	}
+
+	// Vault and token refresh calls go to OCI directly rather than through the Traefik service
+	client, err := egress.NewClient(cfg.Egress, 30*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := NewKeySource(cfg.PrivateKey, cfg.Region, client)
+	if err != nil {
+		return nil, err
+	}
+	interval, _ := time.ParseDuration(cfg.PrivateKey.RefreshInterval)
+	go keys.Run(ctx, interval, onError)
+
+	if cfg.AuthType == TypeAPIKey {
+		return NewSignerWithProvider(&apiKeyProvider{
+			keyID: cfg.TenancyID + "/" + cfg.UserID + "/" + cfg.Fingerprint,
+			keys:  keys,
+		}), nil
+	}
+
+	tokenProvider, err := NewSecurityTokenProvider(cfg.SecurityTokenFile, keys, cfg.Region, client)
+	if err != nil {
+		return nil, err
+	}
+	go tokenProvider.Run(ctx, onError)
+	return NewSignerWithProvider(tokenProvider), nil
+}
+
+// newProvider creates the credentials provider for a principal-based auth type.
+func newProvider(authType string) (KeyProvider, error) {
+	switch authType {
+	case TypeResourcePrincipal:
+		return NewResourcePrincipalProvider()
+	case TypeOKEWorkloadIdentity:
+		return NewOKEWorkloadIdentityProvider()
+	default:
+		return nil, fmt.Errorf("unsupported authType: %s", authType)
+	}
+}
+
+// NewSignerWithProvider creates a signer using the given credentials provider.
+func NewSignerWithProvider(provider KeyProvider) *Signer {
+	return &Signer{
+		provider: provider,
+		now:      time.Now,
+	}
+}
+
+// Sign adds the OCI HTTP signature headers to the request.
+// The body must be the exact payload that will be sent; it may be nil for requests without a body.
+func (s *Signer) Sign(req *http.Request, body []byte) error {
+	keyID, key, err := s.provider.Credentials()
+	if err != nil {
+		return fmt.Errorf("failed to obtain signing credentials: %w", err)
+	}
+
+	// The host header must match what is signed
+	req.Host = req.URL.Host
+	req.Header.Set("Date", s.now().UTC().Format(http.TimeFormat))
+
+	headers := []string{"date", "(request-target)", "host"}
+	if hasBody(req.Method) {
+		if body == nil {
+			body = []byte{}
+		}
+		digest := sha256.Sum256(body)
+		req.Header.Set("X-Content-Sha256", base64.StdEncoding.EncodeToString(digest[:]))
+		req.Header.Set("Content-Length", fmt.Sprintf("%d", len(body)))
+		if req.Header.Get("Content-Type") == "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		req.ContentLength = int64(len(body))
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		headers = append(headers, "content-length", "content-type", "x-content-sha256")
+	}
+
+	lines := make([]string, 0, len(headers))
+	for _, name := range headers {
+		lines = append(lines, fmt.Sprintf("%s: %s", name, headerValue(req, name)))
+	}
+	digest := sha256.Sum256([]byte(strings.Join(lines, "\n")))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return fmt.Errorf("failed to sign request: %w", err)
+	}
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		`Signature version="1",headers=%q,keyId=%q,algorithm="rsa-sha256",signature=%q`,
+		strings.Join(headers, " "), keyID, base64.StdEncoding.EncodeToString(signature),
+	))
+	return nil
+}
+
+// headerValue returns the value of a signed header, including the (request-target) pseudo-header.
+func headerValue(req *http.Request, name string) string {
+	switch name {
+	case "(request-target)":
+		return strings.ToLower(req.Method) + " " + req.URL.RequestURI()
+	case "host":
+		return req.URL.Host
+	default:
+		return req.Header.Get(name)
+	}
+}
+
+// hasBody reports whether the body-related headers must be signed for the method.
+func hasBody(method string) bool {
+	return method == http.MethodPost || method == http.MethodPut || method == http.MethodPatch
+}
@@ -0,0 +1,177 @@
+// Package balancer spreads requests across OCI GenAI endpoints serving the same model.
+// Endpoints are chosen by weighted round-robin or lowest recent latency, and endpoints that
+// fail repeatedly are taken out of rotation for a cooldown period.
+package balancer
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/zalbiraw/ociaitoopenai/internal/config"
+)
+
+// latencyDecay is the weight given to the newest sample in the latency moving average.
+const latencyDecay = 0.3
+
+// Endpoint is a balanced endpoint and its health state.
+type Endpoint struct {
+	// Host is the endpoint host requests are sent to.
+	Host string
+
+	weight         int
+	current        int           // Smooth weighted round-robin counter
+	latency        time.Duration // Exponentially weighted moving average of request latency
+	failures       int           // Consecutive failures
+	unhealthyUntil time.Time
+}
+
+// Balancer picks endpoints for requests. It is safe for concurrent use.
+type Balancer struct {
+	strategy         string
+	failureThreshold int
+	cooldown         time.Duration
+	now              func() time.Time
+
+	mu        sync.Mutex
+	endpoints []*Endpoint
+}
+
+// New creates a balancer. It returns nil when no endpoints are configured.
+func New(cfg config.LoadBalancing) *Balancer {
+	if len(cfg.Endpoints) == 0 {
+		return nil
+	}
+
+	cooldown, _ := time.ParseDuration(cfg.Cooldown)
+	b := &Balancer{
+		strategy:         cfg.Strategy,
+		failureThreshold: cfg.FailureThreshold,
+		cooldown:         cooldown,
+		now:              time.Now,
+	}
+	for _, endpoint := range cfg.Endpoints {
+		host := endpoint.Host
+		if host == "" {
+			host = fmt.Sprintf("generativeai.%s.oci.oraclecloud.com", endpoint.Region)
+		}
+		weight := endpoint.Weight
+		if weight == 0 {
+			weight = 1
+		}
+		b.endpoints = append(b.endpoints, &Endpoint{Host: host, weight: weight})
+	}
+	return b
+}
+
+// Pick returns the endpoint the next request should be sent to. When every endpoint is
+// unhealthy, all of them are considered so requests are not rejected outright.
+func (b *Balancer) Pick() *Endpoint {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	candidates := make([]*Endpoint, 0, len(b.endpoints))
+	for _, endpoint := range b.endpoints {
+		if !now.Before(endpoint.unhealthyUntil) {
+			candidates = append(candidates, endpoint)
+		}
+	}
+	if len(candidates) == 0 {
+		candidates = b.endpoints
+	}
+
+	if b.strategy == config.BalanceLeastLatency {
+		return leastLatency(candidates)
+	}
+	return weightedRoundRobin(candidates)
+}
+
+// Circuit states reported for endpoints.
+const (
+	StateClosed = "closed" // In rotation
+	StateOpen   = "open"   // Out of rotation until the cooldown expires
+)
+
+// EndpointStatus is a snapshot of an endpoint's health.
+type EndpointStatus struct {
+	Host     string
+	State    string
+	Failures int
+	Latency  time.Duration
+}
+
+// Status returns the health of every endpoint, in configuration order.
+func (b *Balancer) Status() []EndpointStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	statuses := make([]EndpointStatus, 0, len(b.endpoints))
+	for _, endpoint := range b.endpoints {
+		state := StateClosed
+		if now.Before(endpoint.unhealthyUntil) {
+			state = StateOpen
+		}
+		statuses = append(statuses, EndpointStatus{
+			Host:     endpoint.Host,
+			State:    state,
+			Failures: endpoint.failures,
+			Latency:  endpoint.latency,
+		})
+	}
+	return statuses
+}
+
+// Report records the outcome of a request sent to endpoint. Server errors and throttling
+// count as failures; any other status, including client errors, marks the endpoint healthy.
+func (b *Balancer) Report(endpoint *Endpoint, status int, latency time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if status >= http.StatusInternalServerError || status == http.StatusTooManyRequests {
+		endpoint.failures++
+		if endpoint.failures >= b.failureThreshold {
+			endpoint.unhealthyUntil = b.now().Add(b.cooldown)
+			endpoint.failures = 0
+		}
+		return
+	}
+
+	endpoint.failures = 0
+	endpoint.unhealthyUntil = time.Time{}
+	if endpoint.latency == 0 {
+		endpoint.latency = latency
+	} else {
+		endpoint.latency = time.Duration(latencyDecay*float64(latency) + (1-latencyDecay)*float64(endpoint.latency))
+	}
+}
+
+// weightedRoundRobin implements smooth weighted round-robin, which interleaves endpoints in
+// proportion to their weights. The caller must hold the lock.
+func weightedRoundRobin(candidates []*Endpoint) *Endpoint {
+	var best *Endpoint
+	total := 0
+	for _, endpoint := range candidates {
+		endpoint.current += endpoint.weight
+		total += endpoint.weight
+		if best == nil || endpoint.current > best.current {
+			best = endpoint
+		}
+	}
+	best.current -= total
+	return best
+}
+
+// leastLatency returns the candidate with the lowest average latency. Endpoints without
+// a latency sample yet are preferred so every endpoint gets measured.
+func leastLatency(candidates []*Endpoint) *Endpoint {
+	best := candidates[0]
+	for _, endpoint := range candidates[1:] {
+		if endpoint.latency < best.latency {
+			best = endpoint
+		}
+	}
+	return best
+}
@@ -0,0 +1,93 @@
+package balancer
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/zalbiraw/ociaitoopenai/internal/config"
+)
+
+func newBalancer(strategy string, endpoints ...config.Endpoint) *Balancer {
+	cfg := config.New().LoadBalancing
+	cfg.Strategy = strategy
+	cfg.Endpoints = endpoints
+	return New(cfg)
+}
+
+func TestNew_DisabledWithoutEndpoints(t *testing.T) {
+	if b := New(config.New().LoadBalancing); b != nil {
+		t.Error("expected nil balancer without endpoints")
+	}
+}
+
+func TestPick_WeightedRoundRobin(t *testing.T) {
+	b := newBalancer(config.BalanceRoundRobin,
+		config.Endpoint{Region: "us-chicago-1", Weight: 2},
+		config.Endpoint{Host: "dedicated.example.com"},
+	)
+
+	counts := map[string]int{}
+	for i := 0; i < 6; i++ {
+		counts[b.Pick().Host]++
+	}
+	if counts["generativeai.us-chicago-1.oci.oraclecloud.com"] != 4 || counts["dedicated.example.com"] != 2 {
+		t.Errorf("expected a 2:1 split, got %v", counts)
+	}
+}
+
+func TestPick_LeastLatency(t *testing.T) {
+	b := newBalancer(config.BalanceLeastLatency,
+		config.Endpoint{Region: "us-chicago-1"},
+		config.Endpoint{Region: "eu-frankfurt-1"},
+	)
+
+	slow := b.Pick()
+	b.Report(slow, http.StatusOK, 2*time.Second)
+	fast := b.Pick()
+	if fast == slow {
+		t.Fatal("expected the unmeasured endpoint to be picked next")
+	}
+	b.Report(fast, http.StatusOK, 100*time.Millisecond)
+
+	if got := b.Pick(); got != fast {
+		t.Errorf("expected the faster endpoint %s, got %s", fast.Host, got.Host)
+	}
+}
+
+func TestReport_TakesFailingEndpointOutOfRotation(t *testing.T) {
+	b := newBalancer(config.BalanceLeastLatency,
+		config.Endpoint{Region: "us-chicago-1"},
+		config.Endpoint{Region: "eu-frankfurt-1"},
+	)
+	now := time.Now()
+	b.now = func() time.Time { return now }
+
+	failing := b.endpoints[0]
+	healthy := b.endpoints[1]
+	b.Report(healthy, http.StatusOK, time.Second)
+	for i := 0; i < 3; i++ {
+		b.Report(failing, http.StatusTooManyRequests, 0)
+	}
+
+	if got := b.Pick(); got != healthy {
+		t.Errorf("expected the healthy endpoint while the other cools down, got %s", got.Host)
+	}
+
+	now = now.Add(31 * time.Second)
+	if got := b.Pick(); got != failing {
+		t.Errorf("expected the endpoint to return to rotation after the cooldown, got %s", got.Host)
+	}
+}
+
+func TestPick_FallsBackWhenAllUnhealthy(t *testing.T) {
+	b := newBalancer(config.BalanceRoundRobin, config.Endpoint{Region: "us-chicago-1"})
+	endpoint := b.endpoints[0]
+	for i := 0; i < 3; i++ {
+		b.Report(endpoint, http.StatusServiceUnavailable, 0)
+	}
+
+	if got := b.Pick(); got != endpoint {
+		t.Errorf("expected the only endpoint to be picked, got %v", got)
+	}
+}
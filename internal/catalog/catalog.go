@@ -0,0 +1,173 @@
+// Package catalog resolves requested model names against the OCI GenAI model catalog.
+// The catalog is cached, and names that are not found are remembered briefly so clients
+// repeatedly requesting a nonexistent model do not trigger a catalog lookup every time.
+package catalog
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/zalbiraw/ociaitoopenai/pkg/types"
+)
+
+// revalidateTimeout bounds a background refresh of a stale catalog.
+const revalidateTimeout = 30 * time.Second
+
+// FetchFunc lists the models available in the OCI catalog.
+type FetchFunc func(ctx context.Context) ([]types.OCIModel, error)
+
+// Catalog is a cached view of the OCI model catalog. It is safe for concurrent use.
+type Catalog struct {
+	fetch       FetchFunc
+	ttl         time.Duration
+	negativeTTL time.Duration
+	maxStale    time.Duration
+	now         func() time.Time
+
+	mu         sync.Mutex
+	models     map[string]types.OCIModel // Active models by display name and ID
+	fetchedAt  time.Time
+	refreshing bool                 // A stale catalog is being refreshed in the background
+	misses     map[string]time.Time // Expiry of "not found" results by name
+	hits       int64                // Lookups answered from the cache
+	lookups    int64                // All lookups
+
+	refreshMu sync.Mutex // Serializes catalog fetches
+}
+
+// New creates a catalog caching fetched models for ttl and "not found" results for negativeTTL.
+// For maxStale after ttl, models are still answered from the cache while it is refreshed in
+// the background, so lookups stay fast when OCI is slow or briefly unavailable.
+func New(fetch FetchFunc, ttl, negativeTTL, maxStale time.Duration) *Catalog {
+	return &Catalog{
+		fetch:       fetch,
+		ttl:         ttl,
+		negativeTTL: negativeTTL,
+		maxStale:    maxStale,
+		now:         time.Now,
+		misses:      make(map[string]time.Time),
+	}
+}
+
+// Resolve looks up a model by display name or ID. It reports false when the model is not in
+// the catalog, refreshing the catalog at most once per call and never while a "not found"
+// result for the name is cached.
+func (c *Catalog) Resolve(ctx context.Context, name string) (types.OCIModel, bool, error) {
+	if model, found, cached := c.lookup(name); cached {
+		return model, found, nil
+	}
+
+	if err := c.refresh(ctx); err != nil {
+		return types.OCIModel{}, false, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	model, found := c.models[name]
+	if !found {
+		c.misses[name] = c.now().Add(c.negativeTTL)
+	}
+	return model, found, nil
+}
+
+// Known reports whether the model is in the cached catalog. Unlike Resolve it never fetches
+// the catalog and is not counted in Stats, so it suits bounding metric labels.
+func (c *Catalog) Known(name string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, found := c.models[name]
+	return found
+}
+
+// lookup answers from the cache. cached is false when the catalog must be refreshed first.
+func (c *Catalog) lookup(name string) (model types.OCIModel, found, cached bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.lookups++
+	defer func() {
+		if cached {
+			c.hits++
+		}
+	}()
+
+	now := c.now()
+	if expiry, ok := c.misses[name]; ok {
+		if now.Before(expiry) {
+			return types.OCIModel{}, false, true
+		}
+		delete(c.misses, name)
+	}
+
+	age := now.Sub(c.fetchedAt)
+	if c.models == nil || age >= c.ttl+c.maxStale {
+		return types.OCIModel{}, false, false
+	}
+	model, found = c.models[name]
+	if found && age >= c.ttl {
+		c.revalidate()
+	}
+	// A catalog that lacks the model may predate it; let the caller refresh
+	return model, found, found
+}
+
+// revalidate refreshes a stale catalog in the background, unless a refresh is already running.
+// A failed refresh leaves the stale catalog in place until it exceeds maxStale. The caller must
+// hold the lock.
+func (c *Catalog) revalidate() {
+	if c.refreshing {
+		return
+	}
+	c.refreshing = true
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), revalidateTimeout)
+		defer cancel()
+		_ = c.refresh(ctx)
+
+		c.mu.Lock()
+		c.refreshing = false
+		c.mu.Unlock()
+	}()
+}
+
+// Stats returns the number of lookups and how many were answered from the cache.
+func (c *Catalog) Stats() (lookups, hits int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lookups, c.hits
+}
+
+// refresh fetches the catalog, unless another caller refreshed it while this one waited.
+func (c *Catalog) refresh(ctx context.Context) error {
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+
+	requested := c.now()
+	c.mu.Lock()
+	recent := c.models != nil && requested.Sub(c.fetchedAt) < time.Second
+	c.mu.Unlock()
+	if recent {
+		return nil
+	}
+
+	items, err := c.fetch(ctx)
+	if err != nil {
+		return err
+	}
+
+	models := make(map[string]types.OCIModel, 2*len(items))
+	for _, item := range items {
+		if item.LifecycleState != "" && item.LifecycleState != "ACTIVE" {
+			continue
+		}
+		models[item.DisplayName] = item
+		models[item.ID] = item
+	}
+
+	c.mu.Lock()
+	c.models = models
+	c.fetchedAt = c.now()
+	c.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,140 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zalbiraw/ociaitoopenai/pkg/types"
+)
+
+func newTestCatalog(fetches *int, err error) (*Catalog, *time.Time) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := New(func(ctx context.Context) ([]types.OCIModel, error) {
+		*fetches++
+		return []types.OCIModel{
+			{ID: "ocid1.generativeaimodel.oc1..llama", DisplayName: "meta.llama-3.3-70b-instruct", LifecycleState: "ACTIVE"},
+			{ID: "ocid1.generativeaimodel.oc1..old", DisplayName: "cohere.command", LifecycleState: "DELETED"},
+		}, err
+	}, 5*time.Minute, 30*time.Second, 0)
+	c.now = func() time.Time { return now }
+	return c, &now
+}
+
+func TestResolve_CachesCatalog(t *testing.T) {
+	fetches := 0
+	c, _ := newTestCatalog(&fetches, nil)
+
+	for _, name := range []string{"meta.llama-3.3-70b-instruct", "ocid1.generativeaimodel.oc1..llama"} {
+		_, found, err := c.Resolve(context.Background(), name)
+		if err != nil || !found {
+			t.Errorf("expected %s to resolve, got found=%v err=%v", name, found, err)
+		}
+	}
+	if fetches != 1 {
+		t.Errorf("expected 1 catalog fetch, got %d", fetches)
+	}
+	if lookups, hits := c.Stats(); lookups != 2 || hits != 1 {
+		t.Errorf("expected 2 lookups with 1 cache hit, got %d and %d", lookups, hits)
+	}
+}
+
+func TestKnown_UsesCacheOnly(t *testing.T) {
+	fetches := 0
+	c, _ := newTestCatalog(&fetches, nil)
+
+	if c.Known("meta.llama-3.3-70b-instruct") {
+		t.Error("expected no model to be known before the catalog is fetched")
+	}
+	if _, _, err := c.Resolve(context.Background(), "meta.llama-3.3-70b-instruct"); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if !c.Known("meta.llama-3.3-70b-instruct") || c.Known("cohere.command") || c.Known("unknown-model") {
+		t.Error("expected only active catalog models to be known")
+	}
+	if fetches != 1 {
+		t.Errorf("expected Known not to fetch the catalog, got %d fetches", fetches)
+	}
+	if lookups, _ := c.Stats(); lookups != 1 {
+		t.Errorf("expected Known not to count as a lookup, got %d lookups", lookups)
+	}
+}
+
+func TestResolve_NegativeCache(t *testing.T) {
+	fetches := 0
+	c, now := newTestCatalog(&fetches, nil)
+
+	for i := 0; i < 5; i++ {
+		if _, found, _ := c.Resolve(context.Background(), "cohere.command"); found {
+			t.Fatal("expected inactive model not to resolve")
+		}
+	}
+	if fetches != 1 {
+		t.Errorf("expected repeated misses to be served from the negative cache, got %d fetches", fetches)
+	}
+
+	*now = now.Add(time.Minute)
+	_, _, _ = c.Resolve(context.Background(), "cohere.command")
+	if fetches != 2 {
+		t.Errorf("expected a new lookup once the negative cache expired, got %d fetches", fetches)
+	}
+}
+
+func TestResolve_StaleWhileRevalidate(t *testing.T) {
+	fetched := make(chan struct{}, 10)
+	c := New(func(ctx context.Context) ([]types.OCIModel, error) {
+		fetched <- struct{}{}
+		return []types.OCIModel{{ID: "ocid1.generativeaimodel.oc1..llama", DisplayName: "meta.llama-3.3-70b-instruct"}}, nil
+	}, 5*time.Minute, 30*time.Second, time.Hour)
+	var mu sync.Mutex
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c.now = func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	}
+	advance := func(d time.Duration) {
+		mu.Lock()
+		now = now.Add(d)
+		mu.Unlock()
+	}
+
+	if _, found, err := c.Resolve(context.Background(), "meta.llama-3.3-70b-instruct"); err != nil || !found {
+		t.Fatalf("expected the model to resolve, got found=%v err=%v", found, err)
+	}
+	<-fetched
+
+	// A stale catalog answers at once and is refreshed in the background
+	advance(10 * time.Minute)
+	if _, found, err := c.Resolve(context.Background(), "meta.llama-3.3-70b-instruct"); err != nil || !found {
+		t.Fatalf("expected the stale catalog to answer, got found=%v err=%v", found, err)
+	}
+	if lookups, hits := c.Stats(); lookups != 2 || hits != 1 {
+		t.Errorf("expected the stale lookup to be a cache hit, got %d lookups and %d hits", lookups, hits)
+	}
+	select {
+	case <-fetched:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected a background refresh")
+	}
+
+	// Beyond the staleness bound, the catalog is refreshed before answering
+	advance(2 * time.Hour)
+	if _, found, _ := c.Resolve(context.Background(), "meta.llama-3.3-70b-instruct"); !found {
+		t.Fatal("expected the model to resolve")
+	}
+	if lookups, hits := c.Stats(); lookups != 3 || hits != 1 {
+		t.Errorf("expected a synchronous refresh, got %d lookups and %d hits", lookups, hits)
+	}
+}
+
+func TestResolve_FetchError(t *testing.T) {
+	fetches := 0
+	c, _ := newTestCatalog(&fetches, errors.New("catalog unavailable"))
+
+	if _, _, err := c.Resolve(context.Background(), "meta.llama-3.3-70b-instruct"); err == nil {
+		t.Error("expected fetch error to be returned")
+	}
+}
@@ -0,0 +1,121 @@
+// Package chaos draws the faults injected into responses when chaos mode is enabled, so clients
+// can test their retry logic against the gateway: added latency, 429s, truncated bodies and
+// malformed JSON.
+package chaos
+
+import (
+	"bytes"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/zalbiraw/ociaitoopenai/internal/config"
+)
+
+// Fault names, as reported in the X-Ociai-Chaos response header and accepted in the opt-in request header.
+const (
+	FaultLatency   = "latency"
+	FaultRateLimit = "rate_limit"
+	FaultTruncate  = "truncate"
+	FaultMalformed = "malformed_json"
+)
+
+// Faults are the faults drawn for a request.
+type Faults struct {
+	Delay     time.Duration // Added before the request is handled
+	RateLimit bool          // Answer with a 429 instead of handling the request
+	Truncate  bool          // Cut a successful response off halfway
+	Malformed bool          // Corrupt the JSON of a successful response
+}
+
+// Names returns the names of the faults, in the order they are applied.
+func (f Faults) Names() []string {
+	var names []string
+	if f.Delay > 0 {
+		names = append(names, FaultLatency)
+	}
+	if f.RateLimit {
+		names = append(names, FaultRateLimit)
+	}
+	if f.Truncate {
+		names = append(names, FaultTruncate)
+	}
+	if f.Malformed {
+		names = append(names, FaultMalformed)
+	}
+	return names
+}
+
+// Injector draws faults with the configured probabilities. It is safe for concurrent use.
+type Injector struct {
+	cfg        config.Chaos
+	latency    time.Duration
+	retryAfter time.Duration
+	random     func() float64
+}
+
+// New creates an injector. It returns nil when chaos mode is disabled.
+func New(cfg config.Chaos) *Injector {
+	if !cfg.Enabled {
+		return nil
+	}
+
+	latency, _ := time.ParseDuration(cfg.Latency)
+	retryAfter, _ := time.ParseDuration(cfg.RetryAfter)
+	return &Injector{
+		cfg:        cfg,
+		latency:    latency,
+		retryAfter: retryAfter,
+		random:     rand.Float64,
+	}
+}
+
+// RetryAfter returns the delay suggested by injected 429s.
+func (i *Injector) RetryAfter() time.Duration {
+	return i.retryAfter
+}
+
+// Draw returns the faults to inject into a request's response. With an opt-in header configured,
+// requests without it get none, and a header value naming a fault selects that fault alone.
+func (i *Injector) Draw(req *http.Request) Faults {
+	if i.cfg.Header != "" {
+		switch req.Header.Get(i.cfg.Header) {
+		case "":
+			return Faults{}
+		case FaultLatency:
+			return Faults{Delay: i.latency}
+		case FaultRateLimit:
+			return Faults{RateLimit: true}
+		case FaultTruncate:
+			return Faults{Truncate: true}
+		case FaultMalformed:
+			return Faults{Malformed: true}
+		}
+	}
+
+	var faults Faults
+	if i.random() < i.cfg.LatencyProbability {
+		faults.Delay = i.latency
+	}
+	faults.RateLimit = i.random() < i.cfg.RateLimitProbability
+	faults.Truncate = i.random() < i.cfg.TruncateProbability
+	faults.Malformed = i.random() < i.cfg.MalformedProbability
+	return faults
+}
+
+// Truncate returns the first half of a response body.
+func Truncate(body []byte) []byte {
+	return body[:len(body)/2]
+}
+
+// Malform corrupts the first JSON object of a response body, which is the whole body of a
+// JSON response or the first event of a stream, by doubling its opening brace.
+func Malform(body []byte) []byte {
+	brace := bytes.IndexByte(body, '{')
+	if brace < 0 {
+		return append([]byte("{"), body...)
+	}
+	malformed := make([]byte, 0, len(body)+1)
+	malformed = append(malformed, body[:brace+1]...)
+	return append(malformed, body[brace:]...)
+}
@@ -0,0 +1,76 @@
+package chaos
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/zalbiraw/ociaitoopenai/internal/config"
+)
+
+func TestNew_Disabled(t *testing.T) {
+	if New(config.New().Chaos) != nil {
+		t.Error("expected no injector when chaos mode is disabled")
+	}
+}
+
+func TestDraw_Probabilities(t *testing.T) {
+	cfg := config.New().Chaos
+	cfg.Enabled = true
+	cfg.LatencyProbability = 0.5
+	cfg.RateLimitProbability = 0.1
+	cfg.TruncateProbability = 0.5
+	cfg.MalformedProbability = 0
+	injector := New(cfg)
+
+	draws := []float64{0.2, 0.3, 0.4, 0.9}
+	injector.random = func() float64 {
+		draw := draws[0]
+		draws = draws[1:]
+		return draw
+	}
+	faults := injector.Draw(httptest.NewRequest("POST", "/v1/chat/completions", nil))
+	want := Faults{Delay: 2 * time.Second, Truncate: true}
+	if faults != want {
+		t.Errorf("expected %+v, got %+v", want, faults)
+	}
+	if names := faults.Names(); !reflect.DeepEqual(names, []string{FaultLatency, FaultTruncate}) {
+		t.Errorf("expected latency and truncate, got %v", names)
+	}
+}
+
+func TestDraw_Header(t *testing.T) {
+	cfg := config.New().Chaos
+	cfg.Enabled = true
+	cfg.Header = "X-Chaos"
+	cfg.MalformedProbability = 1
+	injector := New(cfg)
+
+	req := httptest.NewRequest("POST", "/v1/chat/completions", nil)
+	if faults := injector.Draw(req); len(faults.Names()) != 0 {
+		t.Errorf("expected no faults without the header, got %v", faults.Names())
+	}
+
+	req.Header.Set("X-Chaos", FaultRateLimit)
+	if faults := injector.Draw(req); faults != (Faults{RateLimit: true}) {
+		t.Errorf("expected only the named fault, got %+v", faults)
+	}
+
+	req.Header.Set("X-Chaos", "1")
+	if faults := injector.Draw(req); faults != (Faults{Malformed: true}) {
+		t.Errorf("expected the probabilities to apply, got %+v", faults)
+	}
+}
+
+func TestTruncateAndMalform(t *testing.T) {
+	if got := string(Truncate([]byte(`{"id":"x"}`))); got != `{"id"` {
+		t.Errorf("expected the first half, got %q", got)
+	}
+	if got := string(Malform([]byte("data: {\"id\":\"x\"}\n\ndata: [DONE]\n\n"))); got != "data: {{\"id\":\"x\"}\n\ndata: [DONE]\n\n" {
+		t.Errorf("expected the first event corrupted, got %q", got)
+	}
+	if got := string(Malform([]byte("[DONE]"))); got != "{[DONE]" {
+		t.Errorf("expected a body without objects corrupted, got %q", got)
+	}
+}
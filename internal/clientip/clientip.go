@@ -0,0 +1,104 @@
+// Package clientip determines the address of the client behind any trusted proxies.
+package clientip
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/zalbiraw/ociaitoopenai/internal/config"
+)
+
+// Resolver extracts the client IP of requests, honouring the forwarded header only when it was
+// set by a trusted proxy.
+type Resolver struct {
+	trusted []*net.IPNet
+	header  string
+}
+
+// New creates a resolver for the configured trusted proxies.
+func New(cfg config.ClientIP) (*Resolver, error) {
+	trusted, err := ParseNetworks(cfg.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+	header := cfg.Header
+	if header == "" {
+		header = "X-Forwarded-For"
+	}
+	return &Resolver{trusted: trusted, header: header}, nil
+}
+
+// ParseNetworks parses CIDRs, accepting single IP addresses as /32 or /128 networks.
+func ParseNetworks(values []string) ([]*net.IPNet, error) {
+	networks := make([]*net.IPNet, 0, len(values))
+	for _, value := range values {
+		if !strings.Contains(value, "/") {
+			ip := net.ParseIP(value)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid IP address or CIDR %q", value)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip = ip.To4()
+				bits = 8 * net.IPv4len
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid IP address or CIDR %q", value)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+// Contains reports whether ip is in any of the networks.
+func Contains(networks []*net.IPNet, ip net.IP) bool {
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP returns the client IP of a request. When the connection comes from a trusted proxy,
+// the forwarded header is walked from the nearest hop outwards and the first address that is
+// not a trusted proxy is the client. Otherwise the connection's peer address is the client.
+func (r *Resolver) ClientIP(req *http.Request) string {
+	remote := req.RemoteAddr
+	if host, _, err := net.SplitHostPort(remote); err == nil {
+		remote = host
+	}
+	remoteIP := net.ParseIP(remote)
+	if remoteIP == nil || !Contains(r.trusted, remoteIP) {
+		return remote
+	}
+
+	var hops []string
+	for _, value := range req.Header.Values(r.header) {
+		for _, hop := range strings.Split(value, ",") {
+			if hop = strings.TrimSpace(hop); hop != "" {
+				hops = append(hops, hop)
+			}
+		}
+	}
+
+	client := remote
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip := net.ParseIP(hops[i])
+		if ip == nil {
+			// A malformed hop cannot be trusted to have forwarded anything further out
+			return client
+		}
+		client = ip.String()
+		if !Contains(r.trusted, ip) {
+			return client
+		}
+	}
+	return client
+}
@@ -0,0 +1,57 @@
+package clientip
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/zalbiraw/ociaitoopenai/internal/config"
+)
+
+func TestResolver_ClientIP(t *testing.T) {
+	resolver, err := New(config.ClientIP{TrustedProxies: []string{"10.0.0.0/8", "192.168.1.1"}})
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		remote    string
+		forwarded string
+		expected  string
+	}{
+		{"direct client", "203.0.113.7:5000", "", "203.0.113.7"},
+		{"untrusted peer cannot spoof the header", "203.0.113.7:5000", "198.51.100.1", "203.0.113.7"},
+		{"trusted proxy", "10.0.0.5:5000", "198.51.100.1", "198.51.100.1"},
+		{"chain of trusted proxies", "10.0.0.5:5000", "198.51.100.1, 192.168.1.1, 10.1.2.3", "198.51.100.1"},
+		{"client spoofing behind a proxy", "10.0.0.5:5000", "1.2.3.4, 198.51.100.1", "198.51.100.1"},
+		{"every hop trusted", "10.0.0.5:5000", "10.9.9.9", "10.9.9.9"},
+		{"malformed hop", "10.0.0.5:5000", "198.51.100.1, unknown", "10.0.0.5"},
+		{"trusted proxy without header", "10.0.0.5:5000", "", "10.0.0.5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = tt.remote
+			if tt.forwarded != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+			if got := resolver.ClientIP(req); got != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestParseNetworks(t *testing.T) {
+	networks, err := ParseNetworks([]string{"10.0.0.0/8", "2001:db8::1"})
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if len(networks) != 2 || networks[1].String() != "2001:db8::1/128" {
+		t.Errorf("unexpected networks: %v", networks)
+	}
+
+	if _, err := ParseNetworks([]string{"10.0.0.0/33"}); err == nil {
+		t.Error("expected error for an invalid CIDR")
+	}
+}
@@ -0,0 +1,44 @@
+package clientip
+
+import (
+	"net"
+
+	"github.com/zalbiraw/ociaitoopenai/internal/config"
+)
+
+// Filter allows or blocks client IPs by network.
+type Filter struct {
+	allow []*net.IPNet
+	deny  []*net.IPNet
+}
+
+// NewFilter creates a filter for the configured networks. It returns nil when no networks are configured.
+func NewFilter(cfg config.IPFilter) (*Filter, error) {
+	if len(cfg.Allow) == 0 && len(cfg.Deny) == 0 {
+		return nil, nil
+	}
+
+	allow, err := ParseNetworks(cfg.Allow)
+	if err != nil {
+		return nil, err
+	}
+	deny, err := ParseNetworks(cfg.Deny)
+	if err != nil {
+		return nil, err
+	}
+	return &Filter{allow: allow, deny: deny}, nil
+}
+
+// Allowed reports whether a client IP may use the gateway. Denied networks take precedence;
+// when allowed networks are configured, every other address is blocked. Addresses that cannot
+// be parsed are only allowed when there is no allowlist.
+func (f *Filter) Allowed(clientIP string) bool {
+	ip := net.ParseIP(clientIP)
+	if ip == nil {
+		return len(f.allow) == 0
+	}
+	if Contains(f.deny, ip) {
+		return false
+	}
+	return len(f.allow) == 0 || Contains(f.allow, ip)
+}
@@ -0,0 +1,36 @@
+package clientip
+
+import (
+	"testing"
+
+	"github.com/zalbiraw/ociaitoopenai/internal/config"
+)
+
+func TestFilter_Allowed(t *testing.T) {
+	filter, err := NewFilter(config.IPFilter{Allow: []string{"10.0.0.0/8"}, Deny: []string{"10.66.0.0/16"}})
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	for ip, expected := range map[string]bool{
+		"10.1.2.3":    true,
+		"10.66.1.1":   false,
+		"203.0.113.7": false,
+		"not-an-ip":   false,
+	} {
+		if got := filter.Allowed(ip); got != expected {
+			t.Errorf("expected Allowed(%s) to be %v", ip, expected)
+		}
+	}
+
+	denyOnly, _ := NewFilter(config.IPFilter{Deny: []string{"203.0.113.0/24"}})
+	if denyOnly.Allowed("203.0.113.7") || !denyOnly.Allowed("198.51.100.1") {
+		t.Error("expected only the denied network to be blocked")
+	}
+}
+
+func TestNewFilter_Disabled(t *testing.T) {
+	if filter, err := NewFilter(config.IPFilter{}); filter != nil || err != nil {
+		t.Errorf("expected no filter, got %v, %v", filter, err)
+	}
+}
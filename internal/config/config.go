@@ -4,6 +4,13 @@ package config
 
 import (
 	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/zalbiraw/ociaitoopenai/pkg/vendors"
 )
 
 // Config represents the plugin configuration with all available options.
@@ -13,27 +20,2174 @@ type Config struct {
 	// This is required and must be provided in the plugin configuration.
 	CompartmentID string `json:"compartmentId,omitempty"`
 
+	// ModelCompartments federates the models of other compartments, such as a team compartment
+	// with fine-tuned models, by namespace. Their models are listed and requested as
+	// "<namespace>/<model>".
+	ModelCompartments map[string]string `json:"modelCompartments,omitempty"`
+
 	// Region is the OCI region where the GenAI service is located.
 	// This is required and must be provided in the plugin configuration.
 	// Examples: "us-ashburn-1", "us-phoenix-1", "eu-frankfurt-1"
 	Region string `json:"region,omitempty"`
+
+	// AllowUnknownRegions skips checking regions against the known OCI regions, for regions
+	// launched after this version of the plugin.
+	AllowUnknownRegions bool `json:"allowUnknownRegions,omitempty"`
+
+	// AuthType selects the built-in request signer.
+	// Leave empty to rely on a downstream authentication middleware such as ociauth.
+	// Supported values: "resource_principal", "oke_workload_identity", "security_token", "api_key".
+	AuthType string `json:"authType,omitempty"`
+
+	// SecurityTokenFile is the path to an OCI CLI session token, used with the "security_token" authType.
+	// The token is refreshed before it expires and the file is updated with the new token.
+	SecurityTokenFile string `json:"securityTokenFile,omitempty"`
+
+	// TenancyID, UserID and Fingerprint identify the API signing key for the "api_key" authType.
+	TenancyID   string `json:"tenancyId,omitempty"`
+	UserID      string `json:"userId,omitempty"`
+	Fingerprint string `json:"fingerprint,omitempty"`
+
+	// PrivateKey configures where the signing key for the "security_token" and "api_key"
+	// authTypes is loaded from.
+	PrivateKey PrivateKey `json:"privateKey,omitempty"`
+
+	// Egress configures the forward proxy and TLS settings of the calls the built-in signer makes to
+	// OCI itself, such as security token refreshes and Vault secret fetches.
+	Egress Egress `json:"egress,omitempty"`
+
+	// EnableModelsEndpoint controls whether GET */models is rewritten to the OCI ListModels call.
+	// When false, models requests are passed through untouched. Defaults to true.
+	EnableModelsEndpoint bool `json:"enableModelsEndpoint"`
+
+	// EnableChatEndpoint controls whether POST */chat/completions is translated to the OCI chat
+	// call. When false, chat requests are passed through untouched. Defaults to true.
+	EnableChatEndpoint bool `json:"enableChatEndpoint"`
+
+	// RewriteHost controls whether rewritten chat and models requests are sent to the OCI
+	// Generative AI host over https. When false, only the path and body are rewritten, and the
+	// Traefik service decides where requests go, e.g. through an egress proxy. Defaults to true.
+	// Turning it off requires signing downstream (no authType), since the signature covers the
+	// OCI host, and rules out routes to another region or host.
+	RewriteHost bool `json:"rewriteHost"`
+
+	// TransformResponses controls whether OCI responses are converted back to OpenAI format.
+	// When false, only requests are rewritten and responses are streamed through unbuffered,
+	// for deployments where a downstream component handles response conversion. Defaults to true.
+	TransformResponses bool `json:"transformResponses"`
+
+	// RejectRealtime answers OpenAI Realtime API requests (*/realtime and */realtime/*, usually
+	// websocket upgrades) with an error explaining they are unsupported, instead of passing them
+	// through. Defaults to true.
+	RejectRealtime bool `json:"rejectRealtime"`
+
+	// TransformMaxBytes is the size, as received from OCI, above which successful non-streamed chat
+	// responses are returned untransformed, as a pressure-relief valve for pathological payloads.
+	// 0 transforms every response.
+	TransformMaxBytes int `json:"transformMaxBytes,omitempty"`
+
+	// IDStrategy controls how chat completion IDs are generated: "random" (the default),
+	// "sequential" for reproducible IDs in tests, or "request_id" to embed the OCI opc-request-id
+	// so responses can be traced to OCI requests.
+	IDStrategy string `json:"idStrategy,omitempty"`
+
+	// FixedTime, an RFC 3339 time, is reported as the created timestamp of every completion
+	// instead of the current time, for reproducible responses in tests.
+	FixedTime string `json:"fixedTime,omitempty"`
+
+	// AllowPromptDebug lets clients request the exact prompt the model received, via the
+	// X-Oci-Debug-Prompt header or the oci_debug_prompt request field. Disabled by default
+	// because echoed prompts may include system prompts that clients should not see.
+	AllowPromptDebug bool `json:"allowPromptDebug,omitempty"`
+
+	// Debug logs a summary of how each chat request was transformed: the roles mapped, the
+	// parameters clamped and the messages merged or dropped, along with the raw and transformed
+	// bodies and the outgoing headers, with credentials redacted. Leave it off in production,
+	// since bodies carry message content.
+	Debug bool `json:"debug,omitempty"`
+
+	// Metrics controls the built-in metrics endpoint.
+	Metrics Metrics `json:"metrics,omitempty"`
+
+	// Status controls the built-in JSON status endpoint.
+	Status Status `json:"status,omitempty"`
+
+	// Introspection controls the built-in endpoint reporting the effective configuration.
+	Introspection Introspection `json:"introspection,omitempty"`
+
+	// Mirror configures mirroring of request/response pairs for offline evaluation.
+	Mirror Mirror `json:"mirror,omitempty"`
+
+	// Audit configures the structured audit log of chat requests.
+	Audit Audit `json:"audit,omitempty"`
+
+	// Usage configures the ledger of token usage per tenant and model.
+	Usage Usage `json:"usage,omitempty"`
+
+	// ImageFetch configures inlining of remote image_url parts, since OCI requires base64 image data.
+	ImageFetch ImageFetch `json:"imageFetch,omitempty"`
+
+	// ImageLimits bounds the inline images a request may carry.
+	ImageLimits ImageLimits `json:"imageLimits,omitempty"`
+
+	// MaxTokensLimit caps the max_tokens a client may request. 0 means no limit.
+	MaxTokensLimit int `json:"maxTokensLimit,omitempty"`
+
+	// DefaultMaxTokens is the max_tokens sent when a client sends none. 0 sends none, leaving the
+	// model's default.
+	DefaultMaxTokens int `json:"defaultMaxTokens,omitempty"`
+
+	// LanguageScaling scales defaultMaxTokens by the script of the prompt.
+	LanguageScaling LanguageScaling `json:"languageScaling,omitempty"`
+
+	// MaxHistoryMessages truncates conversations to this many messages, keeping system messages
+	// and the most recent turns. 0 means no limit.
+	MaxHistoryMessages int `json:"maxHistoryMessages,omitempty"`
+
+	// ToolEmulation emulates function calling through prompting for models without native tool support.
+	ToolEmulation ToolEmulation `json:"toolEmulation,omitempty"`
+
+	// JSONRepair validates and repairs the output of requests asking for response_format json_object.
+	JSONRepair JSONRepair `json:"jsonRepair,omitempty"`
+
+	// ResponseShaping reduces chat response payloads for bandwidth-sensitive clients.
+	ResponseShaping ResponseShaping `json:"responseShaping,omitempty"`
+
+	// Compression configures how transformed responses are re-compressed.
+	Compression Compression `json:"compression,omitempty"`
+
+	// StrictParameters rejects requests using OpenAI parameters OCI GenAI does not support,
+	// such as logit_bias, with a 400 instead of dropping them.
+	StrictParameters bool `json:"strictParameters,omitempty"`
+
+	// AllowEmptyMessages accepts chat completion requests without messages, which are sent to
+	// OCI as an empty COHERE message, instead of rejecting them with a 400.
+	AllowEmptyMessages bool `json:"allowEmptyMessages,omitempty"`
+
+	// ExtraBodyPassthrough lists top-level request fields the plugin does not recognize that are
+	// forwarded verbatim in the OCI chatRequest, such as new OCI parameters, e.g. ["seed"].
+	ExtraBodyPassthrough []string `json:"extraBodyPassthrough,omitempty"`
+
+	// RoleMappings overrides the OCI role sent for OpenAI message roles, by API format, e.g.
+	// {"GENERIC": {"system": "SYSTEM"}}. Roles not listed keep the built-in mapping.
+	RoleMappings map[string]map[string]string `json:"roleMappings,omitempty"`
+
+	// CustomVendors maps model name prefixes, e.g. "acme.", to vendors registered with
+	// vendors.Register, which format the requests and parse the responses of those models.
+	CustomVendors map[string]string `json:"customVendors,omitempty"`
+
+	// ModelOwners maps OCI model vendors to the owned_by reported by the models endpoint, e.g.
+	// {"meta": "meta", "": "oracle"}; the "" key is for models without a vendor. Unlisted vendors
+	// are reported as they are, and models without a vendor as "oracle".
+	ModelOwners map[string]string `json:"modelOwners,omitempty"`
+
+	// SamplingPolicy controls how temperature values outside a backend's accepted range are
+	// brought into it: "clamp" (default) caps them at the range limits, "scale" maps the OpenAI
+	// 0-2 temperature range linearly onto the backend range.
+	SamplingPolicy string `json:"samplingPolicy,omitempty"`
+
+	// ReasoningEffort controls what happens to the reasoning_effort parameter of o-series
+	// clients: "drop" (default) ignores it, "forward" sends it to OCI for GENERIC models that
+	// support reasoning, and "reject" answers with a 400.
+	ReasoningEffort string `json:"reasoningEffort,omitempty"`
+
+	// MergeConsecutiveMessages merges adjacent messages with the same role, which some OCI
+	// formats reject, before transformation.
+	MergeConsecutiveMessages bool `json:"mergeConsecutiveMessages,omitempty"`
+
+	// MessageSeparator joins the content of merged messages. Defaults to a blank line.
+	MessageSeparator string `json:"messageSeparator,omitempty"`
+
+	// ModelValidation rejects requests for models missing from the OCI catalog before forwarding them.
+	ModelValidation ModelValidation `json:"modelValidation,omitempty"`
+
+	// Admission configures shedding of low-priority requests when the upstream is saturated.
+	Admission Admission `json:"admission,omitempty"`
+
+	// LoadBalancing spreads chat requests across several regions or dedicated endpoints serving the same model.
+	LoadBalancing LoadBalancing `json:"loadBalancing,omitempty"`
+
+	// TenantHeader is the request header identifying the calling tenant, reported in access log headers
+	// and audit records.
+	TenantHeader string `json:"tenantHeader,omitempty"`
+
+	// ForwardClientHeaders lists client request headers that are normally stripped before a
+	// chat request is rewritten toward OCI, such as Authorization or Cookie, but should be
+	// forwarded anyway, for example to a downstream middleware that reads them.
+	ForwardClientHeaders []string `json:"forwardClientHeaders,omitempty"`
+
+	// ClientIP configures how the address of the client behind proxies is determined for audit
+	// records and metrics.
+	ClientIP ClientIP `json:"clientIp,omitempty"`
+
+	// IPFilter restricts which client IPs may use the gateway.
+	IPFilter IPFilter `json:"ipFilter,omitempty"`
+
+	// CORS configures the cross-origin headers of the OpenAI endpoints, per tenant or host. Defaults
+	// to allowing every origin.
+	CORS CORS `json:"cors,omitempty"`
+
+	// ModelOverride lets operators replace the model of chat requests with a trusted header, for canary testing.
+	ModelOverride ModelOverride `json:"modelOverride,omitempty"`
+
+	// PromptTemplates are named prompt templates served on POST */prompts/{name}/completions.
+	PromptTemplates map[string]PromptTemplate `json:"promptTemplates,omitempty"`
+
+	// Speech serves POST */audio/speech with OCI AI Speech.
+	Speech Speech `json:"speech,omitempty"`
+
+	// Transcription serves POST */audio/transcriptions with OCI AI Speech transcription jobs.
+	Transcription Transcription `json:"transcription,omitempty"`
+
+	// Agents sends chat completions for "agent:<endpoint-ocid>" models to OCI Generative AI Agents.
+	Agents Agents `json:"agents,omitempty"`
+
+	// Assistants serves a minimal emulation of the OpenAI Assistants API, whose runs use OCI chat.
+	Assistants Assistants `json:"assistants,omitempty"`
+
+	// Chaos injects faults into chat completion responses, to test client retry logic against the gateway.
+	Chaos Chaos `json:"chaos,omitempty"`
+
+	// SLO tracks rolling per-model latency and error rates, reported by the status endpoint.
+	SLO SLO `json:"slo,omitempty"`
+
+	// Maintenance answers every OpenAI endpoint served by the plugin with a 503, during OCI
+	// maintenance windows or credential rotation.
+	Maintenance Maintenance `json:"maintenance,omitempty"`
+
+	// WarmUp periodically sends tiny chat requests to dedicated endpoints, keeping them warm and
+	// checking authentication and connectivity.
+	WarmUp WarmUp `json:"warmUp,omitempty"`
+
+	// Degradation turns off optional features that keep failing, so requests are served without
+	// them instead of paying for their failures.
+	Degradation Degradation `json:"degradation,omitempty"`
+
+	// Tenants configures per-tenant model routing, keyed by the value of the tenant header. The
+	// header may carry a virtual API key set by an upstream authentication middleware.
+	Tenants map[string]Tenant `json:"tenants,omitempty"`
+
+	// Schedules change the model or endpoint of chat requests during time windows, such as cheaper
+	// models off-hours or another region during a regional maintenance window. The first matching
+	// rule applies.
+	Schedules []Schedule `json:"schedules,omitempty"`
+
+	// TenantSource loads further tenants from a file or URL and keeps them up to date, so keys and
+	// model routes can change without redeploying the dynamic configuration.
+	TenantSource TenantSource `json:"tenantSource,omitempty"`
+
+	// Fixtures records OCI responses to disk, or replays recorded ones instead of calling OCI.
+	Fixtures Fixtures `json:"fixtures,omitempty"`
+
+	// AccessLog configures response headers carrying request metadata for Traefik access logs.
+	AccessLog AccessLog `json:"accessLog,omitempty"`
+
+	// RequestMetadata configures the gateway metadata sent to OCI for correlation in OCI-side logs.
+	RequestMetadata RequestMetadata `json:"requestMetadata,omitempty"`
+
+	// ServiceTierPriorities maps service tiers to the priority classes ("high", "normal", "low")
+	// used for admission decisions. Admission is decided before the body is read, so the tier is
+	// taken from the X-Oci-Service-Tier header. Unlisted tiers are "normal".
+	ServiceTierPriorities map[string]string `json:"serviceTierPriorities,omitempty"`
+}
+
+// Priority classes derived from the request service tier.
+const (
+	PriorityHigh   = "high"
+	PriorityNormal = "normal"
+	PriorityLow    = "low"
+)
+
+// PromptTemplate is a named template rendered into chat messages.
+type PromptTemplate struct {
+	// Model is the model used when the request does not name one.
+	Model string `json:"model,omitempty"`
+
+	// Messages are the templated messages. Content uses Go text/template syntax, e.g. "{{.topic}}".
+	Messages []PromptTemplateMessage `json:"messages,omitempty"`
+}
+
+// PromptTemplateMessage is a single templated message.
+type PromptTemplateMessage struct {
+	Role    string `json:"role,omitempty"`
+	Content string `json:"content,omitempty"`
+}
+
+// ToolEmulation configures function-calling emulation. Tools are described in a system prompt,
+// and the model's JSON answer is converted to OpenAI tool_calls.
+type ToolEmulation struct {
+	// Enabled turns on tool emulation.
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Models lists the models tools are emulated for, matched case-insensitively as substrings
+	// of the model name. Empty means every model.
+	Models []string `json:"models,omitempty"`
+
+	// MaxRetries is how many times the model is asked again after a malformed tool invocation. Defaults to 1.
+	MaxRetries int `json:"maxRetries,omitempty"`
+}
+
+// JSONRepair configures the repair of invalid JSON output for json_object requests.
+type JSONRepair struct {
+	// Enabled validates the output and repairs common defects: code fences, text around the
+	// JSON object, and trailing commas.
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Reask asks the model once more when the output cannot be repaired.
+	Reask bool `json:"reask,omitempty"`
+}
+
+// Tenant configures a single tenant.
+type Tenant struct {
+	// Models maps the model names the tenant requests to the OCI model and endpoint serving them.
+	Models map[string]ModelRoute `json:"models,omitempty"`
+
+	// Priority is the admission priority class ("high", "normal", "low") of the tenant's
+	// requests that do not carry a service tier header. Empty is "normal".
+	Priority string `json:"priority,omitempty"`
+}
+
+// ModelRoute is where requests for a model alias are sent. Empty fields keep the requested
+// model and the default endpoint.
+type ModelRoute struct {
+	// Model is the OCI model name or OCID to request instead of the alias.
+	Model string `json:"model,omitempty"`
+
+	// Region sends the request to this region instead of the configured one.
+	Region string `json:"region,omitempty"`
+
+	// Host sends the request to this host, e.g. a dedicated AI cluster endpoint. It takes precedence over Region.
+	Host string `json:"host,omitempty"`
 }
 
-// New creates a new configuration with sensible defaults.
-func New() *Config {
-	return &Config{}
+// Schedule routes requests for some models elsewhere during a recurring daily window, a fixed
+// period, or both.
+type Schedule struct {
+	// Name identifies the rule in logs.
+	Name string `json:"name,omitempty"`
+
+	// Models lists the models the rule applies to, after tenant routing. Empty applies to every model.
+	Models []string `json:"models,omitempty"`
+
+	// Days limits the daily window to some days of the week: "mon", "tue", ... "sun". Empty is every day.
+	Days []string `json:"days,omitempty"`
+
+	// Start and End bound the daily window, as "15:04" times. A window ending before it starts
+	// spans midnight and belongs to the day it starts.
+	Start string `json:"start,omitempty"`
+	End   string `json:"end,omitempty"`
+
+	// Timezone is the IANA time zone of Days, Start and End. Defaults to "UTC".
+	Timezone string `json:"timezone,omitempty"`
+
+	// From and Until bound the period the rule applies in, as RFC 3339 times, e.g. a maintenance window.
+	From  string `json:"from,omitempty"`
+	Until string `json:"until,omitempty"`
+
+	// Route is where requests go while the rule applies. Its region or host replaces the tenant's.
+	Route ModelRoute `json:"route"`
 }
 
-// Validate checks if the configuration is valid and returns an error if not.
-// It validates that the required CompartmentID and Region are provided.
-func (c *Config) Validate() error {
-	if c.CompartmentID == "" {
-		return fmt.Errorf("compartmentId is required and cannot be empty")
+// Weekdays maps the day names of schedules to their weekday.
+var Weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// scheduleErrors checks the schedule rules.
+func (c *Config) scheduleErrors() []error {
+	var errs []error
+	for i, s := range c.Schedules {
+		name := fmt.Sprintf("schedules[%d]", i)
+		if s.Route == (ModelRoute{}) {
+			errs = append(errs, fmt.Errorf("%s.route must set a model, region or host", name))
+		}
+		if err := c.regionError(name+".route.region", s.Route.Region); err != nil {
+			errs = append(errs, err)
+		}
+		if !c.RewriteHost && (s.Route.Region != "" || s.Route.Host != "") {
+			errs = append(errs, fmt.Errorf("%s.route region and host require rewriteHost", name))
+		}
+		if s.Start == "" && s.End == "" && s.From == "" && s.Until == "" {
+			errs = append(errs, fmt.Errorf("%s requires a daily window (start and end) or a period (from or until)", name))
+		}
+		if (s.Start == "") != (s.End == "") {
+			errs = append(errs, fmt.Errorf("%s.start and %s.end must be set together", name, name))
+		} else if s.Start != "" && s.Start == s.End {
+			errs = append(errs, fmt.Errorf("%s.start and %s.end must differ", name, name))
+		}
+		for _, field := range []struct{ name, value string }{{"start", s.Start}, {"end", s.End}} {
+			if _, err := time.Parse("15:04", field.value); field.value != "" && err != nil {
+				errs = append(errs, fmt.Errorf("invalid %s.%s %q: must be a 15:04 time", name, field.name, field.value))
+			}
+		}
+		for _, day := range s.Days {
+			if _, ok := Weekdays[strings.ToLower(day)]; !ok {
+				errs = append(errs, fmt.Errorf("invalid %s.days entry %q: must be mon, tue, wed, thu, fri, sat or sun", name, day))
+			}
+		}
+		if len(s.Days) > 0 && s.Start == "" {
+			errs = append(errs, fmt.Errorf("%s.days requires start and end", name))
+		}
+		if s.Timezone != "" {
+			if _, err := time.LoadLocation(s.Timezone); err != nil {
+				errs = append(errs, fmt.Errorf("invalid %s.timezone: %w", name, err))
+			}
+		}
+		var from, until time.Time
+		for _, field := range []struct {
+			name, value string
+			t           *time.Time
+		}{{"from", s.From, &from}, {"until", s.Until, &until}} {
+			if field.value == "" {
+				continue
+			}
+			t, err := time.Parse(time.RFC3339, field.value)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("invalid %s.%s: must be an RFC 3339 time", name, field.name))
+			}
+			*field.t = t
+		}
+		if !from.IsZero() && !until.IsZero() && !until.After(from) {
+			errs = append(errs, fmt.Errorf("%s.until must be after %s.from", name, name))
+		}
 	}
+	return errs
+}
 
-	if c.Region == "" {
-		return fmt.Errorf("region is required and cannot be empty")
+// TenantSource configures where the reloadable tenant table is loaded from. The table is a JSON
+// object in the same format as Tenants, and takes precedence over it for tenants in both.
+type TenantSource struct {
+	// File is a local JSON file holding the table.
+	File string `json:"file,omitempty"`
+
+	// URL is fetched with a GET for the table.
+	URL string `json:"url,omitempty"`
+
+	// RefreshInterval is how often the table is reloaded, as a Go duration. Defaults to "1m".
+	RefreshInterval string `json:"refreshInterval,omitempty"`
+}
+
+// Enabled reports whether a file or URL is configured.
+func (s TenantSource) Enabled() bool {
+	return s.File != "" || s.URL != ""
+}
+
+func (s TenantSource) validate() []error {
+	var errs []error
+	if s.File != "" && s.URL != "" {
+		errs = append(errs, fmt.Errorf("only one of tenantSource.file and tenantSource.url can be set"))
+	}
+	if interval, err := time.ParseDuration(s.RefreshInterval); err != nil {
+		errs = append(errs, fmt.Errorf("invalid tenantSource.refreshInterval: %w", err))
+	} else if interval <= 0 {
+		errs = append(errs, fmt.Errorf("tenantSource.refreshInterval must be positive"))
+	}
+	return errs
+}
+
+// ModelRoute returns the route configured for a tenant's model alias.
+func (c *Config) ModelRoute(tenant, model string) (ModelRoute, bool) {
+	route, ok := c.Tenants[tenant].Models[model]
+	return route, ok
+}
+
+// TenantPriority returns the admission priority class configured for a tenant, empty when unset.
+func (c *Config) TenantPriority(tenant string) string {
+	return c.Tenants[tenant].Priority
+}
+
+// Chat completion ID strategies.
+const (
+	IDStrategyRandom     = "random"
+	IDStrategySequential = "sequential"
+	IDStrategyRequestID  = "request_id"
+)
+
+// Sampling policies for temperature values outside a backend's accepted range.
+const (
+	SamplingPolicyClamp = "clamp"
+	SamplingPolicyScale = "scale"
+)
+
+// Policies for the reasoning_effort parameter.
+const (
+	ReasoningEffortDrop    = "drop"
+	ReasoningEffortForward = "forward"
+	ReasoningEffortReject  = "reject"
+)
+
+// ServiceTierPriority returns the priority class for an OpenAI service_tier value.
+func (c *Config) ServiceTierPriority(tier string) string {
+	if priority, ok := c.ServiceTierPriorities[tier]; ok {
+		return priority
+	}
+	return PriorityNormal
+}
+
+// ImageFetch configures downloading of remote images referenced by image_url content parts.
+type ImageFetch struct {
+	// Enabled turns on image fetching. Without it, image URLs are forwarded as-is.
+	Enabled bool `json:"enabled,omitempty"`
+
+	// AllowedHosts lists the hosts images may be fetched from. Entries like "*.example.com" match subdomains.
+	AllowedHosts []string `json:"allowedHosts,omitempty"`
+
+	// MaxBytes is the largest image that will be downloaded. Defaults to 5 MiB.
+	MaxBytes int64 `json:"maxBytes,omitempty"`
+
+	// Timeout bounds each download, as a Go duration. Defaults to "10s".
+	Timeout string `json:"timeout,omitempty"`
+}
+
+// ImageLimits configures validation of inline (base64) images before they are forwarded.
+type ImageLimits struct {
+	// MaxBytes is the largest decoded image accepted. Defaults to 5 MiB; 0 disables the check.
+	MaxBytes int64 `json:"maxBytes,omitempty"`
+
+	// AllowedTypes lists the accepted MIME types. Defaults to PNG, JPEG, GIF and WebP.
+	AllowedTypes []string `json:"allowedTypes,omitempty"`
+
+	// MaxCount is the most images accepted per request. Defaults to 10; 0 disables the check.
+	MaxCount int `json:"maxCount,omitempty"`
+}
+
+// Scripts detected by language scaling.
+const (
+	ScriptCJK        = "cjk" // Chinese, Japanese and Korean
+	ScriptCyrillic   = "cyrillic"
+	ScriptArabic     = "arabic"
+	ScriptDevanagari = "devanagari"
+	ScriptThai       = "thai"
+)
+
+// LanguageScaling scales the default max_tokens by the script the prompt is written in. Tokenizers
+// need more tokens per character for most non-Latin scripts, so an answer in those scripts would
+// otherwise be truncated sooner than the same answer in English.
+type LanguageScaling struct {
+	// Enabled scales defaultMaxTokens by the factor of the prompt's script.
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Factors maps scripts to the factor applied to defaultMaxTokens. Prompts in other scripts,
+	// such as Latin, keep the default.
+	Factors map[string]float64 `json:"factors,omitempty"`
+}
+
+func (l LanguageScaling) validate(defaultMaxTokens int) []error {
+	var errs []error
+	if defaultMaxTokens <= 0 {
+		errs = append(errs, fmt.Errorf("defaultMaxTokens is required when language scaling is enabled"))
+	}
+	for script, factor := range l.Factors {
+		switch script {
+		case ScriptCJK, ScriptCyrillic, ScriptArabic, ScriptDevanagari, ScriptThai:
+		default:
+			errs = append(errs, fmt.Errorf("invalid languageScaling.factors script %q: must be one of %s, %s, %s, %s, %s",
+				script, ScriptCJK, ScriptCyrillic, ScriptArabic, ScriptDevanagari, ScriptThai))
+		}
+		if factor <= 0 {
+			errs = append(errs, fmt.Errorf("languageScaling.factors.%s must be positive", script))
+		}
+	}
+	return errs
+}
+
+// Audit configures the audit log. Records are written to File or URL when set,
+// otherwise to the Traefik log.
+type Audit struct {
+	// Enabled turns on audit logging.
+	Enabled bool `json:"enabled,omitempty"`
+
+	// File is a JSONL file the records are appended to.
+	File string `json:"file,omitempty"`
+
+	// URL is an HTTP endpoint each record is POSTed to as a JSON line.
+	URL string `json:"url,omitempty"`
+
+	// Rotation rolls the audit file over by size or age.
+	Rotation Rotation `json:"rotation,omitempty"`
+}
+
+// Rotation configures the rolling over of a JSONL file. The file is renamed with a timestamp
+// suffix once it reaches MaxBytes or MaxAge, and writing continues in a new file.
+type Rotation struct {
+	// MaxBytes rolls the file over before it would exceed this size. Zero disables rolling by size.
+	MaxBytes int64 `json:"maxBytes,omitempty"`
+
+	// MaxAge rolls the file over once it has been written to for this long, as a Go duration.
+	// Empty disables rolling by age.
+	MaxAge string `json:"maxAge,omitempty"`
+
+	// Compress gzips rolled-over files.
+	Compress bool `json:"compress,omitempty"`
+
+	// MaxFiles is the number of rolled-over files kept; older ones are deleted. Zero keeps them all.
+	MaxFiles int `json:"maxFiles,omitempty"`
+
+	// Retention deletes rolled-over files older than this, as a Go duration. Empty keeps them.
+	Retention string `json:"retention,omitempty"`
+}
+
+// Enabled reports whether the file is rolled over.
+func (r Rotation) Enabled() bool {
+	return r.MaxBytes > 0 || r.MaxAge != ""
+}
+
+// validate checks the rotation settings, named by their position in the configuration.
+func (r Rotation) validate(name string) []error {
+	var errs []error
+	if r.MaxBytes < 0 {
+		errs = append(errs, fmt.Errorf("%s.maxBytes cannot be negative", name))
+	}
+	if r.MaxFiles < 0 {
+		errs = append(errs, fmt.Errorf("%s.maxFiles cannot be negative", name))
+	}
+	durations := []struct{ field, value string }{{"maxAge", r.MaxAge}, {"retention", r.Retention}}
+	for _, d := range durations {
+		if d.value == "" {
+			continue
+		}
+		if value, err := time.ParseDuration(d.value); err != nil {
+			errs = append(errs, fmt.Errorf("invalid %s.%s: %w", name, d.field, err))
+		} else if value <= 0 {
+			errs = append(errs, fmt.Errorf("%s.%s must be positive", name, d.field))
+		}
+	}
+	if !r.Enabled() && (r.Compress || r.MaxFiles > 0 || r.Retention != "") {
+		errs = append(errs, fmt.Errorf("%s requires maxBytes or maxAge", name))
+	}
+	return errs
+}
+
+// PrivateKey configures the source of the signing private key. Exactly one source must be set.
+// Keys are reloaded periodically so rotated keys are picked up without restarting Traefik.
+type PrivateKey struct {
+	// PEM is the key itself, in PEM format.
+	PEM string `json:"pem,omitempty"`
+
+	// File is the path to a PEM file.
+	File string `json:"file,omitempty"`
+
+	// Env is the name of an environment variable holding the PEM.
+	Env string `json:"env,omitempty"`
+
+	// VaultSecretID is the OCID of an OCI Vault secret holding the PEM.
+	VaultSecretID string `json:"vaultSecretId,omitempty"`
+
+	// VaultAuthType is the principal used to read the Vault secret:
+	// "resource_principal" or "oke_workload_identity".
+	VaultAuthType string `json:"vaultAuthType,omitempty"`
+
+	// RefreshInterval is how often the key is reloaded, as a Go duration. Defaults to "1h".
+	RefreshInterval string `json:"refreshInterval,omitempty"`
+}
+
+// sources returns the number of configured key sources.
+func (k PrivateKey) sources() int {
+	count := 0
+	for _, source := range []string{k.PEM, k.File, k.Env, k.VaultSecretID} {
+		if source != "" {
+			count++
+		}
+	}
+	return count
+}
+
+// validate checks that exactly one key source is configured.
+func (k PrivateKey) validate() []error {
+	var errs []error
+	if k.sources() != 1 {
+		errs = append(errs, fmt.Errorf("exactly one of privateKey.pem, privateKey.file, privateKey.env or privateKey.vaultSecretId is required"))
+	}
+
+	if k.VaultSecretID != "" && k.VaultAuthType != "resource_principal" && k.VaultAuthType != "oke_workload_identity" {
+		errs = append(errs, fmt.Errorf("privateKey.vaultAuthType must be resource_principal or oke_workload_identity"))
+	}
+
+	if k.RefreshInterval != "" {
+		if _, err := time.ParseDuration(k.RefreshInterval); err != nil {
+			errs = append(errs, fmt.Errorf("invalid privateKey.refreshInterval: %w", err))
+		}
+	}
+
+	return errs
+}
+
+// Metrics configures the Prometheus-format metrics endpoint served by the plugin.
+type Metrics struct {
+	// Enabled exposes the metrics endpoint. Metrics are always collected.
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Path is the request path the metrics are served on.
+	// Defaults to "/_ociai/metrics".
+	Path string `json:"path,omitempty"`
+
+	// ClientLabels counts chat requests per client IP. Off by default, since every client adds a series.
+	ClientLabels bool `json:"clientLabels,omitempty"`
+
+	// Models lists the models labelled by name, in addition to the models in the cached catalog
+	// when model validation is enabled. Other models are labelled "other", so client-supplied
+	// model names cannot create unbounded series.
+	Models []string `json:"models,omitempty"`
+}
+
+// Status configures the JSON status endpoint reporting in-flight requests, queue depths,
+// endpoint health and cache hit rates.
+type Status struct {
+	// Enabled exposes the status endpoint.
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Path is the request path the status is served on.
+	// Defaults to "/_ociai/status".
+	Path string `json:"path,omitempty"`
+}
+
+// Introspection configures the JSON endpoint reporting the effective configuration and the
+// optional features running in a plugin instance, with secrets redacted.
+type Introspection struct {
+	// Enabled exposes the introspection endpoint.
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Path is the request path the configuration is served on.
+	// Defaults to "/_ociai/config".
+	Path string `json:"path,omitempty"`
+}
+
+// ResponseShaping configures minification of chat completion responses.
+type ResponseShaping struct {
+	// OmitEmpty removes null values, empty strings and empty arrays or objects.
+	OmitEmpty bool `json:"omitEmpty,omitempty"`
+
+	// Fields is an allowlist of dotted response paths to keep, e.g. "choices.message.content".
+	// Arrays are traversed transparently. Empty keeps every field.
+	Fields []string `json:"fields,omitempty"`
+}
+
+// Compression configures re-compression of transformed responses when OCI compressed the original.
+type Compression struct {
+	// Level is the gzip/deflate level, from -2 (Huffman only) and 0 (none) to 9 (best).
+	// Defaults to -1, the library default balancing speed and size.
+	Level int `json:"level,omitempty"`
+
+	// MinBytes sends responses smaller than this uncompressed. Defaults to 0 (always compress).
+	MinBytes int `json:"minBytes,omitempty"`
+}
+
+// ModelValidation configures checking requested models against the cached OCI model catalog.
+type ModelValidation struct {
+	// Enabled turns on model validation.
+	Enabled bool `json:"enabled,omitempty"`
+
+	// CacheTTL is how long the catalog is cached, as a Go duration. Defaults to "5m".
+	CacheTTL string `json:"cacheTtl,omitempty"`
+
+	// NegativeCacheTTL is how long "model not found" results are cached, as a Go duration. Defaults to "30s".
+	NegativeCacheTTL string `json:"negativeCacheTtl,omitempty"`
+
+	// MaxStaleness is how long after cacheTtl the catalog is still used while it is refreshed in
+	// the background, as a Go duration. Defaults to "1h"; "0s" refreshes before answering.
+	MaxStaleness string `json:"maxStaleness,omitempty"`
+
+	// VisionFallbackModel serves requests with images for models the catalog marks as not
+	// supporting image input. Empty rejects those requests.
+	VisionFallbackModel string `json:"visionFallbackModel,omitempty"`
+}
+
+// Admission configures the admission controller. Low-priority requests are rejected with
+// 503 and Retry-After while the in-flight count or recent p95 latency exceeds its threshold.
+type Admission struct {
+	// Enabled turns on admission control.
+	Enabled bool `json:"enabled,omitempty"`
+
+	// MaxInFlight is the in-flight request count above which low-priority requests are shed. 0 disables the check.
+	MaxInFlight int `json:"maxInFlight,omitempty"`
+
+	// P95Latency is the recent p95 upstream latency, as a Go duration, above which low-priority
+	// requests are shed. Upstream latency is the time until OCI's response headers arrive.
+	// Empty disables the check.
+	P95Latency string `json:"p95Latency,omitempty"`
+
+	// LatencyWindow is the number of recent requests the p95 latency is computed over. Defaults to 100.
+	LatencyWindow int `json:"latencyWindow,omitempty"`
+
+	// LatencyMaxAge is how long a latency sample counts toward the p95, as a Go duration, so
+	// shedding stops once no recent request was slow. Defaults to "1m".
+	LatencyMaxAge string `json:"latencyMaxAge,omitempty"`
+
+	// RetryAfter is the delay suggested to shed clients, as a Go duration. Defaults to "5s".
+	RetryAfter string `json:"retryAfter,omitempty"`
+}
+
+// AccessLog configures the response headers exposing model, tenant, token usage and finish reason,
+// so they can be captured with Traefik's accessLog.fields.headers settings.
+type AccessLog struct {
+	// Enabled turns on the access log headers.
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Prefix is prepended to every header name. Defaults to "X-Ociai-".
+	Prefix string `json:"prefix,omitempty"`
+
+	// Trailers sends the token usage and finish reason of streamed responses as HTTP trailers,
+	// since they are not known when the headers are sent.
+	Trailers bool `json:"trailers,omitempty"`
+}
+
+// Speech configures the text-to-speech endpoint, which translates OpenAI speech requests to
+// OCI AI Speech synthesis.
+type Speech struct {
+	// Enabled serves POST */audio/speech.
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Region is the OCI AI Speech region. Defaults to the configured region.
+	Region string `json:"region,omitempty"`
+
+	// Host overrides the OCI AI Speech endpoint host. It takes precedence over Region.
+	Host string `json:"host,omitempty"`
+
+	// Models maps OpenAI model names to OCI TTS model names. Unlisted models are sent as-is.
+	// Defaults map "tts-1" to "TTS_1_STANDARD" and "tts-1-hd" to "TTS_2_NATURAL".
+	Models map[string]string `json:"models,omitempty"`
+
+	// Voices maps OpenAI voice names to OCI voice IDs. Unlisted voices are sent as-is.
+	Voices map[string]string `json:"voices,omitempty"`
+}
+
+// Transcription configures the transcription endpoint. OCI AI Speech transcribes files in Object
+// Storage with asynchronous jobs, so uploads are staged in a bucket and the job is polled until done.
+type Transcription struct {
+	// Enabled serves POST */audio/transcriptions.
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Region is the OCI AI Speech and Object Storage region. Defaults to the configured region.
+	Region string `json:"region,omitempty"`
+
+	// SpeechHost and ObjectStorageHost send the requests to these hosts, e.g. private endpoints,
+	// instead of the public endpoints of Region.
+	SpeechHost        string `json:"speechHost,omitempty"`
+	ObjectStorageHost string `json:"objectStorageHost,omitempty"`
+
+	// Namespace is the Object Storage namespace of the bucket.
+	Namespace string `json:"namespace,omitempty"`
+
+	// Bucket holds the uploaded audio and the transcription results, which are deleted once read.
+	Bucket string `json:"bucket,omitempty"`
+
+	// Prefix is prepended to the names of the objects written. Defaults to "ociai-transcriptions/".
+	Prefix string `json:"prefix,omitempty"`
+
+	// Models maps OpenAI model names to OCI transcription model types. Unlisted models are sent
+	// as-is. Defaults map "whisper-1" to "WHISPER_MEDIUM".
+	Models map[string]string `json:"models,omitempty"`
+
+	// MaxBytes is the largest audio file accepted. Defaults to 25 MiB.
+	MaxBytes int64 `json:"maxBytes,omitempty"`
+
+	// PollInterval is how often the job is checked, as a Go duration. Defaults to "2s".
+	PollInterval string `json:"pollInterval,omitempty"`
+
+	// Timeout is how long a request waits for its job, as a Go duration. Defaults to "5m".
+	Timeout string `json:"timeout,omitempty"`
+}
+
+func (t Transcription) validate() []error {
+	var errs []error
+	if t.Namespace == "" || t.Bucket == "" {
+		errs = append(errs, fmt.Errorf("transcription.namespace and transcription.bucket are required"))
+	}
+	if t.MaxBytes <= 0 {
+		errs = append(errs, fmt.Errorf("transcription.maxBytes must be positive"))
+	}
+	if interval, err := time.ParseDuration(t.PollInterval); err != nil {
+		errs = append(errs, fmt.Errorf("invalid transcription.pollInterval: %w", err))
+	} else if interval <= 0 {
+		errs = append(errs, fmt.Errorf("transcription.pollInterval must be positive"))
 	}
+	if timeout, err := time.ParseDuration(t.Timeout); err != nil {
+		errs = append(errs, fmt.Errorf("invalid transcription.timeout: %w", err))
+	} else if timeout <= 0 {
+		errs = append(errs, fmt.Errorf("transcription.timeout must be positive"))
+	}
+	return errs
+}
+
+// Agents configures the OCI Generative AI Agents bridge. Chat completions for models named
+// "agent:<agent-endpoint-ocid>" are sent to the agent endpoint, whose sessions keep the
+// conversation history.
+type Agents struct {
+	// Enabled routes "agent:" models to OCI Generative AI Agents.
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Region is the agent runtime region. Defaults to the configured region.
+	Region string `json:"region,omitempty"`
+
+	// Host overrides the agent runtime endpoint host. It takes precedence over Region.
+	Host string `json:"host,omitempty"`
+
+	// SessionTTL is how long an idle session is reused for the same tenant, user and agent
+	// endpoint, as a Go duration. It should not exceed the endpoint's session idle timeout.
+	// Defaults to "30m".
+	SessionTTL string `json:"sessionTtl,omitempty"`
+}
 
+func (a Agents) validate() []error {
+	if ttl, err := time.ParseDuration(a.SessionTTL); err != nil {
+		return []error{fmt.Errorf("invalid agents.sessionTtl: %w", err)}
+	} else if ttl <= 0 {
+		return []error{fmt.Errorf("agents.sessionTtl must be positive")}
+	}
 	return nil
 }
+
+// Assistants configures the Assistants API emulation. Assistants, threads, messages and runs are
+// kept by the plugin, and runs send the thread to OCI as a chat completion.
+type Assistants struct {
+	// Enabled serves */assistants and */threads.
+	Enabled bool `json:"enabled,omitempty"`
+
+	// File is the JSON file objects are kept in. When empty, they are kept in memory and lost on restart.
+	File string `json:"file,omitempty"`
+
+	// RunTimeout is how long a run may wait for OCI, as a Go duration. Defaults to "5m".
+	RunTimeout string `json:"runTimeout,omitempty"`
+
+	// MaxObjects is the most assistants and threads a tenant may keep. Defaults to 1000.
+	MaxObjects int `json:"maxObjects,omitempty"`
+
+	// MaxMessages is the most messages a thread may hold, including answers. Defaults to 1000.
+	MaxMessages int `json:"maxMessages,omitempty"`
+}
+
+func (a Assistants) validate() []error {
+	var errs []error
+	if timeout, err := time.ParseDuration(a.RunTimeout); err != nil {
+		errs = append(errs, fmt.Errorf("invalid assistants.runTimeout: %w", err))
+	} else if timeout <= 0 {
+		errs = append(errs, fmt.Errorf("assistants.runTimeout must be positive"))
+	}
+	if a.MaxObjects < 1 || a.MaxMessages < 1 {
+		errs = append(errs, fmt.Errorf("assistants.maxObjects and assistants.maxMessages must be positive"))
+	}
+	return errs
+}
+
+// Chaos configures fault injection into chat completion responses, so platform teams can check
+// their client retry logic before production. Each fault is drawn independently with its
+// probability. It must not be enabled in production.
+type Chaos struct {
+	// Enabled turns on fault injection.
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Header, when set, limits fault injection to requests carrying this header. A header value
+	// naming a fault (latency, rate_limit, truncate or malformed_json) injects that fault only.
+	Header string `json:"header,omitempty"`
+
+	// LatencyProbability is the fraction of requests delayed by Latency, between 0 and 1.
+	LatencyProbability float64 `json:"latencyProbability,omitempty"`
+
+	// Latency is the injected delay, as a Go duration. Defaults to "2s".
+	Latency string `json:"latency,omitempty"`
+
+	// RateLimitProbability is the fraction of requests answered with a 429, between 0 and 1.
+	RateLimitProbability float64 `json:"rateLimitProbability,omitempty"`
+
+	// RetryAfter is the Retry-After of injected 429s, as a Go duration. Defaults to "1s".
+	RetryAfter string `json:"retryAfter,omitempty"`
+
+	// TruncateProbability is the fraction of successful responses cut off halfway, between 0 and 1.
+	TruncateProbability float64 `json:"truncateProbability,omitempty"`
+
+	// MalformedProbability is the fraction of successful responses whose JSON is corrupted, between 0 and 1.
+	MalformedProbability float64 `json:"malformedProbability,omitempty"`
+}
+
+func (c Chaos) validate() []error {
+	var errs []error
+	probabilities := []struct {
+		name  string
+		value float64
+	}{
+		{"chaos.latencyProbability", c.LatencyProbability},
+		{"chaos.rateLimitProbability", c.RateLimitProbability},
+		{"chaos.truncateProbability", c.TruncateProbability},
+		{"chaos.malformedProbability", c.MalformedProbability},
+	}
+	for _, probability := range probabilities {
+		if probability.value < 0 || probability.value > 1 {
+			errs = append(errs, fmt.Errorf("%s must be between 0 and 1", probability.name))
+		}
+	}
+	if latency, err := time.ParseDuration(c.Latency); err != nil {
+		errs = append(errs, fmt.Errorf("invalid chaos.latency: %w", err))
+	} else if latency < 0 {
+		errs = append(errs, fmt.Errorf("chaos.latency cannot be negative"))
+	}
+	if retryAfter, err := time.ParseDuration(c.RetryAfter); err != nil {
+		errs = append(errs, fmt.Errorf("invalid chaos.retryAfter: %w", err))
+	} else if retryAfter < 0 {
+		errs = append(errs, fmt.Errorf("chaos.retryAfter cannot be negative"))
+	}
+	return errs
+}
+
+// SLO configures rolling per-model latency and error-rate tracking of chat completions. Errors are
+// 5xx and 429 responses. With thresholds set, a warning is logged when a model breaches them.
+type SLO struct {
+	// Enabled turns on tracking.
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Window is the number of recent requests per model the statistics are computed over. Defaults to 100.
+	Window int `json:"window,omitempty"`
+
+	// P95Latency is the p95 latency objective, as a Go duration. Empty disables the check.
+	P95Latency string `json:"p95Latency,omitempty"`
+
+	// ErrorRate is the error-rate objective, between 0 and 1. 0 disables the check.
+	ErrorRate float64 `json:"errorRate,omitempty"`
+
+	// MinRequests is the number of requests in a model's window before its objectives are checked. Defaults to 20.
+	MinRequests int `json:"minRequests,omitempty"`
+
+	// WarnInterval is the minimum time between warnings for a model, as a Go duration. Defaults to "1m".
+	WarnInterval string `json:"warnInterval,omitempty"`
+}
+
+func (s SLO) validate() []error {
+	var errs []error
+	if s.Window <= 0 {
+		errs = append(errs, fmt.Errorf("slo.window must be positive"))
+	}
+	if s.MinRequests < 0 {
+		errs = append(errs, fmt.Errorf("slo.minRequests cannot be negative"))
+	}
+	if s.P95Latency != "" {
+		if _, err := time.ParseDuration(s.P95Latency); err != nil {
+			errs = append(errs, fmt.Errorf("invalid slo.p95Latency: %w", err))
+		}
+	}
+	if s.ErrorRate < 0 || s.ErrorRate > 1 {
+		errs = append(errs, fmt.Errorf("slo.errorRate must be between 0 and 1"))
+	}
+	if _, err := time.ParseDuration(s.WarnInterval); err != nil {
+		errs = append(errs, fmt.Errorf("invalid slo.warnInterval: %w", err))
+	}
+	return errs
+}
+
+// Maintenance configures maintenance mode. The metrics and status endpoints, and requests passed
+// through to the next handler, are not affected.
+type Maintenance struct {
+	// Enabled answers OpenAI endpoints with a 503 service_unavailable error.
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Message is the error message shown to clients.
+	Message string `json:"message,omitempty"`
+
+	// RetryAfter is the delay suggested to clients, as a Go duration. Defaults to "5m".
+	RetryAfter string `json:"retryAfter,omitempty"`
+}
+
+func (m Maintenance) validate() []error {
+	var errs []error
+	if m.Message == "" {
+		errs = append(errs, fmt.Errorf("maintenance.message is required when maintenance mode is enabled"))
+	}
+	if retryAfter, err := time.ParseDuration(m.RetryAfter); err != nil {
+		errs = append(errs, fmt.Errorf("invalid maintenance.retryAfter: %w", err))
+	} else if retryAfter < 0 {
+		errs = append(errs, fmt.Errorf("maintenance.retryAfter cannot be negative"))
+	}
+	return errs
+}
+
+// WarmUp configures the warm-up pinger.
+type WarmUp struct {
+	// Enabled pings the targets periodically, starting when the plugin starts.
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Interval is the time between pings of a target, as a Go duration. Defaults to "5m".
+	Interval string `json:"interval,omitempty"`
+
+	// Timeout bounds each ping, as a Go duration. Defaults to "30s".
+	Timeout string `json:"timeout,omitempty"`
+
+	// Targets lists the endpoints or models to ping.
+	Targets []WarmUpTarget `json:"targets,omitempty"`
+}
+
+// WarmUpTarget is a dedicated endpoint, or an on-demand model, pinged by the warm-up pinger.
+type WarmUpTarget struct {
+	// Endpoint is the OCID of a dedicated endpoint. Without one, Model is pinged on demand.
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// Model is the model served, which selects the API format of the ping (COHERE for Cohere
+	// models, GENERIC otherwise). It is required without an endpoint.
+	Model string `json:"model,omitempty"`
+
+	// Region is the OCI region of the target. Defaults to the plugin's region.
+	Region string `json:"region,omitempty"`
+
+	// Host overrides the target host, e.g. for a dedicated AI cluster endpoint. It takes precedence over Region.
+	Host string `json:"host,omitempty"`
+}
+
+func (w WarmUp) validate() []error {
+	var errs []error
+	if interval, err := time.ParseDuration(w.Interval); err != nil {
+		errs = append(errs, fmt.Errorf("invalid warmUp.interval: %w", err))
+	} else if interval <= 0 {
+		errs = append(errs, fmt.Errorf("warmUp.interval must be positive"))
+	}
+	if timeout, err := time.ParseDuration(w.Timeout); err != nil {
+		errs = append(errs, fmt.Errorf("invalid warmUp.timeout: %w", err))
+	} else if timeout <= 0 {
+		errs = append(errs, fmt.Errorf("warmUp.timeout must be positive"))
+	}
+	if len(w.Targets) == 0 {
+		errs = append(errs, fmt.Errorf("warmUp.targets is required when warm-up is enabled"))
+	}
+	for i, target := range w.Targets {
+		if target.Endpoint == "" && target.Model == "" {
+			errs = append(errs, fmt.Errorf("warmUp.targets[%d] requires an endpoint or a model", i))
+		}
+	}
+	return errs
+}
+
+// Egress configures how the plugin reaches OCI for the calls it makes itself. Without a proxy,
+// the HTTPS_PROXY, HTTP_PROXY and NO_PROXY environment variables apply.
+type Egress struct {
+	// Proxy is the URL of the forward proxy, e.g. "http://proxy.corp.example:3128".
+	Proxy string `json:"proxy,omitempty"`
+
+	// NoProxy lists the hosts reached directly: host names, which also match their subdomains,
+	// domain suffixes such as ".corp.example", IP addresses, CIDR ranges, or "*" for all.
+	NoProxy []string `json:"noProxy,omitempty"`
+
+	// CABundle is the path to a PEM file of certificate authorities trusted in addition to the
+	// system roots, e.g. that of a TLS-inspecting proxy.
+	CABundle string `json:"caBundle,omitempty"`
+
+	// MinTLSVersion is the lowest TLS version accepted from OCI: "1.2" or "1.3". Defaults to "1.2".
+	MinTLSVersion string `json:"minTLSVersion,omitempty"`
+
+	// ClientCertFile and ClientKeyFile are the PEM files of a client certificate presented to
+	// endpoints requiring mutual TLS, such as an internal API gateway fronting OCI.
+	ClientCertFile string `json:"clientCertFile,omitempty"`
+	ClientKeyFile  string `json:"clientKeyFile,omitempty"`
+
+	// MaxIdleConnsPerHost is the number of idle connections kept open to each host. Defaults to 2.
+	MaxIdleConnsPerHost int `json:"maxIdleConnsPerHost,omitempty"`
+
+	// IdleConnTimeout is how long an idle connection is kept open, as a Go duration. Defaults to "90s".
+	IdleConnTimeout string `json:"idleConnTimeout,omitempty"`
+
+	// KeepAlive is the interval of TCP keep-alive probes, as a Go duration. Defaults to "30s".
+	KeepAlive string `json:"keepAlive,omitempty"`
+
+	// DNSCacheTTL is how long resolved addresses are reused, as a Go duration. Unset, every new
+	// connection resolves its host.
+	DNSCacheTTL string `json:"dnsCacheTTL,omitempty"`
+}
+
+// validate checks the egress settings.
+func (e Egress) validate() []error {
+	var errs []error
+	if e.Proxy != "" {
+		if proxy, err := url.Parse(e.Proxy); err != nil || (proxy.Scheme != "http" && proxy.Scheme != "https") || proxy.Host == "" {
+			errs = append(errs, fmt.Errorf("invalid egress.proxy %q: must be an http or https URL", e.Proxy))
+		}
+	}
+	for _, host := range e.NoProxy {
+		if strings.TrimSpace(host) == "" {
+			errs = append(errs, fmt.Errorf("egress.noProxy entries cannot be empty"))
+		}
+	}
+	switch e.MinTLSVersion {
+	case "", "1.2", "1.3":
+	default:
+		errs = append(errs, fmt.Errorf("invalid egress.minTLSVersion %q: must be 1.2 or 1.3", e.MinTLSVersion))
+	}
+	if (e.ClientCertFile == "") != (e.ClientKeyFile == "") {
+		errs = append(errs, fmt.Errorf("egress.clientCertFile and egress.clientKeyFile must be set together"))
+	}
+	if e.MaxIdleConnsPerHost < 0 {
+		errs = append(errs, fmt.Errorf("egress.maxIdleConnsPerHost cannot be negative"))
+	}
+	durations := []struct{ name, value string }{
+		{"idleConnTimeout", e.IdleConnTimeout},
+		{"keepAlive", e.KeepAlive},
+		{"dnsCacheTTL", e.DNSCacheTTL},
+	}
+	for _, d := range durations {
+		if d.value == "" {
+			continue
+		}
+		if value, err := time.ParseDuration(d.value); err != nil {
+			errs = append(errs, fmt.Errorf("invalid egress.%s: %w", d.name, err))
+		} else if value <= 0 {
+			errs = append(errs, fmt.Errorf("egress.%s must be positive", d.name))
+		}
+	}
+	return errs
+}
+
+// Degradation configures the automatic disabling of failing optional features: model validation,
+// request mirroring, audit logging and the usage ledger.
+type Degradation struct {
+	// Enabled turns a feature off once its failures exceed the error budget.
+	Enabled bool `json:"enabled,omitempty"`
+
+	// ErrorBudget is the number of failures of a feature tolerated within Window. Defaults to 5.
+	ErrorBudget int `json:"errorBudget,omitempty"`
+
+	// Window is the period over which failures are counted, as a Go duration. Defaults to "1m".
+	Window string `json:"window,omitempty"`
+
+	// Cooldown is how long a feature stays off before it is tried again, as a Go duration.
+	// Defaults to "5m".
+	Cooldown string `json:"cooldown,omitempty"`
+}
+
+// validate checks the degradation settings.
+func (d Degradation) validate() []error {
+	var errs []error
+	if d.ErrorBudget < 1 {
+		errs = append(errs, fmt.Errorf("degradation.errorBudget must be at least 1"))
+	}
+	if window, err := time.ParseDuration(d.Window); err != nil {
+		errs = append(errs, fmt.Errorf("invalid degradation.window: %w", err))
+	} else if window <= 0 {
+		errs = append(errs, fmt.Errorf("degradation.window must be positive"))
+	}
+	if cooldown, err := time.ParseDuration(d.Cooldown); err != nil {
+		errs = append(errs, fmt.Errorf("invalid degradation.cooldown: %w", err))
+	} else if cooldown <= 0 {
+		errs = append(errs, fmt.Errorf("degradation.cooldown must be positive"))
+	}
+	return errs
+}
+
+// RequestMetadata configures the opc-request-id sent on chat requests. OCI inference requests
+// have no freeform tags or metadata, but OCI records the opc-request-id in its service logs.
+type RequestMetadata struct {
+	// Enabled sends the gateway request ID, and optionally the tenant and user, as the opc-request-id.
+	Enabled bool `json:"enabled,omitempty"`
+
+	// RequestIDHeader is the client request header holding the gateway request ID. An ID is
+	// generated for requests without it. Defaults to "X-Request-Id".
+	RequestIDHeader string `json:"requestIdHeader,omitempty"`
+
+	// IncludeTenant adds the tenant. Leave it off when the tenant header carries API keys.
+	IncludeTenant bool `json:"includeTenant,omitempty"`
+
+	// IncludeUser adds the OpenAI user field.
+	IncludeUser bool `json:"includeUser,omitempty"`
+}
+
+// Mirror configures asynchronous mirroring of chat request/response pairs as JSON lines.
+// Mirroring is enabled by setting either Directory or URL.
+type Mirror struct {
+	// Directory is where mirror.jsonl is written.
+	Directory string `json:"directory,omitempty"`
+
+	// URL is an HTTP endpoint each record is POSTed to as a JSON line.
+	URL string `json:"url,omitempty"`
+
+	// SampleRate is the fraction of requests mirrored, between 0 and 1. Defaults to 1.
+	SampleRate float64 `json:"sampleRate,omitempty"`
+
+	// MaxRecordBytes drops records larger than this size. Defaults to 1 MiB.
+	MaxRecordBytes int `json:"maxRecordBytes,omitempty"`
+
+	// MaxFileBytes stops writing to the mirror file once it reaches this size. Defaults to 100 MiB.
+	// It does not apply when the file is rotated.
+	MaxFileBytes int64 `json:"maxFileBytes,omitempty"`
+
+	// Rotation rolls the mirror file over by size or age, instead of capping it at MaxFileBytes.
+	Rotation Rotation `json:"rotation,omitempty"`
+
+	// RedactFields lists top-level request and response fields removed before recording (e.g. "user").
+	RedactFields []string `json:"redactFields,omitempty"`
+}
+
+// New creates a new configuration with sensible defaults.
+func New() *Config {
+	return &Config{
+		EnableModelsEndpoint: true,
+		EnableChatEndpoint:   true,
+		RewriteHost:          true,
+		CORS:                 CORS{AllowOrigins: []string{"*"}},
+		TransformResponses:   true,
+		RejectRealtime:       true,
+		LanguageScaling: LanguageScaling{
+			Factors: map[string]float64{
+				ScriptCJK:        2,
+				ScriptCyrillic:   1.5,
+				ScriptArabic:     1.5,
+				ScriptDevanagari: 2,
+				ScriptThai:       2,
+			},
+		},
+		Metrics: Metrics{
+			Path: "/_ociai/metrics",
+		},
+		Status: Status{
+			Path: "/_ociai/status",
+		},
+		Introspection: Introspection{
+			Path: "/_ociai/config",
+		},
+		Usage: Usage{
+			Store:          UsageStoreMemory,
+			FlushInterval:  "10s",
+			ReportInterval: "24h",
+		},
+		AccessLog: AccessLog{
+			Prefix: "X-Ociai-",
+		},
+		Speech: Speech{
+			Models: map[string]string{
+				"tts-1":    "TTS_1_STANDARD",
+				"tts-1-hd": "TTS_2_NATURAL",
+			},
+		},
+		Transcription: Transcription{
+			Prefix: "ociai-transcriptions/",
+			Models: map[string]string{
+				"whisper-1": "WHISPER_MEDIUM",
+			},
+			MaxBytes:     25 << 20,
+			PollInterval: "2s",
+			Timeout:      "5m",
+		},
+		Agents: Agents{
+			SessionTTL: "30m",
+		},
+		Assistants: Assistants{
+			RunTimeout:  "5m",
+			MaxObjects:  1000,
+			MaxMessages: 1000,
+		},
+		Chaos: Chaos{
+			Latency:    "2s",
+			RetryAfter: "1s",
+		},
+		ModelOverride: ModelOverride{
+			Header: "X-Model-Override",
+		},
+		SLO: SLO{
+			Window:       100,
+			MinRequests:  20,
+			WarnInterval: "1m",
+		},
+		Maintenance: Maintenance{
+			Message:    "The service is undergoing maintenance, please retry later.",
+			RetryAfter: "5m",
+		},
+		WarmUp: WarmUp{
+			Interval: "5m",
+			Timeout:  "30s",
+		},
+		Degradation: Degradation{
+			ErrorBudget: 5,
+			Window:      "1m",
+			Cooldown:    "5m",
+		},
+		RequestMetadata: RequestMetadata{
+			RequestIDHeader: "X-Request-Id",
+		},
+		Admission: Admission{
+			LatencyWindow: 100,
+			LatencyMaxAge: "1m",
+			RetryAfter:    "5s",
+		},
+		LoadBalancing: LoadBalancing{
+			Strategy:         BalanceRoundRobin,
+			FailureThreshold: 3,
+			Cooldown:         "30s",
+		},
+		TenantSource: TenantSource{
+			RefreshInterval: "1m",
+		},
+		ToolEmulation: ToolEmulation{
+			MaxRetries: 1,
+		},
+		SamplingPolicy:   SamplingPolicyClamp,
+		ReasoningEffort:  ReasoningEffortDrop,
+		MessageSeparator: "\n\n",
+		Compression: Compression{
+			Level: -1,
+		},
+		ModelValidation: ModelValidation{
+			CacheTTL:         "5m",
+			NegativeCacheTTL: "30s",
+			MaxStaleness:     "1h",
+		},
+		Mirror: Mirror{
+			SampleRate:     1,
+			MaxRecordBytes: 1 << 20,
+			MaxFileBytes:   100 << 20,
+		},
+		ImageFetch: ImageFetch{
+			MaxBytes: 5 << 20,
+			Timeout:  "10s",
+		},
+		ImageLimits: ImageLimits{
+			MaxBytes:     5 << 20,
+			AllowedTypes: []string{"image/png", "image/jpeg", "image/gif", "image/webp"},
+			MaxCount:     10,
+		},
+		ServiceTierPriorities: map[string]string{
+			"priority": PriorityHigh,
+			"default":  PriorityNormal,
+			"auto":     PriorityNormal,
+			"flex":     PriorityLow,
+		},
+	}
+}
+
+// ValidationErrors lists every problem found in a configuration.
+type ValidationErrors []error
+
+// Error joins the messages of all the problems.
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Validate checks if the configuration is valid and returns an error if not.
+// Every problem is reported, as a ValidationErrors, rather than only the first.
+func (c *Config) Validate() error {
+	var errs ValidationErrors
+	add := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+	checkRegion := func(field, region string) {
+		if err := c.regionError(field, region); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if c.CompartmentID == "" {
+		add("compartmentId is required and cannot be empty")
+	} else if !compartmentIDPattern.MatchString(c.CompartmentID) {
+		add("invalid compartmentId %q: must be a compartment or tenancy OCID", c.CompartmentID)
+	}
+	for namespace, id := range c.ModelCompartments {
+		if namespace == "" || strings.Contains(namespace, "/") {
+			add("invalid modelCompartments namespace %q: must be non-empty and must not contain '/'", namespace)
+		}
+		if !compartmentIDPattern.MatchString(id) {
+			add("invalid modelCompartments.%s %q: must be a compartment or tenancy OCID", namespace, id)
+		}
+	}
+
+	if c.Region == "" {
+		add("region is required and cannot be empty")
+	}
+	checkRegion("region", c.Region)
+
+	switch c.AuthType {
+	case "", "resource_principal", "oke_workload_identity":
+	case "security_token":
+		if c.SecurityTokenFile == "" {
+			add("securityTokenFile is required for the security_token authType")
+		}
+		errs = append(errs, c.PrivateKey.validate()...)
+	case "api_key":
+		if c.TenancyID == "" || c.UserID == "" || c.Fingerprint == "" {
+			add("tenancyId, userId and fingerprint are required for the api_key authType")
+		}
+		errs = append(errs, c.PrivateKey.validate()...)
+	default:
+		add("unsupported authType: %s", c.AuthType)
+	}
+	if c.AuthType != "" && !c.RewriteHost {
+		add("authType requires rewriteHost, since the signature covers the OCI host; sign downstream instead")
+	}
+	errs = append(errs, c.Egress.validate()...)
+
+	if c.Metrics.Enabled && !strings.HasPrefix(c.Metrics.Path, "/") {
+		add("metrics.path must start with '/'")
+	}
+
+	if c.Status.Enabled && !strings.HasPrefix(c.Status.Path, "/") {
+		add("status.path must start with '/'")
+	}
+
+	if c.Introspection.Enabled && !strings.HasPrefix(c.Introspection.Path, "/") {
+		add("introspection.path must start with '/'")
+	}
+
+	for name, tmpl := range c.PromptTemplates {
+		if name == "" || strings.Contains(name, "/") {
+			add("invalid promptTemplates name %q", name)
+		}
+		if len(tmpl.Messages) == 0 {
+			add("promptTemplates.%s requires at least one message", name)
+		}
+		for i, msg := range tmpl.Messages {
+			if msg.Role == "" {
+				add("promptTemplates.%s.messages[%d] requires a role", name, i)
+			}
+		}
+	}
+
+	if (len(c.Tenants) > 0 || c.TenantSource.Enabled()) && c.TenantHeader == "" {
+		add("tenantHeader is required when tenants are configured")
+	}
+	errs = append(errs, c.tenantErrors(c.Tenants)...)
+	errs = append(errs, c.scheduleErrors()...)
+
+	if c.TenantSource.Enabled() {
+		errs = append(errs, c.TenantSource.validate()...)
+	}
+
+	if c.Usage.Enabled {
+		errs = append(errs, c.Usage.validate()...)
+	}
+
+	for _, proxy := range c.ClientIP.TrustedProxies {
+		if !validNetwork(proxy) {
+			add("invalid clientIp.trustedProxies entry %q: must be an IP address or CIDR", proxy)
+		}
+	}
+
+	for _, network := range append(append([]string{}, c.IPFilter.Allow...), c.IPFilter.Deny...) {
+		if !validNetwork(network) {
+			add("invalid ipFilter entry %q: must be an IP address or CIDR", network)
+		}
+	}
+
+	errs = append(errs, c.CORS.defaultPolicy().validate("cors")...)
+	for tenant, policy := range c.CORS.Tenants {
+		errs = append(errs, policy.validate("cors.tenants."+tenant)...)
+	}
+	for host, policy := range c.CORS.Hosts {
+		errs = append(errs, policy.validate("cors.hosts."+host)...)
+	}
+	if len(c.CORS.Tenants) > 0 && c.TenantHeader == "" {
+		add("tenantHeader is required when cors.tenants are configured")
+	}
+
+	if c.ModelOverride.Enabled && c.ModelOverride.Header == "" {
+		add("modelOverride.header is required when model override is enabled")
+	}
+	if c.ModelOverride.Enabled && len(c.ModelOverride.TrustedNetworks) == 0 {
+		add("modelOverride.trustedNetworks is required when model override is enabled, since the header bypasses tenant routing")
+	}
+	for _, network := range c.ModelOverride.TrustedNetworks {
+		if !validNetwork(network) {
+			add("invalid modelOverride.trustedNetworks entry %q: must be an IP address or CIDR", network)
+		}
+	}
+	for _, field := range c.ExtraBodyPassthrough {
+		if field == "" {
+			add("extraBodyPassthrough entries cannot be empty")
+		}
+	}
+	for format, roles := range c.RoleMappings {
+		if format != "GENERIC" && format != "COHERE" {
+			add("invalid roleMappings format %q: must be GENERIC or COHERE", format)
+		}
+		for role, ociRole := range roles {
+			if !openAIRoles[role] {
+				add("invalid roleMappings.%s role %q: must be an OpenAI message role", format, role)
+			}
+			if ociRole == "" {
+				add("roleMappings.%s.%s cannot be empty", format, role)
+			}
+		}
+	}
+
+	for vendor, owner := range c.ModelOwners {
+		if strings.TrimSpace(owner) == "" {
+			add("modelOwners.%s cannot be empty", vendor)
+		}
+	}
+
+	for prefix, name := range c.CustomVendors {
+		if prefix == "" {
+			add("customVendors prefixes cannot be empty")
+		}
+		if vendor, ok := vendors.Lookup(name); !ok || vendor.Formatter == nil {
+			add("invalid customVendors.%s: no vendor %q is registered", prefix, name)
+		}
+	}
+
+	if c.Fixtures.Mode != "" {
+		errs = append(errs, c.Fixtures.validate()...)
+	}
+
+	// Speech, transcription and agent requests are sent by the plugin itself, so it must sign them
+	if c.Speech.Enabled && c.AuthType == "" {
+		add("speech requires authType, since the plugin signs its Speech requests")
+	}
+	checkRegion("speech.region", c.Speech.Region)
+	if c.Transcription.Enabled {
+		errs = append(errs, c.Transcription.validate()...)
+		if c.AuthType == "" {
+			add("transcription requires authType, since the plugin signs its Object Storage and Speech requests")
+		}
+	}
+	checkRegion("transcription.region", c.Transcription.Region)
+	if c.Agents.Enabled {
+		errs = append(errs, c.Agents.validate()...)
+		if c.AuthType == "" {
+			add("agents requires authType, since the plugin signs its agent requests")
+		}
+	}
+	checkRegion("agents.region", c.Agents.Region)
+	if c.Assistants.Enabled {
+		errs = append(errs, c.Assistants.validate()...)
+		if c.TenantHeader == "" {
+			add("assistants requires tenantHeader, since objects are scoped to the tenant that created them")
+		}
+	}
+	if c.Chaos.Enabled {
+		errs = append(errs, c.Chaos.validate()...)
+	}
+	if c.SLO.Enabled {
+		errs = append(errs, c.SLO.validate()...)
+	}
+	if c.Maintenance.Enabled {
+		errs = append(errs, c.Maintenance.validate()...)
+	}
+	if c.Degradation.Enabled {
+		errs = append(errs, c.Degradation.validate()...)
+	}
+
+	if c.WarmUp.Enabled {
+		errs = append(errs, c.WarmUp.validate()...)
+		for i, target := range c.WarmUp.Targets {
+			checkRegion(fmt.Sprintf("warmUp.targets[%d].region", i), target.Region)
+		}
+	}
+
+	if c.RequestMetadata.Enabled && c.RequestMetadata.RequestIDHeader == "" {
+		add("requestMetadata.requestIdHeader is required when request metadata is enabled")
+	}
+
+	if c.Mirror.Directory != "" && c.Mirror.URL != "" {
+		add("only one of mirror.directory and mirror.url can be set")
+	}
+
+	if c.Mirror.SampleRate < 0 || c.Mirror.SampleRate > 1 {
+		add("mirror.sampleRate must be between 0 and 1")
+	}
+
+	if c.Audit.File != "" && c.Audit.URL != "" {
+		add("only one of audit.file and audit.url can be set")
+	}
+
+	errs = append(errs, c.Mirror.Rotation.validate("mirror.rotation")...)
+	errs = append(errs, c.Audit.Rotation.validate("audit.rotation")...)
+
+	if c.ImageFetch.Enabled {
+		if len(c.ImageFetch.AllowedHosts) == 0 {
+			add("imageFetch.allowedHosts is required when image fetching is enabled")
+		}
+		if c.ImageFetch.MaxBytes <= 0 {
+			add("imageFetch.maxBytes must be positive")
+		}
+		if _, err := time.ParseDuration(c.ImageFetch.Timeout); err != nil {
+			add("invalid imageFetch.timeout: %w", err)
+		}
+	}
+
+	if c.ToolEmulation.MaxRetries < 0 {
+		add("toolEmulation.maxRetries cannot be negative")
+	}
+
+	if c.MaxTokensLimit < 0 || c.MaxHistoryMessages < 0 {
+		add("maxTokensLimit and maxHistoryMessages cannot be negative")
+	}
+	if c.DefaultMaxTokens < 0 {
+		add("defaultMaxTokens cannot be negative")
+	}
+	if c.LanguageScaling.Enabled {
+		errs = append(errs, c.LanguageScaling.validate(c.DefaultMaxTokens)...)
+	}
+
+	switch c.IDStrategy {
+	case "", IDStrategyRandom, IDStrategySequential, IDStrategyRequestID:
+	default:
+		add("unsupported idStrategy %q: must be %q, %q or %q", c.IDStrategy, IDStrategyRandom, IDStrategySequential, IDStrategyRequestID)
+	}
+
+	if c.FixedTime != "" {
+		if _, err := time.Parse(time.RFC3339, c.FixedTime); err != nil {
+			add("invalid fixedTime: %w", err)
+		}
+	}
+
+	if c.SamplingPolicy != "" && c.SamplingPolicy != SamplingPolicyClamp && c.SamplingPolicy != SamplingPolicyScale {
+		add("unsupported samplingPolicy %q: must be %q or %q", c.SamplingPolicy, SamplingPolicyClamp, SamplingPolicyScale)
+	}
+
+	switch c.ReasoningEffort {
+	case "", ReasoningEffortDrop, ReasoningEffortForward, ReasoningEffortReject:
+	default:
+		add("unsupported reasoningEffort %q: must be %q, %q or %q", c.ReasoningEffort, ReasoningEffortDrop, ReasoningEffortForward, ReasoningEffortReject)
+	}
+
+	if c.ImageLimits.MaxBytes < 0 || c.ImageLimits.MaxCount < 0 {
+		add("imageLimits.maxBytes and imageLimits.maxCount cannot be negative")
+	}
+
+	if c.Compression.Level < -2 || c.Compression.Level > 9 {
+		add("compression.level must be between -2 and 9")
+	}
+
+	if c.Compression.MinBytes < 0 {
+		add("compression.minBytes cannot be negative")
+	}
+
+	if c.TransformMaxBytes < 0 {
+		add("transformMaxBytes cannot be negative")
+	}
+
+	if c.ModelValidation.Enabled {
+		if _, err := time.ParseDuration(c.ModelValidation.CacheTTL); err != nil {
+			add("invalid modelValidation.cacheTtl: %w", err)
+		}
+		if _, err := time.ParseDuration(c.ModelValidation.NegativeCacheTTL); err != nil {
+			add("invalid modelValidation.negativeCacheTtl: %w", err)
+		}
+		if maxStaleness, err := time.ParseDuration(c.ModelValidation.MaxStaleness); err != nil {
+			add("invalid modelValidation.maxStaleness: %w", err)
+		} else if maxStaleness < 0 {
+			add("modelValidation.maxStaleness cannot be negative")
+		}
+	}
+
+	if c.Admission.Enabled {
+		errs = append(errs, c.Admission.validate()...)
+	}
+
+	if len(c.LoadBalancing.Endpoints) > 0 {
+		errs = append(errs, c.LoadBalancing.validate()...)
+		if !c.RewriteHost {
+			add("loadBalancing.endpoints requires rewriteHost, since they are chosen by host")
+		}
+	}
+	for i, endpoint := range c.LoadBalancing.Endpoints {
+		checkRegion(fmt.Sprintf("loadBalancing.endpoints[%d].region", i), endpoint.Region)
+	}
+
+	for tier, priority := range c.ServiceTierPriorities {
+		if priority != PriorityHigh && priority != PriorityNormal && priority != PriorityLow {
+			add("serviceTierPriorities.%s must be one of high, normal or low", tier)
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// ValidateTenants checks a tenant table, such as one loaded from the TenantSource, against the
+// same rules as Tenants.
+func (c *Config) ValidateTenants(tenants map[string]Tenant) error {
+	if errs := c.tenantErrors(tenants); len(errs) > 0 {
+		return ValidationErrors(errs)
+	}
+	return nil
+}
+
+func (c *Config) tenantErrors(tenants map[string]Tenant) []error {
+	var errs []error
+	for tenant, cfg := range tenants {
+		if cfg.Priority != "" && cfg.Priority != PriorityHigh && cfg.Priority != PriorityNormal && cfg.Priority != PriorityLow {
+			errs = append(errs, fmt.Errorf("tenants.%s.priority must be one of high, normal or low", tenant))
+		}
+		for alias, route := range cfg.Models {
+			if route == (ModelRoute{}) {
+				errs = append(errs, fmt.Errorf("tenants.%s.models.%s must set a model, region or host", tenant, alias))
+			}
+			if err := c.regionError(fmt.Sprintf("tenants.%s.models.%s.region", tenant, alias), route.Region); err != nil {
+				errs = append(errs, err)
+			}
+			if !c.RewriteHost && (route.Region != "" || route.Host != "") {
+				errs = append(errs, fmt.Errorf("tenants.%s.models.%s region and host require rewriteHost", tenant, alias))
+			}
+		}
+	}
+	return errs
+}
+
+// regionError reports a region, set in the given field, that is not known and not allowed.
+func (c *Config) regionError(field, region string) error {
+	if region != "" && !c.AllowUnknownRegions && !knownRegions[region] {
+		return fmt.Errorf("unknown %s %q: set allowUnknownRegions to use a region not known to this version", field, region)
+	}
+	return nil
+}
+
+// validate checks the admission thresholds.
+func (a Admission) validate() []error {
+	var errs []error
+	if a.MaxInFlight < 0 {
+		errs = append(errs, fmt.Errorf("admission.maxInFlight cannot be negative"))
+	}
+	if a.P95Latency != "" {
+		if _, err := time.ParseDuration(a.P95Latency); err != nil {
+			errs = append(errs, fmt.Errorf("invalid admission.p95Latency: %w", err))
+		}
+	}
+	if a.LatencyWindow < 1 {
+		errs = append(errs, fmt.Errorf("admission.latencyWindow must be positive"))
+	}
+	if maxAge, err := time.ParseDuration(a.LatencyMaxAge); err != nil {
+		errs = append(errs, fmt.Errorf("invalid admission.latencyMaxAge: %w", err))
+	} else if maxAge <= 0 {
+		errs = append(errs, fmt.Errorf("admission.latencyMaxAge must be positive"))
+	}
+	if _, err := time.ParseDuration(a.RetryAfter); err != nil {
+		errs = append(errs, fmt.Errorf("invalid admission.retryAfter: %w", err))
+	}
+	return errs
+}
+
+// Load balancing strategies.
+const (
+	BalanceRoundRobin   = "round_robin"
+	BalanceLeastLatency = "least_latency"
+)
+
+// LoadBalancing configures how chat requests are spread across endpoints. When no endpoints
+// are listed, every request goes to the configured region.
+type LoadBalancing struct {
+	// Strategy is "round_robin" (weighted, the default) or "least_latency".
+	Strategy string `json:"strategy,omitempty"`
+
+	// Endpoints lists the regions or dedicated endpoints serving the requested models.
+	Endpoints []Endpoint `json:"endpoints,omitempty"`
+
+	// FailureThreshold is the number of consecutive failures (5xx or 429) after which an endpoint
+	// is taken out of rotation. Defaults to 3.
+	FailureThreshold int `json:"failureThreshold,omitempty"`
+
+	// Cooldown is how long an unhealthy endpoint stays out of rotation before it is retried,
+	// as a Go duration. Defaults to "30s".
+	Cooldown string `json:"cooldown,omitempty"`
+}
+
+// Endpoint is a single OCI GenAI endpoint requests may be balanced to.
+type Endpoint struct {
+	// Region is the OCI region of the endpoint, used to derive the host when Host is empty.
+	Region string `json:"region,omitempty"`
+
+	// Host overrides the endpoint host, e.g. for a dedicated AI cluster endpoint.
+	Host string `json:"host,omitempty"`
+
+	// Weight is the endpoint's share of round-robin traffic. Defaults to 1.
+	Weight int `json:"weight,omitempty"`
+}
+
+func (l LoadBalancing) validate() []error {
+	var errs []error
+	if l.Strategy != BalanceRoundRobin && l.Strategy != BalanceLeastLatency {
+		errs = append(errs, fmt.Errorf("unsupported loadBalancing.strategy %q: must be %q or %q", l.Strategy, BalanceRoundRobin, BalanceLeastLatency))
+	}
+	for i, endpoint := range l.Endpoints {
+		if endpoint.Region == "" && endpoint.Host == "" {
+			errs = append(errs, fmt.Errorf("loadBalancing.endpoints[%d] requires a region or host", i))
+		}
+		if endpoint.Weight < 0 {
+			errs = append(errs, fmt.Errorf("loadBalancing.endpoints[%d].weight cannot be negative", i))
+		}
+	}
+	if l.FailureThreshold < 1 {
+		errs = append(errs, fmt.Errorf("loadBalancing.failureThreshold must be positive"))
+	}
+	if _, err := time.ParseDuration(l.Cooldown); err != nil {
+		errs = append(errs, fmt.Errorf("invalid loadBalancing.cooldown: %w", err))
+	}
+	return errs
+}
+
+// Usage ledger stores.
+const (
+	UsageStoreMemory = "memory"
+	UsageStoreFile   = "file"
+	UsageStoreHTTP   = "http"
+)
+
+// Usage configures the usage ledger, which totals requests and tokens per tenant and model.
+type Usage struct {
+	// Enabled turns on the usage ledger.
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Store is where totals are kept: "memory" (the default, lost on restart), "file" or "http".
+	Store string `json:"store,omitempty"`
+
+	// File is the JSON file totals are kept in with the "file" store.
+	File string `json:"file,omitempty"`
+
+	// URL is the service totals are loaded from (GET) and added to (POST) with the "http" store.
+	URL string `json:"url,omitempty"`
+
+	// FlushInterval is how often recorded usage is written to the store, as a Go duration. Defaults to "10s".
+	FlushInterval string `json:"flushInterval,omitempty"`
+
+	// ReportURL is a webhook the usage of each reporting period is POSTed to. Empty disables reports.
+	ReportURL string `json:"reportUrl,omitempty"`
+
+	// ReportInterval is the reporting period, as a Go duration. Reports are sent at period
+	// boundaries, so "24h" reports daily at midnight UTC. Defaults to "24h".
+	ReportInterval string `json:"reportInterval,omitempty"`
+}
+
+func (u Usage) validate() []error {
+	var errs []error
+	switch u.Store {
+	case UsageStoreMemory:
+	case UsageStoreFile:
+		if u.File == "" {
+			errs = append(errs, fmt.Errorf("usage.file is required for the file store"))
+		}
+	case UsageStoreHTTP:
+		if u.URL == "" {
+			errs = append(errs, fmt.Errorf("usage.url is required for the http store"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("unsupported usage.store %q", u.Store))
+	}
+	interval, err := time.ParseDuration(u.FlushInterval)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("invalid usage.flushInterval: %w", err))
+	} else if interval <= 0 {
+		errs = append(errs, fmt.Errorf("usage.flushInterval must be positive"))
+	}
+	if u.ReportURL != "" {
+		reportInterval, err := time.ParseDuration(u.ReportInterval)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid usage.reportInterval: %w", err))
+		} else if reportInterval <= 0 {
+			errs = append(errs, fmt.Errorf("usage.reportInterval must be positive"))
+		}
+	}
+	return errs
+}
+
+// Fixture modes.
+const (
+	FixtureModeRecord = "record"
+	FixtureModeReplay = "replay"
+)
+
+// Fixtures configures recording and replaying of OCI responses, for testing client integrations
+// without OCI credentials or spend.
+type Fixtures struct {
+	// Mode is "record", which saves every OCI response while forwarding requests as usual, or
+	// "replay", which answers OCI requests from the saved responses without forwarding them.
+	// Empty disables fixtures.
+	Mode string `json:"mode,omitempty"`
+
+	// Directory holds one JSON file per recorded request.
+	Directory string `json:"directory,omitempty"`
+}
+
+func (f Fixtures) validate() []error {
+	var errs []error
+	if f.Mode != FixtureModeRecord && f.Mode != FixtureModeReplay {
+		errs = append(errs, fmt.Errorf("unsupported fixtures.mode %q: must be %q or %q", f.Mode, FixtureModeRecord, FixtureModeReplay))
+	}
+	if f.Directory == "" {
+		errs = append(errs, fmt.Errorf("fixtures.directory is required"))
+	}
+	return errs
+}
+
+// ClientIP configures client IP extraction. The forwarded header is only honoured on connections
+// from trusted proxies, since any client can set it.
+type ClientIP struct {
+	// TrustedProxies are the IP addresses or CIDRs of proxies in front of Traefik whose forwarded
+	// header is trusted. Empty means the connection's peer address is always the client.
+	TrustedProxies []string `json:"trustedProxies,omitempty"`
+
+	// Header lists the client and proxy addresses. Defaults to "X-Forwarded-For".
+	Header string `json:"header,omitempty"`
+}
+
+// IPFilter configures CIDR-based access control on the client IP, determined as configured by ClientIP.
+type IPFilter struct {
+	// Allow lists the IP addresses or CIDRs that may use the gateway. Empty allows every address
+	// that is not denied.
+	Allow []string `json:"allow,omitempty"`
+
+	// Deny lists the IP addresses or CIDRs that are blocked, even when also allowed.
+	Deny []string `json:"deny,omitempty"`
+}
+
+// CORS configures the cross-origin policies of the OpenAI endpoints. The policy of the request's
+// tenant applies first, then that of its host, then the default one.
+type CORS struct {
+	// AllowOrigins, AllowHeaders, AllowCredentials and MaxAge make up the default policy, as in CORSPolicy.
+	AllowOrigins     []string `json:"allowOrigins,omitempty"`
+	AllowHeaders     []string `json:"allowHeaders,omitempty"`
+	AllowCredentials bool     `json:"allowCredentials,omitempty"`
+	MaxAge           int      `json:"maxAge,omitempty"`
+
+	// Tenants maps tenants, as named by the tenant header, to their policy.
+	Tenants map[string]CORSPolicy `json:"tenants,omitempty"`
+
+	// Hosts maps request hosts, without the port, to their policy.
+	Hosts map[string]CORSPolicy `json:"hosts,omitempty"`
+}
+
+// CORSPolicy is a cross-origin policy.
+type CORSPolicy struct {
+	// AllowOrigins lists the origins allowed to read responses, e.g. "https://app.example.com",
+	// "https://*.example.com" for its subdomains, or "*" for any origin.
+	AllowOrigins []string `json:"allowOrigins,omitempty"`
+
+	// AllowHeaders lists the request headers allowed in preflight responses. Empty allows the headers requested.
+	AllowHeaders []string `json:"allowHeaders,omitempty"`
+
+	// AllowCredentials lets browsers send cookies and authorization with requests. It cannot be
+	// used with the "*" origin.
+	AllowCredentials bool `json:"allowCredentials,omitempty"`
+
+	// MaxAge is how long, in seconds, browsers may cache preflight responses. Zero leaves it to the browser.
+	MaxAge int `json:"maxAge,omitempty"`
+}
+
+// Policy returns the CORS policy of a tenant's request to a host.
+func (c CORS) Policy(tenant, host string) CORSPolicy {
+	if policy, ok := c.Tenants[tenant]; ok && tenant != "" {
+		return policy
+	}
+	for name, policy := range c.Hosts {
+		if strings.EqualFold(name, host) {
+			return policy
+		}
+	}
+	return c.defaultPolicy()
+}
+
+// defaultPolicy returns the policy of requests without a tenant or host policy.
+func (c CORS) defaultPolicy() CORSPolicy {
+	return CORSPolicy{
+		AllowOrigins:     c.AllowOrigins,
+		AllowHeaders:     c.AllowHeaders,
+		AllowCredentials: c.AllowCredentials,
+		MaxAge:           c.MaxAge,
+	}
+}
+
+// validate checks a CORS policy, named by its position in the configuration.
+func (p CORSPolicy) validate(name string) []error {
+	var errs []error
+	for _, origin := range p.AllowOrigins {
+		switch {
+		case origin == "*":
+			if p.AllowCredentials {
+				errs = append(errs, fmt.Errorf("%s.allowCredentials cannot be used with the \"*\" origin", name))
+			}
+		case !validOrigin(origin):
+			errs = append(errs, fmt.Errorf("invalid %s.allowOrigins entry %q: must be \"*\" or a scheme and host", name, origin))
+		}
+	}
+	if p.MaxAge < 0 {
+		errs = append(errs, fmt.Errorf("%s.maxAge cannot be negative", name))
+	}
+	return errs
+}
+
+// validOrigin reports whether an origin is a scheme and host, with an optional port, whose host
+// may start with "*." to match subdomains.
+func validOrigin(origin string) bool {
+	u, err := url.Parse(strings.Replace(origin, "://*.", "://wildcard.", 1))
+	return err == nil && u.Scheme != "" && u.Host != "" && u.Path == "" && u.RawQuery == "" && u.User == nil
+}
+
+// ModelOverride configures the header replacing the model of chat requests. Responses keep reporting
+// the model named by the client, so canaries are transparent to it.
+type ModelOverride struct {
+	// Enabled honours the override header.
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Header is the request header naming the model to use instead. Defaults to "X-Model-Override".
+	Header string `json:"header,omitempty"`
+
+	// TrustedNetworks lists the client IP addresses or CIDRs whose override header is honoured. It is
+	// required, since the override applies after tenant routing and replaces the tenant's model.
+	TrustedNetworks []string `json:"trustedNetworks,omitempty"`
+}
+
+// validNetwork reports whether value is an IP address or CIDR.
+func validNetwork(value string) bool {
+	if _, _, err := net.ParseCIDR(value); err == nil {
+		return true
+	}
+	return net.ParseIP(value) != nil
+}
+
+// compartmentIDPattern matches compartment OCIDs, and tenancy OCIDs for the root compartment:
+// ocid1.<type>.<realm>.[region].<unique ID>.
+var compartmentIDPattern = regexp.MustCompile(`^ocid1\.(compartment|tenancy)\.oc[0-9]+\.[a-z0-9-]*\.[a-z0-9]+$`)
+
+// openAIRoles are the OpenAI message roles roleMappings may map.
+var openAIRoles = map[string]bool{
+	"system": true, "developer": true, "user": true, "assistant": true, "tool": true, "function": true,
+}
+
+// knownRegions are the OCI region identifiers a configuration may use without AllowUnknownRegions.
+var knownRegions = map[string]bool{
+	"af-johannesburg-1": true,
+	"ap-batam-1":        true,
+	"ap-chuncheon-1":    true,
+	"ap-hyderabad-1":    true,
+	"ap-melbourne-1":    true,
+	"ap-mumbai-1":       true,
+	"ap-osaka-1":        true,
+	"ap-seoul-1":        true,
+	"ap-singapore-1":    true,
+	"ap-singapore-2":    true,
+	"ap-sydney-1":       true,
+	"ap-tokyo-1":        true,
+	"ca-montreal-1":     true,
+	"ca-toronto-1":      true,
+	"eu-amsterdam-1":    true,
+	"eu-frankfurt-1":    true,
+	"eu-frankfurt-2":    true,
+	"eu-jovanovac-1":    true,
+	"eu-madrid-1":       true,
+	"eu-madrid-2":       true,
+	"eu-marseille-1":    true,
+	"eu-milan-1":        true,
+	"eu-paris-1":        true,
+	"eu-stockholm-1":    true,
+	"eu-zurich-1":       true,
+	"il-jerusalem-1":    true,
+	"me-abudhabi-1":     true,
+	"me-dubai-1":        true,
+	"me-jeddah-1":       true,
+	"me-riyadh-1":       true,
+	"mx-monterrey-1":    true,
+	"mx-queretaro-1":    true,
+	"sa-bogota-1":       true,
+	"sa-santiago-1":     true,
+	"sa-saopaulo-1":     true,
+	"sa-valparaiso-1":   true,
+	"sa-vinhedo-1":      true,
+	"uk-cardiff-1":      true,
+	"uk-gov-cardiff-1":  true,
+	"uk-gov-london-1":   true,
+	"uk-london-1":       true,
+	"us-ashburn-1":      true,
+	"us-chicago-1":      true,
+	"us-gov-ashburn-1":  true,
+	"us-gov-chicago-1":  true,
+	"us-gov-phoenix-1":  true,
+	"us-langley-1":      true,
+	"us-luke-1":         true,
+	"us-phoenix-1":      true,
+	"us-sanjose-1":      true,
+}
@@ -1,12 +1,16 @@
 package config
 
 import (
+	"strings"
 	"testing"
+
+	"github.com/zalbiraw/ociaitoopenai/pkg/types"
+	"github.com/zalbiraw/ociaitoopenai/pkg/vendors"
 )
 
 func TestValidate_ValidConfig(t *testing.T) {
 	cfg := New()
-	cfg.CompartmentID = "test-compartment-id"
+	cfg.CompartmentID = "ocid1.compartment.oc1..testcompartment"
 	cfg.Region = "us-ashburn-1"
 
 	if err := cfg.Validate(); err != nil {
@@ -28,9 +32,286 @@ func TestValidate_MissingCompartmentID(t *testing.T) {
 	}
 }
 
+func TestValidate_ModelCompartments(t *testing.T) {
+	cfg := New()
+	cfg.CompartmentID = "ocid1.compartment.oc1..testcompartment"
+	cfg.Region = "us-ashburn-1"
+	cfg.ModelCompartments = map[string]string{"team": "ocid1.compartment.oc1..teamcompartment"}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+
+	cfg.ModelCompartments = map[string]string{"team/a": "ocid1.compartment.oc1..teamcompartment"}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), `invalid modelCompartments namespace "team/a"`) {
+		t.Errorf("expected a namespace error, got %v", err)
+	}
+
+	cfg.ModelCompartments = map[string]string{"team": "team-compartment"}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "invalid modelCompartments.team") {
+		t.Errorf("expected a compartment error, got %v", err)
+	}
+}
+
+func TestValidate_LanguageScaling(t *testing.T) {
+	cfg := New()
+	cfg.CompartmentID = "ocid1.compartment.oc1..testcompartment"
+	cfg.Region = "us-ashburn-1"
+	cfg.LanguageScaling.Enabled = true
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "defaultMaxTokens is required") {
+		t.Errorf("expected a defaultMaxTokens error, got %v", err)
+	}
+
+	cfg.DefaultMaxTokens = 1024
+	cfg.LanguageScaling.Factors = map[string]float64{"klingon": 2}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), `invalid languageScaling.factors script "klingon"`) {
+		t.Errorf("expected a script error, got %v", err)
+	}
+
+	cfg.LanguageScaling.Factors = map[string]float64{"cjk": 1.8}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
+
+func TestValidate_WarmUp(t *testing.T) {
+	cfg := New()
+	cfg.CompartmentID = "ocid1.compartment.oc1..testcompartment"
+	cfg.Region = "us-ashburn-1"
+	cfg.WarmUp.Enabled = true
+	cfg.WarmUp.Targets = []WarmUpTarget{{Region: "us-ashburn-1"}}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "warmUp.targets[0] requires an endpoint or a model") {
+		t.Errorf("expected a target error, got %v", err)
+	}
+
+	cfg.WarmUp.Targets = []WarmUpTarget{{Endpoint: "ocid1.generativeaiendpoint.oc1..dedicated"}}
+	cfg.WarmUp.Interval = "0s"
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "warmUp.interval must be positive") {
+		t.Errorf("expected an interval error, got %v", err)
+	}
+
+	cfg.WarmUp.Interval = "1m"
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
+
+func TestValidate_Degradation(t *testing.T) {
+	cfg := New()
+	cfg.CompartmentID = "ocid1.compartment.oc1..testcompartment"
+	cfg.Region = "us-ashburn-1"
+	cfg.Degradation.Enabled = true
+	cfg.Degradation.ErrorBudget = 0
+	cfg.Degradation.Cooldown = "soon"
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "degradation.errorBudget must be at least 1") ||
+		!strings.Contains(err.Error(), "invalid degradation.cooldown") {
+		t.Errorf("expected budget and cooldown errors, got %v", err)
+	}
+
+	cfg.Degradation.ErrorBudget = 3
+	cfg.Degradation.Cooldown = "30s"
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
+
+func TestValidate_RewriteHostWithLoadBalancing(t *testing.T) {
+	cfg := New()
+	cfg.CompartmentID = "ocid1.compartment.oc1..testcompartment"
+	cfg.Region = "us-ashburn-1"
+	cfg.RewriteHost = false
+	cfg.LoadBalancing.Endpoints = []Endpoint{{Region: "us-chicago-1"}}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "loadBalancing.endpoints requires rewriteHost") {
+		t.Errorf("expected a rewriteHost error, got %v", err)
+	}
+}
+
+func TestValidate_RewriteHostWithRoutes(t *testing.T) {
+	cfg := New()
+	cfg.CompartmentID = "ocid1.compartment.oc1..testcompartment"
+	cfg.Region = "us-ashburn-1"
+	cfg.RewriteHost = false
+	cfg.AuthType = "resource_principal"
+	cfg.TenantHeader = "X-Tenant"
+	cfg.Tenants = map[string]Tenant{"team-a": {Models: map[string]ModelRoute{"gpt-4": {Host: "dac.example.com"}}}}
+	cfg.Schedules = []Schedule{{Start: "22:00", End: "06:00", Route: ModelRoute{Region: "us-chicago-1"}}}
+	err := cfg.Validate()
+	for _, msg := range []string{"authType requires rewriteHost", "tenants.team-a.models.gpt-4 region and host require rewriteHost",
+		"schedules[0].route region and host require rewriteHost"} {
+		if err == nil || !strings.Contains(err.Error(), msg) {
+			t.Errorf("expected error containing %q, got %v", msg, err)
+		}
+	}
+
+	// Model-only routes keep working when the Traefik service chooses the upstream
+	cfg.AuthType = ""
+	cfg.Tenants = map[string]Tenant{"team-a": {Models: map[string]ModelRoute{"gpt-4": {Model: "cohere.command-r-plus"}}}}
+	cfg.Schedules = []Schedule{{Start: "22:00", End: "06:00", Route: ModelRoute{Model: "cohere.command-r"}}}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
+
+func TestValidate_Schedules(t *testing.T) {
+	cfg := New()
+	cfg.CompartmentID = "ocid1.compartment.oc1..testcompartment"
+	cfg.Region = "us-ashburn-1"
+	cfg.Schedules = []Schedule{
+		{Start: "25:00", End: "06:00", Days: []string{"someday"}, Timezone: "Mars/Olympus"},
+		{From: "2026-03-01T04:00:00Z", Until: "2026-03-01T02:00:00Z", Route: ModelRoute{Region: "moon-1"}},
+		{Days: []string{"mon"}, Route: ModelRoute{Model: "meta.llama-3.1-8b-instruct"}},
+	}
+	err := cfg.Validate()
+	for _, msg := range []string{"schedules[0].route must set a model, region or host", `invalid schedules[0].start "25:00"`,
+		`invalid schedules[0].days entry "someday"`, "invalid schedules[0].timezone", "schedules[1].until must be after schedules[1].from",
+		`unknown schedules[1].route.region "moon-1"`, "schedules[2] requires a daily window", "schedules[2].days requires start and end"} {
+		if err == nil || !strings.Contains(err.Error(), msg) {
+			t.Errorf("expected %q, got %v", msg, err)
+		}
+	}
+
+	cfg.Schedules = []Schedule{
+		{Days: []string{"Mon", "fri"}, Start: "20:00", End: "07:00", Timezone: "Europe/London", Route: ModelRoute{Model: "meta.llama-3.1-8b-instruct"}},
+		{From: "2026-03-01T02:00:00Z", Until: "2026-03-01T04:00:00Z", Route: ModelRoute{Region: "us-chicago-1"}},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
+
+func TestValidate_CORS(t *testing.T) {
+	cfg := New()
+	cfg.CompartmentID = "ocid1.compartment.oc1..testcompartment"
+	cfg.Region = "us-ashburn-1"
+	cfg.CORS.AllowCredentials = true
+	cfg.CORS.Tenants = map[string]CORSPolicy{"acme": {AllowOrigins: []string{"app.example.com"}, MaxAge: -1}}
+	err := cfg.Validate()
+	for _, msg := range []string{`cors.allowCredentials cannot be used with the "*" origin`,
+		`invalid cors.tenants.acme.allowOrigins entry "app.example.com"`, "cors.tenants.acme.maxAge cannot be negative",
+		"tenantHeader is required when cors.tenants are configured"} {
+		if err == nil || !strings.Contains(err.Error(), msg) {
+			t.Errorf("expected %q, got %v", msg, err)
+		}
+	}
+
+	cfg.TenantHeader = "X-Tenant"
+	cfg.CORS.AllowOrigins = []string{"https://*.example.com", "http://localhost:3000"}
+	cfg.CORS.Tenants = map[string]CORSPolicy{"acme": {AllowOrigins: []string{"*"}}}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
+
+func TestValidate_Egress(t *testing.T) {
+	cfg := New()
+	cfg.CompartmentID = "ocid1.compartment.oc1..testcompartment"
+	cfg.Region = "us-ashburn-1"
+	cfg.Egress = Egress{Proxy: "socks5://proxy.corp.example:1080", NoProxy: []string{""}, MinTLSVersion: "1.1", ClientCertFile: "client.pem",
+		MaxIdleConnsPerHost: -1, IdleConnTimeout: "soon", DNSCacheTTL: "-1m"}
+	err := cfg.Validate()
+	for _, msg := range []string{`invalid egress.proxy "socks5://proxy.corp.example:1080"`, "egress.noProxy entries cannot be empty",
+		`invalid egress.minTLSVersion "1.1"`, "egress.clientCertFile and egress.clientKeyFile must be set together",
+		"egress.maxIdleConnsPerHost cannot be negative", "invalid egress.idleConnTimeout", "egress.dnsCacheTTL must be positive"} {
+		if err == nil || !strings.Contains(err.Error(), msg) {
+			t.Errorf("expected %q, got %v", msg, err)
+		}
+	}
+
+	cfg.Egress = Egress{Proxy: "http://proxy.corp.example:3128", NoProxy: []string{".corp.example"}}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
+
+func TestValidate_TenantPriority(t *testing.T) {
+	cfg := New()
+	cfg.CompartmentID = "ocid1.compartment.oc1..testcompartment"
+	cfg.Region = "us-ashburn-1"
+	cfg.TenantHeader = "X-Tenant"
+	cfg.Tenants = map[string]Tenant{"batch": {Priority: "urgent"}}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "tenants.batch.priority must be one of high, normal or low") {
+		t.Errorf("expected an invalid priority error, got %v", err)
+	}
+
+	cfg.Tenants = map[string]Tenant{"batch": {Priority: PriorityLow}}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
+
+func TestValidate_ModelOwners(t *testing.T) {
+	cfg := New()
+	cfg.CompartmentID = "ocid1.compartment.oc1..testcompartment"
+	cfg.Region = "us-ashburn-1"
+	cfg.ModelOwners = map[string]string{"meta": " ", "": "oracle"}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "modelOwners.meta cannot be empty") {
+		t.Errorf("expected an empty owner error, got %v", err)
+	}
+}
+
+func TestValidate_Rotation(t *testing.T) {
+	cfg := New()
+	cfg.CompartmentID = "ocid1.compartment.oc1..testcompartment"
+	cfg.Region = "us-ashburn-1"
+	cfg.Audit.Rotation = Rotation{MaxBytes: -1, MaxAge: "daily", MaxFiles: -1}
+	cfg.Mirror.Rotation = Rotation{Compress: true, Retention: "0s"}
+	err := cfg.Validate()
+	for _, msg := range []string{"audit.rotation.maxBytes cannot be negative", "audit.rotation.maxFiles cannot be negative",
+		"invalid audit.rotation.maxAge", "mirror.rotation.retention must be positive", "mirror.rotation requires maxBytes or maxAge"} {
+		if err == nil || !strings.Contains(err.Error(), msg) {
+			t.Errorf("expected %q, got %v", msg, err)
+		}
+	}
+
+	cfg.Audit.Rotation = Rotation{MaxAge: "24h", Compress: true, MaxFiles: 7, Retention: "720h"}
+	cfg.Mirror.Rotation = Rotation{MaxBytes: 1 << 20}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
+
+func TestValidate_RoleMappings(t *testing.T) {
+	cfg := New()
+	cfg.CompartmentID = "ocid1.compartment.oc1..testcompartment"
+	cfg.Region = "us-ashburn-1"
+	cfg.RoleMappings = map[string]map[string]string{"OPENAI": {"narrator": ""}}
+	err := cfg.Validate()
+	for _, msg := range []string{`invalid roleMappings format "OPENAI"`, `invalid roleMappings.OPENAI role "narrator"`, "roleMappings.OPENAI.narrator cannot be empty"} {
+		if err == nil || !strings.Contains(err.Error(), msg) {
+			t.Errorf("expected %q, got %v", msg, err)
+		}
+	}
+
+	cfg.RoleMappings = map[string]map[string]string{"GENERIC": {"system": "SYSTEM"}}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
+
+func TestValidate_CustomVendors(t *testing.T) {
+	cfg := New()
+	cfg.CompartmentID = "ocid1.compartment.oc1..testcompartment"
+	cfg.Region = "us-ashburn-1"
+	cfg.CustomVendors = map[string]string{"acme.": "config-test-acme"}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), `no vendor "config-test-acme" is registered`) {
+		t.Errorf("expected an unregistered vendor error, got %v", err)
+	}
+
+	vendors.Register("config-test-acme", vendors.Vendor{Formatter: acmeFormatter{}})
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
+
+type acmeFormatter struct{}
+
+func (acmeFormatter) FormatRequest(types.ChatCompletionRequest) types.ChatRequest {
+	return types.ChatRequest{APIFormat: "ACME"}
+}
+
 func TestValidate_MissingRegion(t *testing.T) {
 	cfg := New()
-	cfg.CompartmentID = "test-compartment-id"
+	cfg.CompartmentID = "ocid1.compartment.oc1..testcompartment"
 	// Region is empty
 
 	err := cfg.Validate()
@@ -57,4 +338,532 @@ func TestNew_DefaultValues(t *testing.T) {
 	if cfg.Region != "" {
 		t.Errorf("expected Region to be empty, got: %s", cfg.Region)
 	}
+
+	if !cfg.EnableModelsEndpoint {
+		t.Error("expected EnableModelsEndpoint to default to true")
+	}
+
+	if !cfg.EnableChatEndpoint {
+		t.Error("expected EnableChatEndpoint to default to true")
+	}
+
+	if !cfg.RewriteHost {
+		t.Error("expected RewriteHost to default to true")
+	}
+
+	if !cfg.TransformResponses {
+		t.Error("expected TransformResponses to default to true")
+	}
+}
+
+func TestValidate_MetricsPath(t *testing.T) {
+	cfg := New()
+	cfg.CompartmentID = "ocid1.compartment.oc1..testcompartment"
+	cfg.Region = "us-ashburn-1"
+	cfg.Metrics.Enabled = true
+	cfg.Metrics.Path = "metrics"
+
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for metrics path without leading slash")
+	}
+
+	cfg.Metrics.Path = "/_ociai/metrics"
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected valid metrics config, got: %v", err)
+	}
+}
+
+func TestValidate_AuthType(t *testing.T) {
+	cfg := New()
+	cfg.CompartmentID = "ocid1.compartment.oc1..testcompartment"
+	cfg.Region = "us-ashburn-1"
+
+	for _, authType := range []string{"", "resource_principal", "oke_workload_identity"} {
+		cfg.AuthType = authType
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("expected authType %q to be valid, got: %v", authType, err)
+		}
+	}
+
+	cfg.AuthType = "security_token"
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for security_token without token and key files")
+	}
+
+	cfg.SecurityTokenFile = "/home/opc/.oci/sessions/DEFAULT/token"
+	cfg.PrivateKey.File = "/home/opc/.oci/sessions/DEFAULT/oci_api_key.pem"
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected security_token config to be valid, got: %v", err)
+	}
+
+	cfg.AuthType = "password"
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for unsupported authType")
+	}
+}
+
+func TestValidate_PrivateKeySources(t *testing.T) {
+	cfg := New()
+	cfg.CompartmentID = "ocid1.compartment.oc1..testcompartment"
+	cfg.Region = "us-ashburn-1"
+	cfg.AuthType = "api_key"
+	cfg.TenancyID = "ocid1.tenancy.oc1..tenancy"
+	cfg.UserID = "ocid1.user.oc1..user"
+	cfg.Fingerprint = "aa:bb:cc"
+
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error when no private key source is configured")
+	}
+
+	cfg.PrivateKey.File = "/etc/oci/key.pem"
+	cfg.PrivateKey.Env = "OCI_PRIVATE_KEY"
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error when several private key sources are configured")
+	}
+
+	cfg.PrivateKey = PrivateKey{VaultSecretID: "ocid1.vaultsecret.oc1..secret"}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for vault secret without vaultAuthType")
+	}
+
+	cfg.PrivateKey.VaultAuthType = "resource_principal"
+	cfg.PrivateKey.RefreshInterval = "15m"
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected vault key source to be valid, got: %v", err)
+	}
+}
+
+func TestValidate_Mirror(t *testing.T) {
+	cfg := New()
+	cfg.CompartmentID = "ocid1.compartment.oc1..testcompartment"
+	cfg.Region = "us-ashburn-1"
+	cfg.Mirror.Directory = "/var/log/ociai"
+	cfg.Mirror.URL = "https://eval.example.com/ingest"
+
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error when both mirror directory and url are set")
+	}
+
+	cfg.Mirror.URL = ""
+	cfg.Mirror.SampleRate = 1.5
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for sample rate above 1")
+	}
+}
+
+func TestValidate_Audit(t *testing.T) {
+	cfg := New()
+	cfg.CompartmentID = "ocid1.compartment.oc1..testcompartment"
+	cfg.Region = "us-ashburn-1"
+	cfg.Audit.File = "/var/log/ociai/audit.jsonl"
+	cfg.Audit.URL = "https://audit.example.com/ingest"
+
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error when both audit file and url are set")
+	}
+
+	cfg.Audit.URL = ""
+	cfg.ServiceTierPriorities["flex"] = "urgent"
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for unknown priority class")
+	}
+}
+
+func TestServiceTierPriority(t *testing.T) {
+	cfg := New()
+
+	tests := map[string]string{
+		"priority": PriorityHigh,
+		"flex":     PriorityLow,
+		"":         PriorityNormal,
+		"unknown":  PriorityNormal,
+	}
+	for tier, expected := range tests {
+		if priority := cfg.ServiceTierPriority(tier); priority != expected {
+			t.Errorf("expected priority %q for tier %q, got %q", expected, tier, priority)
+		}
+	}
+}
+
+func TestValidate_ImageFetch(t *testing.T) {
+	cfg := New()
+	cfg.CompartmentID = "ocid1.compartment.oc1..testcompartment"
+	cfg.Region = "us-ashburn-1"
+	cfg.ImageFetch.Enabled = true
+
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error when image fetching is enabled without allowed hosts")
+	}
+
+	cfg.ImageFetch.AllowedHosts = []string{"*.example.com"}
+	cfg.ImageFetch.Timeout = "soon"
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for invalid timeout")
+	}
+
+	cfg.ImageFetch.Timeout = "5s"
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected no error, got: %v", err)
+	}
+}
+
+func TestValidate_LoadBalancing(t *testing.T) {
+	cfg := New()
+	cfg.CompartmentID = "ocid1.compartment.oc1..testcompartment"
+	cfg.Region = "us-ashburn-1"
+	cfg.LoadBalancing.Endpoints = []Endpoint{{Weight: 1}}
+
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for endpoint without region or host")
+	}
+
+	cfg.LoadBalancing.Endpoints = []Endpoint{{Region: "us-chicago-1"}}
+	cfg.LoadBalancing.Strategy = "random"
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for unsupported strategy")
+	}
+
+	cfg.LoadBalancing.Strategy = BalanceLeastLatency
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected no error, got: %v", err)
+	}
+}
+
+func TestValidate_Tenants(t *testing.T) {
+	cfg := New()
+	cfg.CompartmentID = "ocid1.compartment.oc1..testcompartment"
+	cfg.Region = "us-ashburn-1"
+	cfg.Tenants = map[string]Tenant{"premium": {Models: map[string]ModelRoute{"chat": {Model: "cohere.command-r-plus"}}}}
+
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for tenants without tenantHeader")
+	}
+
+	cfg.TenantHeader = "X-Tenant"
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected no error, got: %v", err)
+	}
+	if route, ok := cfg.ModelRoute("premium", "chat"); !ok || route.Model != "cohere.command-r-plus" {
+		t.Errorf("expected premium route, got %+v, %v", route, ok)
+	}
+	if _, ok := cfg.ModelRoute("standard", "chat"); ok {
+		t.Error("expected no route for an unconfigured tenant")
+	}
+}
+
+func TestValidate_Fixtures(t *testing.T) {
+	cfg := New()
+	cfg.CompartmentID = "ocid1.compartment.oc1..testcompartment"
+	cfg.Region = "us-ashburn-1"
+
+	cfg.Fixtures = Fixtures{Mode: "playback", Directory: "fixtures"}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for an unsupported fixtures mode")
+	}
+
+	cfg.Fixtures = Fixtures{Mode: FixtureModeReplay}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for fixtures without a directory")
+	}
+
+	cfg.Fixtures.Directory = "fixtures"
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected no error, got: %v", err)
+	}
+}
+
+func TestValidate_Identifiers(t *testing.T) {
+	cfg := New()
+	cfg.CompartmentID = "ocid1.compartment.oc1..testcompartment"
+	cfg.Region = "us-ashburn-1"
+
+	cfg.IDStrategy = "uuid"
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for an unsupported idStrategy")
+	}
+
+	cfg.IDStrategy = IDStrategySequential
+	cfg.FixedTime = "yesterday"
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for an invalid fixedTime")
+	}
+
+	cfg.FixedTime = "2024-05-01T12:00:00Z"
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected no error, got: %v", err)
+	}
+}
+
+func TestValidate_ClientIP(t *testing.T) {
+	cfg := New()
+	cfg.CompartmentID = "ocid1.compartment.oc1..testcompartment"
+	cfg.Region = "us-ashburn-1"
+
+	cfg.ClientIP.TrustedProxies = []string{"10.0.0.0/8", "proxy.internal"}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for a trusted proxy that is not an IP or CIDR")
+	}
+
+	cfg.ClientIP.TrustedProxies = []string{"10.0.0.0/8", "192.168.1.1"}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected no error, got: %v", err)
+	}
+}
+
+func TestValidate_IPFilter(t *testing.T) {
+	cfg := New()
+	cfg.CompartmentID = "ocid1.compartment.oc1..testcompartment"
+	cfg.Region = "us-ashburn-1"
+
+	cfg.IPFilter.Deny = []string{"10.0.0.0/40"}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for an invalid CIDR")
+	}
+
+	cfg.IPFilter = IPFilter{Allow: []string{"10.0.0.0/8"}, Deny: []string{"10.66.0.1"}}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected no error, got: %v", err)
+	}
+}
+
+func TestValidate_ReportsAllErrors(t *testing.T) {
+	cfg := New()
+	cfg.AuthType = "api_key"
+	cfg.Mirror.SampleRate = 2
+	cfg.Usage = Usage{Enabled: true, Store: UsageStoreFile, FlushInterval: "soon"}
+
+	err := cfg.Validate()
+	errs, ok := err.(ValidationErrors)
+	if !ok {
+		t.Fatalf("expected ValidationErrors, got: %T %v", err, err)
+	}
+
+	want := []string{
+		"compartmentId is required and cannot be empty",
+		"region is required and cannot be empty",
+		"tenancyId, userId and fingerprint are required for the api_key authType",
+		"exactly one of privateKey.pem, privateKey.file, privateKey.env or privateKey.vaultSecretId is required",
+		"usage.file is required for the file store",
+		`invalid usage.flushInterval: time: invalid duration "soon"`,
+		"mirror.sampleRate must be between 0 and 1",
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("expected %d errors, got %d: %v", len(want), len(errs), err)
+	}
+	for i, message := range want {
+		if errs[i].Error() != message {
+			t.Errorf("error %d: expected %q, got %q", i, message, errs[i].Error())
+		}
+	}
+	if !strings.Contains(err.Error(), "; region is required") {
+		t.Errorf("expected messages joined with '; ', got: %v", err)
+	}
+}
+
+func TestValidate_CompartmentIDFormat(t *testing.T) {
+	cfg := New()
+	cfg.Region = "us-ashburn-1"
+
+	for _, id := range []string{
+		"ocid1.compartment.oc1..aaaaaaaa4r2tqjnymx5rjukz6a7vx7bqlnaqzmbt4mx4e7uqlq3p6xgv2cya",
+		"ocid1.tenancy.oc1..aaaaaaaa4r2tqjnymx5rjukz6a7vx7bqlnaqzmbt4mx4e7uqlq3p6xgv2cya",
+		"ocid1.compartment.oc2.us-langley-1.aaaaaaaa4r2tqjnymx5rjukz6a",
+	} {
+		cfg.CompartmentID = id
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("expected compartmentId %q to be valid, got: %v", id, err)
+		}
+	}
+
+	for _, id := range []string{"my-compartment", "ocid1.user.oc1..aaaaaaaa", "ocid1.compartment.oc1..AAAA", "ocid1.compartment.oc1.."} {
+		cfg.CompartmentID = id
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("expected error for compartmentId %q", id)
+		}
+	}
+}
+
+func TestValidate_KnownRegions(t *testing.T) {
+	cfg := New()
+	cfg.CompartmentID = "ocid1.compartment.oc1..testcompartment"
+	cfg.Region = "us-newregion-1"
+	cfg.LoadBalancing.Endpoints = []Endpoint{{Region: "us-chicago-1"}, {Region: "eu-newregion-1"}}
+
+	err := cfg.Validate()
+	errs, ok := err.(ValidationErrors)
+	if !ok || len(errs) != 2 {
+		t.Fatalf("expected errors for the unknown region and endpoint region, got: %v", err)
+	}
+	if !strings.Contains(errs[1].Error(), "loadBalancing.endpoints[1].region") {
+		t.Errorf("expected the endpoint to be named, got: %v", errs[1])
+	}
+
+	cfg.AllowUnknownRegions = true
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected unknown regions to be allowed, got: %v", err)
+	}
+}
+
+func TestValidate_TenantSource(t *testing.T) {
+	cfg := New()
+	cfg.CompartmentID = "ocid1.compartment.oc1..testcompartment"
+	cfg.Region = "us-ashburn-1"
+	cfg.TenantSource.URL = "https://config.example.com/tenants.json"
+
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for a tenant source without a tenant header")
+	}
+
+	cfg.TenantHeader = "X-Tenant"
+	cfg.TenantSource.File = "/etc/ociai/tenants.json"
+	cfg.TenantSource.RefreshInterval = "0s"
+	err := cfg.Validate()
+	if errs, ok := err.(ValidationErrors); !ok || len(errs) != 2 {
+		t.Errorf("expected errors for both sources and the refresh interval, got: %v", err)
+	}
+
+	cfg.TenantSource.File = ""
+	cfg.TenantSource.RefreshInterval = "30s"
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected valid tenant source, got: %v", err)
+	}
+}
+
+func TestValidate_Speech(t *testing.T) {
+	cfg := New()
+	cfg.CompartmentID = "ocid1.compartment.oc1..testcompartment"
+	cfg.Region = "us-ashburn-1"
+	cfg.Speech.Enabled = true
+
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "speech requires authType") {
+		t.Errorf("expected error for speech without the built-in signer, got: %v", err)
+	}
+
+	cfg.AuthType = "resource_principal"
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected valid speech config, got: %v", err)
+	}
+}
+
+func TestValidate_Transcription(t *testing.T) {
+	cfg := New()
+	cfg.CompartmentID = "ocid1.compartment.oc1..testcompartment"
+	cfg.Region = "us-ashburn-1"
+	cfg.Transcription.Enabled = true
+
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for transcription without a bucket")
+	}
+
+	cfg.Transcription.Namespace = "tenancy-ns"
+	cfg.Transcription.Bucket = "audio"
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "transcription requires authType") {
+		t.Errorf("expected error for transcription without the built-in signer, got: %v", err)
+	}
+
+	cfg.AuthType = "resource_principal"
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected valid transcription config, got: %v", err)
+	}
+}
+
+func TestValidate_Assistants(t *testing.T) {
+	cfg := New()
+	cfg.CompartmentID = "ocid1.compartment.oc1..testcompartment"
+	cfg.Region = "us-ashburn-1"
+	cfg.Assistants.Enabled = true
+	cfg.Assistants.MaxObjects = 0
+	err := cfg.Validate()
+	for _, msg := range []string{"assistants requires tenantHeader", "assistants.maxObjects and assistants.maxMessages must be positive"} {
+		if err == nil || !strings.Contains(err.Error(), msg) {
+			t.Errorf("expected error containing %q, got %v", msg, err)
+		}
+	}
+
+	cfg.TenantHeader = "X-Tenant"
+	cfg.Assistants.MaxObjects = 100
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
+
+func TestValidate_Chaos(t *testing.T) {
+	cfg := New()
+	cfg.CompartmentID = "ocid1.compartment.oc1..testcompartment"
+	cfg.Region = "us-ashburn-1"
+	cfg.Chaos.Enabled = true
+	cfg.Chaos.TruncateProbability = 1.5
+	cfg.Chaos.Latency = "soon"
+
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "chaos.truncateProbability") || !strings.Contains(err.Error(), "chaos.latency") {
+		t.Errorf("expected probability and latency errors, got: %v", err)
+	}
+
+	cfg.Chaos.TruncateProbability = 0.5
+	cfg.Chaos.Latency = "250ms"
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected valid chaos config, got: %v", err)
+	}
+}
+
+func TestValidate_Maintenance(t *testing.T) {
+	cfg := New()
+	cfg.CompartmentID = "ocid1.compartment.oc1..testcompartment"
+	cfg.Region = "us-ashburn-1"
+	cfg.Maintenance.Enabled = true
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected the default maintenance config to be valid, got: %v", err)
+	}
+
+	cfg.Maintenance.Message = ""
+	cfg.Maintenance.RetryAfter = "later"
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "maintenance.message") || !strings.Contains(err.Error(), "maintenance.retryAfter") {
+		t.Errorf("expected message and retryAfter errors, got: %v", err)
+	}
+}
+
+func TestValidate_SLO(t *testing.T) {
+	cfg := New()
+	cfg.CompartmentID = "ocid1.compartment.oc1..testcompartment"
+	cfg.Region = "us-ashburn-1"
+	cfg.SLO.Enabled = true
+	cfg.SLO.P95Latency = "2s"
+	cfg.SLO.ErrorRate = 0.01
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected valid SLO config, got: %v", err)
+	}
+
+	cfg.SLO.Window = 0
+	cfg.SLO.ErrorRate = 2
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "slo.window") || !strings.Contains(err.Error(), "slo.errorRate") {
+		t.Errorf("expected window and errorRate errors, got: %v", err)
+	}
+}
+
+func TestValidate_ModelOverride(t *testing.T) {
+	cfg := New()
+	cfg.CompartmentID = "ocid1.compartment.oc1..testcompartment"
+	cfg.Region = "us-ashburn-1"
+	cfg.ModelOverride.Enabled = true
+	cfg.ModelOverride.TrustedNetworks = []string{"10.0.0.0/8", "192.0.2.1"}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected valid model override config, got: %v", err)
+	}
+
+	cfg.ModelOverride.Header = ""
+	cfg.ModelOverride.TrustedNetworks = []string{"ops-team"}
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "modelOverride.header") || !strings.Contains(err.Error(), `"ops-team"`) {
+		t.Errorf("expected header and network errors, got: %v", err)
+	}
+
+	// The header must not be trusted from every client
+	cfg.ModelOverride.Header = "X-Model-Override"
+	cfg.ModelOverride.TrustedNetworks = nil
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "modelOverride.trustedNetworks is required") {
+		t.Errorf("expected a trustedNetworks error, got: %v", err)
+	}
 }
@@ -0,0 +1,139 @@
+// Package degrade turns off optional features whose failures exceed an error budget, so requests
+// are served without them, and turns them back on after a cooldown.
+package degrade
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/zalbiraw/ociaitoopenai/internal/config"
+)
+
+// Status is the state of a feature that has failed.
+type Status struct {
+	Feature       string
+	Disabled      bool
+	DisabledUntil time.Time // Zero unless disabled
+	Failures      int       // Within the window
+	LastError     string
+}
+
+// Guard tracks the failures of optional features. It is safe for concurrent use.
+type Guard struct {
+	budget   int
+	window   time.Duration
+	cooldown time.Duration
+	onChange func(feature string, disabled bool, err error)
+	now      func() time.Time
+
+	mu       sync.Mutex
+	features map[string]*feature
+}
+
+// feature is the failure history of a feature.
+type feature struct {
+	failures      []time.Time // Within the window, oldest first
+	disabledUntil time.Time
+	lastError     string
+}
+
+// New creates a guard. onChange is called when a feature is turned off, with the failure that
+// exhausted its budget, and when it is turned back on. It returns nil when degradation is disabled.
+func New(cfg config.Degradation, onChange func(feature string, disabled bool, err error)) *Guard {
+	if !cfg.Enabled {
+		return nil
+	}
+
+	window, _ := time.ParseDuration(cfg.Window)
+	cooldown, _ := time.ParseDuration(cfg.Cooldown)
+	return &Guard{
+		budget:   cfg.ErrorBudget,
+		window:   window,
+		cooldown: cooldown,
+		onChange: onChange,
+		now:      time.Now,
+		features: make(map[string]*feature),
+	}
+}
+
+// Enabled reports whether a feature should be used. A feature is turned back on, with a fresh
+// budget, once its cooldown has passed.
+func (g *Guard) Enabled(name string) bool {
+	g.mu.Lock()
+	f, ok := g.features[name]
+	if !ok || f.disabledUntil.IsZero() {
+		g.mu.Unlock()
+		return true
+	}
+	if g.now().Before(f.disabledUntil) {
+		g.mu.Unlock()
+		return false
+	}
+	f.disabledUntil = time.Time{}
+	f.failures = nil
+	g.mu.Unlock()
+
+	g.onChange(name, false, nil)
+	return true
+}
+
+// Failure records a failure of a feature, turning it off when its failures within the window
+// exceed the budget.
+func (g *Guard) Failure(name string, err error) {
+	now := g.now()
+
+	g.mu.Lock()
+	f, ok := g.features[name]
+	if !ok {
+		f = &feature{}
+		g.features[name] = f
+	}
+	f.lastError = err.Error()
+	if !f.disabledUntil.IsZero() {
+		// Late failures of work started before the feature was turned off
+		g.mu.Unlock()
+		return
+	}
+
+	recent := f.failures[:0]
+	for _, failed := range f.failures {
+		if now.Sub(failed) < g.window {
+			recent = append(recent, failed)
+		}
+	}
+	f.failures = append(recent, now)
+	tripped := len(f.failures) > g.budget
+	if tripped {
+		f.disabledUntil = now.Add(g.cooldown)
+	}
+	g.mu.Unlock()
+
+	if tripped {
+		g.onChange(name, true, err)
+	}
+}
+
+// Status returns the state of every feature that has failed, by name.
+func (g *Guard) Status() []Status {
+	now := g.now()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	status := make([]Status, 0, len(g.features))
+	for name, f := range g.features {
+		s := Status{Feature: name, LastError: f.lastError}
+		if !f.disabledUntil.IsZero() && now.Before(f.disabledUntil) {
+			s.Disabled = true
+			s.DisabledUntil = f.disabledUntil
+		}
+		for _, failed := range f.failures {
+			if now.Sub(failed) < g.window {
+				s.Failures++
+			}
+		}
+		status = append(status, s)
+	}
+	sort.Slice(status, func(i, j int) bool { return status[i].Feature < status[j].Feature })
+	return status
+}
@@ -0,0 +1,60 @@
+package degrade
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/zalbiraw/ociaitoopenai/internal/config"
+)
+
+func TestNew_Disabled(t *testing.T) {
+	if New(config.Degradation{}, nil) != nil {
+		t.Error("expected no guard when degradation is disabled")
+	}
+}
+
+func TestGuard(t *testing.T) {
+	cfg := config.New().Degradation
+	cfg.Enabled = true
+	cfg.ErrorBudget = 2
+
+	var changes []bool
+	guard := New(cfg, func(feature string, disabled bool, err error) {
+		if feature != "audit" {
+			t.Errorf("unexpected feature %s", feature)
+		}
+		changes = append(changes, disabled)
+	})
+	now := time.Unix(1700000000, 0)
+	guard.now = func() time.Time { return now }
+
+	// Failures outside the window do not count against the budget
+	guard.Failure("audit", errors.New("webhook returned 500"))
+	now = now.Add(2 * time.Minute)
+	guard.Failure("audit", errors.New("webhook returned 500"))
+	guard.Failure("audit", errors.New("webhook returned 500"))
+	if !guard.Enabled("audit") || len(changes) != 0 {
+		t.Fatalf("expected audit to stay on within its budget, got changes %v", changes)
+	}
+
+	guard.Failure("audit", errors.New("webhook returned 503"))
+	if guard.Enabled("audit") || len(changes) != 1 || !changes[0] {
+		t.Fatalf("expected audit to be turned off, got changes %v", changes)
+	}
+	status := guard.Status()
+	if len(status) != 1 || !status[0].Disabled || status[0].Failures != 3 || status[0].LastError != "webhook returned 503" {
+		t.Errorf("unexpected status %+v", status)
+	}
+	if !guard.Enabled("catalog") {
+		t.Error("expected other features to stay on")
+	}
+
+	now = now.Add(5 * time.Minute)
+	if !guard.Enabled("audit") || len(changes) != 2 || changes[1] {
+		t.Fatalf("expected audit to be turned back on after the cooldown, got changes %v", changes)
+	}
+	if status := guard.Status(); status[0].Disabled || status[0].Failures != 0 {
+		t.Errorf("expected a fresh budget, got %+v", status)
+	}
+}
@@ -0,0 +1,142 @@
+// Package egress builds the HTTP clients the plugin uses to call OCI itself, routed through a
+// corporate forward proxy and with the configured TLS settings.
+package egress
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/zalbiraw/ociaitoopenai/internal/config"
+)
+
+// NewClient creates an HTTP client with the given timeout for the egress configuration. Without
+// any egress settings, it uses the default transport, which honours the proxy environment variables.
+func NewClient(cfg config.Egress, timeout time.Duration) (*http.Client, error) {
+	client := &http.Client{Timeout: timeout}
+	if !configured(cfg) {
+		return client, nil
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	configureConnections(transport, cfg)
+	if cfg.Proxy != "" {
+		proxy, err := url.Parse(cfg.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid egress proxy: %w", err)
+		}
+		transport.Proxy = func(req *http.Request) (*url.URL, error) {
+			if bypassProxy(req.URL.Hostname(), cfg.NoProxy) {
+				return nil, nil
+			}
+			return proxy, nil
+		}
+	}
+	tlsConfig, err := newTLSConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	transport.TLSClientConfig = tlsConfig
+	client.Transport = transport
+	return client, nil
+}
+
+// configured reports whether any egress setting is set. NoProxy only applies with a proxy.
+func configured(cfg config.Egress) bool {
+	return cfg.Proxy != "" || cfg.CABundle != "" || cfg.MinTLSVersion != "" || cfg.ClientCertFile != "" ||
+		cfg.MaxIdleConnsPerHost != 0 || cfg.IdleConnTimeout != "" || cfg.KeepAlive != "" || cfg.DNSCacheTTL != ""
+}
+
+// configureConnections applies the connection pool, keep-alive and DNS cache settings to a transport.
+func configureConnections(transport *http.Transport, cfg config.Egress) {
+	if cfg.MaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = cfg.MaxIdleConnsPerHost
+		if transport.MaxIdleConns < cfg.MaxIdleConnsPerHost {
+			transport.MaxIdleConns = cfg.MaxIdleConnsPerHost
+		}
+	}
+	if idle, err := time.ParseDuration(cfg.IdleConnTimeout); err == nil {
+		transport.IdleConnTimeout = idle
+	}
+
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	if keepAlive, err := time.ParseDuration(cfg.KeepAlive); err == nil {
+		dialer.KeepAlive = keepAlive
+	}
+	transport.DialContext = dialer.DialContext
+	if ttl, err := time.ParseDuration(cfg.DNSCacheTTL); err == nil {
+		transport.DialContext = newCachingResolver(ttl).dialContext(dialer)
+	}
+}
+
+// newTLSConfig creates the TLS configuration of the egress settings.
+func newTLSConfig(cfg config.Egress) (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if cfg.MinTLSVersion == "1.3" {
+		tlsConfig.MinVersion = tls.VersionTLS13
+	}
+	if cfg.CABundle != "" {
+		pool, err := certPool(cfg.CABundle)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if cfg.ClientCertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.ClientCertFile, cfg.ClientKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load egress client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+// certPool returns the system roots with the certificates of a PEM bundle added.
+func certPool(bundle string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(bundle)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read egress CA bundle: %w", err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in %s", bundle)
+	}
+	return pool, nil
+}
+
+// bypassProxy reports whether a host is reached directly according to the noProxy entries.
+func bypassProxy(host string, noProxy []string) bool {
+	host = strings.ToLower(host)
+	ip := net.ParseIP(host)
+	for _, entry := range noProxy {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		switch {
+		case entry == "*":
+			return true
+		case strings.Contains(entry, "/"):
+			if _, network, err := net.ParseCIDR(entry); err == nil && ip != nil && network.Contains(ip) {
+				return true
+			}
+		case net.ParseIP(entry) != nil:
+			if ip != nil && ip.Equal(net.ParseIP(entry)) {
+				return true
+			}
+		default:
+			domain := strings.TrimPrefix(entry, ".")
+			if host == domain || strings.HasSuffix(host, "."+domain) {
+				return true
+			}
+		}
+	}
+	return false
+}
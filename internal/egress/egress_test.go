@@ -0,0 +1,213 @@
+package egress
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/zalbiraw/ociaitoopenai/internal/config"
+)
+
+func TestNewClient_Default(t *testing.T) {
+	client, err := NewClient(config.Egress{}, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.Transport != nil || client.Timeout != time.Second {
+		t.Errorf("expected the default transport with a 1s timeout, got %+v", client)
+	}
+}
+
+func TestNewClient_Proxy(t *testing.T) {
+	client, err := NewClient(config.Egress{
+		Proxy:   "http://proxy.corp.example:3128",
+		NoProxy: []string{"internal.example", "10.0.0.0/8"},
+	}, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transport := client.Transport.(*http.Transport)
+
+	tests := map[string]string{
+		"https://secrets.vaults.us-chicago-1.oci.oraclecloud.com/": "http://proxy.corp.example:3128",
+		"https://vault.internal.example/":                          "",
+		"https://10.1.2.3/":                                        "",
+	}
+	for target, want := range tests {
+		req, _ := http.NewRequest(http.MethodGet, target, nil)
+		proxy, err := transport.Proxy(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := ""
+		if proxy != nil {
+			got = proxy.String()
+		}
+		if got != want {
+			t.Errorf("%s: expected proxy %q, got %q", target, want, got)
+		}
+	}
+}
+
+func TestNewClient_Connections(t *testing.T) {
+	client, err := NewClient(config.Egress{MaxIdleConnsPerHost: 16, IdleConnTimeout: "5m", KeepAlive: "15s", DNSCacheTTL: "1m"}, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transport := client.Transport.(*http.Transport)
+	if transport.MaxIdleConnsPerHost != 16 || transport.MaxIdleConns < 16 || transport.IdleConnTimeout != 5*time.Minute {
+		t.Errorf("unexpected pool settings: %d per host, %d total, %s idle timeout",
+			transport.MaxIdleConnsPerHost, transport.MaxIdleConns, transport.IdleConnTimeout)
+	}
+	if transport.DialContext == nil || transport.Proxy == nil {
+		t.Error("expected a dialer and the environment proxy")
+	}
+}
+
+func TestBypassProxy(t *testing.T) {
+	tests := []struct {
+		host    string
+		noProxy []string
+		want    bool
+	}{
+		{"auth.us-chicago-1.oraclecloud.com", nil, false},
+		{"auth.us-chicago-1.oraclecloud.com", []string{"*"}, true},
+		{"auth.us-chicago-1.oraclecloud.com", []string{"oraclecloud.com"}, true},
+		{"auth.us-chicago-1.oraclecloud.com", []string{".oraclecloud.com"}, true},
+		{"oraclecloud.com", []string{".oraclecloud.com"}, true},
+		{"notoraclecloud.com", []string{"oraclecloud.com"}, false},
+		{"AUTH.EXAMPLE", []string{" auth.example "}, true},
+		{"192.168.1.10", []string{"192.168.1.10"}, true},
+		{"192.168.1.11", []string{"192.168.1.10"}, false},
+		{"192.168.1.11", []string{"192.168.0.0/16"}, true},
+		{"example.com", []string{"192.168.0.0/16"}, false},
+	}
+	for _, tt := range tests {
+		if got := bypassProxy(tt.host, tt.noProxy); got != tt.want {
+			t.Errorf("bypassProxy(%q, %v) = %v, want %v", tt.host, tt.noProxy, got, tt.want)
+		}
+	}
+}
+
+func TestNewClient_CABundle(t *testing.T) {
+	bundle := filepath.Join(t.TempDir(), "ca.pem")
+	cert, _ := testCertificate(t)
+	if err := os.WriteFile(bundle, cert, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	client, err := NewClient(config.Egress{CABundle: bundle}, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transport := client.Transport.(*http.Transport)
+	if transport.TLSClientConfig == nil || transport.TLSClientConfig.RootCAs == nil {
+		t.Error("expected the CA bundle in the root CAs")
+	}
+
+	if err := os.WriteFile(bundle, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewClient(config.Egress{CABundle: bundle}, time.Second); err == nil {
+		t.Error("expected an error for a bundle without certificates")
+	}
+	if _, err := NewClient(config.Egress{CABundle: filepath.Join(t.TempDir(), "missing.pem")}, time.Second); err == nil {
+		t.Error("expected an error for a missing bundle")
+	}
+}
+
+func TestNewClient_MutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	certPEM, keyPEM := testCertificate(t)
+	certFile, keyFile := filepath.Join(dir, "client.pem"), filepath.Join(dir, "client-key.pem")
+	if err := os.WriteFile(certFile, certPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, keyPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AppendCertsFromPEM(certPEM)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if len(req.TLS.PeerCertificates) == 0 {
+			t.Error("expected a client certificate")
+		}
+		if req.TLS.Version != tls.VersionTLS13 {
+			t.Errorf("expected TLS 1.3, got %x", req.TLS.Version)
+		}
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	server.StartTLS()
+	defer server.Close()
+
+	bundle := filepath.Join(dir, "ca.pem")
+	serverPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(bundle, serverPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := NewClient(config.Egress{
+		CABundle:       bundle,
+		MinTLSVersion:  "1.3",
+		ClientCertFile: certFile,
+		ClientKeyFile:  keyFile,
+	}, 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	// Without the client certificate, the server refuses the handshake
+	client, err = NewClient(config.Egress{CABundle: bundle}, 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp, err := client.Get(server.URL); err == nil {
+		resp.Body.Close()
+		t.Error("expected the handshake to fail without a client certificate")
+	}
+}
+
+// testCertificate returns a self-signed CA certificate and its private key in PEM format.
+func testCertificate(t *testing.T) (cert, key []byte) {
+	t.Helper()
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test Proxy CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &priv.PublicKey, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
@@ -0,0 +1,85 @@
+package egress
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+)
+
+// cachingResolver remembers the addresses of resolved hosts for a TTL, so new connections to OCI
+// skip the DNS lookup. It is safe for concurrent use.
+type cachingResolver struct {
+	ttl    time.Duration
+	lookup func(ctx context.Context, host string) ([]string, error)
+	now    func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]resolved
+}
+
+// resolved is a cached lookup result.
+type resolved struct {
+	addrs   []string
+	expires time.Time
+}
+
+// newCachingResolver creates a resolver caching the lookups of the default resolver for ttl.
+func newCachingResolver(ttl time.Duration) *cachingResolver {
+	return &cachingResolver{
+		ttl:     ttl,
+		lookup:  net.DefaultResolver.LookupHost,
+		now:     time.Now,
+		entries: make(map[string]resolved),
+	}
+}
+
+// resolve returns the addresses of a host, from the cache while they are fresh.
+func (r *cachingResolver) resolve(ctx context.Context, host string) ([]string, error) {
+	r.mu.Lock()
+	entry, ok := r.entries[host]
+	r.mu.Unlock()
+	if ok && r.now().Before(entry.expires) {
+		return entry.addrs, nil
+	}
+
+	addrs, err := r.lookup(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	r.entries[host] = resolved{addrs: addrs, expires: r.now().Add(r.ttl)}
+	r.mu.Unlock()
+	return addrs, nil
+}
+
+// dialContext returns a dial function connecting to the cached addresses of a host in turn, and
+// forgetting them when none accepts the connection.
+func (r *cachingResolver) dialContext(dialer *net.Dialer) func(ctx context.Context, network, address string) (net.Conn, error) {
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(address)
+		if err != nil || net.ParseIP(host) != nil {
+			return dialer.DialContext(ctx, network, address)
+		}
+		addrs, err := r.resolve(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+
+		var lastErr error
+		for _, addr := range addrs {
+			conn, err := dialer.DialContext(ctx, network, net.JoinHostPort(addr, port))
+			if err == nil {
+				return conn, nil
+			}
+			lastErr = err
+		}
+		r.mu.Lock()
+		delete(r.entries, host)
+		r.mu.Unlock()
+		if lastErr == nil {
+			lastErr = &net.DNSError{Err: "no addresses", Name: host, IsNotFound: true}
+		}
+		return nil, lastErr
+	}
+}
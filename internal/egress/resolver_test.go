@@ -0,0 +1,71 @@
+package egress
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCachingResolver(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {}))
+	defer server.Close()
+	_, port, _ := net.SplitHostPort(strings.TrimPrefix(server.URL, "http://"))
+
+	lookups := 0
+	addrs := []string{"127.0.0.1"}
+	now := time.Unix(1700000000, 0)
+	resolver := newCachingResolver(time.Minute)
+	resolver.now = func() time.Time { return now }
+	resolver.lookup = func(ctx context.Context, host string) ([]string, error) {
+		lookups++
+		if host != "oci.test" {
+			return nil, errors.New("unexpected host " + host)
+		}
+		return addrs, nil
+	}
+	dial := resolver.dialContext(&net.Dialer{Timeout: time.Second})
+
+	connect := func() error {
+		conn, err := dial(context.Background(), "tcp", net.JoinHostPort("oci.test", port))
+		if err == nil {
+			conn.Close()
+		}
+		return err
+	}
+
+	// Fresh addresses are reused
+	for i := 0; i < 3; i++ {
+		if err := connect(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if lookups != 1 {
+		t.Errorf("expected 1 lookup, got %d", lookups)
+	}
+
+	// Expired addresses are resolved again
+	now = now.Add(2 * time.Minute)
+	if err := connect(); err != nil {
+		t.Fatal(err)
+	}
+	if lookups != 2 {
+		t.Errorf("expected 2 lookups after expiry, got %d", lookups)
+	}
+
+	// Addresses that refuse connections are forgotten
+	server.Close()
+	if err := connect(); err == nil {
+		t.Fatal("expected the dial to fail")
+	}
+	if err := connect(); err == nil {
+		t.Fatal("expected the dial to fail")
+	}
+	if lookups != 3 {
+		t.Errorf("expected a new lookup after a failed dial, got %d lookups", lookups)
+	}
+}
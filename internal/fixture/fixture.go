@@ -0,0 +1,141 @@
+// Package fixture records OCI exchanges to disk and looks them up again, so responses can be
+// replayed deterministically without OCI credentials.
+package fixture
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/zalbiraw/ociaitoopenai/internal/config"
+)
+
+// Redacted replaces sensitive values, such as OCIDs, in recorded fixtures.
+const Redacted = "ocid1.redacted"
+
+// Fixture is a recorded OCI exchange.
+type Fixture struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Request is the sanitized OCI request a fixture answers.
+type Request struct {
+	Method string          `json:"method"`
+	Path   string          `json:"path"`
+	Body   json.RawMessage `json:"body,omitempty"`
+}
+
+// Response is the sanitized, uncompressed OCI response.
+type Response struct {
+	Status      int    `json:"status"`
+	ContentType string `json:"contentType,omitempty"`
+	Body        string `json:"body"`
+}
+
+// Store keeps fixtures as one JSON file per request in a directory. Requests are matched on
+// their method, path and body after sanitization, so fixtures recorded with one compartment
+// replay with another.
+type Store struct {
+	dir        string
+	redactions []string
+}
+
+// New creates a store in the configured directory, replacing the given values with Redacted in
+// everything it records and matches. Empty values are ignored. It returns nil when fixtures
+// are disabled.
+func New(cfg config.Fixtures, redactions ...string) *Store {
+	if cfg.Mode == "" {
+		return nil
+	}
+
+	store := &Store{dir: cfg.Directory}
+	for _, value := range redactions {
+		if value != "" {
+			store.redactions = append(store.redactions, value)
+		}
+	}
+	return store
+}
+
+// Load returns the fixture recorded for a request. The error satisfies os.IsNotExist when
+// there is none.
+func (s *Store) Load(method, path string, body []byte) (*Fixture, error) {
+	request := s.request(method, path, body)
+	raw, err := os.ReadFile(s.file(request))
+	if err != nil {
+		return nil, err
+	}
+
+	var fixture Fixture
+	if err := json.Unmarshal(raw, &fixture); err != nil {
+		return nil, fmt.Errorf("failed to parse fixture: %w", err)
+	}
+	return &fixture, nil
+}
+
+// Save records the response to a request, replacing any earlier recording.
+func (s *Store) Save(method, path string, body []byte, response Response) error {
+	request := s.request(method, path, body)
+	response.Body = string(s.sanitize([]byte(response.Body)))
+
+	raw, err := json.MarshalIndent(Fixture{Request: request, Response: response}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal fixture: %w", err)
+	}
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create fixture directory: %w", err)
+	}
+
+	// Write to a temporary file first, so a concurrent replay never reads a partial fixture
+	file := s.file(request)
+	tmp := file + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0o644); err != nil {
+		return fmt.Errorf("failed to write fixture: %w", err)
+	}
+	if err := os.Rename(tmp, file); err != nil {
+		return fmt.Errorf("failed to write fixture: %w", err)
+	}
+	return nil
+}
+
+// request builds the sanitized request a fixture is keyed on.
+func (s *Store) request(method, path string, body []byte) Request {
+	request := Request{Method: method, Path: string(s.sanitize([]byte(path)))}
+	if len(body) > 0 {
+		body = s.sanitize(body)
+		if json.Valid(body) {
+			var compact bytes.Buffer
+			if json.Compact(&compact, body) == nil {
+				body = compact.Bytes()
+			}
+			request.Body = body
+		} else {
+			// Keep non-JSON bodies in the key, as a JSON string
+			request.Body, _ = json.Marshal(string(body))
+		}
+	}
+	return request
+}
+
+// file returns the path of the fixture for a request.
+func (s *Store) file(request Request) string {
+	hash := sha256.New()
+	hash.Write([]byte(request.Method + " " + request.Path + "\n"))
+	hash.Write(request.Body)
+	return filepath.Join(s.dir, hex.EncodeToString(hash.Sum(nil))+".json")
+}
+
+// sanitize replaces every redacted value in b.
+func (s *Store) sanitize(b []byte) []byte {
+	text := string(b)
+	for _, value := range s.redactions {
+		text = strings.ReplaceAll(text, value, Redacted)
+	}
+	return []byte(text)
+}
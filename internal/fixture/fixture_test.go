@@ -0,0 +1,50 @@
+package fixture
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zalbiraw/ociaitoopenai/internal/config"
+)
+
+func TestStore_SaveAndLoad(t *testing.T) {
+	dir := t.TempDir()
+	store := New(config.Fixtures{Mode: config.FixtureModeRecord, Directory: dir}, "ocid1.compartment.secret")
+
+	body := []byte(`{"compartmentId": "ocid1.compartment.secret", "chatRequest": {"message": "Hi"}}`)
+	response := Response{Status: 200, ContentType: "application/json", Body: `{"compartmentId":"ocid1.compartment.secret"}`}
+	if err := store.Save("POST", "/20231130/actions/chat", body, response); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	if len(files) != 1 {
+		t.Fatalf("expected one fixture file, got %v", files)
+	}
+	raw, _ := os.ReadFile(files[0])
+	if strings.Contains(string(raw), "secret") {
+		t.Errorf("expected the compartment to be redacted, got %s", raw)
+	}
+
+	// A recording made with another compartment, and reformatted JSON, still match
+	replay := New(config.Fixtures{Mode: config.FixtureModeReplay, Directory: dir}, "ocid1.compartment.other")
+	fixture, err := replay.Load("POST", "/20231130/actions/chat", []byte(`{"compartmentId":"ocid1.compartment.other","chatRequest":{"message":"Hi"}}`))
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if fixture.Response.Status != 200 || fixture.Response.Body != `{"compartmentId":"ocid1.redacted"}` {
+		t.Errorf("unexpected fixture response: %+v", fixture.Response)
+	}
+
+	if _, err := replay.Load("POST", "/20231130/actions/chat", []byte(`{"chatRequest":{"message":"Bye"}}`)); !os.IsNotExist(err) {
+		t.Errorf("expected a missing fixture for another request, got: %v", err)
+	}
+}
+
+func TestNew_Disabled(t *testing.T) {
+	if New(config.Fixtures{}) != nil {
+		t.Error("expected no store when fixtures are disabled")
+	}
+}
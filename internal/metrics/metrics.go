@@ -0,0 +1,251 @@
+// Package metrics provides a lightweight metrics registry for the OCI to OpenAI transformation plugin.
+// Metrics are kept in memory and exposed in the Prometheus text exposition format, so they can be
+// scraped without pulling in third-party dependencies (which Traefik plugins cannot vendor easily).
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Labels is a set of metric label names and values.
+type Labels map[string]string
+
+// family holds all the series of a single metric name.
+type family struct {
+	help       string
+	kind       string
+	series     map[string]float64
+	buckets    []float64
+	histograms map[string]*histogram
+}
+
+// histogram holds the observations of a single labelled histogram series.
+type histogram struct {
+	labels Labels
+	counts []uint64 // Non-cumulative count per bucket
+	sum    float64
+	count  uint64
+}
+
+// Registry stores metric families and their labelled series.
+// It is safe for concurrent use.
+type Registry struct {
+	mu       sync.Mutex
+	families map[string]*family
+}
+
+// NewRegistry creates an empty metrics registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		families: make(map[string]*family),
+	}
+}
+
+// NewCounter registers a counter family with the given help text.
+// Registering the same name twice keeps the existing series.
+func (r *Registry) NewCounter(name, help string) {
+	r.register(name, help, "counter")
+}
+
+// NewHistogram registers a histogram family with the given help text and upper bucket bounds,
+// which must be sorted in increasing order. A +Inf bucket is always added.
+func (r *Registry) NewHistogram(name, help string, buckets []float64) {
+	f := r.register(name, help, "histogram")
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if f.histograms == nil {
+		f.buckets = buckets
+		f.histograms = make(map[string]*histogram)
+	}
+}
+
+// Observe records a value in the histogram with the given labels.
+// Observations of unregistered histograms are ignored.
+func (r *Registry) Observe(name string, labels Labels, value float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	f, ok := r.families[name]
+	if !ok || f.histograms == nil {
+		return
+	}
+
+	key := labels.String()
+	h, ok := f.histograms[key]
+	if !ok {
+		h = &histogram{labels: labels, counts: make([]uint64, len(f.buckets))}
+		f.histograms[key] = h
+	}
+
+	for i, bound := range f.buckets {
+		if value <= bound {
+			h.counts[i]++
+			break
+		}
+	}
+	h.sum += value
+	h.count++
+}
+
+// Count returns the number of observations of the histogram with the given labels.
+func (r *Registry) Count(name string, labels Labels) uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	f, ok := r.families[name]
+	if !ok || f.histograms == nil {
+		return 0
+	}
+	if h, ok := f.histograms[labels.String()]; ok {
+		return h.count
+	}
+	return 0
+}
+
+func (r *Registry) register(name, help, kind string) *family {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	f, ok := r.families[name]
+	if !ok {
+		f = &family{help: help, kind: kind, series: make(map[string]float64)}
+		r.families[name] = f
+	}
+	return f
+}
+
+// Inc increments the counter with the given labels by one.
+func (r *Registry) Inc(name string, labels Labels) {
+	r.Add(name, labels, 1)
+}
+
+// Add adds the given value to the counter with the given labels.
+// Unregistered counters are created on first use without help text.
+func (r *Registry) Add(name string, labels Labels, value float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	f, ok := r.families[name]
+	if !ok {
+		f = &family{kind: "counter", series: make(map[string]float64)}
+		r.families[name] = f
+	}
+	f.series[labels.String()] += value
+}
+
+// Value returns the current value of the series with the given labels.
+func (r *Registry) Value(name string, labels Labels) float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	f, ok := r.families[name]
+	if !ok {
+		return 0
+	}
+	return f.series[labels.String()]
+}
+
+// WriteText writes all metrics in the Prometheus text exposition format.
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	names := make([]string, 0, len(r.families))
+	for name := range r.families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		f := r.families[name]
+		if f.help != "" {
+			fmt.Fprintf(&b, "# HELP %s %s\n", name, f.help)
+		}
+		fmt.Fprintf(&b, "# TYPE %s %s\n", name, f.kind)
+
+		if f.histograms != nil {
+			writeHistograms(&b, name, f)
+			continue
+		}
+
+		keys := make([]string, 0, len(f.series))
+		for key := range f.series {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			fmt.Fprintf(&b, "%s%s %v\n", name, key, f.series[key])
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeHistograms writes the cumulative buckets, sum and count of every series in a histogram family.
+func writeHistograms(b *strings.Builder, name string, f *family) {
+	keys := make([]string, 0, len(f.histograms))
+	for key := range f.histograms {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		h := f.histograms[key]
+		var cumulative uint64
+		for i, bound := range f.buckets {
+			cumulative += h.counts[i]
+			fmt.Fprintf(b, "%s_bucket%s %d\n", name, h.labels.with("le", fmt.Sprintf("%v", bound)), cumulative)
+		}
+		fmt.Fprintf(b, "%s_bucket%s %d\n", name, h.labels.with("le", "+Inf"), h.count)
+		fmt.Fprintf(b, "%s_sum%s %v\n", name, key, h.sum)
+		fmt.Fprintf(b, "%s_count%s %d\n", name, key, h.count)
+	}
+}
+
+// ServeHTTP exposes the registry in the Prometheus text exposition format.
+func (r *Registry) ServeHTTP(rw http.ResponseWriter, _ *http.Request) {
+	rw.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	rw.WriteHeader(http.StatusOK)
+	_ = r.WriteText(rw)
+}
+
+// with returns a copy of the labels with one more label set.
+func (l Labels) with(name, value string) Labels {
+	labels := make(Labels, len(l)+1)
+	for k, v := range l {
+		labels[k] = v
+	}
+	labels[name] = value
+	return labels
+}
+
+// labelEscaper escapes label values as the Prometheus text format requires: only backslash,
+// double quote and line feed are escaped, unlike Go string quoting.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// String renders the labels in the canonical {name="value",...} form, sorted by name.
+func (l Labels) String() string {
+	if len(l) == 0 {
+		return ""
+	}
+
+	names := make([]string, 0, len(l))
+	for name := range l {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := make([]string, 0, len(names))
+	for _, name := range names {
+		pairs = append(pairs, name+`="`+labelEscaper.Replace(l[name])+`"`)
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
@@ -0,0 +1,89 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRegistry_IncAndValue(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounter("test_total", "A test counter.")
+
+	r.Inc("test_total", Labels{"model": "a", "status": "400"})
+	r.Inc("test_total", Labels{"status": "400", "model": "a"})
+	r.Inc("test_total", Labels{"model": "b", "status": "500"})
+
+	if got := r.Value("test_total", Labels{"model": "a", "status": "400"}); got != 2 {
+		t.Errorf("expected 2, got %v", got)
+	}
+	if got := r.Value("test_total", Labels{"model": "b", "status": "500"}); got != 1 {
+		t.Errorf("expected 1, got %v", got)
+	}
+	if got := r.Value("missing_total", nil); got != 0 {
+		t.Errorf("expected 0 for unknown metric, got %v", got)
+	}
+}
+
+func TestRegistry_ServeHTTP(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounter("test_total", "A test counter.")
+	r.Inc("test_total", Labels{"model": "cohere.command-r", "status": "502"})
+
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/_ociai/metrics", nil))
+
+	body := recorder.Body.String()
+	expected := []string{
+		"# HELP test_total A test counter.",
+		"# TYPE test_total counter",
+		`test_total{model="cohere.command-r",status="502"} 1`,
+	}
+	for _, line := range expected {
+		if !strings.Contains(body, line) {
+			t.Errorf("expected output to contain %q, got:\n%s", line, body)
+		}
+	}
+}
+
+func TestLabels_StringEscapesValues(t *testing.T) {
+	labels := Labels{"model": "a\"b\\c\nd\té"}
+	if got := labels.String(); got != `{model="a\"b\\c\nd`+"\t"+`é"}` {
+		t.Errorf("unexpected label rendering: %s", got)
+	}
+}
+
+func TestRegistry_Histogram(t *testing.T) {
+	r := NewRegistry()
+	r.NewHistogram("latency_seconds", "A test histogram.", []float64{0.5, 1})
+
+	labels := Labels{"model": "a"}
+	r.Observe("latency_seconds", labels, 0.2)
+	r.Observe("latency_seconds", labels, 0.7)
+	r.Observe("latency_seconds", labels, 3)
+	r.Observe("missing_seconds", labels, 1)
+
+	if got := r.Count("latency_seconds", labels); got != 3 {
+		t.Errorf("expected 3 observations, got %d", got)
+	}
+
+	var b strings.Builder
+	if err := r.WriteText(&b); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"# TYPE latency_seconds histogram",
+		`latency_seconds_bucket{le="0.5",model="a"} 1`,
+		`latency_seconds_bucket{le="1",model="a"} 2`,
+		`latency_seconds_bucket{le="+Inf",model="a"} 3`,
+		`latency_seconds_sum{model="a"} 3.9`,
+		`latency_seconds_count{model="a"} 3`,
+	}
+	for _, line := range expected {
+		if !strings.Contains(b.String(), line) {
+			t.Errorf("expected output to contain %q, got:\n%s", line, b.String())
+		}
+	}
+}
@@ -0,0 +1,135 @@
+// Package mirror asynchronously records request/response pairs as JSON lines for offline evaluation.
+// Records are sampled, redacted and size-capped before being queued, and are written by a background
+// worker so mirroring never delays client responses. When the queue is full, records are dropped.
+package mirror
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/zalbiraw/ociaitoopenai/internal/config"
+	"github.com/zalbiraw/ociaitoopenai/internal/sink"
+)
+
+// queueSize is the number of records buffered for the background writer.
+const queueSize = 256
+
+// Record is a single mirrored request/response pair.
+type Record struct {
+	Time     time.Time       `json:"time"`
+	Model    string          `json:"model"`
+	Status   int             `json:"status"`
+	Request  json.RawMessage `json:"request"`
+	Response json.RawMessage `json:"response,omitempty"`
+}
+
+// writer queues encoded records for writing.
+type writer interface {
+	Write(line []byte)
+	Depth() int
+}
+
+// Mirror samples and queues records for the configured sink.
+type Mirror struct {
+	cfg     config.Mirror
+	writer  writer
+	onError func(error)
+	random  func() float64
+}
+
+// New creates a mirror for the configuration and starts its background writer.
+// It returns nil when mirroring is not configured. The writer stops when ctx is cancelled.
+func New(ctx context.Context, cfg config.Mirror, onError func(error)) (*Mirror, error) {
+	var s sink.Sink
+	switch {
+	case cfg.Directory != "":
+		if err := os.MkdirAll(cfg.Directory, 0o750); err != nil {
+			return nil, fmt.Errorf("failed to create mirror directory: %w", err)
+		}
+		path := filepath.Join(cfg.Directory, "mirror.jsonl")
+		if cfg.Rotation.Enabled() {
+			s = sink.NewRotatingFile(path, cfg.Rotation)
+		} else {
+			s = sink.NewFile(path, cfg.MaxFileBytes)
+		}
+	case cfg.URL != "":
+		s = sink.NewHTTP(cfg.URL)
+	default:
+		return nil, nil
+	}
+
+	return &Mirror{
+		cfg:     cfg,
+		writer:  sink.NewAsync(ctx, s, queueSize, onError),
+		onError: onError,
+		random:  rand.Float64,
+	}, nil
+}
+
+// QueueDepth returns the number of records waiting to be written.
+func (m *Mirror) QueueDepth() int {
+	return m.writer.Depth()
+}
+
+// Record queues a request/response pair, subject to sampling, redaction and the record size cap.
+// Bodies that are not valid JSON are recorded as JSON strings.
+func (m *Mirror) Record(model string, status int, request, response []byte) {
+	if m.random() >= m.cfg.SampleRate {
+		return
+	}
+
+	line, err := json.Marshal(Record{
+		Time:     time.Now().UTC(),
+		Model:    model,
+		Status:   status,
+		Request:  m.redact(request),
+		Response: m.redact(response),
+	})
+	if err != nil {
+		m.reportError(fmt.Errorf("failed to marshal mirror record: %w", err))
+		return
+	}
+	if m.cfg.MaxRecordBytes > 0 && len(line) > m.cfg.MaxRecordBytes {
+		return
+	}
+
+	m.writer.Write(line)
+}
+
+// redact removes the configured top-level fields from a JSON object body.
+func (m *Mirror) redact(body []byte) json.RawMessage {
+	if len(body) == 0 {
+		return nil
+	}
+	if !json.Valid(body) {
+		quoted, _ := json.Marshal(string(body))
+		return quoted
+	}
+	if len(m.cfg.RedactFields) == 0 {
+		return body
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return body
+	}
+	for _, field := range m.cfg.RedactFields {
+		delete(fields, field)
+	}
+	redacted, err := json.Marshal(fields)
+	if err != nil {
+		return body
+	}
+	return redacted
+}
+
+func (m *Mirror) reportError(err error) {
+	if m.onError != nil {
+		m.onError(err)
+	}
+}
@@ -0,0 +1,124 @@
+package mirror
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zalbiraw/ociaitoopenai/internal/config"
+)
+
+// waitForLines polls the mirror file until it holds the expected number of lines.
+func waitForLines(t *testing.T, path string, expected int) []string {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		var lines []string
+		if file, err := os.Open(path); err == nil {
+			scanner := bufio.NewScanner(file)
+			for scanner.Scan() {
+				lines = append(lines, scanner.Text())
+			}
+			file.Close()
+		}
+		if len(lines) >= expected || time.Now().After(deadline) {
+			return lines
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestNew_Disabled(t *testing.T) {
+	m, err := New(context.Background(), config.New().Mirror, nil)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if m != nil {
+		t.Error("expected no mirror when neither directory nor url is set")
+	}
+}
+
+func TestMirror_WritesRedactedRecords(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cfg := config.New().Mirror
+	cfg.Directory = t.TempDir()
+	cfg.RedactFields = []string{"user"}
+
+	m, err := New(ctx, cfg, func(err error) { t.Errorf("unexpected mirror error: %v", err) })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m.Record("cohere.command-r", http.StatusOK,
+		[]byte(`{"model":"cohere.command-r","user":"alice@example.com","messages":[]}`),
+		[]byte(`{"object":"chat.completion"}`))
+	m.Record("cohere.command-r", http.StatusBadGateway, []byte(`{"model":"cohere.command-r"}`), []byte("upstream error"))
+
+	lines := waitForLines(t, filepath.Join(cfg.Directory, "mirror.jsonl"), 2)
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 mirrored records, got %d", len(lines))
+	}
+
+	var record Record
+	if err := json.Unmarshal([]byte(lines[0]), &record); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(record.Request), "alice@example.com") {
+		t.Errorf("expected user field to be redacted, got: %s", record.Request)
+	}
+	if record.Status != http.StatusOK || record.Model != "cohere.command-r" {
+		t.Errorf("unexpected record metadata: %+v", record)
+	}
+
+	if err := json.Unmarshal([]byte(lines[1]), &record); err != nil {
+		t.Fatal(err)
+	}
+	if string(record.Response) != `"upstream error"` {
+		t.Errorf("expected non-JSON response to be recorded as a string, got: %s", record.Response)
+	}
+}
+
+// queueWriter collects queued records in memory.
+type queueWriter struct {
+	lines [][]byte
+}
+
+func (w *queueWriter) Write(line []byte) {
+	w.lines = append(w.lines, line)
+}
+
+func (w *queueWriter) Depth() int {
+	return 0
+}
+
+func TestMirror_SamplingAndRecordCap(t *testing.T) {
+	cfg := config.New().Mirror
+	cfg.SampleRate = 0.5
+	cfg.MaxRecordBytes = 200
+
+	w := &queueWriter{}
+	m := &Mirror{cfg: cfg, writer: w, random: func() float64 { return 0.7 }}
+	m.Record("model", http.StatusOK, []byte(`{}`), []byte(`{}`))
+	if len(w.lines) != 0 {
+		t.Error("expected record outside the sample to be skipped")
+	}
+
+	m.random = func() float64 { return 0.1 }
+	m.Record("model", http.StatusOK, []byte(`{}`), []byte(`{}`))
+	if len(w.lines) != 1 {
+		t.Error("expected sampled record to be queued")
+	}
+
+	m.Record("model", http.StatusOK, []byte(`{"content":"`+strings.Repeat("x", 300)+`"}`), nil)
+	if len(w.lines) != 1 {
+		t.Error("expected record over the size cap to be dropped")
+	}
+}
@@ -0,0 +1,115 @@
+// Package prompt renders named prompt templates into chat completion requests.
+// Templates use Go text/template syntax, e.g. "Summarize {{.document}} in {{.words}} words",
+// with variables supplied by the client in the request body.
+package prompt
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"text/template"
+
+	"github.com/zalbiraw/ociaitoopenai/internal/config"
+	"github.com/zalbiraw/ociaitoopenai/pkg/types"
+)
+
+// ErrNotFound is returned when no template has the requested name.
+var ErrNotFound = errors.New("prompt template not found")
+
+// RenderError reports a request that could not be rendered because of the client.
+type RenderError struct {
+	Param   string
+	Message string
+}
+
+func (e *RenderError) Error() string { return e.Message }
+
+// Renderer renders the configured templates. It is safe for concurrent use.
+type Renderer struct {
+	templates map[string]compiled
+}
+
+// compiled is a parsed template.
+type compiled struct {
+	model    string
+	roles    []string
+	messages []*template.Template
+}
+
+// New parses the configured templates. It returns nil when none are configured.
+func New(templates map[string]config.PromptTemplate) (*Renderer, error) {
+	if len(templates) == 0 {
+		return nil, nil
+	}
+
+	r := &Renderer{templates: make(map[string]compiled, len(templates))}
+	for name, tmpl := range templates {
+		c := compiled{model: tmpl.Model}
+		for i, msg := range tmpl.Messages {
+			parsed, err := template.New(fmt.Sprintf("%s[%d]", name, i)).Option("missingkey=error").Parse(msg.Content)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse prompt template %s: %w", name, err)
+			}
+			c.roles = append(c.roles, msg.Role)
+			c.messages = append(c.messages, parsed)
+		}
+		r.templates[name] = c
+	}
+	return r, nil
+}
+
+// Render builds a chat completion request body from the named template and a client request
+// body. The body may hold any chat completion fields plus "variables", an object of template
+// variables. Rendered messages precede any messages in the body, and the template model is
+// used unless the body names one.
+func (r *Renderer) Render(name string, body []byte) ([]byte, error) {
+	tmpl, ok := r.templates[name]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	fields := map[string]json.RawMessage{}
+	if len(bytes.TrimSpace(body)) > 0 {
+		if err := json.Unmarshal(body, &fields); err != nil {
+			return nil, &RenderError{Message: fmt.Sprintf("Failed to parse prompt request: %v", err)}
+		}
+	}
+
+	variables := map[string]interface{}{}
+	if raw, ok := fields["variables"]; ok {
+		if err := json.Unmarshal(raw, &variables); err != nil {
+			return nil, &RenderError{Param: "variables", Message: "variables must be an object"}
+		}
+		delete(fields, "variables")
+	}
+
+	var messages []types.ChatCompletionMessage
+	for i, msg := range tmpl.messages {
+		var content bytes.Buffer
+		if err := msg.Execute(&content, variables); err != nil {
+			return nil, &RenderError{Param: "variables", Message: fmt.Sprintf("Failed to render prompt template %s: %v", name, err)}
+		}
+		messages = append(messages, types.ChatCompletionMessage{Role: tmpl.roles[i], Content: content.String()})
+	}
+	if raw, ok := fields["messages"]; ok {
+		var extra []types.ChatCompletionMessage
+		if err := json.Unmarshal(raw, &extra); err != nil {
+			return nil, &RenderError{Param: "messages", Message: fmt.Sprintf("Failed to parse messages: %v", err)}
+		}
+		messages = append(messages, extra...)
+	}
+
+	encoded, err := json.Marshal(messages)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal rendered messages: %w", err)
+	}
+	fields["messages"] = encoded
+
+	if _, ok := fields["model"]; !ok && tmpl.model != "" {
+		model, _ := json.Marshal(tmpl.model)
+		fields["model"] = model
+	}
+
+	return json.Marshal(fields)
+}
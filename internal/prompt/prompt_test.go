@@ -0,0 +1,70 @@
+package prompt
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/zalbiraw/ociaitoopenai/internal/config"
+	"github.com/zalbiraw/ociaitoopenai/pkg/types"
+)
+
+func newRenderer(t *testing.T) *Renderer {
+	t.Helper()
+	r, err := New(map[string]config.PromptTemplate{
+		"summarize": {
+			Model: "cohere.command-r-plus",
+			Messages: []config.PromptTemplateMessage{
+				{Role: "system", Content: "You write {{.style}} summaries."},
+				{Role: "user", Content: "Summarize: {{.text}}"},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	return r
+}
+
+func TestRender(t *testing.T) {
+	r := newRenderer(t)
+
+	body, err := r.Render("summarize", []byte(`{"variables":{"style":"short","text":"Hello"},"max_tokens":50,"messages":[{"role":"user","content":"Thanks"}]}`))
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	var req types.ChatCompletionRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		t.Fatalf("failed to decode rendered request: %v", err)
+	}
+	if req.Model != "cohere.command-r-plus" || req.MaxTokens != 50 {
+		t.Errorf("expected template model and client max_tokens, got %s and %d", req.Model, req.MaxTokens)
+	}
+	if len(req.Messages) != 3 || req.Messages[0].Content != "You write short summaries." ||
+		req.Messages[1].Content != "Summarize: Hello" || req.Messages[2].Content != "Thanks" {
+		t.Errorf("unexpected rendered messages: %+v", req.Messages)
+	}
+}
+
+func TestRender_Errors(t *testing.T) {
+	r := newRenderer(t)
+
+	if _, err := r.Render("translate", nil); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+
+	var renderErr *RenderError
+	if _, err := r.Render("summarize", []byte(`{"variables":{"style":"short"}}`)); !errors.As(err, &renderErr) || renderErr.Param != "variables" {
+		t.Errorf("expected a variables error for a missing variable, got %v", err)
+	}
+}
+
+func TestNew_InvalidTemplate(t *testing.T) {
+	_, err := New(map[string]config.PromptTemplate{
+		"broken": {Messages: []config.PromptTemplateMessage{{Role: "user", Content: "{{.text"}}},
+	})
+	if err == nil {
+		t.Error("expected error for an unparseable template")
+	}
+}
@@ -0,0 +1,120 @@
+// Package schedule picks the time-based routing rule that applies to a chat request.
+package schedule
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zalbiraw/ociaitoopenai/internal/config"
+)
+
+// Schedule holds the parsed schedule rules. It is safe for concurrent use.
+type Schedule struct {
+	rules []rule
+}
+
+// rule is a parsed schedule rule.
+type rule struct {
+	name     string
+	models   map[string]bool // Empty for every model
+	days     map[time.Weekday]bool
+	start    int // Minutes after midnight, -1 without a daily window
+	end      int
+	location *time.Location
+	from     time.Time
+	until    time.Time
+	route    config.ModelRoute
+}
+
+// New parses validated schedule rules. It returns nil when there are none.
+func New(schedules []config.Schedule) *Schedule {
+	if len(schedules) == 0 {
+		return nil
+	}
+
+	s := &Schedule{}
+	for i, cfg := range schedules {
+		r := rule{name: cfg.Name, start: -1, location: time.UTC, route: cfg.Route}
+		if r.name == "" {
+			r.name = "#" + strconv.Itoa(i)
+		}
+		if len(cfg.Models) > 0 {
+			r.models = make(map[string]bool, len(cfg.Models))
+			for _, model := range cfg.Models {
+				r.models[model] = true
+			}
+		}
+		if len(cfg.Days) > 0 {
+			r.days = make(map[time.Weekday]bool, len(cfg.Days))
+			for _, day := range cfg.Days {
+				r.days[config.Weekdays[strings.ToLower(day)]] = true
+			}
+		}
+		if cfg.Start != "" {
+			r.start, r.end = minutes(cfg.Start), minutes(cfg.End)
+		}
+		if cfg.Timezone != "" {
+			if location, err := time.LoadLocation(cfg.Timezone); err == nil {
+				r.location = location
+			}
+		}
+		r.from, _ = time.Parse(time.RFC3339, cfg.From)
+		r.until, _ = time.Parse(time.RFC3339, cfg.Until)
+		s.rules = append(s.rules, r)
+	}
+	return s
+}
+
+// Route returns the route and name of the first rule applying to a model at a time.
+func (s *Schedule) Route(model string, now time.Time) (config.ModelRoute, string, bool) {
+	if s == nil {
+		return config.ModelRoute{}, "", false
+	}
+	for _, r := range s.rules {
+		if r.applies(model, now) {
+			return r.route, r.name, true
+		}
+	}
+	return config.ModelRoute{}, "", false
+}
+
+// applies reports whether the rule applies to a model at a time.
+func (r rule) applies(model string, now time.Time) bool {
+	if len(r.models) > 0 && !r.models[model] {
+		return false
+	}
+	if !r.from.IsZero() && now.Before(r.from) {
+		return false
+	}
+	if !r.until.IsZero() && !now.Before(r.until) {
+		return false
+	}
+	if r.start < 0 {
+		return true
+	}
+
+	local := now.In(r.location)
+	minute := local.Hour()*60 + local.Minute()
+	day := local.Weekday()
+	switch {
+	case r.start <= r.end:
+		if minute < r.start || minute >= r.end {
+			return false
+		}
+	case minute >= r.start:
+		// Evening part of a window spanning midnight
+	case minute < r.end:
+		// Morning part of a window spanning midnight, which started the day before
+		day = (day + 6) % 7
+	default:
+		return false
+	}
+	return len(r.days) == 0 || r.days[day]
+}
+
+// minutes returns the minutes after midnight of a validated "15:04" time.
+func minutes(clock string) int {
+	t, _ := time.Parse("15:04", clock)
+	return t.Hour()*60 + t.Minute()
+}
@@ -0,0 +1,74 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/zalbiraw/ociaitoopenai/internal/config"
+)
+
+func TestNew_Empty(t *testing.T) {
+	s := New(nil)
+	if s != nil {
+		t.Fatal("expected no schedule without rules")
+	}
+	if _, _, ok := s.Route("cohere.command-r-plus", time.Now()); ok {
+		t.Error("expected no route from a nil schedule")
+	}
+}
+
+func TestSchedule_Route(t *testing.T) {
+	s := New([]config.Schedule{
+		{
+			Name:   "maintenance",
+			From:   "2026-03-01T02:00:00Z",
+			Until:  "2026-03-01T04:00:00Z",
+			Route:  config.ModelRoute{Region: "us-ashburn-1"},
+			Models: []string{"meta.llama-3.3-70b-instruct"},
+		},
+		{
+			Name:     "off-hours",
+			Days:     []string{"mon", "tue", "wed", "thu", "fri"},
+			Start:    "20:00",
+			End:      "07:00",
+			Timezone: "America/New_York",
+			Route:    config.ModelRoute{Model: "meta.llama-3.1-8b-instruct"},
+		},
+		{
+			Start: "12:00",
+			End:   "13:00",
+			Route: config.ModelRoute{Host: "lunch.example.com"},
+		},
+	})
+
+	tests := []struct {
+		name  string
+		model string
+		now   string
+		rule  string
+	}{
+		{"inside the maintenance period", "meta.llama-3.3-70b-instruct", "2026-03-01T03:00:00Z", "maintenance"},
+		{"maintenance period of another model", "cohere.command-r-plus", "2026-03-01T03:00:00Z", ""},
+		{"after the maintenance period", "meta.llama-3.3-70b-instruct", "2026-03-01T04:00:00Z", ""},
+		// 2026-03-02 is a Monday; 21:00 in New York is 02:00 UTC the next day
+		{"monday evening", "cohere.command-r-plus", "2026-03-03T02:00:00Z", "off-hours"},
+		{"tuesday morning, from monday's window", "cohere.command-r-plus", "2026-03-03T11:30:00Z", "off-hours"},
+		{"tuesday working hours", "cohere.command-r-plus", "2026-03-03T15:00:00Z", ""},
+		{"saturday morning, from friday's window", "cohere.command-r-plus", "2026-03-07T11:30:00Z", "off-hours"},
+		{"saturday evening", "cohere.command-r-plus", "2026-03-08T02:00:00Z", ""},
+		{"daily window in UTC", "cohere.command-r-plus", "2026-03-04T12:30:00Z", "#2"},
+		{"end of the daily window", "cohere.command-r-plus", "2026-03-04T13:00:00Z", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now, err := time.Parse(time.RFC3339, tt.now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, rule, ok := s.Route(tt.model, now)
+			if ok != (tt.rule != "") || rule != tt.rule {
+				t.Errorf("expected rule %q, got %q (matched %v)", tt.rule, rule, ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package sink
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zalbiraw/ociaitoopenai/internal/config"
+)
+
+// rotatedTimeFormat suffixes rolled-over files, sorting them by the time they were rolled over.
+const rotatedTimeFormat = "20060102T150405.000000000"
+
+// RotatingFile appends lines to a file, rolling it over by size or age. Rolled-over files are
+// named "<path>.<timestamp>", with ".gz" when compressed, and pruned by count and age.
+type RotatingFile struct {
+	path      string
+	maxBytes  int64
+	maxAge    time.Duration
+	compress  bool
+	maxFiles  int
+	retention time.Duration
+	now       func() time.Time
+
+	mu      sync.Mutex
+	started time.Time // When writing to the current file started, zero before the first write
+}
+
+// NewRotatingFile creates a file sink rolling over with the validated rotation settings.
+func NewRotatingFile(path string, cfg config.Rotation) *RotatingFile {
+	maxAge, _ := time.ParseDuration(cfg.MaxAge)
+	retention, _ := time.ParseDuration(cfg.Retention)
+	return &RotatingFile{
+		path:      path,
+		maxBytes:  cfg.MaxBytes,
+		maxAge:    maxAge,
+		compress:  cfg.Compress,
+		maxFiles:  cfg.MaxFiles,
+		retention: retention,
+		now:       time.Now,
+	}
+}
+
+// Write implements Sink. Like File, it opens the file for each line, so several plugin instances
+// can share it across configuration reloads. The age of a file left by an earlier run counts
+// from the first line written to it.
+func (s *RotatingFile) Write(line []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if s.started.IsZero() {
+		s.started = now
+	}
+	var tidyErr error
+	if info, err := os.Stat(s.path); err == nil && info.Size() > 0 {
+		tooLarge := s.maxBytes > 0 && info.Size()+int64(len(line))+1 > s.maxBytes
+		tooOld := s.maxAge > 0 && now.Sub(s.started) >= s.maxAge
+		if tooLarge || tooOld {
+			rotated := s.path + "." + now.UTC().Format(rotatedTimeFormat)
+			if err := os.Rename(s.path, rotated); err != nil {
+				return fmt.Errorf("failed to roll over %s: %w", s.path, err)
+			}
+			s.started = now
+			// The line is still written when compressing or pruning fails
+			tidyErr = s.tidy(rotated)
+		}
+	}
+
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o640)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", s.path, err)
+	}
+	defer file.Close()
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write to %s: %w", s.path, err)
+	}
+	return tidyErr
+}
+
+// tidy compresses a rolled-over file and prunes old ones, as configured.
+func (s *RotatingFile) tidy(rotated string) error {
+	if s.compress {
+		if err := compressFile(rotated); err != nil {
+			return err
+		}
+	}
+	return s.prune()
+}
+
+// prune deletes the rolled-over files beyond maxFiles, oldest first, and those older than retention.
+func (s *RotatingFile) prune() error {
+	if s.maxFiles == 0 && s.retention == 0 {
+		return nil
+	}
+	matches, err := filepath.Glob(s.path + ".*")
+	if err != nil {
+		return fmt.Errorf("failed to list rolled-over files of %s: %w", s.path, err)
+	}
+	base := filepath.Base(s.path)
+	rotated := matches[:0]
+	for _, path := range matches {
+		if rotatedName(base, filepath.Base(path)) {
+			rotated = append(rotated, path)
+		}
+	}
+	sort.Strings(rotated)
+
+	for i, path := range rotated {
+		expired := s.maxFiles > 0 && i < len(rotated)-s.maxFiles
+		if !expired && s.retention > 0 {
+			if info, err := os.Stat(path); err == nil && s.now().Sub(info.ModTime()) > s.retention {
+				expired = true
+			}
+		}
+		if !expired {
+			continue
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to delete %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// compressFile gzips a file into "<path>.gz" and deletes the original.
+func compressFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer src.Close()
+
+	compressed := path + ".gz"
+	dst, err := os.OpenFile(compressed, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o640)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", compressed, err)
+	}
+	gz := gzip.NewWriter(dst)
+	_, err = io.Copy(gz, src)
+	if closeErr := gz.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(compressed)
+		return fmt.Errorf("failed to compress %s: %w", path, err)
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to delete %s: %w", path, err)
+	}
+	return nil
+}
+
+// rotatedName reports whether a file name is that of a file rolled over from base.
+func rotatedName(base, name string) bool {
+	suffix := strings.TrimSuffix(strings.TrimPrefix(name, base+"."), ".gz")
+	_, err := time.Parse(rotatedTimeFormat, suffix)
+	return strings.HasPrefix(name, base+".") && err == nil
+}
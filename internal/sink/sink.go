@@ -0,0 +1,148 @@
+// Package sink provides destinations for the JSON-lines records written by the audit log and request mirror.
+package sink
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// Sink writes a single encoded JSON line to a destination.
+type Sink interface {
+	Write(line []byte) error
+}
+
+// File appends lines to a file, refusing writes once the file reaches maxBytes.
+type File struct {
+	mu       sync.Mutex
+	path     string
+	maxBytes int64
+}
+
+// NewFile creates a file sink. A maxBytes of zero means no cap.
+func NewFile(path string, maxBytes int64) *File {
+	return &File{path: path, maxBytes: maxBytes}
+}
+
+// Write implements Sink.
+func (s *File) Write(line []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o640)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", s.path, err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", s.path, err)
+	}
+	if s.maxBytes > 0 && info.Size()+int64(len(line))+1 > s.maxBytes {
+		return fmt.Errorf("%s reached its %d byte cap, dropping record", s.path, s.maxBytes)
+	}
+
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write to %s: %w", s.path, err)
+	}
+	return nil
+}
+
+// HTTP posts each line to an HTTP endpoint.
+type HTTP struct {
+	url    string
+	client *http.Client
+}
+
+// NewHTTP creates an HTTP sink posting to the given URL.
+func NewHTTP(url string) *HTTP {
+	return &HTTP{url: url, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Write implements Sink.
+func (s *HTTP) Write(line []byte) error {
+	resp, err := s.client.Post(s.url, "application/x-ndjson", bytes.NewReader(append(line, '\n')))
+	if err != nil {
+		return fmt.Errorf("failed to post record: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("%s returned status %d", s.url, resp.StatusCode)
+	}
+	return nil
+}
+
+// Log writes lines to the standard logger, which Traefik captures in its own log.
+type Log struct {
+	prefix string
+}
+
+// NewLog creates a log sink prefixing every line.
+func NewLog(prefix string) *Log {
+	return &Log{prefix: prefix}
+}
+
+// Write implements Sink.
+func (s *Log) Write(line []byte) error {
+	log.Printf("%s%s", s.prefix, line)
+	return nil
+}
+
+// Async queues lines for a background writer so callers are never delayed by the sink.
+// When the queue is full, lines are dropped and reported as errors.
+type Async struct {
+	sink    Sink
+	lines   chan []byte
+	onError func(error)
+}
+
+// NewAsync starts a background writer for the sink that runs until ctx is cancelled.
+func NewAsync(ctx context.Context, s Sink, queueSize int, onError func(error)) *Async {
+	a := &Async{
+		sink:    s,
+		lines:   make(chan []byte, queueSize),
+		onError: onError,
+	}
+	go a.run(ctx)
+	return a
+}
+
+// Write queues a line without blocking.
+func (a *Async) Write(line []byte) {
+	select {
+	case a.lines <- line:
+	default:
+		a.reportError(fmt.Errorf("queue full, dropping record"))
+	}
+}
+
+// Depth returns the number of lines waiting to be written.
+func (a *Async) Depth() int {
+	return len(a.lines)
+}
+
+func (a *Async) run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case line := <-a.lines:
+			if err := a.sink.Write(line); err != nil {
+				a.reportError(err)
+			}
+		}
+	}
+}
+
+func (a *Async) reportError(err error) {
+	if a.onError != nil {
+		a.onError(err)
+	}
+}
@@ -0,0 +1,167 @@
+package sink
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/zalbiraw/ociaitoopenai/internal/config"
+)
+
+func TestFile_StopsAtCap(t *testing.T) {
+	s := NewFile(filepath.Join(t.TempDir(), "records.jsonl"), 10)
+
+	if err := s.Write([]byte(`{"a":1}`)); err != nil {
+		t.Fatalf("expected first record to fit, got: %v", err)
+	}
+	if err := s.Write([]byte(`{"a":2}`)); err == nil {
+		t.Error("expected error once the file cap is reached")
+	}
+}
+
+func TestHTTP_PostsJSONLines(t *testing.T) {
+	received := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Content-Type") != "application/x-ndjson" {
+			t.Errorf("unexpected content type: %s", req.Header.Get("Content-Type"))
+		}
+		body, _ := io.ReadAll(req.Body)
+		received <- string(body)
+	}))
+	defer server.Close()
+
+	s := NewHTTP(server.URL)
+	if err := s.Write([]byte(`{"a":1}`)); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if body := <-received; body != "{\"a\":1}\n" {
+		t.Errorf("unexpected posted body: %q", body)
+	}
+}
+
+func TestAsync_WritesInBackground(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	path := filepath.Join(t.TempDir(), "records.jsonl")
+	a := NewAsync(ctx, NewFile(path, 0), 4, func(err error) { t.Errorf("unexpected error: %v", err) })
+	a.Write([]byte(`{"a":1}`))
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		data, _ := os.ReadFile(path)
+		if string(data) == "{\"a\":1}\n" {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected record to be written, got: %q", data)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestRotatingFile_RollsOverBySize(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "audit.jsonl")
+	if err := os.WriteFile(filepath.Join(dir, "audit.jsonl.bak"), []byte("keep"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewRotatingFile(path, config.Rotation{MaxBytes: 16, Compress: true, MaxFiles: 2})
+	now := time.Unix(1700000000, 0)
+	s.now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+
+	// Two 8-byte lines fill a file, so five lines roll over twice
+	for i := 1; i <= 5; i++ {
+		if err := s.Write([]byte(fmt.Sprintf(`{"a":%d}`, i))); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+	}
+
+	rotated, _ := filepath.Glob(path + ".*.gz")
+	if len(rotated) != 2 {
+		t.Fatalf("expected 2 compressed files, got %v", rotated)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "{\"a\":5}\n" {
+		t.Errorf("expected the current file to hold the last line, got %q", data)
+	}
+	if lines := readGzip(t, rotated[0]); lines != "{\"a\":1}\n{\"a\":2}\n" {
+		t.Errorf("unexpected first rolled-over file: %q", lines)
+	}
+
+	// A third roll over deletes the oldest file, leaving unrelated files alone
+	for i := 6; i <= 7; i++ {
+		if err := s.Write([]byte(fmt.Sprintf(`{"a":%d}`, i))); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+	}
+	kept, _ := filepath.Glob(path + ".*.gz")
+	if len(kept) != 2 || kept[0] != rotated[1] {
+		t.Errorf("expected the 2 newest files to be kept, got %v", kept)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "audit.jsonl.bak")); err != nil {
+		t.Errorf("expected unrelated files to be kept, got: %v", err)
+	}
+}
+
+func TestRotatingFile_RollsOverByAge(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mirror.jsonl")
+	s := NewRotatingFile(path, config.Rotation{MaxAge: "1h", Retention: "2h"})
+	// Rolled-over files are aged by their modification time, so the clock ends at the real time
+	now := time.Now().Add(-4 * time.Hour)
+	s.now = func() time.Time { return now }
+
+	for i := 0; i < 4; i++ {
+		if err := s.Write([]byte(`{}`)); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		now = now.Add(61 * time.Minute)
+	}
+
+	rotated, _ := filepath.Glob(path + ".*")
+	if len(rotated) != 3 {
+		t.Fatalf("expected 3 rolled-over files, got %v", rotated)
+	}
+	old := time.Now().Add(-3 * time.Hour)
+	if err := os.Chtimes(rotated[0], old, old); err != nil {
+		t.Fatal(err)
+	}
+	now = time.Now().Add(time.Hour)
+	if err := s.Write([]byte(`{}`)); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if _, err := os.Stat(rotated[0]); !os.IsNotExist(err) {
+		t.Errorf("expected the expired file to be deleted, got: %v", err)
+	}
+	if kept, _ := filepath.Glob(path + ".*"); len(kept) != 3 {
+		t.Errorf("expected 3 rolled-over files to be kept, got %v", kept)
+	}
+}
+
+func readGzip(t *testing.T, path string) string {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
@@ -0,0 +1,145 @@
+// Package slo tracks rolling per-model latency and error rates of chat completions against
+// service level objectives. Statistics are computed over each model's most recent requests.
+package slo
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/zalbiraw/ociaitoopenai/internal/config"
+)
+
+// Stats are the statistics of a model's recent requests.
+type Stats struct {
+	Requests   int
+	P50Latency time.Duration
+	P95Latency time.Duration
+	ErrorRate  float64
+	Breached   bool // An objective is breached, once enough requests were recorded
+}
+
+// sample is the outcome of a request.
+type sample struct {
+	latency time.Duration
+	failed  bool
+}
+
+// window is a ring buffer of a model's recent samples.
+type window struct {
+	samples  []sample
+	next     int
+	filled   bool
+	lastWarn time.Time
+}
+
+// Tracker records request outcomes per model. It is safe for concurrent use.
+type Tracker struct {
+	size         int
+	p95Latency   time.Duration
+	errorRate    float64
+	minRequests  int
+	warnInterval time.Duration
+	onBreach     func(model string, stats Stats)
+	now          func() time.Time
+
+	mu     sync.Mutex
+	models map[string]*window
+}
+
+// New creates a tracker. onBreach is called, at most once per warn interval and model, when a
+// recorded request leaves the model in breach of an objective. It returns nil when tracking is disabled.
+func New(cfg config.SLO, onBreach func(model string, stats Stats)) *Tracker {
+	if !cfg.Enabled {
+		return nil
+	}
+
+	p95Latency, _ := time.ParseDuration(cfg.P95Latency)
+	warnInterval, _ := time.ParseDuration(cfg.WarnInterval)
+	return &Tracker{
+		size:         cfg.Window,
+		p95Latency:   p95Latency,
+		errorRate:    cfg.ErrorRate,
+		minRequests:  cfg.MinRequests,
+		warnInterval: warnInterval,
+		onBreach:     onBreach,
+		now:          time.Now,
+		models:       make(map[string]*window),
+	}
+}
+
+// Record adds the outcome of a request to a model's window. Responses with a 5xx or 429 status are errors.
+func (t *Tracker) Record(model string, status int, latency time.Duration) {
+	t.mu.Lock()
+	w := t.models[model]
+	if w == nil {
+		w = &window{samples: make([]sample, t.size)}
+		t.models[model] = w
+	}
+	w.samples[w.next] = sample{latency: latency, failed: status >= 500 || status == 429}
+	w.next++
+	if w.next == len(w.samples) {
+		w.next, w.filled = 0, true
+	}
+
+	stats := t.stats(w)
+	warn := stats.Breached && t.now().Sub(w.lastWarn) >= t.warnInterval
+	if warn {
+		w.lastWarn = t.now()
+	}
+	t.mu.Unlock()
+
+	if warn && t.onBreach != nil {
+		t.onBreach(model, stats)
+	}
+}
+
+// Stats returns the statistics of every model with recorded requests.
+func (t *Tracker) Stats() map[string]Stats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stats := make(map[string]Stats, len(t.models))
+	for model, w := range t.models {
+		stats[model] = t.stats(w)
+	}
+	return stats
+}
+
+// stats computes the statistics of a window. The caller must hold the lock.
+func (t *Tracker) stats(w *window) Stats {
+	n := w.next
+	if w.filled {
+		n = len(w.samples)
+	}
+	if n == 0 {
+		return Stats{}
+	}
+
+	latencies := make([]time.Duration, n)
+	failures := 0
+	for i, s := range w.samples[:n] {
+		latencies[i] = s.latency
+		if s.failed {
+			failures++
+		}
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+
+	stats := Stats{
+		Requests:   n,
+		P50Latency: percentile(latencies, 50),
+		P95Latency: percentile(latencies, 95),
+		ErrorRate:  float64(failures) / float64(n),
+	}
+	if n >= t.minRequests {
+		stats.Breached = (t.p95Latency > 0 && stats.P95Latency > t.p95Latency) ||
+			(t.errorRate > 0 && stats.ErrorRate > t.errorRate)
+	}
+	return stats
+}
+
+// percentile returns the nearest-rank percentile of sorted latencies.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	return sorted[(len(sorted)*p+99)/100-1]
+}
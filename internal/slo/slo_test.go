@@ -0,0 +1,77 @@
+package slo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/zalbiraw/ociaitoopenai/internal/config"
+)
+
+func TestNew_Disabled(t *testing.T) {
+	if New(config.New().SLO, nil) != nil {
+		t.Error("expected no tracker when SLO tracking is disabled")
+	}
+}
+
+func TestRecord_Stats(t *testing.T) {
+	cfg := config.New().SLO
+	cfg.Enabled = true
+	cfg.Window = 10
+	tracker := New(cfg, nil)
+
+	// The first requests fall out of the window
+	for i := 0; i < 5; i++ {
+		tracker.Record("model-a", 500, time.Minute)
+	}
+	for i := 1; i <= 10; i++ {
+		status := 200
+		if i == 10 {
+			status = 429
+		}
+		tracker.Record("model-a", status, time.Duration(i)*100*time.Millisecond)
+	}
+	tracker.Record("model-b", 400, time.Second)
+
+	stats := tracker.Stats()
+	a := stats["model-a"]
+	if a.Requests != 10 || a.P50Latency != 500*time.Millisecond || a.P95Latency != time.Second || a.ErrorRate != 0.1 {
+		t.Errorf("unexpected model-a stats: %+v", a)
+	}
+	if b := stats["model-b"]; b.Requests != 1 || b.ErrorRate != 0 {
+		t.Errorf("expected client errors not to count as errors, got %+v", b)
+	}
+}
+
+func TestRecord_WarnsOnBreach(t *testing.T) {
+	cfg := config.New().SLO
+	cfg.Enabled = true
+	cfg.ErrorRate = 0.2
+	cfg.P95Latency = "1s"
+	cfg.MinRequests = 4
+
+	var breaches []Stats
+	tracker := New(cfg, func(model string, stats Stats) {
+		breaches = append(breaches, stats)
+	})
+	now := time.Unix(0, 0)
+	tracker.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		tracker.Record("model", 503, 10*time.Millisecond)
+	}
+	if len(breaches) != 0 {
+		t.Fatalf("expected no warning before the minimum requests, got %+v", breaches)
+	}
+
+	tracker.Record("model", 200, 10*time.Millisecond)
+	tracker.Record("model", 200, 10*time.Millisecond)
+	if len(breaches) != 1 || breaches[0].Requests != 4 || !breaches[0].Breached {
+		t.Fatalf("expected one warning per interval, got %+v", breaches)
+	}
+
+	now = now.Add(time.Minute)
+	tracker.Record("model", 200, 2*time.Second)
+	if len(breaches) != 2 {
+		t.Errorf("expected another warning after the interval, got %d", len(breaches))
+	}
+}
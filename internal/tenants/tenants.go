@@ -0,0 +1,154 @@
+// Package tenants keeps the tenant table used for model routing. The configured tenants are
+// combined with a table loaded from a file or URL, which is reloaded periodically so keys and
+// model routes can change without a configuration redeploy.
+package tenants
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/zalbiraw/ociaitoopenai/internal/config"
+)
+
+// Table holds the current tenants. It is safe for concurrent use.
+type Table struct {
+	cfg     *config.Config
+	client  *http.Client
+	onError func(error)
+
+	mu      sync.RWMutex
+	tenants map[string]config.Tenant
+	etag    string // ETag of the last table fetched from the URL
+}
+
+// New loads the configured tenant source and starts reloading it every refresh interval until
+// ctx is cancelled. It returns nil when no source is configured.
+func New(ctx context.Context, cfg *config.Config, onError func(error)) (*Table, error) {
+	if !cfg.TenantSource.Enabled() {
+		return nil, nil
+	}
+
+	t := &Table{
+		cfg:     cfg,
+		client:  &http.Client{Timeout: 10 * time.Second},
+		onError: onError,
+	}
+	if err := t.Reload(); err != nil {
+		return nil, err
+	}
+
+	interval, _ := time.ParseDuration(cfg.TenantSource.RefreshInterval)
+	go t.run(ctx, interval)
+	return t, nil
+}
+
+// ModelRoute returns the route for a tenant's model alias.
+func (t *Table) ModelRoute(tenant, model string) (config.ModelRoute, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	route, ok := t.tenants[tenant].Models[model]
+	return route, ok
+}
+
+// Priority returns the admission priority class of a tenant, empty when unset.
+func (t *Table) Priority(tenant string) string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.tenants[tenant].Priority
+}
+
+// Reload loads the source again. An unreadable or invalid table leaves the current one in place.
+func (t *Table) Reload() error {
+	var (
+		data []byte
+		etag string
+		err  error
+	)
+	if t.cfg.TenantSource.URL != "" {
+		data, etag, err = t.fetch()
+	} else {
+		data, err = os.ReadFile(t.cfg.TenantSource.File)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load tenants: %w", err)
+	}
+	if data == nil {
+		// Unchanged since the last fetch
+		return nil
+	}
+
+	var loaded map[string]config.Tenant
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		return fmt.Errorf("failed to parse tenants: %w", err)
+	}
+	if err := t.cfg.ValidateTenants(loaded); err != nil {
+		return fmt.Errorf("invalid tenants: %w", err)
+	}
+
+	tenants := make(map[string]config.Tenant, len(t.cfg.Tenants)+len(loaded))
+	for name, tenant := range t.cfg.Tenants {
+		tenants[name] = tenant
+	}
+	for name, tenant := range loaded {
+		tenants[name] = tenant
+	}
+
+	t.mu.Lock()
+	t.tenants = tenants
+	t.etag = etag
+	t.mu.Unlock()
+	return nil
+}
+
+// fetch GETs the table from the URL. It returns nil data when the table has not changed.
+func (t *Table) fetch() ([]byte, string, error) {
+	req, err := http.NewRequest(http.MethodGet, t.cfg.TenantSource.URL, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	t.mu.RLock()
+	if t.etag != "" {
+		req.Header.Set("If-None-Match", t.etag)
+	}
+	t.mu.RUnlock()
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, "", nil
+	}
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return nil, "", fmt.Errorf("%s returned status %d", t.cfg.TenantSource.URL, resp.StatusCode)
+	}
+
+	var data json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return nil, "", err
+	}
+	return data, resp.Header.Get("ETag"), nil
+}
+
+func (t *Table) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := t.Reload(); err != nil && t.onError != nil {
+				t.onError(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package tenants
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/zalbiraw/ociaitoopenai/internal/config"
+)
+
+func testConfig() *config.Config {
+	cfg := config.New()
+	cfg.TenantHeader = "X-Tenant"
+	cfg.Tenants = map[string]config.Tenant{
+		"static": {Models: map[string]config.ModelRoute{"chat": {Model: "cohere.command-r-plus"}}},
+	}
+	return cfg
+}
+
+func TestNew_Disabled(t *testing.T) {
+	table, err := New(context.Background(), config.New(), nil)
+	if err != nil || table != nil {
+		t.Errorf("expected nil table without a tenant source, got %v, %v", table, err)
+	}
+}
+
+func TestTable_FileReload(t *testing.T) {
+	cfg := testConfig()
+	cfg.TenantSource.File = filepath.Join(t.TempDir(), "tenants.json")
+	writeFile(t, cfg.TenantSource.File, `{"key-1": {"models": {"chat": {"model": "meta.llama-3.1-70b-instruct"}}}}`)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	table, err := New(ctx, cfg, nil)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if route, ok := table.ModelRoute("key-1", "chat"); !ok || route.Model != "meta.llama-3.1-70b-instruct" {
+		t.Errorf("expected the loaded route, got %+v, %v", route, ok)
+	}
+	if route, ok := table.ModelRoute("static", "chat"); !ok || route.Model != "cohere.command-r-plus" {
+		t.Errorf("expected the configured route, got %+v, %v", route, ok)
+	}
+
+	// Rotate the key
+	writeFile(t, cfg.TenantSource.File, `{"key-2": {"models": {"chat": {"model": "meta.llama-3.1-70b-instruct"}}}}`)
+	if err := table.Reload(); err != nil {
+		t.Fatalf("expected reload to succeed, got: %v", err)
+	}
+	if _, ok := table.ModelRoute("key-1", "chat"); ok {
+		t.Error("expected the rotated key to be removed")
+	}
+	if _, ok := table.ModelRoute("key-2", "chat"); !ok {
+		t.Error("expected the new key to be loaded")
+	}
+
+	// Keep the current table when the file is invalid
+	writeFile(t, cfg.TenantSource.File, `{"key-3": {"models": {"chat": {}}}}`)
+	if err := table.Reload(); err == nil {
+		t.Error("expected an error for an invalid table")
+	}
+	if _, ok := table.ModelRoute("key-2", "chat"); !ok {
+		t.Error("expected the previous table to be kept")
+	}
+}
+
+func TestTable_LoadedTenantsOverrideConfigured(t *testing.T) {
+	cfg := testConfig()
+	cfg.TenantSource.File = filepath.Join(t.TempDir(), "tenants.json")
+	writeFile(t, cfg.TenantSource.File, `{"static": {"models": {"chat": {"region": "us-chicago-1"}}}}`)
+
+	table, err := New(context.Background(), cfg, nil)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if route, _ := table.ModelRoute("static", "chat"); route != (config.ModelRoute{Region: "us-chicago-1"}) {
+		t.Errorf("expected the loaded route to take precedence, got %+v", route)
+	}
+}
+
+func TestTable_URL(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = w.Write([]byte(`{"key-1": {"models": {"chat": {"host": "inference.example.com"}}}}`))
+	}))
+	defer server.Close()
+
+	cfg := testConfig()
+	cfg.TenantSource.URL = server.URL
+
+	table, err := New(context.Background(), cfg, nil)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if err := table.Reload(); err != nil {
+		t.Fatalf("expected an unchanged table to reload, got: %v", err)
+	}
+	if requests != 2 {
+		t.Errorf("expected 2 requests, got %d", requests)
+	}
+	if route, ok := table.ModelRoute("key-1", "chat"); !ok || route.Host != "inference.example.com" {
+		t.Errorf("expected the fetched route to be kept, got %+v, %v", route, ok)
+	}
+}
+
+func TestNew_LoadFailure(t *testing.T) {
+	cfg := testConfig()
+	cfg.TenantSource.File = filepath.Join(t.TempDir(), "missing.json")
+
+	if _, err := New(context.Background(), cfg, nil); err == nil {
+		t.Error("expected an error when the tenant source cannot be loaded")
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
@@ -0,0 +1,82 @@
+package transform
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/zalbiraw/ociaitoopenai/pkg/types"
+)
+
+// FromLegacyFunctions translates the deprecated functions and function_call fields, and
+// function_call and "function" messages in the history, to their tools equivalents. It
+// reports whether the request used the legacy fields, in which case the response should be
+// converted back with ToLegacyFunctionCall. The resulting tools only reach the model when they
+// are emulated with EmulateTools.
+func FromLegacyFunctions(req *types.ChatCompletionRequest) bool {
+	legacy := len(req.Functions) > 0 || len(req.FunctionCall) > 0
+
+	if len(req.Tools) == 0 {
+		for _, function := range req.Functions {
+			req.Tools = append(req.Tools, types.Tool{Type: "function", Function: function})
+		}
+	}
+	req.Functions = nil
+
+	if len(req.ToolChoice) == 0 && len(req.FunctionCall) > 0 {
+		req.ToolChoice = legacyToolChoice(req.FunctionCall)
+	}
+	req.FunctionCall = nil
+
+	// Legacy calls have no IDs, so each is given one its result message can refer to
+	pending := map[string]string{}
+	for i := range req.Messages {
+		msg := &req.Messages[i]
+		switch {
+		case msg.FunctionCall != nil:
+			legacy = true
+			id := fmt.Sprintf("call_%d", i)
+			msg.ToolCalls = append(msg.ToolCalls, types.ToolCall{ID: id, Type: "function", Function: *msg.FunctionCall})
+			msg.FunctionCall = nil
+			pending[msg.ToolCalls[len(msg.ToolCalls)-1].Function.Name] = id
+		case strings.EqualFold(msg.Role, "function"):
+			legacy = true
+			msg.Role = "tool"
+			msg.ToolCallID = pending[msg.Name]
+		}
+	}
+	return legacy
+}
+
+// legacyToolChoice converts a function_call value to a tool_choice value.
+func legacyToolChoice(functionCall json.RawMessage) json.RawMessage {
+	var forced struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(functionCall, &forced); err != nil || forced.Name == "" {
+		// "none" and "auto" mean the same in both
+		return functionCall
+	}
+	choice, _ := json.Marshal(map[string]interface{}{
+		"type":     "function",
+		"function": map[string]string{"name": forced.Name},
+	})
+	return choice
+}
+
+// ToLegacyFunctionCall converts tool calls in a response to the deprecated function_call
+// format. Legacy clients accept a single call, so only the first is kept.
+func ToLegacyFunctionCall(resp *types.ChatCompletionResponse) {
+	for i := range resp.Choices {
+		choice := &resp.Choices[i]
+		if len(choice.Message.ToolCalls) == 0 {
+			continue
+		}
+		function := choice.Message.ToolCalls[0].Function
+		choice.Message.FunctionCall = &function
+		choice.Message.ToolCalls = nil
+		if choice.FinishReason == "tool_calls" {
+			choice.FinishReason = "function_call"
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package transform
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/zalbiraw/ociaitoopenai/pkg/types"
+)
+
+func TestFromLegacyFunctions(t *testing.T) {
+	req := types.ChatCompletionRequest{
+		Functions:    []types.FunctionDefinition{{Name: "get_weather", Parameters: json.RawMessage(`{"type": "object"}`)}},
+		FunctionCall: json.RawMessage(`{"name": "get_weather"}`),
+		Messages: []types.ChatCompletionMessage{
+			{Role: "user", Content: "Weather in Paris?"},
+			{Role: "assistant", FunctionCall: &types.ToolCallFunction{Name: "get_weather", Arguments: `{"city":"Paris"}`}},
+			{Role: "function", Name: "get_weather", Content: "Sunny"},
+		},
+	}
+	if !FromLegacyFunctions(&req) {
+		t.Fatal("expected the legacy fields to be detected")
+	}
+
+	if len(req.Tools) != 1 || req.Tools[0].Type != "function" || req.Tools[0].Function.Name != "get_weather" || req.Functions != nil {
+		t.Errorf("expected functions converted to tools, got %+v", req.Tools)
+	}
+	if string(req.ToolChoice) != `{"function":{"name":"get_weather"},"type":"function"}` || req.FunctionCall != nil {
+		t.Errorf("expected a forced tool choice, got %s", req.ToolChoice)
+	}
+	call := req.Messages[1]
+	if call.FunctionCall != nil || len(call.ToolCalls) != 1 || call.ToolCalls[0].Function.Arguments != `{"city":"Paris"}` {
+		t.Fatalf("expected the function call converted to a tool call, got %+v", call)
+	}
+	if req.Messages[2].Role != "tool" || req.Messages[2].ToolCallID != call.ToolCalls[0].ID {
+		t.Errorf("expected the function result converted to a tool message, got %+v", req.Messages[2])
+	}
+}
+
+func TestFromLegacyFunctions_PassesThroughModes(t *testing.T) {
+	req := types.ChatCompletionRequest{FunctionCall: json.RawMessage(`"none"`)}
+	FromLegacyFunctions(&req)
+	if string(req.ToolChoice) != `"none"` {
+		t.Errorf("expected tool_choice none, got %s", req.ToolChoice)
+	}
+
+	req = types.ChatCompletionRequest{Messages: []types.ChatCompletionMessage{{Role: "user", Content: "Hi"}}}
+	if FromLegacyFunctions(&req) {
+		t.Error("expected a request without legacy fields not to be flagged")
+	}
+}
+
+func TestToLegacyFunctionCall(t *testing.T) {
+	resp := types.ChatCompletionResponse{Choices: []types.ChatCompletionChoice{{
+		Message: types.ChatCompletionMessage{Role: "assistant", ToolCalls: []types.ToolCall{
+			{ID: "call_1", Type: "function", Function: types.ToolCallFunction{Name: "get_weather", Arguments: `{"city":"Paris"}`}},
+			{ID: "call_2", Type: "function", Function: types.ToolCallFunction{Name: "get_time", Arguments: `{}`}},
+		}},
+		FinishReason: "tool_calls",
+	}}}
+	ToLegacyFunctionCall(&resp)
+
+	choice := resp.Choices[0]
+	if choice.Message.FunctionCall == nil || choice.Message.FunctionCall.Name != "get_weather" || choice.Message.ToolCalls != nil {
+		t.Errorf("expected the first tool call as function_call, got %+v", choice.Message)
+	}
+	if choice.FinishReason != "function_call" {
+		t.Errorf("expected finish_reason function_call, got %q", choice.FinishReason)
+	}
+}
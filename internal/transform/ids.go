@@ -0,0 +1,53 @@
+package transform
+
+import (
+	"crypto/rand"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/zalbiraw/ociaitoopenai/internal/config"
+)
+
+// IDs generates chat completion IDs with the configured strategy. Streamed and non-streamed
+// responses share one generator, so their IDs have the same form. It is safe for concurrent use.
+type IDs struct {
+	sequence uint64                        // Completions numbered by the "sequential" strategy, updated atomically; first for 64-bit alignment
+	strategy string                        // config.IDStrategy* value
+	generate func(requestID string) string // Replacement generator, if set
+}
+
+// NewIDs creates an ID generator for the given strategy.
+func NewIDs(strategy string) *IDs {
+	return &IDs{strategy: strategy}
+}
+
+// New returns a completion ID. It is given the OCI opc-request-id of the response, or an empty
+// string when there is none.
+func (g *IDs) New(requestID string) string {
+	if g.generate != nil {
+		return g.generate(requestID)
+	}
+
+	switch g.strategy {
+	case config.IDStrategySequential:
+		return fmt.Sprintf("chatcmpl-%d", atomic.AddUint64(&g.sequence, 1))
+	case config.IDStrategyRequestID:
+		if requestID != "" {
+			return "chatcmpl-" + requestID
+		}
+	}
+	return generateCompletionID()
+}
+
+// generateCompletionID generates a unique identifier for the completion.
+func generateCompletionID() string {
+	// Generate a random ID similar to OpenAI's format: chatcmpl-XXXXXX
+	const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	b := make([]byte, 29)
+	for i := range b {
+		num := make([]byte, 1)
+		_, _ = rand.Read(num)
+		b[i] = charset[num[0]%byte(len(charset))]
+	}
+	return fmt.Sprintf("chatcmpl-%s", string(b))
+}
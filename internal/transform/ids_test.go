@@ -0,0 +1,29 @@
+package transform
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/zalbiraw/ociaitoopenai/internal/config"
+)
+
+func TestIDs_New(t *testing.T) {
+	random := NewIDs(config.IDStrategyRandom)
+	first, second := random.New("ABC123"), random.New("ABC123")
+	if !strings.HasPrefix(first, "chatcmpl-") || len(first) != len("chatcmpl-")+29 || first == second {
+		t.Errorf("expected distinct random IDs, got %q and %q", first, second)
+	}
+
+	sequential := NewIDs(config.IDStrategySequential)
+	if first, second := sequential.New(""), sequential.New(""); first != "chatcmpl-1" || second != "chatcmpl-2" {
+		t.Errorf("expected sequential IDs, got %q and %q", first, second)
+	}
+
+	requestID := NewIDs(config.IDStrategyRequestID)
+	if id := requestID.New("ABC123"); id != "chatcmpl-ABC123" {
+		t.Errorf("expected the request ID embedded, got %q", id)
+	}
+	if id := requestID.New(""); !strings.HasPrefix(id, "chatcmpl-") || len(id) != len("chatcmpl-")+29 {
+		t.Errorf("expected a random ID without a request ID, got %q", id)
+	}
+}
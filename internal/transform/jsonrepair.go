@@ -0,0 +1,90 @@
+package transform
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/zalbiraw/ociaitoopenai/pkg/types"
+)
+
+// RepairJSON returns text as a valid JSON object, removing a surrounding code fence, any text
+// before or after the object, and trailing commas. It reports false when the text cannot be repaired.
+func RepairJSON(text string) (string, bool) {
+	repaired := stripCodeFence(strings.TrimSpace(text))
+	if strings.HasPrefix(repaired, "{") && json.Valid([]byte(repaired)) {
+		return repaired, true
+	}
+
+	start := strings.IndexByte(repaired, '{')
+	end := strings.LastIndexByte(repaired, '}')
+	if start < 0 || end < start {
+		return text, false
+	}
+	repaired = removeTrailingCommas(repaired[start : end+1])
+	if !json.Valid([]byte(repaired)) {
+		return text, false
+	}
+	return repaired, true
+}
+
+// removeTrailingCommas removes commas directly followed, ignoring whitespace, by a closing
+// brace or bracket. Commas inside strings are kept.
+func removeTrailingCommas(text string) string {
+	var b strings.Builder
+	b.Grow(len(text))
+	inString, escaped := false, false
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case inString:
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+		case c == '"':
+			inString = true
+		case c == ',':
+			next := strings.TrimLeft(text[i+1:], " \t\r\n")
+			if next != "" && (next[0] == '}' || next[0] == ']') {
+				continue
+			}
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// RepairJSONResponse repairs the content of every choice that does not carry tool calls.
+// It returns an error naming the first choice whose content is not valid JSON and could not
+// be repaired; such choices are left unchanged.
+func RepairJSONResponse(resp *types.ChatCompletionResponse) error {
+	var failed error
+	for i := range resp.Choices {
+		msg := &resp.Choices[i].Message
+		if len(msg.ToolCalls) > 0 {
+			continue
+		}
+		repaired, ok := RepairJSON(msg.Content)
+		if !ok {
+			if failed == nil {
+				failed = fmt.Errorf("choice %d is not a valid JSON object", i)
+			}
+			continue
+		}
+		msg.Content = repaired
+	}
+	return failed
+}
+
+// ReaskJSON extends the request with an invalid JSON answer and a correction, asking the model to answer again.
+func ReaskJSON(req *types.ChatCompletionRequest, answer string) {
+	req.Messages = append(req.Messages,
+		types.ChatCompletionMessage{Role: "assistant", Content: answer},
+		types.ChatCompletionMessage{Role: "user", Content: "Your previous reply was not valid JSON. Reply again with only a valid JSON object."},
+	)
+}
@@ -0,0 +1,44 @@
+package transform
+
+import (
+	"testing"
+
+	"github.com/zalbiraw/ociaitoopenai/pkg/types"
+)
+
+func TestRepairJSON(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		ok       bool
+	}{
+		{`{"a": 1}`, `{"a": 1}`, true},
+		{"```json\n{\"a\": 1}\n```", `{"a": 1}`, true},
+		{`Here you go: {"a": [1, 2,], "b": "x, }",} Hope this helps.`, `{"a": [1, 2], "b": "x, }"}`, true},
+		{`{"a": }`, `{"a": }`, false},
+		{`42`, `42`, false},
+	}
+	for _, tt := range tests {
+		got, ok := RepairJSON(tt.input)
+		if got != tt.expected || ok != tt.ok {
+			t.Errorf("RepairJSON(%q) = %q, %v; expected %q, %v", tt.input, got, ok, tt.expected, tt.ok)
+		}
+	}
+}
+
+func TestRepairJSONResponse(t *testing.T) {
+	resp := types.ChatCompletionResponse{Choices: []types.ChatCompletionChoice{
+		{Message: types.ChatCompletionMessage{Content: "```\n{\"a\": 1,}\n```"}},
+		{Message: types.ChatCompletionMessage{Content: "not json"}},
+	}}
+
+	if err := RepairJSONResponse(&resp); err == nil {
+		t.Error("expected an error for the unrepairable choice")
+	}
+	if resp.Choices[0].Message.Content != `{"a": 1}` {
+		t.Errorf("expected the first choice to be repaired, got %q", resp.Choices[0].Message.Content)
+	}
+	if resp.Choices[1].Message.Content != "not json" {
+		t.Errorf("expected the second choice to be unchanged, got %q", resp.Choices[1].Message.Content)
+	}
+}
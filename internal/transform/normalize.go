@@ -0,0 +1,218 @@
+package transform
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/zalbiraw/ociaitoopenai/internal/config"
+	"github.com/zalbiraw/ociaitoopenai/pkg/types"
+)
+
+// Sampling ranges accepted by OCI GenAI for each API format, and by OpenAI.
+const (
+	maxCohereTemperature  = 1.0
+	maxGenericTemperature = 2.0
+	maxOpenAITemperature  = 2.0
+	maxTopP               = 1.0
+	maxCohereTopK         = 500
+	minGenericTopK        = -1 // -1 considers all tokens
+)
+
+// Normalize clamps request parameters to what OCI GenAI and the configured limits accept,
+// and truncates the conversation history to the configured length. It returns a description
+// of each adjustment made, such as "max_tokens=4000 (was 8000)", so clients can be told why
+// outputs may differ from other providers.
+func (t *Transformer) Normalize(req *types.ChatCompletionRequest) []string {
+	var adjustments []string
+
+	// o-series clients send max_completion_tokens, which OCI knows as max_tokens
+	if req.MaxCompletionTokens > 0 {
+		req.MaxTokens = req.MaxCompletionTokens
+		req.MaxCompletionTokens = 0
+	}
+
+	if req.MaxTokens == 0 && t.config.DefaultMaxTokens > 0 {
+		req.MaxTokens = t.config.DefaultMaxTokens
+		if t.config.LanguageScaling.Enabled {
+			script := promptScript(req.Messages)
+			if factor, ok := t.config.LanguageScaling.Factors[script]; ok && factor != 1 {
+				req.MaxTokens = int(float64(req.MaxTokens) * factor)
+				adjustments = append(adjustments, fmt.Sprintf("max_tokens=%d (default scaled for %s)", req.MaxTokens, script))
+			}
+		}
+	}
+
+	if limit := t.config.MaxTokensLimit; limit > 0 && req.MaxTokens > limit {
+		adjustments = append(adjustments, fmt.Sprintf("max_tokens=%d (was %d)", limit, req.MaxTokens))
+		req.MaxTokens = limit
+	}
+
+	maxTemperature := maxGenericTemperature
+	if containsIgnoreCase(req.Model, "cohere") {
+		maxTemperature = maxCohereTemperature
+	}
+	if req.Temperature != nil {
+		temperature := *req.Temperature
+		if t.config.SamplingPolicy == config.SamplingPolicyScale {
+			temperature = temperature * maxTemperature / maxOpenAITemperature
+		}
+		temperature = clamp(temperature, 0, maxTemperature)
+		if temperature != *req.Temperature {
+			adjustments = append(adjustments, fmt.Sprintf("temperature=%v (was %v)", temperature, *req.Temperature))
+			req.Temperature = &temperature
+		}
+	}
+
+	if topP := clamp(req.TopP, 0, maxTopP); topP != req.TopP {
+		adjustments = append(adjustments, fmt.Sprintf("top_p=%v (was %v)", topP, req.TopP))
+		req.TopP = topP
+	}
+
+	if adjustment := normalizeTopK(req); adjustment != "" {
+		adjustments = append(adjustments, adjustment)
+	}
+
+	if len(req.LogitBias) > 0 {
+		adjustments = append(adjustments, "logit_bias=ignored (unsupported by OCI)")
+		req.LogitBias = nil
+	}
+
+	if len(req.Prediction) > 0 && string(req.Prediction) != "null" {
+		adjustments = append(adjustments, "prediction=ignored (unsupported by OCI)")
+	}
+	req.Prediction = nil
+
+	// reasoning_effort can only be forwarded to GENERIC models
+	if req.ReasoningEffort != "" && (t.config.ReasoningEffort != config.ReasoningEffortForward || containsIgnoreCase(req.Model, "cohere")) {
+		adjustments = append(adjustments, fmt.Sprintf("reasoning_effort=ignored (was %s)", req.ReasoningEffort))
+		req.ReasoningEffort = ""
+	}
+
+	// o-series clients send instructions as developer messages, which OCI knows as system
+	// messages, unless roleMappings maps developer messages itself
+	if _, mapped := t.config.RoleMappings[apiFormat(req.Model)]["developer"]; !mapped {
+		for i := range req.Messages {
+			if strings.EqualFold(req.Messages[i].Role, "developer") {
+				req.Messages[i].Role = "system"
+			}
+		}
+	}
+
+	if t.config.MergeConsecutiveMessages {
+		original := len(req.Messages)
+		req.Messages = mergeConsecutiveMessages(req.Messages, t.config.MessageSeparator)
+		if merged := original - len(req.Messages); merged > 0 {
+			adjustments = append(adjustments, fmt.Sprintf("merged_messages=%d", merged))
+		}
+	}
+
+	if limit := t.config.MaxHistoryMessages; limit > 0 && len(req.Messages) > limit {
+		original := len(req.Messages)
+		req.Messages = truncateHistory(req.Messages, limit)
+		adjustments = append(adjustments, fmt.Sprintf("messages=%d (was %d)", len(req.Messages), original))
+	}
+
+	return adjustments
+}
+
+// normalizeTopK fits top_k to the target model: COHERE accepts 0-500, Meta models ignore it, so
+// it is not sent to them, and other GENERIC models accept -1 or more. It returns the adjustment
+// made, if any.
+func normalizeTopK(req *types.ChatCompletionRequest) string {
+	if req.TopK == nil {
+		return ""
+	}
+	original := *req.TopK
+	if strings.HasPrefix(strings.ToLower(req.Model), "meta.") {
+		req.TopK = nil
+		return fmt.Sprintf("top_k=ignored (was %d)", original)
+	}
+
+	topK := original
+	if containsIgnoreCase(req.Model, "cohere") {
+		if topK < 0 {
+			topK = 0
+		} else if topK > maxCohereTopK {
+			topK = maxCohereTopK
+		}
+	} else if topK < minGenericTopK {
+		topK = minGenericTopK
+	}
+	if topK == original {
+		return ""
+	}
+	req.TopK = &topK
+	return fmt.Sprintf("top_k=%d (was %d)", topK, original)
+}
+
+// clamp limits value to the range [low, high].
+func clamp(value, low, high float64) float64 {
+	if value < low {
+		return low
+	}
+	if value > high {
+		return high
+	}
+	return value
+}
+
+// mergeConsecutiveMessages merges adjacent messages with the same role into one, joining their
+// content with separator. Tool results and messages carrying tool calls are never merged.
+func mergeConsecutiveMessages(messages []types.ChatCompletionMessage, separator string) []types.ChatCompletionMessage {
+	merged := make([]types.ChatCompletionMessage, 0, len(messages))
+	for _, msg := range messages {
+		if len(merged) > 0 {
+			prev := &merged[len(merged)-1]
+			if strings.EqualFold(prev.Role, msg.Role) && mergeable(*prev) && mergeable(msg) {
+				if len(prev.Parts) > 0 || len(msg.Parts) > 0 {
+					prev.Parts = append(append(contentParts(*prev), types.ContentPart{Type: "text", Text: separator}), contentParts(msg)...)
+				}
+				prev.Content = prev.Content + separator + msg.Content
+				continue
+			}
+		}
+		merged = append(merged, msg)
+	}
+	return merged
+}
+
+// mergeable reports whether a message can be merged with a neighbour of the same role.
+func mergeable(msg types.ChatCompletionMessage) bool {
+	return !isToolMessage(msg) && len(msg.ToolCalls) == 0
+}
+
+// contentParts returns the message content as parts, converting plain text content to a text part.
+func contentParts(msg types.ChatCompletionMessage) []types.ContentPart {
+	if len(msg.Parts) > 0 {
+		return msg.Parts
+	}
+	return []types.ContentPart{{Type: "text", Text: msg.Content}}
+}
+
+// truncateHistory keeps the system messages and the most recent messages, up to limit in total.
+// Tool results are never separated from the assistant message that requested them.
+func truncateHistory(messages []types.ChatCompletionMessage, limit int) []types.ChatCompletionMessage {
+	var system []types.ChatCompletionMessage
+	var conversation []types.ChatCompletionMessage
+	for _, msg := range messages {
+		if strings.EqualFold(msg.Role, "system") {
+			system = append(system, msg)
+		} else {
+			conversation = append(conversation, msg)
+		}
+	}
+
+	keep := limit - len(system)
+	if keep < 1 {
+		keep = 1
+	}
+	if keep < len(conversation) {
+		start := len(conversation) - keep
+		for start < len(conversation)-1 && isToolMessage(conversation[start]) {
+			start++
+		}
+		conversation = conversation[start:]
+	}
+
+	return append(system, conversation...)
+}
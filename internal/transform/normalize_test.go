@@ -0,0 +1,253 @@
+package transform
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/zalbiraw/ociaitoopenai/internal/config"
+	"github.com/zalbiraw/ociaitoopenai/pkg/types"
+)
+
+func TestNormalize_ClampsParameters(t *testing.T) {
+	cfg := config.New()
+	cfg.MaxTokensLimit = 4000
+	transformer := New(cfg)
+
+	req := types.ChatCompletionRequest{
+		Model:       "cohere.command-r-plus",
+		MaxTokens:   8000,
+		Temperature: float(1.5),
+	}
+	adjustments := transformer.Normalize(&req)
+
+	if req.MaxTokens != 4000 || *req.Temperature != 1 {
+		t.Errorf("expected max_tokens 4000 and temperature 1, got %d and %v", req.MaxTokens, *req.Temperature)
+	}
+	expected := "max_tokens=4000 (was 8000); temperature=1 (was 1.5)"
+	if got := strings.Join(adjustments, "; "); got != expected {
+		t.Errorf("expected adjustments %q, got %q", expected, got)
+	}
+
+	generic := types.ChatCompletionRequest{Model: "meta.llama-3.3-70b-instruct", Temperature: float(1.5)}
+	if adjustments := transformer.Normalize(&generic); len(adjustments) != 0 {
+		t.Errorf("expected no adjustments for a valid GENERIC request, got %v", adjustments)
+	}
+}
+
+func TestNormalize_SamplingPolicy(t *testing.T) {
+	cfg := config.New()
+	transformer := New(cfg)
+
+	req := types.ChatCompletionRequest{Model: "meta.llama-3.3-70b-instruct", Temperature: float(-0.5), TopP: 1.2}
+	adjustments := transformer.Normalize(&req)
+
+	expected := "temperature=0 (was -0.5); top_p=1 (was 1.2)"
+	if got := strings.Join(adjustments, "; "); got != expected {
+		t.Errorf("expected adjustments %q, got %q", expected, got)
+	}
+
+	cfg.SamplingPolicy = config.SamplingPolicyScale
+	cohere := types.ChatCompletionRequest{Model: "cohere.command-r-plus", Temperature: float(1.5)}
+	adjustments = transformer.Normalize(&cohere)
+
+	if *cohere.Temperature != 0.75 {
+		t.Errorf("expected temperature scaled to 0.75, got %v", *cohere.Temperature)
+	}
+	if len(adjustments) != 1 || adjustments[0] != "temperature=0.75 (was 1.5)" {
+		t.Errorf("unexpected adjustments: %v", adjustments)
+	}
+}
+
+func TestNormalize_DropsLogitBias(t *testing.T) {
+	transformer := New(config.New())
+
+	req := types.ChatCompletionRequest{Model: "meta.llama-3.3-70b-instruct", LogitBias: map[string]float64{"50256": -100}}
+	adjustments := transformer.Normalize(&req)
+
+	if req.LogitBias != nil {
+		t.Errorf("expected logit_bias to be dropped, got %v", req.LogitBias)
+	}
+	if len(adjustments) != 1 || adjustments[0] != "logit_bias=ignored (unsupported by OCI)" {
+		t.Errorf("unexpected adjustments: %v", adjustments)
+	}
+}
+
+func TestNormalize_DropsPrediction(t *testing.T) {
+	transformer := New(config.New())
+
+	req := types.ChatCompletionRequest{
+		Model:      "meta.llama-3.3-70b-instruct",
+		Prediction: json.RawMessage(`{"type":"content","content":"func main() {}"}`),
+	}
+	adjustments := transformer.Normalize(&req)
+
+	if req.Prediction != nil {
+		t.Errorf("expected prediction to be dropped, got %s", req.Prediction)
+	}
+	if len(adjustments) != 1 || adjustments[0] != "prediction=ignored (unsupported by OCI)" {
+		t.Errorf("unexpected adjustments: %v", adjustments)
+	}
+
+	req.Prediction = json.RawMessage(`null`)
+	if adjustments := transformer.Normalize(&req); len(adjustments) != 0 {
+		t.Errorf("expected a null prediction to be dropped silently, got %v", adjustments)
+	}
+}
+
+func TestNormalize_TopKByVendor(t *testing.T) {
+	transformer := New(config.New())
+
+	messages := []types.ChatCompletionMessage{{Role: "user", Content: "Hello"}}
+	cohere := types.ChatCompletionRequest{Model: "cohere.command-r-plus", Messages: messages, TopK: integer(800)}
+	if adjustments := transformer.Normalize(&cohere); len(adjustments) != 1 || adjustments[0] != "top_k=500 (was 800)" {
+		t.Errorf("unexpected adjustments: %v", adjustments)
+	}
+	if ociReq := transformer.ToOracleCloudRequest(cohere); ociReq.ChatRequest.TopK == nil || *ociReq.ChatRequest.TopK != 500 {
+		t.Errorf("expected topK 500, got %v", ociReq.ChatRequest.TopK)
+	}
+
+	meta := types.ChatCompletionRequest{Model: "meta.llama-3.3-70b-instruct", Messages: messages, TopK: integer(40)}
+	if adjustments := transformer.Normalize(&meta); len(adjustments) != 1 || adjustments[0] != "top_k=ignored (was 40)" {
+		t.Errorf("unexpected adjustments: %v", adjustments)
+	}
+	body, err := json.Marshal(transformer.ToOracleCloudRequest(meta))
+	if err != nil {
+		t.Fatalf("failed to marshal request: %v", err)
+	}
+	if strings.Contains(string(body), "topK") || strings.Contains(string(body), "topP") {
+		t.Errorf("expected no sampling parameters for a Meta model, got %s", body)
+	}
+
+	generic := types.ChatCompletionRequest{Model: "xai.grok-3", TopK: integer(-5)}
+	if adjustments := transformer.Normalize(&generic); len(adjustments) != 1 || adjustments[0] != "top_k=-1 (was -5)" {
+		t.Errorf("unexpected adjustments: %v", adjustments)
+	}
+}
+
+func TestNormalize_LanguageScaling(t *testing.T) {
+	cfg := config.New()
+	cfg.DefaultMaxTokens = 1000
+	cfg.LanguageScaling.Enabled = true
+	transformer := New(cfg)
+
+	tests := map[string]int{
+		"What is the capital of France?": 1000,
+		"フランスの首都はどこですか？":                 1000 * 2,
+		"Какая столица Франции?":         1000 * 3 / 2,
+	}
+	for prompt, expected := range tests {
+		req := types.ChatCompletionRequest{Model: "meta.llama-3.3-70b-instruct", Messages: []types.ChatCompletionMessage{{Role: "user", Content: prompt}}}
+		transformer.Normalize(&req)
+		if req.MaxTokens != expected {
+			t.Errorf("%s: expected max_tokens %d, got %d", prompt, expected, req.MaxTokens)
+		}
+	}
+
+	req := types.ChatCompletionRequest{Model: "meta.llama-3.3-70b-instruct", MaxTokens: 10, Messages: []types.ChatCompletionMessage{{Role: "user", Content: "你好"}}}
+	if adjustments := transformer.Normalize(&req); req.MaxTokens != 10 || len(adjustments) != 0 {
+		t.Errorf("expected the client's max_tokens to be kept, got %d and %v", req.MaxTokens, adjustments)
+	}
+
+	cfg.MaxTokensLimit = 1500
+	req = types.ChatCompletionRequest{Model: "meta.llama-3.3-70b-instruct", Messages: []types.ChatCompletionMessage{{Role: "user", Content: "你好"}}}
+	expected := "max_tokens=2000 (default scaled for cjk); max_tokens=1500 (was 2000)"
+	if got := strings.Join(transformer.Normalize(&req), "; "); got != expected {
+		t.Errorf("expected adjustments %q, got %q", expected, got)
+	}
+}
+
+func TestNormalize_TruncatesHistory(t *testing.T) {
+	cfg := config.New()
+	cfg.MaxHistoryMessages = 3
+	transformer := New(cfg)
+
+	req := types.ChatCompletionRequest{
+		Model: "meta.llama-3.3-70b-instruct",
+		Messages: []types.ChatCompletionMessage{
+			{Role: "system", Content: "Be brief."},
+			{Role: "user", Content: "one"},
+			{Role: "assistant", ToolCalls: []types.ToolCall{{ID: "call_1"}}},
+			{Role: "tool", ToolCallID: "call_1", Content: "result"},
+			{Role: "user", Content: "two"},
+		},
+	}
+	adjustments := transformer.Normalize(&req)
+
+	if len(adjustments) != 1 || adjustments[0] != "messages=2 (was 5)" {
+		t.Errorf("unexpected adjustments: %v", adjustments)
+	}
+	if req.Messages[0].Role != "system" || req.Messages[1].Content != "two" {
+		t.Errorf("expected system message and latest turn without orphaned tool result, got %+v", req.Messages)
+	}
+}
+
+func TestNormalize_MergesConsecutiveMessages(t *testing.T) {
+	cfg := config.New()
+	cfg.MergeConsecutiveMessages = true
+	transformer := New(cfg)
+
+	req := types.ChatCompletionRequest{
+		Model: "cohere.command-r-plus",
+		Messages: []types.ChatCompletionMessage{
+			{Role: "user", Content: "First"},
+			{Role: "user", Content: "Second"},
+			{Role: "assistant", ToolCalls: []types.ToolCall{{ID: "call_1"}}},
+			{Role: "tool", ToolCallID: "call_1", Content: "a"},
+			{Role: "tool", ToolCallID: "call_2", Content: "b"},
+			{Role: "user", Content: "Third"},
+		},
+	}
+	adjustments := transformer.Normalize(&req)
+
+	if len(req.Messages) != 5 {
+		t.Fatalf("expected 5 messages, got %d", len(req.Messages))
+	}
+	if req.Messages[0].Content != "First\n\nSecond" {
+		t.Errorf("expected merged user content, got %q", req.Messages[0].Content)
+	}
+	if len(adjustments) != 1 || adjustments[0] != "merged_messages=1" {
+		t.Errorf("unexpected adjustments: %v", adjustments)
+	}
+}
+
+func TestNormalize_ReasoningModelParameters(t *testing.T) {
+	cfg := config.New()
+	transformer := New(cfg)
+
+	req := types.ChatCompletionRequest{
+		Model:               "meta.llama-3.3-70b-instruct",
+		MaxTokens:           100,
+		MaxCompletionTokens: 2000,
+		ReasoningEffort:     "high",
+		Messages: []types.ChatCompletionMessage{
+			{Role: "developer", Content: "Be brief"},
+			{Role: "user", Content: "Hello"},
+		},
+	}
+	adjustments := transformer.Normalize(&req)
+
+	if req.MaxTokens != 2000 || req.Temperature != nil || req.Messages[0].Role != "system" {
+		t.Errorf("expected max_completion_tokens, no temperature and a system message, got %+v", req)
+	}
+	if req.ReasoningEffort != "" || len(adjustments) != 1 || adjustments[0] != "reasoning_effort=ignored (was high)" {
+		t.Errorf("expected reasoning_effort to be dropped, got %q and %v", req.ReasoningEffort, adjustments)
+	}
+
+	cfg.ReasoningEffort = config.ReasoningEffortForward
+	req.ReasoningEffort = "low"
+	if adjustments := transformer.Normalize(&req); len(adjustments) != 0 || req.ReasoningEffort != "low" {
+		t.Errorf("expected reasoning_effort to be kept, got %q and %v", req.ReasoningEffort, adjustments)
+	}
+	if ociReq := transformer.ToOracleCloudRequest(req); ociReq.ChatRequest.ReasoningEffort != "LOW" || ociReq.ChatRequest.Temperature != nil {
+		t.Errorf("expected reasoningEffort LOW without a temperature, got %+v", ociReq.ChatRequest)
+	}
+}
+
+func float(v float64) *float64 {
+	return &v
+}
+
+func integer(v int) *int {
+	return &v
+}
@@ -0,0 +1,79 @@
+package transform
+
+import (
+	"strings"
+
+	"github.com/zalbiraw/ociaitoopenai/pkg/types"
+)
+
+// defaultModelOwner is reported for models without a vendor, unless modelOwners maps them.
+const defaultModelOwner = "oracle"
+
+// ModelOwner returns the owned_by reported for an OCI vendor, as mapped by modelOwners.
+func (t *Transformer) ModelOwner(vendor string) string {
+	for key, owner := range t.config.ModelOwners {
+		if strings.EqualFold(key, vendor) {
+			return owner
+		}
+	}
+	if vendor == "" {
+		return defaultModelOwner
+	}
+	return vendor
+}
+
+// ModelVendor returns the OCI vendor whose models are owned by owner, so a models listing can be
+// filtered by OCI. It reports false when no single vendor maps to the owner.
+func (t *Transformer) ModelVendor(owner string) (string, bool) {
+	var vendors []string
+	listed := false
+	for key, mapped := range t.config.ModelOwners {
+		if strings.EqualFold(mapped, owner) {
+			vendors = append(vendors, key)
+		}
+		if strings.EqualFold(key, owner) {
+			listed = true
+		}
+	}
+	if len(vendors) == 0 && !listed && !strings.EqualFold(owner, defaultModelOwner) {
+		// Unlisted vendors are owned by themselves
+		vendors = append(vendors, owner)
+	}
+	if len(vendors) != 1 || vendors[0] == "" {
+		return "", false
+	}
+	return vendors[0], true
+}
+
+// lineage returns the names of the parent and root base model of a fine-tuned model, both empty
+// for base models, and the vendor of the model, taken from its lineage when OCI reports none.
+// Base models missing from models are named by their OCID.
+func lineage(model types.OCIModel, models map[string]types.OCIModel) (parent, root, vendor string) {
+	vendor = model.Vendor
+	seen := map[string]bool{model.ID: true}
+	for model.BaseModelID != nil && *model.BaseModelID != "" {
+		id := *model.BaseModelID
+		if seen[id] {
+			break
+		}
+		seen[id] = true
+
+		base, ok := models[id]
+		if !ok {
+			root = id
+			if parent == "" {
+				parent = id
+			}
+			break
+		}
+		root = base.DisplayName
+		if parent == "" {
+			parent = base.DisplayName
+		}
+		if vendor == "" {
+			vendor = base.Vendor
+		}
+		model = base
+	}
+	return parent, root, vendor
+}
@@ -0,0 +1,63 @@
+package transform
+
+import (
+	"testing"
+
+	"github.com/zalbiraw/ociaitoopenai/internal/config"
+	"github.com/zalbiraw/ociaitoopenai/pkg/types"
+)
+
+func TestToOpenAIModelsResponse_OwnersAndLineage(t *testing.T) {
+	cfg := config.New()
+	cfg.ModelOwners = map[string]string{"Meta": "meta-llama", "": "acme"}
+	transformer := New(cfg)
+
+	base, tuned, missing := "ocid1.base", "ocid1.tuned", "ocid1.missing"
+	resp := transformer.ToOpenAIModelsResponse(types.OCIModelsResponse{Items: []types.OCIModel{
+		{ID: base, DisplayName: "meta.llama-3.3-70b-instruct", Vendor: "meta", LifecycleState: "ACTIVE"},
+		{ID: tuned, DisplayName: "support-bot", BaseModelID: &base, LifecycleState: "ACTIVE"},
+		{ID: "ocid1.tuned2", DisplayName: "support-bot-v2", BaseModelID: &tuned, LifecycleState: "ACTIVE"},
+		{ID: "ocid1.orphan", DisplayName: "orphan", BaseModelID: &missing, LifecycleState: "ACTIVE"},
+		{ID: "ocid1.cohere", DisplayName: "cohere.command-r-plus", Vendor: "cohere", LifecycleState: "ACTIVE"},
+		{ID: "ocid1.other", DisplayName: "other", LifecycleState: "ACTIVE"},
+	}})
+
+	want := []types.OpenAIModel{
+		{ID: "meta.llama-3.3-70b-instruct", OwnedBy: "meta-llama"},
+		{ID: "support-bot", OwnedBy: "meta-llama", Parent: "meta.llama-3.3-70b-instruct", Root: "meta.llama-3.3-70b-instruct"},
+		{ID: "support-bot-v2", OwnedBy: "meta-llama", Parent: "support-bot", Root: "meta.llama-3.3-70b-instruct"},
+		{ID: "orphan", OwnedBy: "acme", Parent: missing, Root: missing},
+		{ID: "cohere.command-r-plus", OwnedBy: "cohere"},
+	}
+	if len(resp.Data) != len(want) {
+		t.Fatalf("expected %d models, got %+v", len(want), resp.Data)
+	}
+	for i, model := range resp.Data {
+		if model.ID != want[i].ID || model.OwnedBy != want[i].OwnedBy || model.Parent != want[i].Parent || model.Root != want[i].Root {
+			t.Errorf("expected %+v, got %+v", want[i], model)
+		}
+	}
+}
+
+func TestModelVendor(t *testing.T) {
+	cfg := config.New()
+	cfg.ModelOwners = map[string]string{"meta": "meta-llama", "xai": "grok", "grok-labs": "grok"}
+	transformer := New(cfg)
+
+	tests := map[string]struct {
+		vendor string
+		ok     bool
+	}{
+		"meta-llama": {"meta", true},
+		"cohere":     {"cohere", true},
+		"grok":       {"", false}, // Several vendors
+		"meta":       {"", false}, // Reported as meta-llama
+		"oracle":     {"", false}, // Models without a vendor
+	}
+	for owner, tt := range tests {
+		vendor, ok := transformer.ModelVendor(owner)
+		if vendor != tt.vendor || ok != tt.ok {
+			t.Errorf("ModelVendor(%q) = %q, %v; expected %q, %v", owner, vendor, ok, tt.vendor, tt.ok)
+		}
+	}
+}
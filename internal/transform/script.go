@@ -0,0 +1,54 @@
+package transform
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/zalbiraw/ociaitoopenai/internal/config"
+	"github.com/zalbiraw/ociaitoopenai/pkg/types"
+)
+
+// scriptTables are the Unicode scripts counted by promptScript, by language scaling script.
+var scriptTables = map[string][]*unicode.RangeTable{
+	config.ScriptCJK:        {unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul},
+	config.ScriptCyrillic:   {unicode.Cyrillic},
+	config.ScriptArabic:     {unicode.Arabic},
+	config.ScriptDevanagari: {unicode.Devanagari},
+	config.ScriptThai:       {unicode.Thai},
+}
+
+// promptScript returns the script most letters of the last user message are written in, or ""
+// when most are in another script, such as Latin. The last user message is the one answered,
+// so it best predicts the language of the answer.
+func promptScript(messages []types.ChatCompletionMessage) string {
+	var text string
+	for i := len(messages) - 1; i >= 0; i-- {
+		if strings.EqualFold(messages[i].Role, "user") {
+			text = messages[i].Content
+			break
+		}
+	}
+
+	counts := make(map[string]int, len(scriptTables)+1)
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		script := ""
+		for name, tables := range scriptTables {
+			if unicode.IsOneOf(tables, r) {
+				script = name
+				break
+			}
+		}
+		counts[script]++
+	}
+
+	dominant := ""
+	for script, count := range counts {
+		if count > counts[dominant] || (count == counts[dominant] && script < dominant) {
+			dominant = script
+		}
+	}
+	return dominant
+}
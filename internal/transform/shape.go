@@ -0,0 +1,104 @@
+package transform
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// ShapeResponse reduces a marshalled OpenAI response for bandwidth-sensitive clients.
+// With responseShaping.fields set, only the listed dotted paths (e.g. "choices.message.content")
+// are kept; arrays are traversed transparently. With responseShaping.omitEmpty, null values,
+// empty strings and empty arrays or objects are removed. The body is returned unchanged when
+// shaping is not configured.
+func (t *Transformer) ShapeResponse(body []byte) ([]byte, error) {
+	shaping := t.config.ResponseShaping
+	if len(shaping.Fields) == 0 && !shaping.OmitEmpty {
+		return body, nil
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		return nil, err
+	}
+
+	if len(shaping.Fields) > 0 {
+		paths := make([][]string, 0, len(shaping.Fields))
+		for _, field := range shaping.Fields {
+			paths = append(paths, strings.Split(field, "."))
+		}
+		value = pruneFields(value, paths)
+	}
+	if shaping.OmitEmpty {
+		value, _ = omitEmpty(value)
+	}
+
+	return json.Marshal(value)
+}
+
+// pruneFields keeps only the object keys on the given paths.
+// A path that ends at a key keeps the whole value under it.
+func pruneFields(value interface{}, paths [][]string) interface{} {
+	switch v := value.(type) {
+	case []interface{}:
+		for i, item := range v {
+			v[i] = pruneFields(item, paths)
+		}
+		return v
+	case map[string]interface{}:
+		kept := make(map[string]interface{})
+		for key, child := range v {
+			var rest [][]string
+			keepAll := false
+			for _, path := range paths {
+				if path[0] != key {
+					continue
+				}
+				if len(path) == 1 {
+					keepAll = true
+					break
+				}
+				rest = append(rest, path[1:])
+			}
+			switch {
+			case keepAll:
+				kept[key] = child
+			case len(rest) > 0:
+				kept[key] = pruneFields(child, rest)
+			}
+		}
+		return kept
+	default:
+		return value
+	}
+}
+
+// omitEmpty removes null values, empty strings and empty collections.
+// It reports whether the value itself is empty.
+func omitEmpty(value interface{}) (interface{}, bool) {
+	switch v := value.(type) {
+	case nil:
+		return nil, true
+	case string:
+		return v, v == ""
+	case []interface{}:
+		items := make([]interface{}, 0, len(v))
+		for _, item := range v {
+			// Array items are kept even if empty so indexes stay meaningful
+			cleaned, _ := omitEmpty(item)
+			items = append(items, cleaned)
+		}
+		return items, len(items) == 0
+	case map[string]interface{}:
+		for key, child := range v {
+			cleaned, empty := omitEmpty(child)
+			if empty {
+				delete(v, key)
+				continue
+			}
+			v[key] = cleaned
+		}
+		return v, len(v) == 0
+	default:
+		return value, false
+	}
+}
@@ -0,0 +1,54 @@
+package transform
+
+import (
+	"testing"
+
+	"github.com/zalbiraw/ociaitoopenai/internal/config"
+)
+
+const shapeResponseBody = `{"id":"chatcmpl-1","object":"chat.completion","created":1,"model":"m",` +
+	`"choices":[{"index":0,"message":{"role":"assistant","content":"Hi","name":""},"finish_reason":"stop"}],` +
+	`"usage":{"prompt_tokens":1,"completion_tokens":1,"total_tokens":2},"system_fingerprint":null,"tools":[]}`
+
+func TestShapeResponse_Disabled(t *testing.T) {
+	transformer := New(config.New())
+
+	body, err := transformer.ShapeResponse([]byte(shapeResponseBody))
+	if err != nil || string(body) != shapeResponseBody {
+		t.Errorf("expected body to be unchanged, got %s (%v)", body, err)
+	}
+}
+
+func TestShapeResponse_OmitEmpty(t *testing.T) {
+	cfg := config.New()
+	cfg.ResponseShaping.OmitEmpty = true
+	transformer := New(cfg)
+
+	body, err := transformer.ShapeResponse([]byte(shapeResponseBody))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"choices":[{"finish_reason":"stop","index":0,"message":{"content":"Hi","role":"assistant"}}],` +
+		`"created":1,"id":"chatcmpl-1","model":"m","object":"chat.completion",` +
+		`"usage":{"completion_tokens":1,"prompt_tokens":1,"total_tokens":2}}`
+	if string(body) != expected {
+		t.Errorf("expected %s, got %s", expected, body)
+	}
+}
+
+func TestShapeResponse_Fields(t *testing.T) {
+	cfg := config.New()
+	cfg.ResponseShaping.Fields = []string{"id", "choices.message.content", "usage.total_tokens"}
+	transformer := New(cfg)
+
+	body, err := transformer.ShapeResponse([]byte(shapeResponseBody))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"choices":[{"message":{"content":"Hi"}}],"id":"chatcmpl-1","usage":{"total_tokens":2}}`
+	if string(body) != expected {
+		t.Errorf("expected %s, got %s", expected, body)
+	}
+}
@@ -0,0 +1,132 @@
+package transform
+
+import (
+	"github.com/zalbiraw/ociaitoopenai/pkg/types"
+)
+
+// Stream converts the events of a streamed OCI GenAI response into OpenAI chat.completion.chunk events.
+// A Stream is created per response and is not safe for concurrent use.
+type Stream struct {
+	transformer  *Transformer
+	id           string // Completion ID shared by every chunk, generated at stream start
+	created      int64  // Created timestamp shared by every chunk, taken at stream start
+	model        string
+	includeUsage bool
+	started      map[int]bool               // Choices that already sent their role
+	usage        *types.ChatCompletionUsage // Usage reported by OCI, nil until reported
+	finishReason string                     // OpenAI finish reason of the first choice, once it finished
+}
+
+// NewStream creates a converter for a streamed response to the given model.
+func (t *Transformer) NewStream(model string, includeUsage bool) *Stream {
+	return &Stream{
+		transformer:  t,
+		model:        model,
+		includeUsage: includeUsage,
+		started:      make(map[int]bool),
+	}
+}
+
+// Convert returns the OpenAI chunks for an OCI stream event, which may be none.
+func (s *Stream) Convert(event types.OracleCloudStreamEvent) []types.ChatCompletionChunk {
+	if event.Usage != nil {
+		s.usage = &types.ChatCompletionUsage{
+			PromptTokens:     event.Usage.PromptTokens,
+			CompletionTokens: event.Usage.CompletionTokens,
+			TotalTokens:      event.Usage.TotalTokens,
+		}
+		if s.usage.TotalTokens == 0 {
+			s.usage.TotalTokens = s.usage.PromptTokens + s.usage.CompletionTokens
+		}
+	}
+
+	text := event.Text
+	if event.Message != nil {
+		text = ""
+		for _, content := range event.Message.Content {
+			text += content.Text
+		}
+	}
+	// The final COHERE event repeats the whole response, which was already streamed
+	if event.APIFormat == "COHERE" && event.FinishReason != "" {
+		text = ""
+	}
+
+	// Like OpenAI's, the first chunk of a choice carries only its role, the following ones its
+	// content, and the last one only its finish reason
+	var chunks []types.ChatCompletionChunk
+	if !s.started[event.Index] {
+		s.started[event.Index] = true
+		chunks = append(chunks, s.chunk(event.Index, types.ChatCompletionDelta{Role: "assistant"}, nil))
+	}
+	if text != "" {
+		chunks = append(chunks, s.chunk(event.Index, types.ChatCompletionDelta{Content: text}, nil))
+	}
+
+	if event.FinishReason != "" {
+		finish := mapFinishReason(event.FinishReason)
+		if event.Index == 0 {
+			s.finishReason = finish
+		}
+		chunks = append(chunks, s.chunk(event.Index, types.ChatCompletionDelta{}, &finish))
+	}
+	return chunks
+}
+
+// Finish returns the trailing usage chunk, if usage was requested. When OCI reported no usage,
+// the chunk reports zero tokens rather than an estimate.
+func (s *Stream) Finish() []types.ChatCompletionChunk {
+	if !s.includeUsage {
+		return nil
+	}
+
+	s.Start("")
+	usage, _ := s.Usage()
+	return []types.ChatCompletionChunk{{
+		ID:      s.id,
+		Object:  "chat.completion.chunk",
+		Created: s.created,
+		Model:   s.model,
+		Choices: []types.ChatCompletionChunkChoice{},
+		Usage:   &usage,
+	}}
+}
+
+// Usage returns the token usage reported by OCI, and whether OCI reported any.
+func (s *Stream) Usage() (types.ChatCompletionUsage, bool) {
+	if s.usage != nil {
+		return *s.usage, true
+	}
+	return types.ChatCompletionUsage{}, false
+}
+
+// FinishReason returns the OpenAI finish reason of the first choice, or an empty string before it finished.
+func (s *Stream) FinishReason() string {
+	return s.finishReason
+}
+
+// Start generates the completion ID and created timestamp shared by every chunk of the stream,
+// given the OCI opc-request-id of the response, if known. It is called once the response headers
+// arrive; a stream converting events without it starts with the first chunk. Later calls have no effect.
+func (s *Stream) Start(requestID string) {
+	if s.id != "" {
+		return
+	}
+	s.id = s.transformer.ids.New(requestID)
+	s.created = s.transformer.now().Unix()
+}
+
+func (s *Stream) chunk(index int, delta types.ChatCompletionDelta, finishReason *string) types.ChatCompletionChunk {
+	s.Start("")
+	return types.ChatCompletionChunk{
+		ID:      s.id,
+		Object:  "chat.completion.chunk",
+		Created: s.created,
+		Model:   s.model,
+		Choices: []types.ChatCompletionChunkChoice{{
+			Index:        index,
+			Delta:        delta,
+			FinishReason: finishReason,
+		}},
+	}
+}
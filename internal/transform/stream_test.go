@@ -0,0 +1,149 @@
+package transform
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/zalbiraw/ociaitoopenai/internal/config"
+	"github.com/zalbiraw/ociaitoopenai/pkg/types"
+)
+
+func TestStream_GenericEvents(t *testing.T) {
+	stream := New(config.New()).NewStream("meta.llama-3.3-70b-instruct", true)
+
+	event := func(text string) types.OracleCloudStreamEvent {
+		return types.OracleCloudStreamEvent{
+			APIFormat: "GENERIC",
+			Message: &types.OracleGenericMessage{
+				Role:    "ASSISTANT",
+				Content: []types.OracleGenericContent{{Type: "TEXT", Text: text}},
+			},
+		}
+	}
+
+	first := stream.Convert(event("Hel"))
+	if len(first) != 2 || first[0].Choices[0].Delta.Role != "assistant" || first[0].Choices[0].Delta.Content != "" {
+		t.Fatalf("expected a role chunk followed by a content chunk, got %+v", first)
+	}
+	if first[1].Choices[0].Delta.Role != "" || first[1].Choices[0].Delta.Content != "Hel" {
+		t.Fatalf("expected content-only chunk, got %+v", first[1])
+	}
+
+	second := stream.Convert(event("lo"))
+	if len(second) != 1 || second[0].Choices[0].Delta.Role != "" || second[0].ID != first[0].ID {
+		t.Fatalf("expected content-only chunk with the same ID, got %+v", second)
+	}
+
+	final := stream.Convert(types.OracleCloudStreamEvent{
+		APIFormat:    "GENERIC",
+		FinishReason: "MAX_TOKENS",
+		Usage:        &types.OracleCloudUsage{PromptTokens: 5, CompletionTokens: 2},
+	})
+	if len(final) != 1 || final[0].Choices[0].FinishReason == nil || *final[0].Choices[0].FinishReason != "length" {
+		t.Fatalf("expected finish chunk with reason length, got %+v", final)
+	}
+
+	usage := stream.Finish()
+	if len(usage) != 1 || usage[0].Usage == nil || usage[0].Usage.TotalTokens != 7 || len(usage[0].Choices) != 0 {
+		t.Fatalf("expected usage chunk with 7 total tokens, got %+v", usage)
+	}
+}
+
+func TestStream_RequestID(t *testing.T) {
+	cfg := config.New()
+	cfg.IDStrategy = config.IDStrategyRequestID
+	stream := New(cfg).NewStream("cohere.command-r-plus", false)
+	stream.Start("ABC123")
+
+	chunks := stream.Convert(types.OracleCloudStreamEvent{APIFormat: "COHERE", Text: "Hi"})
+	if len(chunks) != 2 || chunks[0].ID != "chatcmpl-ABC123" || chunks[1].ID != chunks[0].ID {
+		t.Errorf("expected the request ID embedded, got %+v", chunks)
+	}
+}
+
+func TestStream_CohereFinalEventDoesNotRepeatText(t *testing.T) {
+	stream := New(config.New()).NewStream("cohere.command-r-plus", false)
+
+	stream.Convert(types.OracleCloudStreamEvent{APIFormat: "COHERE", Text: "Hello"})
+	final := stream.Convert(types.OracleCloudStreamEvent{APIFormat: "COHERE", Text: "Hello", FinishReason: "COMPLETE"})
+
+	for _, chunk := range final {
+		if chunk.Choices[0].Delta.Content != "" {
+			t.Errorf("expected final COHERE event not to repeat text, got %q", chunk.Choices[0].Delta.Content)
+		}
+	}
+	if stream.Finish() != nil {
+		t.Error("expected no usage chunk when usage was not requested")
+	}
+	if usage, reported := stream.Usage(); reported || usage.CompletionTokens != 0 {
+		t.Errorf("expected no usage without a report from OCI, got %+v", usage)
+	}
+}
+
+func TestStream_ChunkSchema(t *testing.T) {
+	cfg := config.New()
+	cfg.IDStrategy = config.IDStrategySequential
+	cfg.FixedTime = "2024-01-01T00:00:00Z"
+	stream := New(cfg).NewStream("cohere.command-r-plus", false)
+
+	chunks := stream.Convert(types.OracleCloudStreamEvent{APIFormat: "COHERE", Text: "Hi"})
+	chunks = append(chunks, stream.Convert(types.OracleCloudStreamEvent{APIFormat: "COHERE", Text: "Hi", FinishReason: "COMPLETE"})...)
+
+	prefix := `{"id":"chatcmpl-1","object":"chat.completion.chunk","created":1704067200,"model":"cohere.command-r-plus","choices":[{"index":0,`
+	expected := []string{
+		prefix + `"delta":{"role":"assistant","content":""},"logprobs":null,"finish_reason":null}]}`,
+		prefix + `"delta":{"content":"Hi"},"logprobs":null,"finish_reason":null}]}`,
+		prefix + `"delta":{},"logprobs":null,"finish_reason":"stop"}]}`,
+	}
+	if len(chunks) != len(expected) {
+		t.Fatalf("expected %d chunks, got %d", len(expected), len(chunks))
+	}
+	for i, chunk := range chunks {
+		data, err := json.Marshal(chunk)
+		if err != nil {
+			t.Fatalf("failed to marshal chunk: %v", err)
+		}
+		if string(data) != expected[i] {
+			t.Errorf("chunk %d:\nexpected %s\ngot      %s", i, expected[i], data)
+		}
+	}
+}
+
+func TestStream_StableIDAndCreated(t *testing.T) {
+	cfg := config.New()
+	cfg.IDStrategy = config.IDStrategySequential
+	transformer := New(cfg)
+	now := time.Unix(100, 0)
+	transformer.SetClock(func() time.Time { return now })
+
+	stream := transformer.NewStream("meta.llama-3.3-70b-instruct", true)
+	now = time.Unix(200, 0)
+	stream.Start("")
+	stream.Start("ignored")
+
+	var chunks []types.ChatCompletionChunk
+	for _, text := range []string{"Hel", "lo"} {
+		now = now.Add(time.Second)
+		chunks = append(chunks, stream.Convert(types.OracleCloudStreamEvent{APIFormat: "COHERE", Text: text})...)
+	}
+	if stream.FinishReason() != "" {
+		t.Errorf("expected no finish reason before the stream finished, got %q", stream.FinishReason())
+	}
+	chunks = append(chunks, stream.Convert(types.OracleCloudStreamEvent{APIFormat: "COHERE", FinishReason: "COMPLETE"})...)
+	chunks = append(chunks, stream.Finish()...)
+	if stream.FinishReason() != "stop" {
+		t.Errorf("expected finish reason stop, got %q", stream.FinishReason())
+	}
+
+	for _, chunk := range chunks {
+		if chunk.ID != "chatcmpl-1" || chunk.Created != 200 || chunk.Object != "chat.completion.chunk" {
+			t.Errorf("expected every chunk to share the ID and created time of the stream start, got %+v", chunk)
+		}
+	}
+
+	// Non-streamed responses continue the same sequence
+	if id := transformer.ToOpenAIResponse(types.OracleCloudResponse{}, "model").ID; id != "chatcmpl-2" {
+		t.Errorf("expected the next sequential ID, got %q", id)
+	}
+}
@@ -0,0 +1,66 @@
+package transform
+
+import (
+	"strings"
+
+	"github.com/zalbiraw/ociaitoopenai/pkg/types"
+)
+
+// Summary describes how a chat request was changed on its way to OCI, without any message
+// content, so the changes can be logged and audited.
+type Summary struct {
+	Model       string            `json:"model"`
+	APIFormat   string            `json:"apiFormat"`
+	Roles       map[string]string `json:"roles,omitempty"` // OpenAI role to OCI role
+	Messages    int               `json:"messages"`        // Received from the client
+	Forwarded   int               `json:"forwarded"`       // Sent to OCI
+	Adjustments []string          `json:"adjustments,omitempty"`
+}
+
+// Summarize describes the transformation of a request into ociReq. Messages are the messages
+// the client sent, before normalization, and adjustments are those returned by Normalize.
+func (t *Transformer) Summarize(messages []types.ChatCompletionMessage, adjustments []string, ociReq types.OracleCloudRequest) Summary {
+	chat := ociReq.ChatRequest
+	summary := Summary{
+		Model:       ociReq.ServingMode.ModelID,
+		APIFormat:   chat.APIFormat,
+		Messages:    len(messages),
+		Adjustments: adjustments,
+	}
+
+	switch chat.APIFormat {
+	case apiFormatGeneric:
+		summary.Forwarded = len(chat.Messages)
+		summary.Roles = t.summaryRoles(apiFormatGeneric, messages)
+	case apiFormatCohere:
+		summary.Forwarded = len(chat.ChatHistory) + len(chat.ToolResults)
+		if chat.Message != "" {
+			summary.Forwarded++
+		}
+		summary.Roles = t.summaryRoles(apiFormatCohere, messages)
+	}
+	if _, ok := t.CustomVendor(summary.Model); ok {
+		// Custom vendors map roles themselves
+		summary.Roles = nil
+	}
+	return summary
+}
+
+// summaryRoles maps each distinct role of the messages to its OCI role in an API format.
+// Unless roleMappings maps them, developer messages are sent as system messages, as Normalize does.
+func (t *Transformer) summaryRoles(apiFormat string, messages []types.ChatCompletionMessage) map[string]string {
+	if len(messages) == 0 {
+		return nil
+	}
+	_, developerMapped := t.config.RoleMappings[apiFormat]["developer"]
+	roles := make(map[string]string)
+	for _, msg := range messages {
+		role := strings.ToLower(msg.Role)
+		if role == "developer" && !developerMapped {
+			roles[role] = t.role(apiFormat, "system")
+			continue
+		}
+		roles[role] = t.role(apiFormat, role)
+	}
+	return roles
+}
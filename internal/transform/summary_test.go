@@ -0,0 +1,61 @@
+package transform
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/zalbiraw/ociaitoopenai/internal/config"
+	"github.com/zalbiraw/ociaitoopenai/pkg/types"
+)
+
+func TestSummarize(t *testing.T) {
+	cfg := config.New()
+	cfg.MergeConsecutiveMessages = true
+	transformer := New(cfg)
+
+	tests := []struct {
+		model     string
+		format    string
+		roles     map[string]string
+		forwarded int
+	}{
+		{
+			model:     "meta.llama-3.3-70b-instruct",
+			format:    "GENERIC",
+			roles:     map[string]string{"developer": "ASSISTANT", "user": "USER"},
+			forwarded: 2,
+		},
+		{
+			model:     "cohere.command-r-plus",
+			format:    "COHERE",
+			roles:     map[string]string{"developer": "CHATBOT", "user": "USER"},
+			forwarded: 2,
+		},
+	}
+	for _, tt := range tests {
+		req := types.ChatCompletionRequest{
+			Model: tt.model,
+			Messages: []types.ChatCompletionMessage{
+				{Role: "developer", Content: "Be brief."},
+				{Role: "user", Content: "Hello"},
+				{Role: "user", Content: "Anyone there?"},
+			},
+		}
+		received := append([]types.ChatCompletionMessage(nil), req.Messages...)
+		adjustments := transformer.Normalize(&req)
+		summary := transformer.Summarize(received, adjustments, transformer.ToOracleCloudRequest(req))
+
+		if summary.Model != tt.model || summary.APIFormat != tt.format {
+			t.Errorf("%s: unexpected model or format in %+v", tt.model, summary)
+		}
+		if !reflect.DeepEqual(summary.Roles, tt.roles) {
+			t.Errorf("%s: expected roles %v, got %v", tt.model, tt.roles, summary.Roles)
+		}
+		if summary.Messages != 3 || summary.Forwarded != tt.forwarded {
+			t.Errorf("%s: expected 3 messages forwarded as %d, got %+v", tt.model, tt.forwarded, summary)
+		}
+		if !reflect.DeepEqual(summary.Adjustments, []string{"merged_messages=1"}) {
+			t.Errorf("%s: expected the merge to be listed, got %v", tt.model, summary.Adjustments)
+		}
+	}
+}
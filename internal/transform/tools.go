@@ -0,0 +1,250 @@
+package transform
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/zalbiraw/ociaitoopenai/pkg/types"
+)
+
+// toolPrompt introduces the tool catalogue in the emulation system prompt.
+const toolPrompt = `You can call the following tools. To call one or more tools, reply with only a JSON object of the form
+{"tool_calls": [{"name": "<tool name>", "arguments": {<arguments matching the tool parameters>}}]}
+and nothing else. If no tool is needed, answer normally in plain text.
+
+Tools:`
+
+// ToolEmulation carries the state of a request whose tools are emulated through prompting.
+type ToolEmulation struct {
+	tools map[string]bool
+}
+
+// EmulateTools rewrites a request with tools for a model without native tool support, when tool
+// emulation is enabled for the model. The tool catalogue is described in a system prompt, earlier
+// tool calls and results are rewritten as plain messages, and the tools are removed from the request.
+// It returns nil when the request is left unchanged.
+func (t *Transformer) EmulateTools(req *types.ChatCompletionRequest) *ToolEmulation {
+	if !t.config.ToolEmulation.Enabled || len(req.Tools) == 0 || !t.emulatesToolsFor(req.Model) {
+		return nil
+	}
+
+	choice := ToolChoice(req.ToolChoice)
+	tools := req.Tools
+	req.Tools = nil
+	req.ToolChoice = nil
+	if choice == "none" {
+		return nil
+	}
+
+	var prompt strings.Builder
+	prompt.WriteString(toolPrompt)
+	emulation := &ToolEmulation{tools: make(map[string]bool, len(tools))}
+	for _, tool := range tools {
+		emulation.tools[tool.Function.Name] = true
+		fmt.Fprintf(&prompt, "\n- %s", tool.Function.Name)
+		if tool.Function.Description != "" {
+			fmt.Fprintf(&prompt, ": %s", tool.Function.Description)
+		}
+		if len(tool.Function.Parameters) > 0 {
+			var compact bytes.Buffer
+			if err := json.Compact(&compact, tool.Function.Parameters); err == nil {
+				fmt.Fprintf(&prompt, "\n  parameters: %s", compact.String())
+			}
+		}
+	}
+	switch {
+	case choice == "required":
+		prompt.WriteString("\n\nYou must call at least one tool.")
+	case choice != "auto" && choice != "":
+		fmt.Fprintf(&prompt, "\n\nYou must call the %s tool.", choice)
+	}
+
+	messages := make([]types.ChatCompletionMessage, 0, len(req.Messages)+1)
+	if len(req.Messages) > 0 && strings.EqualFold(req.Messages[0].Role, "system") {
+		system := req.Messages[0]
+		system.Content += "\n\n" + prompt.String()
+		system.Parts = nil
+		messages = append(messages, system)
+		req.Messages = req.Messages[1:]
+	} else {
+		messages = append(messages, types.ChatCompletionMessage{Role: "system", Content: prompt.String()})
+	}
+	for i, msg := range req.Messages {
+		messages = append(messages, emulatedMessage(msg, req.Messages[:i]))
+	}
+	req.Messages = messages
+	return emulation
+}
+
+// emulatesToolsFor reports whether tool emulation is configured for the model.
+func (t *Transformer) emulatesToolsFor(model string) bool {
+	if len(t.config.ToolEmulation.Models) == 0 {
+		return true
+	}
+	for _, pattern := range t.config.ToolEmulation.Models {
+		if containsIgnoreCase(model, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// ToolChoice returns "none", "auto", "required", the name of a forced function, or "" when unset.
+func ToolChoice(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+	var mode string
+	if err := json.Unmarshal(raw, &mode); err == nil {
+		return mode
+	}
+	var forced struct {
+		Function struct {
+			Name string `json:"name"`
+		} `json:"function"`
+	}
+	if err := json.Unmarshal(raw, &forced); err == nil {
+		return forced.Function.Name
+	}
+	return ""
+}
+
+// emulatedMessage rewrites tool calls and tool results as plain messages.
+func emulatedMessage(msg types.ChatCompletionMessage, previous []types.ChatCompletionMessage) types.ChatCompletionMessage {
+	if isToolMessage(msg) {
+		name := ""
+		for _, prev := range previous {
+			for _, call := range prev.ToolCalls {
+				if call.ID == msg.ToolCallID {
+					name = call.Function.Name
+				}
+			}
+		}
+		return types.ChatCompletionMessage{
+			Role:    "user",
+			Content: fmt.Sprintf("Result of the %s tool call %s:\n%s", name, msg.ToolCallID, msg.Content),
+		}
+	}
+
+	if len(msg.ToolCalls) == 0 {
+		return msg
+	}
+	calls := make([]emulatedCall, 0, len(msg.ToolCalls))
+	for _, call := range msg.ToolCalls {
+		calls = append(calls, emulatedCall{Name: call.Function.Name, Arguments: decodeArguments(call.Function.Arguments)})
+	}
+	encoded, _ := json.Marshal(emulatedAnswer{ToolCalls: calls})
+	content := string(encoded)
+	if msg.Content != "" {
+		content = msg.Content + "\n" + content
+	}
+	return types.ChatCompletionMessage{Role: msg.Role, Content: content}
+}
+
+// emulatedAnswer is the JSON answer the model is asked to give to call tools.
+type emulatedAnswer struct {
+	ToolCalls []emulatedCall `json:"tool_calls"` //nolint:tagliatelle
+}
+
+type emulatedCall struct {
+	Name      string      `json:"name"`
+	Arguments interface{} `json:"arguments"`
+}
+
+// Parse extracts the tool calls from a model answer. It returns no calls for plain text answers,
+// and an error for answers that attempt a tool call but are not valid.
+func (e *ToolEmulation) Parse(text string) ([]types.ToolCall, error) {
+	trimmed := stripCodeFence(strings.TrimSpace(text))
+	if !strings.HasPrefix(trimmed, "{") {
+		return nil, nil
+	}
+
+	var answer struct {
+		ToolCalls []struct {
+			Name      string          `json:"name"`
+			Arguments json.RawMessage `json:"arguments"`
+		} `json:"tool_calls"` //nolint:tagliatelle
+	}
+	if err := json.Unmarshal([]byte(trimmed), &answer); err != nil {
+		if strings.Contains(trimmed, "tool_calls") {
+			return nil, fmt.Errorf("invalid JSON: %w", err)
+		}
+		return nil, nil
+	}
+
+	calls := make([]types.ToolCall, 0, len(answer.ToolCalls))
+	for _, call := range answer.ToolCalls {
+		if !e.tools[call.Name] {
+			return nil, fmt.Errorf("unknown tool %q", call.Name)
+		}
+		arguments := "{}"
+		if len(call.Arguments) > 0 && string(call.Arguments) != "null" {
+			// Arguments given as a JSON-encoded string are passed through as-is
+			var encoded string
+			if err := json.Unmarshal(call.Arguments, &encoded); err == nil {
+				arguments = encoded
+			} else {
+				arguments = string(call.Arguments)
+			}
+		}
+		if !json.Valid([]byte(arguments)) {
+			return nil, fmt.Errorf("invalid arguments for tool %q", call.Name)
+		}
+		calls = append(calls, types.ToolCall{
+			ID:       generateToolCallID(),
+			Type:     "function",
+			Function: types.ToolCallFunction{Name: call.Name, Arguments: arguments},
+		})
+	}
+	return calls, nil
+}
+
+// Reask extends the request with the malformed answer and a correction, asking the model to answer again.
+func (e *ToolEmulation) Reask(req *types.ChatCompletionRequest, answer string, err error) {
+	req.Messages = append(req.Messages,
+		types.ChatCompletionMessage{Role: "assistant", Content: answer},
+		types.ChatCompletionMessage{Role: "user", Content: fmt.Sprintf(
+			"Your previous reply was not a valid tool call (%v). Reply again with only the JSON object.", err)},
+	)
+}
+
+// Apply converts choices whose content is a tool invocation to OpenAI tool_calls.
+// Choices that cannot be parsed are left as text.
+func (e *ToolEmulation) Apply(resp *types.ChatCompletionResponse) {
+	for i := range resp.Choices {
+		choice := &resp.Choices[i]
+		calls, err := e.Parse(choice.Message.Content)
+		if err != nil || len(calls) == 0 {
+			continue
+		}
+		choice.Message.Content = ""
+		choice.Message.ToolCalls = calls
+		choice.FinishReason = "tool_calls"
+	}
+}
+
+// stripCodeFence removes a surrounding Markdown code fence, such as ```json ... ```.
+func stripCodeFence(text string) string {
+	if !strings.HasPrefix(text, "```") || !strings.HasSuffix(text, "```") || len(text) < 6 {
+		return text
+	}
+	inner := text[3 : len(text)-3]
+	if newline := strings.IndexByte(inner, '\n'); newline >= 0 {
+		inner = inner[newline+1:]
+	}
+	return strings.TrimSpace(inner)
+}
+
+// generateToolCallID generates an identifier for an emulated tool call, like OpenAI's call_XXXX.
+func generateToolCallID() string {
+	const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	b := make([]byte, 24)
+	_, _ = rand.Read(b)
+	for i := range b {
+		b[i] = charset[b[i]%byte(len(charset))]
+	}
+	return "call_" + string(b)
+}
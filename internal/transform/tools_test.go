@@ -0,0 +1,99 @@
+package transform
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/zalbiraw/ociaitoopenai/internal/config"
+	"github.com/zalbiraw/ociaitoopenai/pkg/types"
+)
+
+func weatherRequest() types.ChatCompletionRequest {
+	return types.ChatCompletionRequest{
+		Model: "meta.llama-3.3-70b-instruct",
+		Tools: []types.Tool{{Type: "function", Function: types.FunctionDefinition{
+			Name:        "get_weather",
+			Description: "Get the weather for a city",
+			Parameters:  json.RawMessage(`{"type": "object", "properties": {"city": {"type": "string"}}}`),
+		}}},
+		Messages: []types.ChatCompletionMessage{
+			{Role: "user", Content: "Weather in Paris?"},
+			{Role: "assistant", ToolCalls: []types.ToolCall{{ID: "call_1", Type: "function", Function: types.ToolCallFunction{Name: "get_weather", Arguments: `{"city":"Paris"}`}}}},
+			{Role: "tool", ToolCallID: "call_1", Content: "Sunny"},
+		},
+	}
+}
+
+func TestEmulateTools(t *testing.T) {
+	cfg := config.New()
+	cfg.ToolEmulation.Enabled = true
+	cfg.ToolEmulation.Models = []string{"llama"}
+	transformer := New(cfg)
+
+	req := weatherRequest()
+	if transformer.EmulateTools(&req) == nil {
+		t.Fatal("expected tools to be emulated")
+	}
+
+	if req.Tools != nil {
+		t.Error("expected tools to be removed from the request")
+	}
+	if len(req.Messages) != 4 || req.Messages[0].Role != "system" || !strings.Contains(req.Messages[0].Content, `get_weather: Get the weather for a city`) {
+		t.Fatalf("expected a tool catalogue system prompt, got %+v", req.Messages)
+	}
+	if req.Messages[2].Content != `{"tool_calls":[{"name":"get_weather","arguments":{"city":"Paris"}}]}` || len(req.Messages[2].ToolCalls) != 0 {
+		t.Errorf("expected the tool call rewritten as text, got %+v", req.Messages[2])
+	}
+	if req.Messages[3].Role != "user" || !strings.Contains(req.Messages[3].Content, "Sunny") {
+		t.Errorf("expected the tool result rewritten as a user message, got %+v", req.Messages[3])
+	}
+
+	cohere := weatherRequest()
+	cohere.Model = "cohere.command-r-plus"
+	if transformer.EmulateTools(&cohere) != nil {
+		t.Error("expected no emulation for a model that is not listed")
+	}
+}
+
+func TestToolEmulation_Parse(t *testing.T) {
+	cfg := config.New()
+	cfg.ToolEmulation.Enabled = true
+	req := weatherRequest()
+	emulation := New(cfg).EmulateTools(&req)
+
+	calls, err := emulation.Parse("```json\n{\"tool_calls\": [{\"name\": \"get_weather\", \"arguments\": {\"city\": \"Oslo\"}}]}\n```")
+	if err != nil || len(calls) != 1 || calls[0].Function.Arguments != `{"city": "Oslo"}` || !strings.HasPrefix(calls[0].ID, "call_") {
+		t.Errorf("expected a get_weather call, got %+v, %v", calls, err)
+	}
+
+	if calls, err := emulation.Parse("It is sunny."); err != nil || calls != nil {
+		t.Errorf("expected a plain answer, got %+v, %v", calls, err)
+	}
+
+	if _, err := emulation.Parse(`{"tool_calls": [{"name": "get_weather", "arguments": {"city": }]}`); err == nil {
+		t.Error("expected an error for malformed JSON")
+	}
+
+	if _, err := emulation.Parse(`{"tool_calls": [{"name": "book_flight"}]}`); err == nil {
+		t.Error("expected an error for an unknown tool")
+	}
+}
+
+func TestToolEmulation_Apply(t *testing.T) {
+	cfg := config.New()
+	cfg.ToolEmulation.Enabled = true
+	req := weatherRequest()
+	emulation := New(cfg).EmulateTools(&req)
+
+	resp := types.ChatCompletionResponse{Choices: []types.ChatCompletionChoice{{
+		Message:      types.ChatCompletionMessage{Role: "assistant", Content: `{"tool_calls": [{"name": "get_weather", "arguments": {"city": "Rome"}}]}`},
+		FinishReason: "stop",
+	}}}
+	emulation.Apply(&resp)
+
+	choice := resp.Choices[0]
+	if choice.FinishReason != "tool_calls" || choice.Message.Content != "" || len(choice.Message.ToolCalls) != 1 {
+		t.Errorf("expected a tool_calls choice, got %+v", choice)
+	}
+}
@@ -3,8 +3,7 @@
 package transform
 
 import (
-	"crypto/rand"
-	"fmt"
+	"encoding/json"
 	"strings"
 	"time"
 
@@ -12,16 +11,44 @@ import (
 	"github.com/zalbiraw/ociaitoopenai/pkg/types"
 )
 
+// OCI API formats.
+const (
+	apiFormatGeneric = "GENERIC"
+	apiFormatCohere  = "COHERE"
+)
+
 // Transformer handles the conversion between different API formats.
 type Transformer struct {
-	config *config.Config
+	config *config.Config   // Plugin configuration
+	now    func() time.Time // Clock for created timestamps
+	ids    *IDs             // Completion ID generator
 }
 
 // New creates a new transformer with the given configuration.
 func New(cfg *config.Config) *Transformer {
-	return &Transformer{
+	t := &Transformer{
 		config: cfg,
+		now:    time.Now,
+		ids:    NewIDs(cfg.IDStrategy),
+	}
+
+	if cfg.FixedTime != "" {
+		if fixed, err := time.Parse(time.RFC3339, cfg.FixedTime); err == nil {
+			t.now = func() time.Time { return fixed }
+		}
 	}
+	return t
+}
+
+// SetClock replaces the clock used for created timestamps.
+func (t *Transformer) SetClock(now func() time.Time) {
+	t.now = now
+}
+
+// SetIDGenerator replaces the chat completion ID generator. It is given the OCI opc-request-id
+// of the response, or an empty string when there is none.
+func (t *Transformer) SetIDGenerator(newID func(requestID string) string) {
+	t.ids.generate = newID
 }
 
 // ToOracleCloudRequest converts an OpenAI ChatCompletion request to Oracle Cloud GenAI format.
@@ -33,6 +60,43 @@ func New(cfg *config.Config) *Transformer {
 // 3. Uses OpenAI request parameters if provided, otherwise falls back to config defaults
 // 4. Constructs the Oracle Cloud request structure with proper serving mode and chat parameters.
 func (t *Transformer) ToOracleCloudRequest(openAIReq types.ChatCompletionRequest) types.OracleCloudRequest {
+	ociReq := t.buildOracleCloudRequest(openAIReq)
+	ociReq.ChatRequest.Extra = openAIReq.Extra
+	t.applyDebugPrompt(&ociReq, openAIReq.DebugPrompt)
+	return ociReq
+}
+
+// applyDebugPrompt sets the OCI echo and raw-prompt flags when prompt debugging is requested and allowed.
+func (t *Transformer) applyDebugPrompt(ociReq *types.OracleCloudRequest, mode string) {
+	if !t.config.AllowPromptDebug {
+		return
+	}
+
+	switch strings.ToLower(mode) {
+	case "echo":
+		ociReq.ChatRequest.IsEcho = true
+	case "raw":
+		ociReq.ChatRequest.IsEcho = true
+		// Raw prompting is only available for the COHERE format
+		if ociReq.ChatRequest.APIFormat == "COHERE" {
+			ociReq.ChatRequest.IsRawPrompting = true
+		}
+	}
+}
+
+// buildOracleCloudRequest builds the OCI request for the format matching the requested model.
+func (t *Transformer) buildOracleCloudRequest(openAIReq types.ChatCompletionRequest) types.OracleCloudRequest {
+	if vendor, ok := t.CustomVendor(openAIReq.Model); ok {
+		return types.OracleCloudRequest{
+			CompartmentID: t.config.CompartmentID,
+			ServingMode: types.ServingMode{
+				ModelID:     openAIReq.Model,
+				ServingType: "ON_DEMAND",
+			},
+			ChatRequest: vendor.Formatter.FormatRequest(openAIReq),
+		}
+	}
+
 	if len(openAIReq.Messages) == 0 {
 		return types.OracleCloudRequest{
 			CompartmentID: t.config.CompartmentID,
@@ -42,7 +106,7 @@ func (t *Transformer) ToOracleCloudRequest(openAIReq types.ChatCompletionRequest
 			},
 			ChatRequest: types.ChatRequest{
 				MaxTokens:   openAIReq.MaxTokens,
-				Temperature: float64(openAIReq.Temperature),
+				Temperature: openAIReq.Temperature,
 				Message:     "",
 				APIFormat:   "COHERE",
 			},
@@ -58,20 +122,24 @@ func (t *Transformer) ToOracleCloudRequest(openAIReq types.ChatCompletionRequest
 		// COHERE format (legacy): chatHistory/message
 		var chatHistory []interface{}
 		var currentMessage string
+		var toolResults []interface{}
+		last := len(openAIReq.Messages) - 1
 		for i, msg := range openAIReq.Messages {
-			mappedRole := "CHATBOT"
-			if containsIgnoreCase(msg.Role, "user") {
-				mappedRole = "USER"
+			// Trailing tool messages are the results the model is waiting for
+			if isToolMessage(msg) && trailingToolMessages(openAIReq.Messages[i:]) {
+				toolResults = append(toolResults, cohereToolResult(msg, openAIReq.Messages[:i]))
+				continue
 			}
-			if i == len(openAIReq.Messages)-1 {
+			// A trailing assistant message is a prefill: it stays a CHATBOT turn and the user turn is empty
+			if i == last && len(msg.ToolCalls) == 0 && !isAssistantMessage(msg) {
 				currentMessage = msg.Content
-			} else {
-				historyEntry := map[string]interface{}{
-					"role":    mappedRole,
-					"message": msg.Content,
-				}
-				chatHistory = append(chatHistory, historyEntry)
+				continue
 			}
+			chatHistory = append(chatHistory, t.cohereHistoryEntry(msg, openAIReq.Messages[:i]))
+		}
+		// OCI already holds the history of a server-side conversation
+		if openAIReq.ConversationID != "" {
+			chatHistory = nil
 		}
 		return types.OracleCloudRequest{
 			CompartmentID: t.config.CompartmentID,
@@ -80,13 +148,16 @@ func (t *Transformer) ToOracleCloudRequest(openAIReq types.ChatCompletionRequest
 				ServingType: "ON_DEMAND",
 			},
 			ChatRequest: types.ChatRequest{
-				MaxTokens:   openAIReq.MaxTokens,
-				Temperature: float64(openAIReq.Temperature),
-				TopP:        float64(openAIReq.TopP),
-				IsStream:    false,
-				ChatHistory: chatHistory,
-				Message:     currentMessage,
-				APIFormat:   "COHERE",
+				MaxTokens:      openAIReq.MaxTokens,
+				Temperature:    openAIReq.Temperature,
+				TopP:           float64(openAIReq.TopP),
+				TopK:           openAIReq.TopK,
+				IsStream:       openAIReq.Stream,
+				ChatHistory:    chatHistory,
+				Message:        currentMessage,
+				ToolResults:    toolResults,
+				ConversationID: openAIReq.ConversationID,
+				APIFormat:      "COHERE",
 			},
 		}
 	}
@@ -94,20 +165,7 @@ func (t *Transformer) ToOracleCloudRequest(openAIReq types.ChatCompletionRequest
 	// GENERIC format: messages array with nested content
 	var genericMessages []interface{}
 	for _, msg := range openAIReq.Messages {
-		mappedRole := "ASSISTANT"
-		if containsIgnoreCase(msg.Role, "user") {
-			mappedRole = "USER"
-		}
-		contentArr := []map[string]interface{}{
-			{
-				"type": "TEXT",
-				"text": msg.Content,
-			},
-		}
-		genericMessages = append(genericMessages, map[string]interface{}{
-			"role":    mappedRole,
-			"content": contentArr,
-		})
+		genericMessages = append(genericMessages, t.genericMessage(msg))
 	}
 
 	return types.OracleCloudRequest{
@@ -117,16 +175,213 @@ func (t *Transformer) ToOracleCloudRequest(openAIReq types.ChatCompletionRequest
 			ServingType: "ON_DEMAND",
 		},
 		ChatRequest: types.ChatRequest{
-			MaxTokens:   openAIReq.MaxTokens,
-			Temperature: float64(openAIReq.Temperature),
-			TopP:        float64(openAIReq.TopP),
-			IsStream:    false,
-			APIFormat:   "GENERIC",
-			Messages:    genericMessages,
+			MaxTokens:       openAIReq.MaxTokens,
+			Temperature:     openAIReq.Temperature,
+			TopP:            float64(openAIReq.TopP),
+			TopK:            openAIReq.TopK,
+			IsStream:        openAIReq.Stream,
+			APIFormat:       "GENERIC",
+			Messages:        genericMessages,
+			ReasoningEffort: strings.ToUpper(openAIReq.ReasoningEffort),
 		},
 	}
 }
 
+// cohereHistoryEntry converts a prior conversation message to a COHERE chat history entry.
+// Assistant messages that only carry tool calls have no text, so the message is omitted
+// and the calls are sent as toolCalls instead.
+func (t *Transformer) cohereHistoryEntry(msg types.ChatCompletionMessage, previous []types.ChatCompletionMessage) map[string]interface{} {
+	if isToolMessage(msg) {
+		return map[string]interface{}{
+			"role":        t.role(apiFormatCohere, msg.Role),
+			"toolResults": []interface{}{cohereToolResult(msg, previous)},
+		}
+	}
+
+	entry := map[string]interface{}{
+		"role": t.role(apiFormatCohere, msg.Role),
+	}
+	if msg.Content != "" || len(msg.ToolCalls) == 0 {
+		entry["message"] = msg.Content
+	}
+	if len(msg.ToolCalls) > 0 {
+		calls := make([]interface{}, 0, len(msg.ToolCalls))
+		for _, call := range msg.ToolCalls {
+			calls = append(calls, cohereToolCall(call))
+		}
+		entry["toolCalls"] = calls
+	}
+	return entry
+}
+
+// cohereToolCall converts an OpenAI tool call to the COHERE name/parameters format.
+func cohereToolCall(call types.ToolCall) map[string]interface{} {
+	return map[string]interface{}{
+		"name":       call.Function.Name,
+		"parameters": decodeArguments(call.Function.Arguments),
+	}
+}
+
+// cohereToolResult converts a "tool" message to a COHERE tool result.
+// The originating call is looked up by tool_call_id in the previous messages.
+func cohereToolResult(msg types.ChatCompletionMessage, previous []types.ChatCompletionMessage) map[string]interface{} {
+	call := map[string]interface{}{
+		"name":       msg.Name,
+		"parameters": map[string]interface{}{},
+	}
+	for _, prev := range previous {
+		for _, tc := range prev.ToolCalls {
+			if tc.ID == msg.ToolCallID {
+				call = cohereToolCall(tc)
+			}
+		}
+	}
+
+	// COHERE expects outputs as objects; wrap plain text results
+	var output map[string]interface{}
+	if err := json.Unmarshal([]byte(msg.Content), &output); err != nil || output == nil {
+		output = map[string]interface{}{"result": msg.Content}
+	}
+
+	return map[string]interface{}{
+		"call":    call,
+		"outputs": []interface{}{output},
+	}
+}
+
+// genericMessage converts an OpenAI message to a GENERIC format message.
+// Text content is omitted for assistant messages that only carry tool calls.
+func (t *Transformer) genericMessage(msg types.ChatCompletionMessage) map[string]interface{} {
+	entry := map[string]interface{}{
+		"role": t.role(apiFormatGeneric, msg.Role),
+	}
+	if len(msg.Parts) > 0 {
+		entry["content"] = genericContent(msg.Parts)
+	} else if msg.Content != "" || len(msg.ToolCalls) == 0 {
+		entry["content"] = []map[string]interface{}{
+			{
+				"type": "TEXT",
+				"text": msg.Content,
+			},
+		}
+	}
+	if len(msg.ToolCalls) > 0 {
+		calls := make([]interface{}, 0, len(msg.ToolCalls))
+		for _, call := range msg.ToolCalls {
+			calls = append(calls, map[string]interface{}{
+				"id":        call.ID,
+				"type":      "FUNCTION",
+				"name":      call.Function.Name,
+				"arguments": call.Function.Arguments,
+			})
+		}
+		entry["toolCalls"] = calls
+	}
+	if isToolMessage(msg) {
+		entry["toolCallId"] = msg.ToolCallID
+	}
+	return entry
+}
+
+// genericContent converts OpenAI content parts to GENERIC TEXT and IMAGE content blocks.
+func genericContent(parts []types.ContentPart) []map[string]interface{} {
+	content := make([]map[string]interface{}, 0, len(parts))
+	for _, part := range parts {
+		switch {
+		case part.Type == "text":
+			content = append(content, map[string]interface{}{
+				"type": "TEXT",
+				"text": part.Text,
+			})
+		case part.Type == "image_url" && part.ImageURL != nil:
+			imageURL := map[string]interface{}{
+				"url": part.ImageURL.URL,
+			}
+			if part.ImageURL.Detail != "" {
+				imageURL["detail"] = strings.ToUpper(part.ImageURL.Detail)
+			}
+			content = append(content, map[string]interface{}{
+				"type":     "IMAGE",
+				"imageUrl": imageURL,
+			})
+		}
+	}
+	return content
+}
+
+// decodeArguments decodes JSON-encoded tool call arguments, returning an empty object on failure.
+func decodeArguments(arguments string) map[string]interface{} {
+	params := map[string]interface{}{}
+	if arguments != "" {
+		_ = json.Unmarshal([]byte(arguments), &params)
+	}
+	return params
+}
+
+// role maps an OpenAI role to its OCI role in an API format: the role configured in
+// roleMappings, if any, otherwise the built-in one.
+func (t *Transformer) role(apiFormat, role string) string {
+	if mapped, ok := t.config.RoleMappings[apiFormat][role]; ok {
+		return mapped
+	}
+	if apiFormat == apiFormatCohere {
+		return cohereRole(role)
+	}
+	return genericRole(role)
+}
+
+// apiFormat returns the OCI API format used for a model.
+func apiFormat(model string) string {
+	if containsIgnoreCase(model, "cohere") {
+		return apiFormatCohere
+	}
+	return apiFormatGeneric
+}
+
+// cohereRole maps an OpenAI role to its COHERE chat history role.
+func cohereRole(role string) string {
+	switch {
+	case strings.EqualFold(role, "tool"):
+		return "TOOL"
+	case containsIgnoreCase(role, "user"):
+		return "USER"
+	default:
+		return "CHATBOT"
+	}
+}
+
+// genericRole maps an OpenAI role to its GENERIC message role.
+func genericRole(role string) string {
+	switch {
+	case strings.EqualFold(role, "tool"):
+		return "TOOL"
+	case containsIgnoreCase(role, "user"):
+		return "USER"
+	default:
+		return "ASSISTANT"
+	}
+}
+
+// isAssistantMessage reports whether the message was written by the assistant.
+func isAssistantMessage(msg types.ChatCompletionMessage) bool {
+	return strings.EqualFold(msg.Role, "assistant")
+}
+
+// isToolMessage reports whether the message carries a tool result.
+func isToolMessage(msg types.ChatCompletionMessage) bool {
+	return strings.EqualFold(msg.Role, "tool")
+}
+
+// trailingToolMessages reports whether all the given messages are tool results.
+func trailingToolMessages(msgs []types.ChatCompletionMessage) bool {
+	for _, msg := range msgs {
+		if !isToolMessage(msg) {
+			return false
+		}
+	}
+	return true
+}
+
 func containsIgnoreCase(s, substr string) bool {
 	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
 }
@@ -142,7 +397,7 @@ func containsIgnoreCase(s, substr string) bool {
 func (t *Transformer) ToOpenAIResponse(oracleResp types.OracleCloudResponse, originalModel string) types.ChatCompletionResponse {
 
 	// Generate a unique ID for the completion
-	id := generateCompletionID()
+	id := t.ids.New(oracleResp.RequestID)
 
 	// Map finish reason from OCI to OpenAI format
 	finishReason := mapFinishReason(oracleResp.ChatResponse.FinishReason)
@@ -159,10 +414,15 @@ func (t *Transformer) ToOpenAIResponse(oracleResp types.OracleCloudResponse, ori
 			if c.FinishReason != "" {
 				finish = mapFinishReason(c.FinishReason)
 			}
+			guardrails := c.Guardrails
+			if guardrails == nil {
+				guardrails = oracleResp.ChatResponse.Guardrails
+			}
 			choicesOut = append(choicesOut, types.ChatCompletionChoice{
-				Index:        i,
-				Message:      types.ChatCompletionMessage{Role: "assistant", Content: msg},
-				FinishReason: finish,
+				Index:                i,
+				Message:              types.ChatCompletionMessage{Role: "assistant", Content: msg},
+				FinishReason:         finish,
+				ContentFilterResults: contentFilterResults(guardrails, finish),
 			})
 		}
 	}
@@ -170,9 +430,10 @@ func (t *Transformer) ToOpenAIResponse(oracleResp types.OracleCloudResponse, ori
 	if len(choicesOut) == 0 {
 		responseText := oracleResp.ChatResponse.Text
 		choicesOut = []types.ChatCompletionChoice{{
-			Index:        0,
-			Message:      types.ChatCompletionMessage{Role: "assistant", Content: responseText},
-			FinishReason: finishReason,
+			Index:                0,
+			Message:              types.ChatCompletionMessage{Role: "assistant", Content: responseText},
+			FinishReason:         finishReason,
+			ContentFilterResults: contentFilterResults(oracleResp.ChatResponse.Guardrails, finishReason),
 		}}
 	}
 
@@ -201,42 +462,69 @@ func (t *Transformer) ToOpenAIResponse(oracleResp types.OracleCloudResponse, ori
 	openAIResp := types.ChatCompletionResponse{
 		ID:      id,
 		Object:  "chat.completion",
-		Created: time.Now().Unix(),
+		Created: t.now().Unix(),
 		Model:   model,
 		Choices: choicesOut,
 		Usage:   usage,
+		Prompt:  oracleResp.ChatResponse.Prompt,
 	}
 
 	return openAIResp
 }
 
-// generateCompletionID generates a unique identifier for the completion.
-func generateCompletionID() string {
-	// Generate a random ID similar to OpenAI's format: chatcmpl-XXXXXX
-	const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-	b := make([]byte, 29)
-	for i := range b {
-		num := make([]byte, 1)
-		_, _ = rand.Read(num)
-		b[i] = charset[num[0]%byte(len(charset))]
-	}
-	return fmt.Sprintf("chatcmpl-%s", string(b))
-}
-
 // mapFinishReason maps Oracle Cloud finish reasons to OpenAI format.
+// COHERE reports upper-case reasons, GENERIC models mostly report OpenAI's own.
 func mapFinishReason(oracleReason string) string {
-	switch oracleReason {
+	switch strings.ToUpper(oracleReason) {
 	case "COMPLETE":
 		return "stop"
-	case "MAX_TOKENS":
+	case "MAX_TOKENS", "LENGTH":
 		return "length"
-	case "CONTENT_FILTER":
+	case "CONTENT_FILTER", "ERROR_TOXIC":
 		return "content_filter"
 	default:
 		return "stop" // Default to "stop" for unknown reasons
 	}
 }
 
+// guardrailThreshold is the score at or above which a guardrail category counts as detected.
+const guardrailThreshold = 0.5
+
+// contentFilterResults converts OCI guardrail results to OpenAI-style content filter results,
+// keyed by snake_case category name. It returns nil when OCI reported no guardrail results.
+func contentFilterResults(guardrails *types.OracleGuardrailResults, finishReason string) map[string]types.ContentFilterResult {
+	if guardrails == nil {
+		return nil
+	}
+
+	results := make(map[string]types.ContentFilterResult)
+	add := func(category string, score float64) {
+		key := strings.ReplaceAll(strings.ToLower(strings.TrimSpace(category)), " ", "_")
+		if existing, ok := results[key]; ok && existing.Score >= score {
+			return
+		}
+		detected := score >= guardrailThreshold
+		results[key] = types.ContentFilterResult{
+			Filtered: detected && finishReason == "content_filter",
+			Detected: detected,
+			Score:    score,
+		}
+	}
+
+	if guardrails.ContentModeration != nil {
+		for _, category := range guardrails.ContentModeration.Categories {
+			add(category.Name, category.Score)
+		}
+	}
+	if guardrails.PromptInjection != nil {
+		add("prompt_injection", guardrails.PromptInjection.Score)
+	}
+	for _, pii := range guardrails.PersonallyIdentifiableInformation {
+		add("personally_identifiable_information", pii.Score)
+	}
+	return results
+}
+
 func shouldFilterModel(owner string) bool {
 	if owner == "xai" || owner == "cohere" || owner == "meta" {
 		return false
@@ -244,26 +532,41 @@ func shouldFilterModel(owner string) bool {
 	return true
 }
 
-// ToOpenAIModelsResponse converts an OCI models response to OpenAI models format.
+// ToOpenAIModelsResponse converts an OCI models response to OpenAI models format. Fine-tuned
+// models take the vendor of their base model when OCI reports none, and report their lineage.
 func (t *Transformer) ToOpenAIModelsResponse(ociResp types.OCIModelsResponse) types.OpenAIModelsResponse {
 	var openAIModels []types.OpenAIModel
 
+	byID := make(map[string]types.OCIModel, len(ociResp.Items))
 	for _, ociModel := range ociResp.Items {
-		if ociModel.LifecycleState == "ACTIVE" && !shouldFilterModel(ociModel.Vendor) {
-			// Parse time created
-			created := time.Now().Unix() // Default to now if parsing fails
-			if parsedTime, err := time.Parse(time.RFC3339, ociModel.TimeCreated); err == nil {
-				created = parsedTime.Unix()
-			}
+		byID[ociModel.ID] = ociModel
+	}
 
-			openAIModel := types.OpenAIModel{
-				ID:      ociModel.DisplayName,
-				Object:  "model",
-				Created: created,
-				OwnedBy: ociModel.Vendor,
-			}
-			openAIModels = append(openAIModels, openAIModel)
+	for _, ociModel := range ociResp.Items {
+		if ociModel.LifecycleState != "ACTIVE" {
+			continue
+		}
+		parent, root, vendor := lineage(ociModel, byID)
+		// Fine-tunes of base models missing from the list are kept, since their vendor is unknown
+		if (vendor != "" || parent == "") && shouldFilterModel(vendor) {
+			continue
+		}
+
+		// Parse time created
+		created := t.now().Unix() // Default to now if parsing fails
+		if parsedTime, err := time.Parse(time.RFC3339, ociModel.TimeCreated); err == nil {
+			created = parsedTime.Unix()
+		}
+
+		openAIModel := types.OpenAIModel{
+			ID:      ociModel.DisplayName,
+			Object:  "model",
+			Created: created,
+			OwnedBy: t.ModelOwner(vendor),
+			Parent:  parent,
+			Root:    root,
 		}
+		openAIModels = append(openAIModels, openAIModel)
 	}
 
 	return types.OpenAIModelsResponse{
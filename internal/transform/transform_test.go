@@ -1,8 +1,11 @@
 package transform
 
 import (
+	"encoding/json"
 	"math"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/zalbiraw/ociaitoopenai/internal/config"
 	"github.com/zalbiraw/ociaitoopenai/pkg/types"
@@ -28,7 +31,7 @@ func TestNew(t *testing.T) {
 
 func TestToOracleCloudRequest_BasicTransformation(t *testing.T) {
 	cfg := config.New()
-	cfg.CompartmentID = "test-compartment-id"
+	cfg.CompartmentID = "ocid1.compartment.oc1..testcompartment"
 	transformer := New(cfg)
 
 	openAIReq := types.ChatCompletionRequest{
@@ -61,7 +64,7 @@ func TestToOracleCloudRequest_BasicTransformation(t *testing.T) {
 
 func TestToOracleCloudRequest_MultipleMessages(t *testing.T) {
 	cfg := config.New()
-	cfg.CompartmentID = "test-compartment-id"
+	cfg.CompartmentID = "ocid1.compartment.oc1..testcompartment"
 	transformer := New(cfg)
 
 	openAIReq := types.ChatCompletionRequest{
@@ -84,7 +87,7 @@ func TestToOracleCloudRequest_MultipleMessages(t *testing.T) {
 
 func TestToOracleCloudRequest_EmptyMessages(t *testing.T) {
 	cfg := config.New()
-	cfg.CompartmentID = "test-compartment-id"
+	cfg.CompartmentID = "ocid1.compartment.oc1..testcompartment"
 	transformer := New(cfg)
 
 	openAIReq := types.ChatCompletionRequest{
@@ -101,7 +104,7 @@ func TestToOracleCloudRequest_EmptyMessages(t *testing.T) {
 
 func TestToOracleCloudRequest_OpenAIOverrides(t *testing.T) {
 	cfg := config.New()
-	cfg.CompartmentID = "test-compartment-id"
+	cfg.CompartmentID = "ocid1.compartment.oc1..testcompartment"
 	transformer := New(cfg)
 
 	openAIReq := types.ChatCompletionRequest{
@@ -110,7 +113,7 @@ func TestToOracleCloudRequest_OpenAIOverrides(t *testing.T) {
 			{Role: "user", Content: "Test message"},
 		},
 		MaxTokens:        1000,
-		Temperature:      0.5,
+		Temperature:      float(0.5),
 		TopP:             0.9,
 		FrequencyPenalty: 0.2,
 		PresencePenalty:  0.1,
@@ -125,7 +128,7 @@ func TestToOracleCloudRequest_OpenAIOverrides(t *testing.T) {
 
 func TestToOracleCloudRequest_StreamingDefaults(t *testing.T) {
 	cfg := config.New()
-	cfg.CompartmentID = "test-compartment-id"
+	cfg.CompartmentID = "ocid1.compartment.oc1..testcompartment"
 	transformer := New(cfg)
 
 	openAIReq := types.ChatCompletionRequest{
@@ -142,6 +145,38 @@ func TestToOracleCloudRequest_StreamingDefaults(t *testing.T) {
 	}
 }
 
+func TestToOracleCloudRequest_ExtraFields(t *testing.T) {
+	cfg := config.New()
+	cfg.CompartmentID = "ocid1.compartment.oc1..testcompartment"
+	transformer := New(cfg)
+
+	openAIReq := types.ChatCompletionRequest{
+		Model:    "meta.llama-3.3-70b-instruct",
+		Messages: []types.ChatCompletionMessage{{Role: "user", Content: "Test message"}},
+		Extra: map[string]json.RawMessage{
+			"seed":      json.RawMessage(`42`),
+			"apiFormat": json.RawMessage(`"COHERE"`),
+		},
+	}
+
+	body, err := json.Marshal(transformer.ToOracleCloudRequest(openAIReq))
+	if err != nil {
+		t.Fatalf("failed to marshal request: %v", err)
+	}
+	var ociReq struct {
+		ChatRequest map[string]json.RawMessage `json:"chatRequest"`
+	}
+	if err := json.Unmarshal(body, &ociReq); err != nil {
+		t.Fatalf("failed to parse request: %v", err)
+	}
+	if string(ociReq.ChatRequest["seed"]) != "42" {
+		t.Errorf("expected seed to be forwarded, got %s", ociReq.ChatRequest["seed"])
+	}
+	if string(ociReq.ChatRequest["apiFormat"]) != `"GENERIC"` {
+		t.Errorf("expected extra fields not to replace apiFormat, got %s", ociReq.ChatRequest["apiFormat"])
+	}
+}
+
 func TestToOpenAIResponse_BasicTransformation(t *testing.T) {
 	transformer := New(&config.Config{})
 
@@ -217,6 +252,9 @@ func TestToOpenAIResponse_FinishReasonMapping(t *testing.T) {
 		{"COMPLETE", "stop"},
 		{"MAX_TOKENS", "length"},
 		{"CONTENT_FILTER", "content_filter"},
+		{"ERROR_TOXIC", "content_filter"},
+		{"length", "length"},
+		{"content_filter", "content_filter"},
 		{"UNKNOWN", "stop"},
 	}
 
@@ -241,3 +279,359 @@ func TestToOpenAIResponse_FinishReasonMapping(t *testing.T) {
 		}
 	}
 }
+
+func TestToOracleCloudRequest_NullContentWithToolCalls(t *testing.T) {
+	cfg := config.New()
+	cfg.CompartmentID = "ocid1.compartment.oc1..testcompartment"
+	transformer := New(cfg)
+
+	body := `{
+		"model": "meta.llama-3.3-70b-instruct",
+		"messages": [
+			{"role": "user", "content": "What's the weather in Paris?"},
+			{"role": "assistant", "content": null, "tool_calls": [
+				{"id": "call_1", "type": "function", "function": {"name": "get_weather", "arguments": "{\"city\":\"Paris\"}"}}
+			]},
+			{"role": "tool", "tool_call_id": "call_1", "content": "{\"temp\":21}"}
+		]
+	}`
+
+	var openAIReq types.ChatCompletionRequest
+	if err := json.Unmarshal([]byte(body), &openAIReq); err != nil {
+		t.Fatalf("failed to parse request with null content: %v", err)
+	}
+
+	result := transformer.ToOracleCloudRequest(openAIReq)
+
+	if len(result.ChatRequest.Messages) != 3 {
+		t.Fatalf("expected 3 messages, got %d", len(result.ChatRequest.Messages))
+	}
+
+	assistant := result.ChatRequest.Messages[1].(map[string]interface{})
+	if _, ok := assistant["content"]; ok {
+		t.Errorf("expected no content for tool-call-only assistant message, got %v", assistant["content"])
+	}
+	calls, ok := assistant["toolCalls"].([]interface{})
+	if !ok || len(calls) != 1 {
+		t.Fatalf("expected 1 tool call, got %v", assistant["toolCalls"])
+	}
+	if name := calls[0].(map[string]interface{})["name"]; name != "get_weather" {
+		t.Errorf("expected tool call name get_weather, got %v", name)
+	}
+
+	tool := result.ChatRequest.Messages[2].(map[string]interface{})
+	if tool["role"] != "TOOL" || tool["toolCallId"] != "call_1" {
+		t.Errorf("expected TOOL message for call_1, got %v", tool)
+	}
+}
+
+func TestToOracleCloudRequest_CohereToolCallHistory(t *testing.T) {
+	cfg := config.New()
+	cfg.CompartmentID = "ocid1.compartment.oc1..testcompartment"
+	transformer := New(cfg)
+
+	openAIReq := types.ChatCompletionRequest{
+		Model: "cohere.command-r-plus",
+		Messages: []types.ChatCompletionMessage{
+			{Role: "user", Content: "What's the weather in Paris?"},
+			{Role: "assistant", ToolCalls: []types.ToolCall{
+				{ID: "call_1", Type: "function", Function: types.ToolCallFunction{Name: "get_weather", Arguments: `{"city":"Paris"}`}},
+			}},
+			{Role: "tool", ToolCallID: "call_1", Content: "sunny"},
+		},
+	}
+
+	result := transformer.ToOracleCloudRequest(openAIReq)
+
+	if result.ChatRequest.Message != "" {
+		t.Errorf("expected empty message when answering tool calls, got %q", result.ChatRequest.Message)
+	}
+
+	if len(result.ChatRequest.ChatHistory) != 2 {
+		t.Fatalf("expected 2 history entries, got %d", len(result.ChatRequest.ChatHistory))
+	}
+	chatbot := result.ChatRequest.ChatHistory[1].(map[string]interface{})
+	if _, ok := chatbot["message"]; ok {
+		t.Errorf("expected no message text for tool-call-only entry, got %v", chatbot["message"])
+	}
+	if _, ok := chatbot["toolCalls"]; !ok {
+		t.Error("expected toolCalls on CHATBOT history entry")
+	}
+
+	if len(result.ChatRequest.ToolResults) != 1 {
+		t.Fatalf("expected 1 tool result, got %d", len(result.ChatRequest.ToolResults))
+	}
+	toolResult := result.ChatRequest.ToolResults[0].(map[string]interface{})
+	call := toolResult["call"].(map[string]interface{})
+	if call["name"] != "get_weather" {
+		t.Errorf("expected tool result for get_weather, got %v", call["name"])
+	}
+	outputs := toolResult["outputs"].([]interface{})
+	if outputs[0].(map[string]interface{})["result"] != "sunny" {
+		t.Errorf("expected wrapped text output, got %v", outputs[0])
+	}
+}
+
+func TestToOracleCloudRequest_DebugPrompt(t *testing.T) {
+	testCases := []struct {
+		name       string
+		allow      bool
+		model      string
+		mode       string
+		expectEcho bool
+		expectRaw  bool
+	}{
+		{"disabled by config", false, "cohere.command-r", "raw", false, false},
+		{"echo", true, "meta.llama-3.3-70b-instruct", "echo", true, false},
+		{"raw cohere", true, "cohere.command-r", "raw", true, true},
+		{"raw generic falls back to echo", true, "meta.llama-3.3-70b-instruct", "raw", true, false},
+		{"not requested", true, "cohere.command-r", "", false, false},
+	}
+
+	for _, tc := range testCases {
+		cfg := config.New()
+		cfg.AllowPromptDebug = tc.allow
+		transformer := New(cfg)
+
+		result := transformer.ToOracleCloudRequest(types.ChatCompletionRequest{
+			Model:       tc.model,
+			Messages:    []types.ChatCompletionMessage{{Role: "user", Content: "Hi"}},
+			DebugPrompt: tc.mode,
+		})
+
+		if result.ChatRequest.IsEcho != tc.expectEcho {
+			t.Errorf("%s: expected isEcho=%v, got %v", tc.name, tc.expectEcho, result.ChatRequest.IsEcho)
+		}
+		if result.ChatRequest.IsRawPrompting != tc.expectRaw {
+			t.Errorf("%s: expected isRawPrompting=%v, got %v", tc.name, tc.expectRaw, result.ChatRequest.IsRawPrompting)
+		}
+	}
+}
+
+func TestToOpenAIResponse_EchoedPrompt(t *testing.T) {
+	transformer := New(&config.Config{})
+
+	openAIResp := transformer.ToOpenAIResponse(types.OracleCloudResponse{
+		ChatResponse: types.OracleCloudChatResponse{
+			APIFormat: "COHERE",
+			Text:      "Hello!",
+			Prompt:    "<BOS_TOKEN>User: Hi",
+		},
+	}, "cohere.command-r")
+
+	if openAIResp.Prompt != "<BOS_TOKEN>User: Hi" {
+		t.Errorf("expected echoed prompt, got %q", openAIResp.Prompt)
+	}
+}
+
+func TestToOracleCloudRequest_ImageContentParts(t *testing.T) {
+	cfg := config.New()
+	cfg.CompartmentID = "ocid1.compartment.oc1..testcompartment"
+	transformer := New(cfg)
+
+	body := `{
+		"model": "meta.llama-3.2-90b-vision-instruct",
+		"messages": [
+			{"role": "user", "content": [
+				{"type": "text", "text": "What is in this image?"},
+				{"type": "image_url", "image_url": {"url": "data:image/png;base64,AAAA", "detail": "high"}}
+			]}
+		]
+	}`
+
+	var openAIReq types.ChatCompletionRequest
+	if err := json.Unmarshal([]byte(body), &openAIReq); err != nil {
+		t.Fatalf("failed to parse request with content parts: %v", err)
+	}
+	if openAIReq.Messages[0].Content != "What is in this image?" {
+		t.Errorf("expected text parts to be joined into content, got %q", openAIReq.Messages[0].Content)
+	}
+
+	result := transformer.ToOracleCloudRequest(openAIReq)
+
+	content := result.ChatRequest.Messages[0].(map[string]interface{})["content"].([]map[string]interface{})
+	if len(content) != 2 {
+		t.Fatalf("expected 2 content blocks, got %d", len(content))
+	}
+	if content[0]["type"] != "TEXT" || content[1]["type"] != "IMAGE" {
+		t.Errorf("expected TEXT and IMAGE blocks, got %v", content)
+	}
+	imageURL := content[1]["imageUrl"].(map[string]interface{})
+	if imageURL["url"] != "data:image/png;base64,AAAA" || imageURL["detail"] != "HIGH" {
+		t.Errorf("unexpected image block: %v", imageURL)
+	}
+}
+
+func TestToOracleCloudRequest_CohereConversationID(t *testing.T) {
+	cfg := config.New()
+	cfg.CompartmentID = "ocid1.compartment.oc1..testcompartment"
+	transformer := New(cfg)
+
+	openAIReq := types.ChatCompletionRequest{
+		Model:          "cohere.command-r-plus",
+		ConversationID: "conv-123",
+		Messages: []types.ChatCompletionMessage{
+			{Role: "user", Content: "Hello"},
+			{Role: "assistant", Content: "Hi there!"},
+			{Role: "user", Content: "How are you?"},
+		},
+	}
+
+	result := transformer.ToOracleCloudRequest(openAIReq)
+
+	if result.ChatRequest.ConversationID != "conv-123" {
+		t.Errorf("expected conversationId conv-123, got %q", result.ChatRequest.ConversationID)
+	}
+	if len(result.ChatRequest.ChatHistory) != 0 {
+		t.Errorf("expected history to be left to the server-side conversation, got %v", result.ChatRequest.ChatHistory)
+	}
+	if result.ChatRequest.Message != "How are you?" {
+		t.Errorf("expected current message to be sent, got %q", result.ChatRequest.Message)
+	}
+
+	openAIReq.Model = "meta.llama-3.3-70b-instruct"
+	if generic := transformer.ToOracleCloudRequest(openAIReq); generic.ChatRequest.ConversationID != "" {
+		t.Error("expected conversationId to be ignored for GENERIC models")
+	}
+}
+
+func TestToOracleCloudRequest_AssistantPrefill(t *testing.T) {
+	cfg := config.New()
+	cfg.CompartmentID = "ocid1.compartment.oc1..testcompartment"
+	transformer := New(cfg)
+
+	messages := []types.ChatCompletionMessage{
+		{Role: "user", Content: "List three colors as JSON."},
+		{Role: "assistant", Content: `{"colors": [`},
+	}
+
+	cohere := transformer.ToOracleCloudRequest(types.ChatCompletionRequest{Model: "cohere.command-r-plus", Messages: messages})
+	if cohere.ChatRequest.Message != "" {
+		t.Errorf("expected empty user turn for prefill, got %q", cohere.ChatRequest.Message)
+	}
+	if len(cohere.ChatRequest.ChatHistory) != 2 {
+		t.Fatalf("expected 2 history entries, got %d", len(cohere.ChatRequest.ChatHistory))
+	}
+	prefill := cohere.ChatRequest.ChatHistory[1].(map[string]interface{})
+	if prefill["role"] != "CHATBOT" || prefill["message"] != `{"colors": [` {
+		t.Errorf("expected prefill as CHATBOT history entry, got %v", prefill)
+	}
+
+	generic := transformer.ToOracleCloudRequest(types.ChatCompletionRequest{Model: "meta.llama-3.3-70b-instruct", Messages: messages})
+	last := generic.ChatRequest.Messages[len(generic.ChatRequest.Messages)-1].(map[string]interface{})
+	if last["role"] != "ASSISTANT" {
+		t.Errorf("expected ASSISTANT prefill to stay last, got %v", last["role"])
+	}
+}
+
+func TestToOracleCloudRequest_RoleMappings(t *testing.T) {
+	cfg := config.New()
+	cfg.CompartmentID = "ocid1.compartment.oc1..testcompartment"
+	cfg.RoleMappings = map[string]map[string]string{
+		"GENERIC": {"system": "SYSTEM", "developer": "DEVELOPER"},
+		"COHERE":  {"system": "SYSTEM"},
+	}
+	transformer := New(cfg)
+
+	messages := []types.ChatCompletionMessage{
+		{Role: "system", Content: "Be brief."},
+		{Role: "developer", Content: "Answer in French."},
+		{Role: "assistant", Content: "Bonjour."},
+		{Role: "user", Content: "Hello"},
+	}
+
+	req := types.ChatCompletionRequest{Model: "meta.llama-3.3-70b-instruct", Messages: append([]types.ChatCompletionMessage(nil), messages...)}
+	transformer.Normalize(&req)
+	generic := transformer.ToOracleCloudRequest(req)
+	var roles []string
+	for _, msg := range generic.ChatRequest.Messages {
+		roles = append(roles, msg.(map[string]interface{})["role"].(string))
+	}
+	if strings.Join(roles, ",") != "SYSTEM,DEVELOPER,ASSISTANT,USER" {
+		t.Errorf("expected the configured GENERIC roles, got %v", roles)
+	}
+
+	// Developer messages become system messages unless they are mapped
+	req = types.ChatCompletionRequest{Model: "cohere.command-r-plus", Messages: append([]types.ChatCompletionMessage(nil), messages...)}
+	transformer.Normalize(&req)
+	cohere := transformer.ToOracleCloudRequest(req)
+	roles = nil
+	for _, entry := range cohere.ChatRequest.ChatHistory {
+		roles = append(roles, entry.(map[string]interface{})["role"].(string))
+	}
+	if strings.Join(roles, ",") != "SYSTEM,SYSTEM,CHATBOT" {
+		t.Errorf("expected the configured COHERE roles, got %v", roles)
+	}
+}
+
+func TestToOpenAIResponse_ContentFilterResults(t *testing.T) {
+	transformer := New(&config.Config{})
+
+	oracleResp := types.OracleCloudResponse{
+		ChatResponse: types.OracleCloudChatResponse{
+			APIFormat:    "COHERE",
+			FinishReason: "ERROR_TOXIC",
+			Guardrails: &types.OracleGuardrailResults{
+				ContentModeration: &types.OracleContentModeration{
+					Categories: []types.OracleModerationCategory{{Name: "OVERALL", Score: 0.9}, {Name: "BLOCKLIST", Score: 0}},
+				},
+				PromptInjection: &types.OracleGuardrailScore{Score: 0.1},
+			},
+		},
+	}
+
+	choice := transformer.ToOpenAIResponse(oracleResp, "cohere.command-r-plus").Choices[0]
+
+	if choice.FinishReason != "content_filter" {
+		t.Errorf("expected finish reason content_filter, got %s", choice.FinishReason)
+	}
+	expected := map[string]types.ContentFilterResult{
+		"overall":          {Filtered: true, Detected: true, Score: 0.9},
+		"blocklist":        {},
+		"prompt_injection": {Score: 0.1},
+	}
+	if len(choice.ContentFilterResults) != len(expected) {
+		t.Fatalf("expected %d results, got %+v", len(expected), choice.ContentFilterResults)
+	}
+	for category, result := range expected {
+		if choice.ContentFilterResults[category] != result {
+			t.Errorf("expected %s result %+v, got %+v", category, result, choice.ContentFilterResults[category])
+		}
+	}
+
+	oracleResp.ChatResponse.Guardrails = nil
+	if results := transformer.ToOpenAIResponse(oracleResp, "cohere.command-r-plus").Choices[0].ContentFilterResults; results != nil {
+		t.Errorf("expected no content filter results without guardrails, got %+v", results)
+	}
+}
+
+func TestToOpenAIResponse_IDStrategies(t *testing.T) {
+	oracleResp := types.OracleCloudResponse{RequestID: "ABC123/DEF456", ChatResponse: types.OracleCloudChatResponse{Text: "Hi"}}
+
+	cfg := config.New()
+	cfg.IDStrategy = config.IDStrategySequential
+	cfg.FixedTime = "2024-05-01T12:00:00Z"
+	transformer := New(cfg)
+	first := transformer.ToOpenAIResponse(oracleResp, "model")
+	second := transformer.ToOpenAIResponse(oracleResp, "model")
+	if first.ID != "chatcmpl-1" || second.ID != "chatcmpl-2" {
+		t.Errorf("expected sequential IDs, got %q and %q", first.ID, second.ID)
+	}
+	if first.Created != 1714564800 {
+		t.Errorf("expected the fixed time, got %d", first.Created)
+	}
+
+	cfg = config.New()
+	cfg.IDStrategy = config.IDStrategyRequestID
+	if id := New(cfg).ToOpenAIResponse(oracleResp, "model").ID; id != "chatcmpl-ABC123/DEF456" {
+		t.Errorf("expected the request ID embedded, got %q", id)
+	}
+
+	transformer = New(config.New())
+	transformer.SetClock(func() time.Time { return time.Unix(42, 0) })
+	transformer.SetIDGenerator(func(requestID string) string { return "chatcmpl-test-" + requestID })
+	resp := transformer.ToOpenAIResponse(oracleResp, "model")
+	if resp.ID != "chatcmpl-test-ABC123/DEF456" || resp.Created != 42 {
+		t.Errorf("expected the injected clock and ID generator, got %q at %d", resp.ID, resp.Created)
+	}
+}
@@ -0,0 +1,22 @@
+package transform
+
+import (
+	"strings"
+
+	"github.com/zalbiraw/ociaitoopenai/pkg/vendors"
+)
+
+// CustomVendor returns the registered vendor of a model, by the longest customVendors prefix of its name.
+func (t *Transformer) CustomVendor(model string) (vendors.Vendor, bool) {
+	name, longest := "", -1
+	for prefix, vendor := range t.config.CustomVendors {
+		if len(prefix) > longest && strings.HasPrefix(strings.ToLower(model), strings.ToLower(prefix)) {
+			name, longest = vendor, len(prefix)
+		}
+	}
+	if longest < 0 {
+		return vendors.Vendor{}, false
+	}
+	vendor, ok := vendors.Lookup(name)
+	return vendor, ok && vendor.Formatter != nil
+}
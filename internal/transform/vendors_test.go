@@ -0,0 +1,43 @@
+package transform
+
+import (
+	"testing"
+
+	"github.com/zalbiraw/ociaitoopenai/internal/config"
+	"github.com/zalbiraw/ociaitoopenai/pkg/types"
+	"github.com/zalbiraw/ociaitoopenai/pkg/vendors"
+)
+
+type prefixFormatter string
+
+func (f prefixFormatter) FormatRequest(req types.ChatCompletionRequest) types.ChatRequest {
+	return types.ChatRequest{APIFormat: string(f), Message: req.Messages[len(req.Messages)-1].Content}
+}
+
+func TestToOracleCloudRequest_CustomVendor(t *testing.T) {
+	vendors.Register("transform-test-acme", vendors.Vendor{Formatter: prefixFormatter("ACME")})
+	vendors.Register("transform-test-acme-mini", vendors.Vendor{Formatter: prefixFormatter("ACME_MINI")})
+
+	cfg := config.New()
+	cfg.CompartmentID = "ocid1.compartment.oc1..testcompartment"
+	cfg.CustomVendors = map[string]string{"acme.": "transform-test-acme", "acme.mini": "transform-test-acme-mini"}
+	transformer := New(cfg)
+
+	tests := map[string]string{
+		"acme.large-1":                "ACME",
+		"ACME.mini-2":                 "ACME_MINI",
+		"meta.llama-3.3-70b-instruct": "GENERIC",
+	}
+	for model, format := range tests {
+		ociReq := transformer.ToOracleCloudRequest(types.ChatCompletionRequest{
+			Model:    model,
+			Messages: []types.ChatCompletionMessage{{Role: "user", Content: "Hello"}},
+		})
+		if ociReq.ChatRequest.APIFormat != format {
+			t.Errorf("%s: expected API format %s, got %s", model, format, ociReq.ChatRequest.APIFormat)
+		}
+		if ociReq.CompartmentID != cfg.CompartmentID || ociReq.ServingMode.ModelID != model {
+			t.Errorf("%s: expected the compartment and serving mode to be set, got %+v", model, ociReq)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package usage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Report is the usage accumulated over a reporting period, as POSTed to the report webhook.
+type Report struct {
+	PeriodStart time.Time `json:"periodStart"`
+	PeriodEnd   time.Time `json:"periodEnd"`
+	Usage       []Entry   `json:"usage"`
+}
+
+// Reporter periodically posts the usage recorded by a ledger since the previous report.
+type Reporter struct {
+	ledger   *Ledger
+	url      string
+	interval time.Duration
+	client   *http.Client
+	now      func() time.Time
+
+	baseline    map[Key]Totals // Ledger totals at the end of the last reported period
+	periodStart time.Time
+}
+
+// NewReporter creates a reporter posting the ledger's usage to url every interval.
+func NewReporter(ledger *Ledger, url string, interval time.Duration) *Reporter {
+	r := &Reporter{
+		ledger:   ledger,
+		url:      url,
+		interval: interval,
+		client:   &http.Client{Timeout: 30 * time.Second},
+		now:      time.Now,
+		baseline: make(map[Key]Totals),
+	}
+	r.periodStart = r.now().UTC()
+	merge(r.baseline, ledger.Totals())
+	return r
+}
+
+// Run sends a report at each interval boundary, e.g. at midnight UTC for a 24h interval,
+// until ctx is cancelled. Failed reports are logged through onError and their usage is
+// carried into the next report.
+func (r *Reporter) Run(ctx context.Context, onError func(error)) {
+	for {
+		now := r.now()
+		timer := time.NewTimer(now.Truncate(r.interval).Add(r.interval).Sub(now))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+			if err := r.Send(ctx); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
+
+// Send posts the usage recorded since the previous successful report.
+func (r *Reporter) Send(ctx context.Context) error {
+	end := r.now().UTC()
+	current := r.ledger.Totals()
+
+	report := Report{PeriodStart: r.periodStart, PeriodEnd: end, Usage: []Entry{}}
+	for _, entry := range current {
+		previous := r.baseline[entry.Key]
+		delta := Totals{
+			Requests:         entry.Requests - previous.Requests,
+			PromptTokens:     entry.PromptTokens - previous.PromptTokens,
+			CompletionTokens: entry.CompletionTokens - previous.CompletionTokens,
+			TotalTokens:      entry.TotalTokens - previous.TotalTokens,
+		}
+		if delta != (Totals{}) {
+			report.Usage = append(report.Usage, Entry{Key: entry.Key, Totals: delta})
+		}
+	}
+
+	body, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("failed to marshal usage report: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create usage report request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post usage report: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("%s returned status %d", r.url, resp.StatusCode)
+	}
+
+	r.baseline = make(map[Key]Totals, len(current))
+	merge(r.baseline, current)
+	r.periodStart = end
+	return nil
+}
@@ -0,0 +1,58 @@
+package usage
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/zalbiraw/ociaitoopenai/pkg/types"
+)
+
+func TestReporter_SendsPeriodUsage(t *testing.T) {
+	var reports []Report
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		var report Report
+		_ = json.NewDecoder(req.Body).Decode(&report)
+		reports = append(reports, report)
+		rw.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	ledger := &Ledger{store: NewMemory(), totals: make(map[Key]Totals), pending: make(map[Key]Totals)}
+	ledger.Record("team-a", "m", types.ChatCompletionUsage{TotalTokens: 10})
+	reporter := NewReporter(ledger, server.URL, 0)
+
+	// Usage recorded before the reporter started is not reported
+	ledger.Record("team-a", "m", types.ChatCompletionUsage{TotalTokens: 5})
+	status = http.StatusServiceUnavailable
+	if err := reporter.Send(context.Background()); err == nil {
+		t.Fatal("expected failed report to return an error")
+	}
+
+	// A failed report's usage is carried into the next one
+	ledger.Record("team-b", "m", types.ChatCompletionUsage{TotalTokens: 3})
+	status = http.StatusOK
+	if err := reporter.Send(context.Background()); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if err := reporter.Send(context.Background()); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	if len(reports) != 3 {
+		t.Fatalf("expected 3 reports, got %d", len(reports))
+	}
+	usage := reports[1].Usage
+	if len(usage) != 2 || usage[0].TotalTokens != 5 || usage[1].Tenant != "team-b" || usage[1].TotalTokens != 3 {
+		t.Errorf("unexpected report usage: %+v", usage)
+	}
+	if !reports[1].PeriodStart.Equal(reports[0].PeriodStart) {
+		t.Error("expected the period to start at the last successful report")
+	}
+	if len(reports[2].Usage) != 0 {
+		t.Errorf("expected an empty report for a period without usage, got %+v", reports[2].Usage)
+	}
+}
@@ -0,0 +1,184 @@
+package usage
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Store durably holds usage totals.
+type Store interface {
+	// Load returns the totals recorded so far.
+	Load() ([]Entry, error)
+
+	// Add adds the given deltas to the stored totals.
+	Add(deltas []Entry) error
+}
+
+// Memory keeps totals in memory only, so they are lost on restart.
+type Memory struct {
+	mu     sync.Mutex
+	totals map[Key]Totals
+}
+
+// NewMemory creates an empty in-memory store.
+func NewMemory() *Memory {
+	return &Memory{totals: make(map[Key]Totals)}
+}
+
+// Load implements Store.
+func (s *Memory) Load() ([]Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return entries(s.totals), nil
+}
+
+// Add implements Store.
+func (s *Memory) Add(deltas []Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	merge(s.totals, deltas)
+	return nil
+}
+
+// File keeps totals in a local JSON file, rewritten atomically on every Add.
+type File struct {
+	path string
+
+	mu     sync.Mutex
+	totals map[Key]Totals // Cached file contents, nil until loaded
+}
+
+// NewFile creates a store backed by the file at path. The file is created on the first Add.
+func NewFile(path string) *File {
+	return &File{path: path}
+}
+
+// Load implements Store.
+func (s *File) Load() ([]Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return entries(s.totals), nil
+}
+
+// Add implements Store.
+func (s *File) Add(deltas []Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.load(); err != nil {
+		return err
+	}
+	updated := make(map[Key]Totals, len(s.totals))
+	for key, totals := range s.totals {
+		updated[key] = totals
+	}
+	merge(updated, deltas)
+
+	data, err := json.Marshal(entries(updated))
+	if err != nil {
+		return fmt.Errorf("failed to marshal usage: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("failed to create usage file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write usage file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write usage file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to replace usage file: %w", err)
+	}
+
+	s.totals = updated
+	return nil
+}
+
+// load reads the file into the cache, unless it is already loaded. The caller must hold the lock.
+func (s *File) load() error {
+	if s.totals != nil {
+		return nil
+	}
+
+	s.totals = make(map[Key]Totals)
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		s.totals = nil
+		return fmt.Errorf("failed to read usage file: %w", err)
+	}
+
+	var stored []Entry
+	if err := json.Unmarshal(data, &stored); err != nil {
+		s.totals = nil
+		return fmt.Errorf("failed to parse usage file: %w", err)
+	}
+	merge(s.totals, stored)
+	return nil
+}
+
+// HTTP keeps totals in a remote service. Load issues a GET for the current totals and Add
+// POSTs the deltas, both as JSON arrays of entries, so several plugin instances can share
+// a ledger.
+type HTTP struct {
+	url    string
+	client *http.Client
+}
+
+// NewHTTP creates a store backed by the service at url.
+func NewHTTP(url string) *HTTP {
+	return &HTTP{url: url, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Load implements Store.
+func (s *HTTP) Load() ([]Entry, error) {
+	resp, err := s.client.Get(s.url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load usage: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return nil, fmt.Errorf("%s returned status %d", s.url, resp.StatusCode)
+	}
+	var stored []Entry
+	if err := json.NewDecoder(resp.Body).Decode(&stored); err != nil {
+		return nil, fmt.Errorf("failed to parse usage: %w", err)
+	}
+	return stored, nil
+}
+
+// Add implements Store.
+func (s *HTTP) Add(deltas []Entry) error {
+	data, err := json.Marshal(deltas)
+	if err != nil {
+		return fmt.Errorf("failed to marshal usage: %w", err)
+	}
+	resp, err := s.client.Post(s.url, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to post usage: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("%s returned status %d", s.url, resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+// Package usage keeps a ledger of token usage per tenant and model. Usage is totalled in
+// memory and periodically flushed to a pluggable store, so totals survive restarts when
+// the store is durable.
+package usage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/zalbiraw/ociaitoopenai/internal/config"
+	"github.com/zalbiraw/ociaitoopenai/pkg/types"
+)
+
+// Key identifies a ledger row.
+type Key struct {
+	Tenant string `json:"tenant"`
+	Model  string `json:"model"`
+}
+
+// Totals are the accumulated usage of a ledger row.
+type Totals struct {
+	Requests         int64 `json:"requests"`
+	PromptTokens     int64 `json:"promptTokens"`
+	CompletionTokens int64 `json:"completionTokens"`
+	TotalTokens      int64 `json:"totalTokens"`
+}
+
+func (t Totals) add(other Totals) Totals {
+	return Totals{
+		Requests:         t.Requests + other.Requests,
+		PromptTokens:     t.PromptTokens + other.PromptTokens,
+		CompletionTokens: t.CompletionTokens + other.CompletionTokens,
+		TotalTokens:      t.TotalTokens + other.TotalTokens,
+	}
+}
+
+// Entry is a ledger row as stored.
+type Entry struct {
+	Key
+	Totals
+}
+
+// Ledger records usage and flushes it to a store. It is safe for concurrent use.
+type Ledger struct {
+	store   Store
+	onError func(error)
+
+	mu      sync.Mutex
+	totals  map[Key]Totals // Totals including unflushed usage
+	pending map[Key]Totals // Usage not yet added to the store
+}
+
+// New creates a ledger for the configuration, loading the totals already in its store, and
+// starts flushing every flush interval until ctx is cancelled. It returns nil when the
+// ledger is disabled.
+func New(ctx context.Context, cfg config.Usage, onError func(error)) (*Ledger, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	var store Store
+	switch cfg.Store {
+	case config.UsageStoreFile:
+		store = NewFile(cfg.File)
+	case config.UsageStoreHTTP:
+		store = NewHTTP(cfg.URL)
+	default:
+		store = NewMemory()
+	}
+
+	stored, err := store.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load usage ledger: %w", err)
+	}
+
+	l := &Ledger{
+		store:   store,
+		onError: onError,
+		totals:  make(map[Key]Totals),
+		pending: make(map[Key]Totals),
+	}
+	merge(l.totals, stored)
+
+	interval, _ := time.ParseDuration(cfg.FlushInterval)
+	go l.run(ctx, interval)
+	return l, nil
+}
+
+// Record adds the usage of one request.
+func (l *Ledger) Record(tenant, model string, usage types.ChatCompletionUsage) {
+	delta := Totals{
+		Requests:         1,
+		PromptTokens:     int64(usage.PromptTokens),
+		CompletionTokens: int64(usage.CompletionTokens),
+		TotalTokens:      int64(usage.TotalTokens),
+	}
+	key := Key{Tenant: tenant, Model: model}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.totals[key] = l.totals[key].add(delta)
+	l.pending[key] = l.pending[key].add(delta)
+}
+
+// Totals returns the current totals, including usage not yet flushed, sorted by tenant and model.
+func (l *Ledger) Totals() []Entry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return entries(l.totals)
+}
+
+// Flush adds pending usage to the store. On failure the usage stays pending for the next flush.
+func (l *Ledger) Flush() error {
+	l.mu.Lock()
+	pending := l.pending
+	l.pending = make(map[Key]Totals)
+	l.mu.Unlock()
+
+	if len(pending) == 0 {
+		return nil
+	}
+	if err := l.store.Add(entries(pending)); err != nil {
+		l.mu.Lock()
+		merge(l.pending, entries(pending))
+		l.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+func (l *Ledger) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			l.flush()
+			return
+		case <-ticker.C:
+			l.flush()
+		}
+	}
+}
+
+func (l *Ledger) flush() {
+	if err := l.Flush(); err != nil && l.onError != nil {
+		l.onError(err)
+	}
+}
+
+// merge adds entries to totals.
+func merge(totals map[Key]Totals, deltas []Entry) {
+	for _, delta := range deltas {
+		totals[delta.Key] = totals[delta.Key].add(delta.Totals)
+	}
+}
+
+// entries converts totals to entries sorted by tenant and model.
+func entries(totals map[Key]Totals) []Entry {
+	result := make([]Entry, 0, len(totals))
+	for key, t := range totals {
+		result = append(result, Entry{Key: key, Totals: t})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Tenant != result[j].Tenant {
+			return result[i].Tenant < result[j].Tenant
+		}
+		return result[i].Model < result[j].Model
+	})
+	return result
+}
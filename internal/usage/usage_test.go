@@ -0,0 +1,108 @@
+package usage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/zalbiraw/ociaitoopenai/internal/config"
+	"github.com/zalbiraw/ociaitoopenai/pkg/types"
+)
+
+func TestNew_Disabled(t *testing.T) {
+	ledger, err := New(context.Background(), config.New().Usage, nil)
+	if err != nil || ledger != nil {
+		t.Errorf("expected nil ledger when disabled, got %v, %v", ledger, err)
+	}
+}
+
+func TestLedger_FilePersistsAcrossRestarts(t *testing.T) {
+	cfg := config.New().Usage
+	cfg.Enabled = true
+	cfg.Store = config.UsageStoreFile
+	cfg.File = filepath.Join(t.TempDir(), "usage.json")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ledger, err := New(ctx, cfg, nil)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	ledger.Record("team-a", "cohere.command-r-plus", types.ChatCompletionUsage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15})
+	ledger.Record("team-a", "cohere.command-r-plus", types.ChatCompletionUsage{PromptTokens: 1, CompletionTokens: 1, TotalTokens: 2})
+	if err := ledger.Flush(); err != nil {
+		t.Fatalf("expected flush to succeed, got: %v", err)
+	}
+
+	restarted, err := New(ctx, cfg, nil)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	totals := restarted.Totals()
+	expected := Totals{Requests: 2, PromptTokens: 11, CompletionTokens: 6, TotalTokens: 17}
+	if len(totals) != 1 || totals[0].Totals != expected || totals[0].Tenant != "team-a" {
+		t.Errorf("expected persisted totals %+v, got %+v", expected, totals)
+	}
+}
+
+// failingStore fails every Add until it is told to succeed.
+type failingStore struct {
+	Memory
+	fail bool
+}
+
+func (s *failingStore) Add(deltas []Entry) error {
+	if s.fail {
+		return errors.New("store unavailable")
+	}
+	return s.Memory.Add(deltas)
+}
+
+func TestLedger_FlushRetainsPendingOnFailure(t *testing.T) {
+	store := &failingStore{Memory: Memory{totals: make(map[Key]Totals)}, fail: true}
+	ledger := &Ledger{store: store, totals: make(map[Key]Totals), pending: make(map[Key]Totals)}
+
+	ledger.Record("", "meta.llama-3.3-70b-instruct", types.ChatCompletionUsage{TotalTokens: 7})
+	if err := ledger.Flush(); err == nil {
+		t.Fatal("expected flush to fail")
+	}
+
+	store.fail = false
+	if err := ledger.Flush(); err != nil {
+		t.Fatalf("expected flush to succeed, got: %v", err)
+	}
+	stored, _ := store.Load()
+	if len(stored) != 1 || stored[0].TotalTokens != 7 || stored[0].Requests != 1 {
+		t.Errorf("expected pending usage to be flushed once, got %+v", stored)
+	}
+}
+
+func TestHTTP_LoadAndAdd(t *testing.T) {
+	var posted []Entry
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodPost {
+			_ = json.NewDecoder(req.Body).Decode(&posted)
+			return
+		}
+		_, _ = rw.Write([]byte(`[{"tenant":"team-b","model":"m","requests":3,"totalTokens":30}]`))
+	}))
+	defer server.Close()
+
+	store := NewHTTP(server.URL)
+	stored, err := store.Load()
+	if err != nil || len(stored) != 1 || stored[0].Requests != 3 || stored[0].Tenant != "team-b" {
+		t.Fatalf("unexpected load result %+v, %v", stored, err)
+	}
+
+	if err := store.Add([]Entry{{Key: Key{Tenant: "team-b", Model: "m"}, Totals: Totals{Requests: 1}}}); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if len(posted) != 1 || posted[0].Requests != 1 {
+		t.Errorf("expected deltas to be posted, got %+v", posted)
+	}
+}
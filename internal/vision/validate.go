@@ -0,0 +1,99 @@
+package vision
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/zalbiraw/ociaitoopenai/internal/config"
+	"github.com/zalbiraw/ociaitoopenai/pkg/types"
+)
+
+// ValidationError describes an image that does not satisfy the configured limits.
+type ValidationError struct {
+	Param   string // Request parameter of the offending image, e.g. "messages[0].content[1].image_url"
+	Message string // Human-readable description of the problem
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Param, e.Message)
+}
+
+// Validate checks inline image payloads against the configured limits: the number of images in
+// the request, the decoded size of each image and its MIME type. Remote URLs count toward the
+// number of images, so requests with too many are rejected before anything is downloaded; their
+// size and type are checked by the Fetcher.
+func Validate(messages []types.ChatCompletionMessage, limits config.ImageLimits) error {
+	count := 0
+	for i, msg := range messages {
+		for j, part := range msg.Parts {
+			if part.ImageURL == nil {
+				continue
+			}
+			param := fmt.Sprintf("messages[%d].content[%d].image_url", i, j)
+
+			count++
+			if limits.MaxCount > 0 && count > limits.MaxCount {
+				return &ValidationError{Param: param, Message: fmt.Sprintf("too many images, at most %d are allowed per request", limits.MaxCount)}
+			}
+
+			if !strings.HasPrefix(part.ImageURL.URL, "data:") {
+				continue
+			}
+			if err := validateDataURL(part.ImageURL.URL, limits); err != nil {
+				return &ValidationError{Param: param, Message: err.Error()}
+			}
+		}
+	}
+	return nil
+}
+
+// HasImages reports whether any message carries an image content part.
+func HasImages(messages []types.ChatCompletionMessage) bool {
+	for _, msg := range messages {
+		for _, part := range msg.Parts {
+			if part.ImageURL != nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// validateDataURL checks the MIME type, encoding and decoded size of a data URL.
+func validateDataURL(dataURL string, limits config.ImageLimits) error {
+	header, data, ok := strings.Cut(strings.TrimPrefix(dataURL, "data:"), ",")
+	if !ok || !strings.HasSuffix(header, ";base64") {
+		return fmt.Errorf("image data URL must be base64-encoded")
+	}
+
+	mediaType := strings.ToLower(strings.TrimSuffix(header, ";base64"))
+	if !allowedType(mediaType, limits.AllowedTypes) {
+		return fmt.Errorf("image type %q is not supported, expected one of %s", mediaType, strings.Join(limits.AllowedTypes, ", "))
+	}
+
+	if limits.MaxBytes > 0 && int64(base64.StdEncoding.DecodedLen(len(data))) > limits.MaxBytes+2 {
+		return fmt.Errorf("image exceeds the maximum size of %d bytes", limits.MaxBytes)
+	}
+	decoded, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return fmt.Errorf("image data is not valid base64")
+	}
+	if limits.MaxBytes > 0 && int64(len(decoded)) > limits.MaxBytes {
+		return fmt.Errorf("image exceeds the maximum size of %d bytes", limits.MaxBytes)
+	}
+	return nil
+}
+
+// allowedType reports whether the media type is in the allowlist. An empty allowlist accepts any image type.
+func allowedType(mediaType string, allowed []string) bool {
+	if len(allowed) == 0 {
+		return strings.HasPrefix(mediaType, "image/")
+	}
+	for _, t := range allowed {
+		if strings.EqualFold(t, mediaType) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,155 @@
+// Package vision inlines remote images referenced by OpenAI image_url content parts.
+// OCI GenAI only accepts base64 image data, so http(s) URLs are downloaded and re-encoded
+// as data URLs before the request is transformed.
+package vision
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/zalbiraw/ociaitoopenai/internal/config"
+	"github.com/zalbiraw/ociaitoopenai/pkg/types"
+)
+
+// Fetcher downloads images from allowlisted hosts.
+type Fetcher struct {
+	allowedHosts []string
+	allowedTypes []string
+	maxBytes     int64
+	client       *http.Client
+}
+
+// NewFetcher creates an image fetcher. It returns nil when image fetching is disabled.
+// Downloads stop at the smaller of the fetch and image size limits, and images of types the
+// limits do not allow are refused before their body is read.
+func NewFetcher(cfg config.ImageFetch, limits config.ImageLimits) *Fetcher {
+	if !cfg.Enabled {
+		return nil
+	}
+
+	timeout, err := time.ParseDuration(cfg.Timeout)
+	if err != nil {
+		timeout = 10 * time.Second
+	}
+
+	f := &Fetcher{
+		allowedHosts: cfg.AllowedHosts,
+		allowedTypes: limits.AllowedTypes,
+		maxBytes:     cfg.MaxBytes,
+	}
+	if limits.MaxBytes > 0 && limits.MaxBytes < f.maxBytes {
+		f.maxBytes = limits.MaxBytes
+	}
+	f.client = &http.Client{
+		Timeout: timeout,
+		// Every redirect is checked like the original URL, so allowed hosts cannot redirect elsewhere
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 10 {
+				return fmt.Errorf("stopped after %d redirects", len(via))
+			}
+			return f.check(req.URL)
+		},
+	}
+	return f
+}
+
+// InlineImages replaces http(s) image URLs in the messages with base64 data URLs.
+// Images that are already data URLs are left untouched.
+func (f *Fetcher) InlineImages(ctx context.Context, messages []types.ChatCompletionMessage) error {
+	for i := range messages {
+		for j := range messages[i].Parts {
+			part := &messages[i].Parts[j]
+			if part.ImageURL == nil || strings.HasPrefix(part.ImageURL.URL, "data:") {
+				continue
+			}
+
+			dataURL, err := f.fetch(ctx, part.ImageURL.URL)
+			if err != nil {
+				return err
+			}
+			part.ImageURL.URL = dataURL
+		}
+	}
+	return nil
+}
+
+// fetch downloads an image and returns it as a data URL.
+func (f *Fetcher) fetch(ctx context.Context, rawURL string) (string, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("unsupported image URL: %s", rawURL)
+	}
+	if err := f.check(parsed); err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create image request: %w", err)
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch image: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to fetch image: status %d", resp.StatusCode)
+	}
+
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil || !strings.HasPrefix(mediaType, "image/") {
+		return "", fmt.Errorf("image URL returned non-image content type %q", resp.Header.Get("Content-Type"))
+	}
+	if !allowedType(mediaType, f.allowedTypes) {
+		return "", fmt.Errorf("image type %q is not supported, expected one of %s", mediaType, strings.Join(f.allowedTypes, ", "))
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, f.maxBytes+1))
+	if err != nil {
+		return "", fmt.Errorf("failed to read image: %w", err)
+	}
+	if int64(len(data)) > f.maxBytes {
+		return "", fmt.Errorf("image exceeds %d bytes", f.maxBytes)
+	}
+
+	return "data:" + mediaType + ";base64," + base64.StdEncoding.EncodeToString(data), nil
+}
+
+// check returns an error unless an image URL is http(s) on an allowed host.
+func (f *Fetcher) check(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("unsupported image URL: %s", u.Redacted())
+	}
+	if !f.allowed(u.Hostname()) {
+		return fmt.Errorf("image host %s is not allowed", u.Hostname())
+	}
+	return nil
+}
+
+// allowed reports whether the host matches the allowlist.
+// Entries match exactly, or as a domain suffix when written as "*.example.com".
+func (f *Fetcher) allowed(host string) bool {
+	host = strings.ToLower(host)
+	for _, entry := range f.allowedHosts {
+		entry = strings.ToLower(entry)
+		if strings.HasPrefix(entry, "*.") {
+			if strings.HasSuffix(host, entry[1:]) {
+				return true
+			}
+			continue
+		}
+		if host == entry {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,215 @@
+package vision
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/zalbiraw/ociaitoopenai/internal/config"
+	"github.com/zalbiraw/ociaitoopenai/pkg/types"
+)
+
+func newTestFetcher(t *testing.T, handler http.HandlerFunc, maxBytes int64) (*Fetcher, string) {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	parsed, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fetcher := NewFetcher(config.ImageFetch{
+		Enabled:      true,
+		AllowedHosts: []string{parsed.Hostname()},
+		MaxBytes:     maxBytes,
+		Timeout:      "5s",
+	}, config.ImageLimits{})
+	return fetcher, server.URL
+}
+
+func imageMessage(imageURL string) []types.ChatCompletionMessage {
+	return []types.ChatCompletionMessage{{
+		Role: "user",
+		Parts: []types.ContentPart{
+			{Type: "text", Text: "What is this?"},
+			{Type: "image_url", ImageURL: &types.ImageURL{URL: imageURL}},
+		},
+	}}
+}
+
+func TestNewFetcher_Disabled(t *testing.T) {
+	if fetcher := NewFetcher(config.ImageFetch{}, config.ImageLimits{}); fetcher != nil {
+		t.Error("expected no fetcher when image fetching is disabled")
+	}
+}
+
+func TestInlineImages_EncodesRemoteImage(t *testing.T) {
+	fetcher, serverURL := newTestFetcher(t, func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("Content-Type", "image/png")
+		_, _ = rw.Write([]byte("png-bytes"))
+	}, 1024)
+
+	messages := imageMessage(serverURL + "/cat.png")
+	if err := fetcher.InlineImages(context.Background(), messages); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	if got := messages[0].Parts[1].ImageURL.URL; got != "data:image/png;base64,cG5nLWJ5dGVz" {
+		t.Errorf("unexpected data URL: %s", got)
+	}
+}
+
+func TestInlineImages_Limits(t *testing.T) {
+	fetcher, serverURL := newTestFetcher(t, func(rw http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/page" {
+			rw.Header().Set("Content-Type", "text/html")
+		} else {
+			rw.Header().Set("Content-Type", "image/jpeg")
+		}
+		_, _ = rw.Write([]byte(strings.Repeat("x", 64)))
+	}, 32)
+
+	tests := map[string]string{
+		"too large":     serverURL + "/big.jpg",
+		"not an image":  serverURL + "/page",
+		"host rejected": "https://images.example.com/cat.png",
+		"bad scheme":    "file:///etc/passwd",
+	}
+	for name, imageURL := range tests {
+		if err := fetcher.InlineImages(context.Background(), imageMessage(imageURL)); err == nil {
+			t.Errorf("%s: expected error for %s", name, imageURL)
+		}
+	}
+}
+
+func TestInlineImages_ImageLimits(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/cat.gif" {
+			rw.Header().Set("Content-Type", "image/gif")
+		} else {
+			rw.Header().Set("Content-Type", "image/png")
+		}
+		_, _ = rw.Write([]byte(strings.Repeat("x", 64)))
+	}))
+	defer server.Close()
+	parsed, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The image limits are stricter than the fetch limit, so they apply while downloading
+	fetcher := NewFetcher(
+		config.ImageFetch{Enabled: true, AllowedHosts: []string{parsed.Hostname()}, MaxBytes: 1024},
+		config.ImageLimits{MaxBytes: 32, AllowedTypes: []string{"image/png"}},
+	)
+	err = fetcher.InlineImages(context.Background(), imageMessage(server.URL+"/cat.png"))
+	if err == nil || !strings.Contains(err.Error(), "image exceeds 32 bytes") {
+		t.Errorf("expected the image size limit to stop the download, got: %v", err)
+	}
+	err = fetcher.InlineImages(context.Background(), imageMessage(server.URL+"/cat.gif"))
+	if err == nil || !strings.Contains(err.Error(), `image type "image/gif" is not supported`) {
+		t.Errorf("expected the image type to be refused, got: %v", err)
+	}
+}
+
+func TestInlineImages_Redirects(t *testing.T) {
+	internal := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		t.Error("expected the redirect to a host that is not allowed to be refused")
+		rw.Header().Set("Content-Type", "image/png")
+		_, _ = rw.Write([]byte("secret"))
+	}))
+	defer internal.Close()
+	// The same server under another name, which is not on the allowlist
+	internalURL := strings.Replace(internal.URL, "127.0.0.1", "localhost", 1)
+
+	fetcher, serverURL := newTestFetcher(t, func(rw http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/moved.png":
+			http.Redirect(rw, req, "/cat.png", http.StatusFound)
+		case "/escape.png":
+			http.Redirect(rw, req, internalURL+"/metadata", http.StatusFound)
+		default:
+			rw.Header().Set("Content-Type", "image/png")
+			_, _ = rw.Write([]byte("png-bytes"))
+		}
+	}, 1024)
+
+	if err := fetcher.InlineImages(context.Background(), imageMessage(serverURL+"/moved.png")); err != nil {
+		t.Errorf("expected redirects within allowed hosts to be followed, got: %v", err)
+	}
+	err := fetcher.InlineImages(context.Background(), imageMessage(serverURL+"/escape.png"))
+	if err == nil || !strings.Contains(err.Error(), "image host localhost is not allowed") {
+		t.Errorf("expected the redirect to be refused, got: %v", err)
+	}
+}
+
+func TestInlineImages_KeepsDataURLs(t *testing.T) {
+	fetcher := NewFetcher(config.ImageFetch{Enabled: true, MaxBytes: 1024}, config.ImageLimits{})
+
+	messages := imageMessage("data:image/png;base64,AAAA")
+	if err := fetcher.InlineImages(context.Background(), messages); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if messages[0].Parts[1].ImageURL.URL != "data:image/png;base64,AAAA" {
+		t.Error("expected data URL to be left untouched")
+	}
+}
+
+func TestAllowed_Wildcard(t *testing.T) {
+	fetcher := &Fetcher{allowedHosts: []string{"*.example.com", "cdn.test"}}
+
+	for host, expected := range map[string]bool{
+		"images.example.com": true,
+		"example.com":        false,
+		"evil-example.com":   false,
+		"cdn.test":           true,
+		"CDN.TEST":           true,
+	} {
+		if got := fetcher.allowed(host); got != expected {
+			t.Errorf("allowed(%q) = %v, expected %v", host, got, expected)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	limits := config.ImageLimits{
+		MaxBytes:     4,
+		AllowedTypes: []string{"image/png"},
+		MaxCount:     1,
+	}
+
+	tests := map[string]struct {
+		messages []types.ChatCompletionMessage
+		valid    bool
+	}{
+		"valid image":      {imageMessage("data:image/png;base64,AAAA"), true},
+		"remote url":       {imageMessage("https://images.example.com/cat.png"), true},
+		"too large":        {imageMessage("data:image/png;base64,AAAAAAAA"), false},
+		"unsupported type": {imageMessage("data:image/svg+xml;base64,AAAA"), false},
+		"not base64":       {imageMessage("data:image/png,raw"), false},
+		"malformed base64": {imageMessage("data:image/png;base64,!!!!"), false},
+		"too many images":  {append(imageMessage("data:image/png;base64,AAAA"), imageMessage("data:image/png;base64,AAAA")...), false},
+	}
+	for name, tt := range tests {
+		err := Validate(tt.messages, limits)
+		if tt.valid && err != nil {
+			t.Errorf("%s: expected no error, got: %v", name, err)
+		}
+		if !tt.valid && err == nil {
+			t.Errorf("%s: expected validation error", name)
+		}
+	}
+}
+
+func TestHasImages(t *testing.T) {
+	if !HasImages(imageMessage("https://example.com/cat.png")) {
+		t.Error("expected an image part to be detected")
+	}
+	if HasImages([]types.ChatCompletionMessage{{Role: "user", Content: "Hi"}}) {
+		t.Error("expected no images in a text message")
+	}
+}
@@ -0,0 +1,126 @@
+// Package warmup periodically sends tiny requests to configured targets, keeping dedicated
+// endpoints warm and continuously checking authentication and connectivity.
+package warmup
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/zalbiraw/ociaitoopenai/internal/config"
+)
+
+// PingFunc sends a ping to a target, returning an error when it fails.
+type PingFunc func(ctx context.Context, target config.WarmUpTarget) error
+
+// Status is the outcome of the latest ping of a target.
+type Status struct {
+	Target    string
+	Healthy   bool
+	LastPing  time.Time // Zero until the target is first pinged
+	Latency   time.Duration
+	Failures  int // Consecutive failed pings
+	LastError string
+}
+
+// Pinger pings its targets on an interval. It is safe for concurrent use.
+type Pinger struct {
+	targets   []config.WarmUpTarget
+	interval  time.Duration
+	timeout   time.Duration
+	ping      PingFunc
+	onFailure func(target string, err error)
+	now       func() time.Time
+
+	mu     sync.Mutex
+	status []Status // By target, in configuration order
+}
+
+// New creates a pinger. onFailure is called for every failed ping. It returns nil when warm-up is disabled.
+func New(cfg config.WarmUp, ping PingFunc, onFailure func(target string, err error)) *Pinger {
+	if !cfg.Enabled {
+		return nil
+	}
+
+	interval, _ := time.ParseDuration(cfg.Interval)
+	timeout, _ := time.ParseDuration(cfg.Timeout)
+	status := make([]Status, len(cfg.Targets))
+	for i, target := range cfg.Targets {
+		status[i].Target = Name(target)
+	}
+	return &Pinger{
+		targets:   cfg.Targets,
+		interval:  interval,
+		timeout:   timeout,
+		ping:      ping,
+		onFailure: onFailure,
+		now:       time.Now,
+		status:    status,
+	}
+}
+
+// Name identifies a target by its endpoint, or its model when it has none.
+func Name(target config.WarmUpTarget) string {
+	if target.Endpoint != "" {
+		return target.Endpoint
+	}
+	return target.Model
+}
+
+// Start pings every target now, then on each interval, until ctx is done.
+func (p *Pinger) Start(ctx context.Context) {
+	go func() {
+		p.PingAll(ctx)
+
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				p.PingAll(ctx)
+			}
+		}
+	}()
+}
+
+// PingAll pings every target once, one after the other.
+func (p *Pinger) PingAll(ctx context.Context) {
+	for i, target := range p.targets {
+		if ctx.Err() != nil {
+			return
+		}
+
+		pingCtx, cancel := context.WithTimeout(ctx, p.timeout)
+		started := p.now()
+		err := p.ping(pingCtx, target)
+		cancel()
+
+		p.mu.Lock()
+		status := &p.status[i]
+		name := status.Target
+		status.LastPing = started
+		status.Latency = p.now().Sub(started)
+		status.Healthy = err == nil
+		if err != nil {
+			status.Failures++
+			status.LastError = err.Error()
+		} else {
+			status.Failures = 0
+			status.LastError = ""
+		}
+		p.mu.Unlock()
+
+		if err != nil && p.onFailure != nil {
+			p.onFailure(name, err)
+		}
+	}
+}
+
+// Status returns the status of every target, in configuration order.
+func (p *Pinger) Status() []Status {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]Status(nil), p.status...)
+}
@@ -0,0 +1,54 @@
+package warmup
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/zalbiraw/ociaitoopenai/internal/config"
+)
+
+func TestNew_Disabled(t *testing.T) {
+	if New(config.WarmUp{}, nil, nil) != nil {
+		t.Error("expected no pinger when warm-up is disabled")
+	}
+}
+
+func TestPingAll(t *testing.T) {
+	cfg := config.New().WarmUp
+	cfg.Enabled = true
+	cfg.Targets = []config.WarmUpTarget{
+		{Endpoint: "ocid1.generativeaiendpoint.oc1..dedicated"},
+		{Model: "cohere.command-r-plus"},
+	}
+
+	fail := true
+	var failures []string
+	pinger := New(cfg, func(ctx context.Context, target config.WarmUpTarget) error {
+		if target.Model != "" && fail {
+			return errors.New("status 401")
+		}
+		return nil
+	}, func(target string, err error) {
+		failures = append(failures, target)
+	})
+
+	pinger.PingAll(context.Background())
+	pinger.PingAll(context.Background())
+	status := pinger.Status()
+	if status[0].Target != "ocid1.generativeaiendpoint.oc1..dedicated" || !status[0].Healthy || status[0].LastPing.IsZero() {
+		t.Errorf("expected a healthy dedicated endpoint, got %+v", status[0])
+	}
+	if status[1].Target != "cohere.command-r-plus" || status[1].Healthy || status[1].Failures != 2 || status[1].LastError != "status 401" {
+		t.Errorf("expected two consecutive failures, got %+v", status[1])
+	}
+	if len(failures) != 2 {
+		t.Errorf("expected a failure callback per failed ping, got %v", failures)
+	}
+
+	fail = false
+	pinger.PingAll(context.Background())
+	if status := pinger.Status(); !status[1].Healthy || status[1].Failures != 0 || status[1].LastError != "" {
+		t.Errorf("expected the model to recover, got %+v", status[1])
+	}
+}
@@ -0,0 +1,141 @@
+package ociaitoopenai
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// redacted replaces secrets in the introspected configuration.
+const redacted = "REDACTED"
+
+// introspectionResponse is the body of the introspection endpoint.
+type introspectionResponse struct {
+	Instance string                 `json:"instance"`
+	Features map[string]bool        `json:"features"`
+	Config   map[string]interface{} `json:"config"`
+}
+
+// serveIntrospection writes the effective configuration of the plugin instance, with secrets
+// redacted, and which of its optional features are running.
+func (p *Proxy) serveIntrospection(rw http.ResponseWriter, _ *http.Request) {
+	var cfg map[string]interface{}
+	body, err := json.Marshal(p.config)
+	if err == nil {
+		err = json.Unmarshal(body, &cfg)
+	}
+	if err != nil {
+		log.Printf("[%s] ERROR: Failed to encode the configuration: %v", p.name, err)
+		writeFailure(rw, err)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(rw).Encode(introspectionResponse{
+		Instance: p.name,
+		Features: p.features(),
+		Config:   redact(cfg).(map[string]interface{}),
+	})
+}
+
+// features reports which optional features are running. Features turned off by the
+// degradation guard are reported as not running until their cooldown has passed.
+func (p *Proxy) features() map[string]bool {
+	features := map[string]bool{
+		"signing":              p.signer != nil,
+		featureMirror:          p.mirror != nil,
+		featureAudit:           p.audit != nil,
+		featureUsage:           p.usage != nil,
+		featureModelValidation: p.catalog != nil,
+		"imageFetch":           p.images != nil,
+		"admission":            p.admission != nil,
+		"loadBalancing":        p.balancer != nil,
+		"promptTemplates":      p.prompts != nil,
+		"ipFilter":             p.ipFilter != nil,
+		"fixtures":             p.fixtures != nil,
+		"tenantSource":         p.tenants != nil,
+		"schedules":            p.schedule != nil,
+		"agents":               p.sessions != nil,
+		"assistants":           p.assistants != nil,
+		"chaos":                p.chaos != nil,
+		"slo":                  p.slo != nil,
+		"warmUp":               p.warmUp != nil,
+		"degradation":          p.degrade != nil,
+		"maintenance":          p.config.Maintenance.Enabled,
+		"metrics":              p.config.Metrics.Enabled,
+		"status":               p.config.Status.Enabled,
+	}
+	if p.degrade != nil {
+		for _, feature := range p.degrade.Status() {
+			if feature.Disabled {
+				features[feature.Feature] = false
+			}
+		}
+	}
+	return features
+}
+
+// redact replaces the secrets in a decoded JSON value: private keys, tenant values, which may be
+// virtual API keys, and the user information and query values of URLs, which may carry credentials.
+func redact(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if s, ok := field.(string); ok && key == "pem" && s != "" {
+				v[key] = redacted
+				continue
+			}
+			if tenants, ok := field.(map[string]interface{}); ok && key == "tenants" {
+				v[key] = redact(redactTenants(tenants))
+				continue
+			}
+			v[key] = redact(field)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redact(item)
+		}
+	case string:
+		return redactURL(v)
+	}
+	return value
+}
+
+// redactTenants keys tenant settings by a hash of the tenant value instead of the value itself,
+// so operators can still tell tenants apart.
+func redactTenants(tenants map[string]interface{}) map[string]interface{} {
+	hashed := make(map[string]interface{}, len(tenants))
+	for tenant, settings := range tenants {
+		sum := sha256.Sum256([]byte(tenant))
+		hashed["sha256:"+hex.EncodeToString(sum[:6])] = settings
+	}
+	return hashed
+}
+
+// redactURL redacts the user information and query values of an absolute URL, returning other
+// strings as they are.
+func redactURL(s string) string {
+	if !strings.Contains(s, "://") {
+		return s
+	}
+	u, err := url.Parse(s)
+	if err != nil || u.Host == "" || (u.User == nil && u.RawQuery == "") {
+		return s
+	}
+	if u.User != nil {
+		u.User = url.User(redacted)
+	}
+	if u.RawQuery != "" {
+		query := u.Query()
+		for key := range query {
+			query[key] = []string{redacted}
+		}
+		u.RawQuery = query.Encode()
+	}
+	return u.String()
+}
@@ -0,0 +1,16 @@
+package ociaitoopenai
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// serveMaintenance answers a request for an OpenAI endpoint while maintenance mode is enabled.
+func (p *Proxy) serveMaintenance(rw http.ResponseWriter) {
+	log.Printf("[%s] ServeHTTP: Rejecting request during maintenance", p.name)
+	retryAfter, _ := time.ParseDuration(p.config.Maintenance.RetryAfter)
+	rw.Header().Set("Retry-After", fmt.Sprintf("%d", int(retryAfter.Seconds()+0.5)))
+	writeError(rw, http.StatusServiceUnavailable, p.config.Maintenance.Message, "", "service_unavailable")
+}
@@ -0,0 +1,77 @@
+package ociaitoopenai
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/zalbiraw/ociaitoopenai/pkg/types"
+)
+
+// modelsQuery is the filtering a client requested on the models endpoint.
+type modelsQuery struct {
+	limit   int    // Zero for no limit
+	ownedBy string // Empty for every owner
+}
+
+// parseModelsQuery reads the limit and owned_by parameters of a models request, answering the
+// request with a 400 and reporting false when they are invalid.
+func parseModelsQuery(rw http.ResponseWriter, req *http.Request) (modelsQuery, bool) {
+	var query modelsQuery
+	if limit := req.URL.Query().Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > 1000 {
+			writeError(rw, http.StatusBadRequest, "limit must be an integer between 1 and 1000", "limit", "invalid_limit")
+			return query, false
+		}
+		query.limit = n
+	}
+	query.ownedBy = strings.TrimSpace(req.URL.Query().Get("owned_by"))
+	return query, true
+}
+
+// filter keeps the models of the requested owner, up to the limit, setting HasMore when models
+// were left out by the limit.
+func (q modelsQuery) filter(resp *types.OpenAIModelsResponse) {
+	if q.ownedBy != "" {
+		kept := resp.Data[:0]
+		for _, model := range resp.Data {
+			if strings.EqualFold(model.OwnedBy, q.ownedBy) {
+				kept = append(kept, model)
+			}
+		}
+		resp.Data = kept
+	}
+	if q.limit > 0 && len(resp.Data) > q.limit {
+		resp.Data = resp.Data[:q.limit]
+		resp.HasMore = true
+	}
+}
+
+// acceptsJSON reports whether a client's Accept header accepts a JSON response. A missing header
+// accepts anything.
+func acceptsJSON(accept string) bool {
+	if strings.TrimSpace(accept) == "" {
+		return true
+	}
+	for _, part := range strings.Split(accept, ",") {
+		fields := strings.Split(part, ";")
+		mediaType := strings.ToLower(strings.TrimSpace(fields[0]))
+		if mediaType != "application/json" && mediaType != "application/*" && mediaType != "*/*" {
+			continue
+		}
+		quality := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if q, err := strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64); err == nil {
+					quality = q
+				}
+			}
+		}
+		if quality > 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,33 @@
+package ociaitoopenai
+
+import (
+	"log"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/zalbiraw/ociaitoopenai/internal/clientip"
+)
+
+// modelOverride returns the model named by the override header of a trusted client, if any. The
+// header is removed either way, so it never reaches OCI. The override replaces the model chosen by
+// tenant routing, so clients outside the trusted networks are never trusted, even when none are
+// configured.
+func (p *Proxy) modelOverride(req *http.Request) string {
+	if !p.config.ModelOverride.Enabled {
+		return ""
+	}
+	header := p.config.ModelOverride.Header
+	model := strings.TrimSpace(req.Header.Get(header))
+	req.Header.Del(header)
+	if model == "" {
+		return ""
+	}
+
+	clientIP := p.clientIPs.ClientIP(req)
+	if ip := net.ParseIP(clientIP); ip == nil || !clientip.Contains(p.overriders, ip) {
+		log.Printf("[%s] Ignoring %s header from untrusted client %s", p.name, header, clientIP)
+		return ""
+	}
+	return model
+}
@@ -0,0 +1,301 @@
+// Package ocimock provides a mock of the OCI Generative AI inference and ListModels APIs for
+// end-to-end tests of pipelines using the plugin. A Server is an http.Handler, so it can be
+// passed as the plugin's next handler or wrapped with httptest.NewServer.
+package ocimock
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/zalbiraw/ociaitoopenai/pkg/types"
+)
+
+const (
+	// ChatPath is the OCI chat action path.
+	ChatPath = "/20231130/actions/chat"
+	// ModelsPath is the OCI ListModels path.
+	ModelsPath = "/20231130/models"
+)
+
+// Failure is an error response returned instead of the next request's normal response.
+type Failure struct {
+	// Status is the HTTP status code
+	Status int
+	// Code is the OCI error code, e.g. "TooManyRequests"
+	Code string
+	// Message is the error message
+	Message string
+	// RetryAfter is sent as the Retry-After header, in seconds, when positive
+	RetryAfter int
+}
+
+// Throttled returns the failure OCI responds with when a tenancy exceeds its request limit.
+func Throttled() Failure {
+	return Failure{Status: http.StatusTooManyRequests, Code: "TooManyRequests", Message: "Too many requests for the tenancy", RetryAfter: 1}
+}
+
+// Server is a mock OCI Generative AI endpoint. Chat requests are answered with the configured
+// reply, or an echo of the last user message, in the request's API format; streamed requests
+// receive the reply word by word as server-sent events.
+type Server struct {
+	mu       sync.Mutex
+	reply    string
+	models   []types.OCIModel
+	failures []Failure
+	requests []types.OracleCloudRequest
+	counter  int
+}
+
+// New creates a mock server listing a COHERE and a GENERIC chat model.
+func New() *Server {
+	return &Server{
+		models: []types.OCIModel{
+			Model("cohere.command-r-plus", "cohere"),
+			Model("meta.llama-3.3-70b-instruct", "meta"),
+		},
+	}
+}
+
+// Model returns an active on-demand chat model for use with SetModels.
+func Model(name, vendor string) types.OCIModel {
+	return types.OCIModel{
+		ID:             "ocid1.generativeaimodel.oc1.mock." + name,
+		DisplayName:    name,
+		Vendor:         vendor,
+		Capabilities:   []string{"CHAT"},
+		LifecycleState: "ACTIVE",
+		Type:           "BASE",
+		TimeCreated:    "2024-01-01T00:00:00.000Z",
+	}
+}
+
+// SetReply sets the text returned by chat requests. An empty reply echoes the last user message.
+func (s *Server) SetReply(reply string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reply = reply
+}
+
+// SetModels replaces the models returned by ListModels.
+func (s *Server) SetModels(models ...types.OCIModel) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.models = models
+}
+
+// Fail queues failures, each returned for one subsequent request in order.
+func (s *Server) Fail(failures ...Failure) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = append(s.failures, failures...)
+}
+
+// Requests returns the chat requests received so far, including failed ones.
+func (s *Server) Requests() []types.OracleCloudRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]types.OracleCloudRequest(nil), s.requests...)
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	s.mu.Lock()
+	s.counter++
+	rw.Header().Set("Opc-Request-Id", fmt.Sprintf("mock-%d", s.counter))
+	s.mu.Unlock()
+
+	switch {
+	case req.Method == http.MethodPost && req.URL.Path == ChatPath:
+		s.serveChat(rw, req)
+	case req.Method == http.MethodGet && req.URL.Path == ModelsPath:
+		if s.fail(rw) {
+			return
+		}
+		s.mu.Lock()
+		models := types.OCIModelsResponse{Items: append([]types.OCIModel{}, s.models...)}
+		s.mu.Unlock()
+		writeJSON(rw, http.StatusOK, models)
+	default:
+		writeFailure(rw, Failure{Status: http.StatusNotFound, Code: "NotAuthorizedOrNotFound", Message: "Unknown resource " + req.URL.Path})
+	}
+}
+
+// serveChat answers a chat request, recording it first.
+func (s *Server) serveChat(rw http.ResponseWriter, req *http.Request) {
+	var chat types.OracleCloudRequest
+	if err := json.NewDecoder(req.Body).Decode(&chat); err != nil {
+		writeFailure(rw, Failure{Status: http.StatusBadRequest, Code: "InvalidParameter", Message: "Invalid request body: " + err.Error()})
+		return
+	}
+	s.mu.Lock()
+	s.requests = append(s.requests, chat)
+	reply := s.reply
+	s.mu.Unlock()
+
+	if s.fail(rw) {
+		return
+	}
+	if chat.ServingMode.ModelID == "" {
+		writeFailure(rw, Failure{Status: http.StatusBadRequest, Code: "InvalidParameter", Message: "servingMode.modelId is required"})
+		return
+	}
+
+	if reply == "" {
+		reply = lastUserMessage(chat.ChatRequest)
+	}
+	usage := types.OracleCloudUsage{
+		PromptTokens:     countTokens(promptText(chat.ChatRequest)),
+		CompletionTokens: countTokens(reply),
+	}
+	usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+
+	if chat.ChatRequest.IsStream {
+		streamReply(rw, chat.ChatRequest.APIFormat, reply, usage)
+		return
+	}
+
+	response := types.OracleCloudResponse{
+		ModelID:      chat.ServingMode.ModelID,
+		ModelVersion: "1.0",
+		ChatResponse: types.OracleCloudChatResponse{APIFormat: chat.ChatRequest.APIFormat, Usage: usage},
+	}
+	if chat.ChatRequest.APIFormat == "COHERE" {
+		response.ChatResponse.Text = reply
+		response.ChatResponse.FinishReason = "COMPLETE"
+	} else {
+		response.ChatResponse.Choices = []types.OracleGenericChoice{{
+			Message:      genericMessage(reply),
+			FinishReason: "stop",
+		}}
+	}
+	writeJSON(rw, http.StatusOK, response)
+}
+
+// fail writes the next queued failure, if any, and reports whether it did.
+func (s *Server) fail(rw http.ResponseWriter) bool {
+	s.mu.Lock()
+	if len(s.failures) == 0 {
+		s.mu.Unlock()
+		return false
+	}
+	failure := s.failures[0]
+	s.failures = s.failures[1:]
+	s.mu.Unlock()
+
+	writeFailure(rw, failure)
+	return true
+}
+
+// streamReply sends the reply as OCI server-sent events, one word per event, followed by a
+// final event carrying the finish reason and usage.
+func streamReply(rw http.ResponseWriter, apiFormat, reply string, usage types.OracleCloudUsage) {
+	rw.Header().Set("Content-Type", "text/event-stream")
+	rw.WriteHeader(http.StatusOK)
+
+	words := strings.SplitAfter(reply, " ")
+	for _, word := range words {
+		if word == "" {
+			continue
+		}
+		event := types.OracleCloudStreamEvent{APIFormat: apiFormat}
+		if apiFormat == "COHERE" {
+			event.Text = word
+		} else {
+			message := genericMessage(word)
+			event.Message = &message
+		}
+		writeEvent(rw, event)
+	}
+
+	final := types.OracleCloudStreamEvent{APIFormat: apiFormat, FinishReason: "stop", Usage: &usage}
+	if apiFormat == "COHERE" {
+		final.Text = reply
+		final.FinishReason = "COMPLETE"
+	}
+	writeEvent(rw, final)
+}
+
+// writeEvent writes a single server-sent event and flushes it.
+func writeEvent(rw http.ResponseWriter, event types.OracleCloudStreamEvent) {
+	payload, _ := json.Marshal(event)
+	_, _ = fmt.Fprintf(rw, "data: %s\n\n", payload)
+	if flusher, ok := rw.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// genericMessage wraps text in a GENERIC assistant message.
+func genericMessage(text string) types.OracleGenericMessage {
+	return types.OracleGenericMessage{
+		Role:    "ASSISTANT",
+		Content: []types.OracleGenericContent{{Type: "TEXT", Text: text}},
+	}
+}
+
+// lastUserMessage returns the text of the message being answered.
+func lastUserMessage(chat types.ChatRequest) string {
+	if chat.Message != "" {
+		return chat.Message
+	}
+	for i := len(chat.Messages) - 1; i >= 0; i-- {
+		if message, ok := chat.Messages[i].(map[string]interface{}); ok && message["role"] == "USER" {
+			return messageText(message)
+		}
+	}
+	return ""
+}
+
+// promptText returns the text of every message in the request.
+func promptText(chat types.ChatRequest) string {
+	text := []string{chat.Message}
+	for _, entry := range chat.ChatHistory {
+		if message, ok := entry.(map[string]interface{}); ok {
+			value, _ := message["message"].(string)
+			text = append(text, value)
+		}
+	}
+	for _, entry := range chat.Messages {
+		if message, ok := entry.(map[string]interface{}); ok {
+			text = append(text, messageText(message))
+		}
+	}
+	return strings.Join(text, " ")
+}
+
+// messageText joins the TEXT content of a decoded GENERIC message.
+func messageText(message map[string]interface{}) string {
+	contents, _ := message["content"].([]interface{})
+	var text []string
+	for _, content := range contents {
+		if part, ok := content.(map[string]interface{}); ok && part["type"] == "TEXT" {
+			if value, ok := part["text"].(string); ok {
+				text = append(text, value)
+			}
+		}
+	}
+	return strings.Join(text, " ")
+}
+
+// countTokens approximates a token count as the number of words.
+func countTokens(text string) int {
+	return len(strings.Fields(text))
+}
+
+// writeFailure writes an OCI error response.
+func writeFailure(rw http.ResponseWriter, failure Failure) {
+	if failure.RetryAfter > 0 {
+		rw.Header().Set("Retry-After", strconv.Itoa(failure.RetryAfter))
+	}
+	writeJSON(rw, failure.Status, map[string]string{"code": failure.Code, "message": failure.Message})
+}
+
+// writeJSON writes a JSON response with the given status.
+func writeJSON(rw http.ResponseWriter, status int, body interface{}) {
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(status)
+	_ = json.NewEncoder(rw).Encode(body)
+}
@@ -0,0 +1,123 @@
+package ocimock_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/zalbiraw/ociaitoopenai"
+	"github.com/zalbiraw/ociaitoopenai/pkg/ocimock"
+	"github.com/zalbiraw/ociaitoopenai/pkg/types"
+)
+
+func newPlugin(t *testing.T, mock *ocimock.Server) http.Handler {
+	t.Helper()
+	cfg := ociaitoopenai.CreateConfig()
+	cfg.CompartmentID = "ocid1.compartment.oc1..testcompartment"
+	cfg.Region = "us-ashburn-1"
+	cfg.EnableModelsEndpoint = true
+
+	handler, err := ociaitoopenai.New(context.Background(), mock, cfg, "test-plugin")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	return handler
+}
+
+func chat(handler http.Handler, body string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/chat/completions", strings.NewReader(body)))
+	return recorder
+}
+
+func TestServer_Chat(t *testing.T) {
+	for _, model := range []string{"cohere.command-r-plus", "meta.llama-3.3-70b-instruct"} {
+		t.Run(model, func(t *testing.T) {
+			mock := ocimock.New()
+			recorder := chat(newPlugin(t, mock), `{"model":"`+model+`","messages":[{"role":"user","content":"Hello there"}]}`)
+
+			var resp types.ChatCompletionResponse
+			if err := json.Unmarshal(recorder.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("failed to decode response %q: %v", recorder.Body.String(), err)
+			}
+			if len(resp.Choices) != 1 || resp.Choices[0].Message.Content != "Hello there" || resp.Choices[0].FinishReason != "stop" {
+				t.Errorf("expected the message echoed, got %+v", resp.Choices)
+			}
+			if resp.Usage.CompletionTokens != 2 {
+				t.Errorf("expected 2 completion tokens, got %+v", resp.Usage)
+			}
+			if requests := mock.Requests(); len(requests) != 1 || requests[0].ServingMode.ModelID != model {
+				t.Errorf("expected the request to be recorded, got %+v", requests)
+			}
+		})
+	}
+}
+
+func TestServer_Stream(t *testing.T) {
+	mock := ocimock.New()
+	mock.SetReply("One two three")
+	recorder := chat(newPlugin(t, mock), `{"model":"meta.llama-3.3-70b-instruct","stream":true,"messages":[{"role":"user","content":"Count"}]}`)
+
+	var content strings.Builder
+	for _, line := range strings.Split(recorder.Body.String(), "\n") {
+		payload := strings.TrimPrefix(line, "data: ")
+		if payload == line || payload == "[DONE]" {
+			continue
+		}
+		var chunk types.ChatCompletionChunk
+		if err := json.Unmarshal([]byte(payload), &chunk); err != nil {
+			t.Fatalf("failed to decode chunk %q: %v", payload, err)
+		}
+		for _, choice := range chunk.Choices {
+			content.WriteString(choice.Delta.Content)
+		}
+	}
+	if content.String() != "One two three" {
+		t.Errorf("expected the streamed reply, got %q", content.String())
+	}
+}
+
+func TestServer_ListModels(t *testing.T) {
+	mock := ocimock.New()
+	mock.SetModels(ocimock.Model("xai.grok-3", "xai"))
+	recorder := httptest.NewRecorder()
+	newPlugin(t, mock).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/v1/models", nil))
+
+	var resp types.OpenAIModelsResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response %q: %v", recorder.Body.String(), err)
+	}
+	if len(resp.Data) != 1 || resp.Data[0].ID != "xai.grok-3" {
+		t.Errorf("expected the configured model, got %+v", resp.Data)
+	}
+}
+
+func TestServer_Failures(t *testing.T) {
+	mock := ocimock.New()
+	mock.Fail(ocimock.Throttled())
+	server := httptest.NewServer(mock)
+	defer server.Close()
+
+	body := []byte(`{"compartmentId":"c","servingMode":{"modelId":"cohere.command-r-plus"},"chatRequest":{"apiFormat":"COHERE","message":"Hi"}}`)
+	resp, err := http.Post(server.URL+ocimock.ChatPath, "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusTooManyRequests || resp.Header.Get("Retry-After") != "1" {
+		t.Errorf("expected a throttled response, got %d", resp.StatusCode)
+	}
+
+	resp, err = http.Post(server.URL+ocimock.ChatPath, "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected the failure to apply once, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,74 @@
+package types
+
+// OCIAgentSessionRequest represents an OCI Generative AI Agents createSession request.
+type OCIAgentSessionRequest struct {
+	// DisplayName is the name of the session
+	DisplayName string `json:"displayName,omitempty"`
+
+	// Description describes the session
+	Description string `json:"description,omitempty"`
+}
+
+// OCIAgentSession represents an OCI Generative AI Agents session.
+type OCIAgentSession struct {
+	// ID is the session ID, passed on chat requests to continue the conversation
+	ID string `json:"id"`
+}
+
+// OCIAgentChatRequest represents an OCI Generative AI Agents chat request.
+type OCIAgentChatRequest struct {
+	// UserMessage is the user's message; the session holds the earlier conversation
+	UserMessage string `json:"userMessage"`
+
+	// ShouldStream streams the response as server-sent events
+	ShouldStream bool `json:"shouldStream"`
+
+	// SessionID is the session the message belongs to
+	SessionID string `json:"sessionId,omitempty"`
+}
+
+// OCIAgentChatResponse represents an OCI Generative AI Agents chat response.
+type OCIAgentChatResponse struct {
+	// Message is the agent's answer
+	Message *OCIAgentMessage `json:"message"`
+}
+
+// OCIAgentMessage represents a message of an agent conversation.
+type OCIAgentMessage struct {
+	// Role is "USER" or "AGENT"
+	Role string `json:"role"`
+
+	// Content holds the text of the message and the sources it cites
+	Content OCIAgentMessageContent `json:"content"`
+}
+
+// OCIAgentMessageContent represents the content of an agent message.
+type OCIAgentMessageContent struct {
+	// Text is the message text
+	Text string `json:"text"`
+
+	// Citations are the knowledge base sources the answer is based on
+	Citations []AgentCitation `json:"citations,omitempty"`
+}
+
+// AgentCitation represents a knowledge base source cited by an agent. It is returned to
+// clients unchanged as a plugin extension.
+type AgentCitation struct {
+	// SourceText is the cited passage
+	SourceText string `json:"sourceText,omitempty"`
+
+	// Title is the title of the source document
+	Title string `json:"title,omitempty"`
+
+	// SourceLocation identifies the source document
+	SourceLocation *AgentSourceLocation `json:"sourceLocation,omitempty"`
+}
+
+// AgentSourceLocation represents the location of a cited source.
+type AgentSourceLocation struct {
+	// SourceLocationType is "OCI_OBJECT_STORAGE" or "OCI_OPEN_SEARCH", among others
+	SourceLocationType string `json:"sourceLocationType,omitempty"`
+
+	// URL is the location of the source
+	URL string `json:"url,omitempty"`
+}
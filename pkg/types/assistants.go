@@ -0,0 +1,147 @@
+package types
+
+import "encoding/json"
+
+// Assistant represents an OpenAI assistant.
+type Assistant struct {
+	ID           string            `json:"id"`
+	Object       string            `json:"object"`     // Always "assistant"
+	CreatedAt    int64             `json:"created_at"` //nolint:tagliatelle
+	Name         string            `json:"name"`
+	Description  string            `json:"description"`
+	Model        string            `json:"model"`
+	Instructions string            `json:"instructions"`
+	Tools        []json.RawMessage `json:"tools"`
+	Metadata     map[string]string `json:"metadata"`
+	Temperature  *float64          `json:"temperature,omitempty"`
+	TopP         *float64          `json:"top_p,omitempty"` //nolint:tagliatelle
+}
+
+// AssistantRequest represents an OpenAI create assistant request.
+type AssistantRequest struct {
+	Model        string            `json:"model"`
+	Name         string            `json:"name,omitempty"`
+	Description  string            `json:"description,omitempty"`
+	Instructions string            `json:"instructions,omitempty"`
+	Tools        []json.RawMessage `json:"tools,omitempty"`
+	Metadata     map[string]string `json:"metadata,omitempty"`
+	Temperature  *float64          `json:"temperature,omitempty"`
+	TopP         *float64          `json:"top_p,omitempty"` //nolint:tagliatelle
+}
+
+// Thread represents an OpenAI assistants conversation thread.
+type Thread struct {
+	ID        string            `json:"id"`
+	Object    string            `json:"object"`     // Always "thread"
+	CreatedAt int64             `json:"created_at"` //nolint:tagliatelle
+	Metadata  map[string]string `json:"metadata"`
+}
+
+// ThreadRequest represents an OpenAI create thread request.
+type ThreadRequest struct {
+	Messages []ThreadMessageRequest `json:"messages,omitempty"`
+	Metadata map[string]string      `json:"metadata,omitempty"`
+}
+
+// ThreadMessage represents a message of a thread.
+type ThreadMessage struct {
+	ID          string                 `json:"id"`
+	Object      string                 `json:"object"`     // Always "thread.message"
+	CreatedAt   int64                  `json:"created_at"` //nolint:tagliatelle
+	ThreadID    string                 `json:"thread_id"`  //nolint:tagliatelle
+	Status      string                 `json:"status"`     // Always "completed"
+	Role        string                 `json:"role"`       // "user" or "assistant"
+	Content     []ThreadMessageContent `json:"content"`
+	AssistantID *string                `json:"assistant_id"` //nolint:tagliatelle
+	RunID       *string                `json:"run_id"`       //nolint:tagliatelle
+	Metadata    map[string]string      `json:"metadata"`
+}
+
+// Text returns the concatenated text content of the message.
+func (m ThreadMessage) Text() string {
+	var text string
+	for _, content := range m.Content {
+		if content.Type == "text" {
+			text += content.Text.Value
+		}
+	}
+	return text
+}
+
+// ThreadMessageContent represents a content part of a thread message. Only text is supported.
+type ThreadMessageContent struct {
+	Type string            `json:"type"` // Always "text"
+	Text ThreadMessageText `json:"text"`
+}
+
+// ThreadMessageText represents the text of a message content part.
+type ThreadMessageText struct {
+	Value       string            `json:"value"`
+	Annotations []json.RawMessage `json:"annotations"`
+}
+
+// ThreadMessageRequest represents an OpenAI create message request. Content is a string or
+// an array of content parts, of which only text parts are supported.
+type ThreadMessageRequest struct {
+	Role     string            `json:"role"`
+	Content  json.RawMessage   `json:"content"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// Run represents an execution of an assistant on a thread.
+type Run struct {
+	ID           string               `json:"id"`
+	Object       string               `json:"object"`       // Always "thread.run"
+	CreatedAt    int64                `json:"created_at"`   //nolint:tagliatelle
+	ThreadID     string               `json:"thread_id"`    //nolint:tagliatelle
+	AssistantID  string               `json:"assistant_id"` //nolint:tagliatelle
+	Status       string               `json:"status"`       // "queued", "in_progress", "completed" or "failed"
+	Model        string               `json:"model"`
+	Instructions string               `json:"instructions"`
+	Tools        []json.RawMessage    `json:"tools"`
+	StartedAt    *int64               `json:"started_at"`   //nolint:tagliatelle
+	CompletedAt  *int64               `json:"completed_at"` //nolint:tagliatelle
+	FailedAt     *int64               `json:"failed_at"`    //nolint:tagliatelle
+	LastError    *RunError            `json:"last_error"`   //nolint:tagliatelle
+	Usage        *ChatCompletionUsage `json:"usage"`
+	Metadata     map[string]string    `json:"metadata"`
+	Temperature  *float64             `json:"temperature,omitempty"`
+	TopP         *float64             `json:"top_p,omitempty"` //nolint:tagliatelle
+}
+
+// RunError describes why a run failed.
+type RunError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// RunRequest represents an OpenAI create run request. Thread is only used when creating a
+// thread and a run in one request.
+type RunRequest struct {
+	AssistantID            string                 `json:"assistant_id"` //nolint:tagliatelle
+	Model                  string                 `json:"model,omitempty"`
+	Instructions           string                 `json:"instructions,omitempty"`
+	AdditionalInstructions string                 `json:"additional_instructions,omitempty"` //nolint:tagliatelle
+	AdditionalMessages     []ThreadMessageRequest `json:"additional_messages,omitempty"`     //nolint:tagliatelle
+	Temperature            *float64               `json:"temperature,omitempty"`
+	TopP                   *float64               `json:"top_p,omitempty"` //nolint:tagliatelle
+	Stream                 bool                   `json:"stream,omitempty"`
+	Metadata               map[string]string      `json:"metadata,omitempty"`
+	Thread                 *ThreadRequest         `json:"thread,omitempty"`
+}
+
+// ListResponse represents a page of an OpenAI list endpoint.
+type ListResponse struct {
+	Object  string      `json:"object"` // Always "list"
+	Data    interface{} `json:"data"`
+	FirstID *string     `json:"first_id"` //nolint:tagliatelle
+	LastID  *string     `json:"last_id"`  //nolint:tagliatelle
+	HasMore bool        `json:"has_more"` //nolint:tagliatelle
+}
+
+// DeletedResponse represents the response to an OpenAI delete request.
+type DeletedResponse struct {
+	ID      string `json:"id"`
+	Object  string `json:"object"` // e.g. "assistant.deleted"
+	Deleted bool   `json:"deleted"`
+}
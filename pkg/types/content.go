@@ -0,0 +1,83 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ContentPart is a single part of a multi-part message content array.
+type ContentPart struct {
+	// Type is the part type ("text" or "image_url"). Other types, such as "input_audio", are
+	// kept so they can be rejected, but carry no content
+	Type string `json:"type"`
+
+	// Text is the text of a "text" part
+	Text string `json:"text,omitempty"`
+
+	// ImageURL is the image of an "image_url" part
+	ImageURL *ImageURL `json:"image_url,omitempty"` //nolint:tagliatelle
+}
+
+// ImageURL references an image by URL or as a base64 data URL.
+type ImageURL struct {
+	// URL is an http(s) URL or a "data:<mime>;base64,<data>" URL
+	URL string `json:"url"`
+
+	// Detail is the requested image fidelity ("auto", "low", "high")
+	Detail string `json:"detail,omitempty"`
+}
+
+// chatCompletionMessage is ChatCompletionMessage without its JSON methods.
+type chatCompletionMessage ChatCompletionMessage
+
+// UnmarshalJSON accepts content as a string, null, or an array of content parts.
+// For arrays, Content is set to the concatenated text parts and Parts keeps all parts.
+func (m *ChatCompletionMessage) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		chatCompletionMessage
+		Content json.RawMessage `json:"content"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*m = ChatCompletionMessage(raw.chatCompletionMessage)
+	m.Content = ""
+	m.Parts = nil
+
+	content := strings.TrimSpace(string(raw.Content))
+	switch {
+	case content == "" || content == "null":
+		return nil
+	case strings.HasPrefix(content, "["):
+		if err := json.Unmarshal(raw.Content, &m.Parts); err != nil {
+			return fmt.Errorf("invalid content parts: %w", err)
+		}
+		var texts []string
+		for _, part := range m.Parts {
+			if part.Type == "text" {
+				texts = append(texts, part.Text)
+			}
+		}
+		m.Content = strings.Join(texts, "\n")
+		return nil
+	default:
+		return json.Unmarshal(raw.Content, &m.Content)
+	}
+}
+
+// MarshalJSON writes content as a parts array when the message has parts, otherwise as a string.
+func (m ChatCompletionMessage) MarshalJSON() ([]byte, error) {
+	var content interface{} = m.Content
+	if len(m.Parts) > 0 {
+		content = m.Parts
+	}
+	return json.Marshal(struct {
+		chatCompletionMessage
+		Content interface{} `json:"content"`
+	}{
+		chatCompletionMessage: chatCompletionMessage(m),
+		Content:               content,
+	})
+}
@@ -0,0 +1,151 @@
+package types
+
+// SpeechRequest represents an OpenAI text-to-speech request.
+type SpeechRequest struct {
+	// Model is the TTS model (e.g., "tts-1")
+	Model string `json:"model"`
+
+	// Input is the text to speak
+	Input string `json:"input"`
+
+	// Voice is the voice to speak with
+	Voice string `json:"voice"`
+
+	// ResponseFormat is the audio format: "mp3" (the default), "opus", "aac", "flac", "wav" or "pcm"
+	ResponseFormat string `json:"response_format,omitempty"` //nolint:tagliatelle
+
+	// Speed is the speaking rate, from 0.25 to 4.0. 0 means the default of 1.0
+	Speed float64 `json:"speed,omitempty"`
+}
+
+// OCISpeechRequest represents an OCI AI Speech synthesizeSpeech request.
+type OCISpeechRequest struct {
+	// Text is plain text or SSML, as set by the speech settings
+	Text string `json:"text"`
+
+	// IsStreamEnabled streams the audio as it is synthesized
+	IsStreamEnabled bool `json:"isStreamEnabled"`
+
+	// CompartmentID is the OCI compartment the request is made in
+	CompartmentID string `json:"compartmentId"`
+
+	// Configuration selects the model, voice and output
+	Configuration OCISpeechConfiguration `json:"configuration"`
+}
+
+// OCISpeechConfiguration represents the TTS configuration of an OCI speech request.
+type OCISpeechConfiguration struct {
+	// ModelFamily is always "ORACLE"
+	ModelFamily string `json:"modelFamily"`
+
+	// ModelDetails selects the model and voice
+	ModelDetails OCISpeechModelDetails `json:"modelDetails"`
+
+	// SpeechSettings selects the input and output formats
+	SpeechSettings OCISpeechSettings `json:"speechSettings"`
+}
+
+// OCISpeechModelDetails represents the model and voice of an OCI speech request.
+type OCISpeechModelDetails struct {
+	// ModelName is the TTS model (e.g., "TTS_2_NATURAL")
+	ModelName string `json:"modelName"`
+
+	// VoiceID is the voice to speak with (e.g., "Annabelle")
+	VoiceID string `json:"voiceId"`
+}
+
+// OCISpeechSettings represents the input and output formats of an OCI speech request.
+type OCISpeechSettings struct {
+	// TextType is "TEXT" or "SSML"
+	TextType string `json:"textType"`
+
+	// SampleRateInHz is the sample rate of the audio
+	SampleRateInHz int `json:"sampleRateInHz"`
+
+	// OutputFormat is "MP3", "OGG" or "PCM"
+	OutputFormat string `json:"outputFormat"`
+}
+
+// TranscriptionResponse represents an OpenAI transcription response in the json format.
+type TranscriptionResponse struct {
+	// Text is the transcribed text
+	Text string `json:"text"`
+}
+
+// OCITranscriptionJobRequest represents an OCI AI Speech createTranscriptionJob request.
+type OCITranscriptionJobRequest struct {
+	// CompartmentID is the OCI compartment the job is created in
+	CompartmentID string `json:"compartmentId"`
+
+	// DisplayName names the job
+	DisplayName string `json:"displayName,omitempty"`
+
+	// ModelDetails selects the model and language
+	ModelDetails OCITranscriptionModelDetails `json:"modelDetails"`
+
+	// InputLocation lists the audio objects to transcribe
+	InputLocation OCITranscriptionInputLocation `json:"inputLocation"`
+
+	// OutputLocation is where the results are written
+	OutputLocation OCIObjectPrefix `json:"outputLocation"`
+}
+
+// OCITranscriptionModelDetails represents the model of a transcription job.
+type OCITranscriptionModelDetails struct {
+	// ModelType is the transcription model (e.g., "WHISPER_MEDIUM" or "ORACLE")
+	ModelType string `json:"modelType"`
+
+	// LanguageCode is the language of the audio; empty uses the model's default
+	LanguageCode string `json:"languageCode,omitempty"`
+}
+
+// OCITranscriptionInputLocation represents the audio objects of a transcription job.
+type OCITranscriptionInputLocation struct {
+	// LocationType is always "OBJECT_LIST_INLINE_INPUT_LOCATION"
+	LocationType string `json:"locationType"`
+
+	// ObjectLocations lists the objects by bucket
+	ObjectLocations []OCIObjectLocation `json:"objectLocations"`
+}
+
+// OCIObjectLocation represents objects in an Object Storage bucket.
+type OCIObjectLocation struct {
+	NamespaceName string   `json:"namespaceName"`
+	BucketName    string   `json:"bucketName"`
+	ObjectNames   []string `json:"objectNames"`
+}
+
+// OCIObjectPrefix represents an object name prefix in an Object Storage bucket.
+type OCIObjectPrefix struct {
+	NamespaceName string `json:"namespaceName"`
+	BucketName    string `json:"bucketName"`
+	Prefix        string `json:"prefix,omitempty"`
+}
+
+// OCITranscriptionJob represents the state of an OCI AI Speech transcription job.
+type OCITranscriptionJob struct {
+	ID               string `json:"id"`
+	LifecycleState   string `json:"lifecycleState"`
+	LifecycleDetails string `json:"lifecycleDetails,omitempty"`
+}
+
+// OCITranscriptionTasks represents the tasks of a transcription job, one per input object.
+type OCITranscriptionTasks struct {
+	Items []OCITranscriptionTask `json:"items"`
+}
+
+// OCITranscriptionTask represents a transcription task and the location of its result.
+type OCITranscriptionTask struct {
+	ID             string            `json:"id"`
+	OutputLocation OCIObjectLocation `json:"outputLocation"`
+}
+
+// OCITranscriptionResult represents the result object written by a transcription task.
+type OCITranscriptionResult struct {
+	Transcriptions []OCITranscription `json:"transcriptions"`
+}
+
+// OCITranscription represents the transcript of one audio channel.
+type OCITranscription struct {
+	Transcription string `json:"transcription"`
+}
@@ -1,13 +1,80 @@
 // Package types defines the data structures used throughout the OCI to OpenAI transformation plugin.
 package types
 
+import "encoding/json"
+
 // ChatCompletionMessage represents a message in a chat completion conversation.
 type ChatCompletionMessage struct {
 	// Role is the role of the author of this message (e.g., "user", "assistant", "system")
 	Role string `json:"role"`
 
-	// Content is the content of the message
+	// Content is the content of the message.
+	// Assistant messages that only carry tool calls send `null`, which decodes to "".
+	// For content arrays it holds the concatenated text parts.
 	Content string `json:"content"`
+
+	// Parts holds the content parts when content is sent as an array (e.g. text and images)
+	Parts []ContentPart `json:"-"`
+
+	// Name is an optional name for the participant
+	Name string `json:"name,omitempty"`
+
+	// ToolCalls are the tool invocations requested by the assistant
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"` //nolint:tagliatelle
+
+	// ToolCallID is the ID of the tool call a "tool" message responds to
+	ToolCallID string `json:"tool_call_id,omitempty"` //nolint:tagliatelle
+
+	// FunctionCall is the deprecated single function invocation requested by the assistant
+	FunctionCall *ToolCallFunction `json:"function_call,omitempty"` //nolint:tagliatelle
+}
+
+// ToolCall represents a tool invocation requested by the assistant.
+type ToolCall struct {
+	// ID is the identifier of the tool call
+	ID string `json:"id"`
+
+	// Type is the type of the tool (always "function")
+	Type string `json:"type"`
+
+	// Function is the function the model wants to call
+	Function ToolCallFunction `json:"function"`
+}
+
+// ToolCallFunction represents the function name and JSON-encoded arguments of a tool call.
+type ToolCallFunction struct {
+	// Name is the name of the function to call
+	Name string `json:"name"`
+
+	// Arguments is the JSON-encoded arguments object
+	Arguments string `json:"arguments"`
+}
+
+// ResponseFormat represents the requested output format.
+type ResponseFormat struct {
+	// Type is "text", "json_object" or "json_schema"
+	Type string `json:"type"`
+}
+
+// Tool represents a tool the model may call.
+type Tool struct {
+	// Type is the type of the tool (always "function")
+	Type string `json:"type"`
+
+	// Function describes the function
+	Function FunctionDefinition `json:"function"`
+}
+
+// FunctionDefinition describes a function the model may call.
+type FunctionDefinition struct {
+	// Name is the name of the function
+	Name string `json:"name"`
+
+	// Description tells the model what the function does
+	Description string `json:"description,omitempty"`
+
+	// Parameters is the JSON Schema of the function arguments
+	Parameters json.RawMessage `json:"parameters,omitempty"`
 }
 
 // ChatCompletionRequest represents a request to the OpenAI chat completion API.
@@ -21,24 +88,104 @@ type ChatCompletionRequest struct {
 	// MaxTokens is the maximum number of tokens to generate in the chat completion
 	MaxTokens int `json:"max_tokens,omitempty"` //nolint:tagliatelle
 
-	// Temperature controls randomness (0.0 = deterministic, 2.0 = very random)
-	Temperature float64 `json:"temperature,omitempty"`
+	// MaxCompletionTokens is the successor of MaxTokens sent by clients of o-series models;
+	// it takes precedence over MaxTokens
+	MaxCompletionTokens int `json:"max_completion_tokens,omitempty"` //nolint:tagliatelle
+
+	// ReasoningEffort is the o-series reasoning effort: "minimal", "low", "medium" or "high".
+	// It is dropped, forwarded or rejected depending on the reasoningEffort setting
+	ReasoningEffort string `json:"reasoning_effort,omitempty"` //nolint:tagliatelle
+
+	// Temperature controls randomness (0.0 = deterministic, 2.0 = very random).
+	// It is nil when not sent, as by clients of o-series models, leaving the model's default
+	Temperature *float64 `json:"temperature,omitempty"`
 
 	// TopP controls nucleus sampling
 	TopP float64 `json:"top_p,omitempty"`
 
+	// TopK limits sampling to the k most likely tokens. It is not part of the OpenAI API but is
+	// sent by many compatible clients; when nil, the model's default is used
+	TopK *int `json:"top_k,omitempty"` //nolint:tagliatelle
+
 	// FrequencyPenalty reduces repetition of tokens based on their frequency
 	FrequencyPenalty float64 `json:"frequency_penalty,omitempty"`
 
 	// PresencePenalty reduces repetition of tokens based on their presence
 	PresencePenalty float64 `json:"presence_penalty,omitempty"`
+
+	// ResponseFormat constrains the output format, e.g. {"type": "json_object"}
+	ResponseFormat *ResponseFormat `json:"response_format,omitempty"` //nolint:tagliatelle
+
+	// Tools are the functions the model may call
+	Tools []Tool `json:"tools,omitempty"`
+
+	// Functions is the deprecated predecessor of Tools
+	Functions []FunctionDefinition `json:"functions,omitempty"`
+
+	// FunctionCall is the deprecated predecessor of ToolChoice: "none", "auto", or {"name": ...}
+	FunctionCall json.RawMessage `json:"function_call,omitempty"` //nolint:tagliatelle
+
+	// ToolChoice controls tool use: "none", "auto", "required", or an object naming a function
+	ToolChoice json.RawMessage `json:"tool_choice,omitempty"` //nolint:tagliatelle
+
+	// Stream asks for the response as server-sent chat.completion.chunk events
+	Stream bool `json:"stream,omitempty"`
+
+	// StreamOptions configures streamed responses
+	StreamOptions *StreamOptions `json:"stream_options,omitempty"` //nolint:tagliatelle
+
+	// Store asks OpenAI to store the completion; it is recorded but has no OCI equivalent
+	Store *bool `json:"store,omitempty"`
+
+	// Metadata is a set of client-supplied key/value pairs attached to audit records
+	Metadata map[string]string `json:"metadata,omitempty"`
+
+	// LogitBias adjusts token likelihoods. OCI GenAI does not support it, so it is dropped
+	// or rejected depending on the strictParameters setting
+	LogitBias map[string]float64 `json:"logit_bias,omitempty"` //nolint:tagliatelle
+
+	// Prediction is predicted output for speculative decoding, which OCI GenAI does not support, so it
+	// is dropped or rejected depending on the strictParameters setting
+	Prediction json.RawMessage `json:"prediction,omitempty"`
+
+	// ServiceTier is the requested processing tier, mapped to a priority class
+	ServiceTier string `json:"service_tier,omitempty"` //nolint:tagliatelle
+
+	// User identifies the end user; it can be sent to OCI in the opc-request-id
+	User string `json:"user,omitempty"`
+
+	// Modalities lists the requested output types; OCI GenAI only produces "text"
+	Modalities []string `json:"modalities,omitempty"`
+
+	// Audio configures audio output, which OCI GenAI does not support
+	Audio json.RawMessage `json:"audio,omitempty"`
+
+	// ConversationID is a plugin extension naming a server-side conversation (COHERE only)
+	ConversationID string `json:"conversation_id,omitempty"` //nolint:tagliatelle
+
+	// DebugPrompt is a plugin extension requesting the prompt the model received.
+	// "echo" asks OCI to echo the prompt, "raw" additionally disables prompt preprocessing (COHERE only).
+	DebugPrompt string `json:"oci_debug_prompt,omitempty"` //nolint:tagliatelle
+
+	// Extra holds the unrecognized top-level fields allowed by the extraBodyPassthrough setting,
+	// which are forwarded verbatim in the OCI chat request
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+// StreamOptions represents the options for streamed responses.
+type StreamOptions struct {
+	// IncludeUsage asks for a final chunk carrying token usage
+	IncludeUsage bool `json:"include_usage,omitempty"` //nolint:tagliatelle
 }
 
 // ServingMode represents the serving configuration for Oracle Cloud GenAI.
 // It specifies which model to use and how it should be served.
 type ServingMode struct {
 	// ModelID is the identifier of the AI model to use (e.g., "gpt-4", "claude-3")
-	ModelID string `json:"modelId"`
+	ModelID string `json:"modelId,omitempty"`
+
+	// EndpointID is the OCID of the dedicated endpoint serving the model ("DEDICATED" serving type)
+	EndpointID string `json:"endpointId,omitempty"`
 
 	// ServingType specifies how the model is served (typically "ON_DEMAND")
 	ServingType string `json:"servingType"`
@@ -52,11 +199,16 @@ type ChatRequest struct {
 	// MaxTokens is the maximum number of tokens to generate in the response
 	MaxTokens int `json:"maxTokens"`
 
-	// Temperature controls randomness in the response (0.0 = deterministic, 1.0 = very random)
-	Temperature float64 `json:"temperature"`
+	// Temperature controls randomness in the response (0.0 = deterministic, 1.0 = very random).
+	// When nil, the model's default is used
+	Temperature *float64 `json:"temperature,omitempty"`
+
+	// TopP controls nucleus sampling (0.0 = most focused, 1.0 = least focused).
+	// When zero, the model's default is used
+	TopP float64 `json:"topP,omitempty"`
 
-	// TopP controls nucleus sampling (0.0 = most focused, 1.0 = least focused)
-	TopP float64 `json:"topP"`
+	// TopK limits sampling to the k most likely tokens. When nil, the model's default is used
+	TopK *int `json:"topK,omitempty"`
 
 	// IsStream determines if the response should be streamed
 	IsStream bool `json:"isStream"`
@@ -67,8 +219,48 @@ type ChatRequest struct {
 	// Message is the current user message to process
 	Message string `json:"message,omitempty"`
 
+	// ToolResults contains the results of tool calls requested by the model (COHERE format)
+	ToolResults []interface{} `json:"toolResults,omitempty"`
+
+	// ConversationID makes OCI keep the conversation history server-side (COHERE format)
+	ConversationID string `json:"conversationId,omitempty"`
+
 	// APIFormat specifies the API format to use (e.g., "COHERE")
 	APIFormat string `json:"apiFormat"`
+
+	// IsEcho asks OCI to include the prompt in the response
+	IsEcho bool `json:"isEcho,omitempty"`
+
+	// IsRawPrompting sends the message to the model without any preprocessing (COHERE format)
+	IsRawPrompting bool `json:"isRawPrompting,omitempty"`
+
+	// ReasoningEffort is the reasoning effort of models that support it: "MINIMAL", "LOW",
+	// "MEDIUM" or "HIGH" (GENERIC format)
+	ReasoningEffort string `json:"reasoningEffort,omitempty"`
+
+	// Extra holds fields the plugin does not model, forwarded verbatim. They never replace a
+	// modelled field
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+// MarshalJSON adds the extra fields to the modelled ones.
+func (r ChatRequest) MarshalJSON() ([]byte, error) {
+	type chatRequest ChatRequest
+	data, err := json.Marshal(chatRequest(r))
+	if err != nil || len(r.Extra) == 0 {
+		return data, err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for name, value := range r.Extra {
+		if _, modelled := fields[name]; !modelled {
+			fields[name] = value
+		}
+	}
+	return json.Marshal(fields)
 }
 
 // OracleCloudRequest represents the complete request structure for Oracle Cloud GenAI.
@@ -107,6 +299,21 @@ type ChatCompletionChoice struct {
 
 	// FinishReason indicates why the completion finished
 	FinishReason string `json:"finish_reason"` //nolint:tagliatelle
+
+	// ContentFilterResults is an extension field carrying the OCI guardrail results, by category
+	ContentFilterResults map[string]ContentFilterResult `json:"content_filter_results,omitempty"` //nolint:tagliatelle
+}
+
+// ContentFilterResult is the guardrail result for a single category.
+type ContentFilterResult struct {
+	// Filtered is true when the category was detected and the completion was cut short because of it
+	Filtered bool `json:"filtered"`
+
+	// Detected is true when the category scored at or above the detection threshold
+	Detected bool `json:"detected"`
+
+	// Score is the highest score OCI reported for the category
+	Score float64 `json:"score,omitempty"`
 }
 
 // ChatCompletionUsage represents token usage statistics in OpenAI format.
@@ -140,6 +347,91 @@ type ChatCompletionResponse struct {
 
 	// Usage contains token usage statistics
 	Usage ChatCompletionUsage `json:"usage"`
+
+	// Prompt is a plugin extension carrying the exact prompt the model received,
+	// returned only when prompt debugging was requested
+	Prompt string `json:"oci_prompt,omitempty"` //nolint:tagliatelle
+
+	// Citations is a plugin extension carrying the sources cited by an OCI Generative AI agent
+	Citations []AgentCitation `json:"oci_citations,omitempty"` //nolint:tagliatelle
+}
+
+// ChatCompletionChunk represents a single server-sent event of a streamed OpenAI chat completion.
+type ChatCompletionChunk struct {
+	// ID is the completion identifier, shared by all chunks of a stream
+	ID string `json:"id"`
+
+	// Object is always "chat.completion.chunk"
+	Object string `json:"object"`
+
+	// Created is the Unix timestamp when the completion was created
+	Created int64 `json:"created"`
+
+	// Model is the model used for the completion
+	Model string `json:"model"`
+
+	// Choices carries the content deltas; it is empty in the final usage chunk
+	Choices []ChatCompletionChunkChoice `json:"choices"`
+
+	// Usage is only set on the final chunk, when requested with stream_options.include_usage
+	Usage *ChatCompletionUsage `json:"usage,omitempty"`
+}
+
+// ChatCompletionChunkChoice represents a content delta for one choice of a streamed completion.
+type ChatCompletionChunkChoice struct {
+	// Index is the index of the choice
+	Index int `json:"index"`
+
+	// Delta is the content added by this chunk
+	Delta ChatCompletionDelta `json:"delta"`
+
+	// Logprobs is always null, since OCI does not return log probabilities
+	Logprobs json.RawMessage `json:"logprobs"`
+
+	// FinishReason is set on the last chunk of the choice, and null before
+	FinishReason *string `json:"finish_reason"` //nolint:tagliatelle
+}
+
+// ChatCompletionDelta represents the incremental message content of a chunk.
+type ChatCompletionDelta struct {
+	// Role is only set on the first chunk
+	Role string `json:"role,omitempty"`
+
+	// Content is the text added by this chunk
+	Content string `json:"content,omitempty"`
+}
+
+// MarshalJSON includes empty content in the chunk carrying the role, as OpenAI does.
+func (d ChatCompletionDelta) MarshalJSON() ([]byte, error) {
+	if d.Role == "" {
+		type delta ChatCompletionDelta
+		return json.Marshal(delta(d))
+	}
+	return json.Marshal(struct {
+		Role    string `json:"role"`
+		Content string `json:"content"`
+	}{d.Role, d.Content})
+}
+
+// OracleCloudStreamEvent represents a single server-sent event of a streamed OCI GenAI chat response.
+type OracleCloudStreamEvent struct {
+	// APIFormat is the API format used
+	APIFormat string `json:"apiFormat"`
+
+	// Text is the generated text (COHERE format). The final COHERE event repeats the full text.
+	Text string `json:"text,omitempty"`
+
+	// Index is the choice index (GENERIC format)
+	Index int `json:"index,omitempty"`
+
+	// Message carries the generated content (GENERIC format)
+	Message *OracleGenericMessage `json:"message,omitempty"`
+
+	// FinishReason is set on the final event
+	FinishReason string `json:"finishReason,omitempty"`
+
+	// Usage is set on the final event when OCI reports it
+	Usage *OracleCloudUsage `json:"usage,omitempty"`
 }
 
 // OracleCloudUsage represents usage statistics from Oracle Cloud GenAI.
@@ -171,6 +463,9 @@ type OracleCloudChatResponse struct {
 	// Text is the generated response text (COHERE format)
 	Text string `json:"text"`
 
+	// Prompt is the full prompt sent to the model when isEcho was requested
+	Prompt string `json:"prompt,omitempty"`
+
 	// ChatHistory contains the conversation history (COHERE format)
 	ChatHistory []OracleCloudChatHistory `json:"chatHistory"`
 
@@ -182,6 +477,43 @@ type OracleCloudChatResponse struct {
 
 	// Choices is the list of choices (GENERIC format)
 	Choices []OracleGenericChoice `json:"choices,omitempty"`
+
+	// Guardrails holds content moderation, prompt injection and PII results, when guardrails are applied
+	Guardrails *OracleGuardrailResults `json:"guardrails,omitempty"`
+}
+
+// OracleGuardrailResults represents the guardrail results reported by Oracle Cloud GenAI.
+type OracleGuardrailResults struct {
+	// ContentModeration scores the text against each moderation category
+	ContentModeration *OracleContentModeration `json:"contentModeration,omitempty"`
+
+	// PromptInjection scores the likelihood of a prompt injection attempt
+	PromptInjection *OracleGuardrailScore `json:"promptInjection,omitempty"`
+
+	// PersonallyIdentifiableInformation lists the PII entities detected
+	PersonallyIdentifiableInformation []OraclePIIResult `json:"personallyIdentifiableInformation,omitempty"`
+}
+
+// OracleContentModeration represents content moderation results.
+type OracleContentModeration struct {
+	Categories []OracleModerationCategory `json:"categories"`
+}
+
+// OracleModerationCategory represents the score of a single moderation category.
+type OracleModerationCategory struct {
+	Name  string  `json:"name"`
+	Score float64 `json:"score"`
+}
+
+// OracleGuardrailScore represents a single guardrail score.
+type OracleGuardrailScore struct {
+	Score float64 `json:"score"`
+}
+
+// OraclePIIResult represents a detected PII entity.
+type OraclePIIResult struct {
+	Label string  `json:"label"`
+	Score float64 `json:"score"`
 }
 
 // OracleGenericContent represents a content item (GENERIC)
@@ -198,9 +530,10 @@ type OracleGenericMessage struct {
 
 // OracleGenericChoice represents a single choice (GENERIC)
 type OracleGenericChoice struct {
-	Index        int                  `json:"index"`
-	Message      OracleGenericMessage `json:"message"`
-	FinishReason string               `json:"finishReason"`
+	Index        int                     `json:"index"`
+	Message      OracleGenericMessage    `json:"message"`
+	FinishReason string                  `json:"finishReason"`
+	Guardrails   *OracleGuardrailResults `json:"guardrails,omitempty"`
 }
 
 // OracleCloudResponse represents the complete response from Oracle Cloud GenAI.
@@ -213,6 +546,9 @@ type OracleCloudResponse struct {
 
 	// ChatResponse contains the actual response data
 	ChatResponse OracleCloudChatResponse `json:"chatResponse"`
+
+	// RequestID is the opc-request-id response header, set by the caller since it is not part of the body
+	RequestID string `json:"-"`
 }
 
 // OpenAIModel represents a model in OpenAI format.
@@ -221,12 +557,23 @@ type OpenAIModel struct {
 	Object  string `json:"object"`
 	Created int64  `json:"created"`
 	OwnedBy string `json:"owned_by"` //nolint:tagliatelle
+
+	// Parent is the model a fine-tuned model was trained from, and Root the base model at the
+	// start of that lineage. Both are empty for base models.
+	Parent string `json:"parent,omitempty"`
+	Root   string `json:"root,omitempty"`
 }
 
 // OpenAIModelsResponse represents the response from OpenAI models API.
 type OpenAIModelsResponse struct {
 	Object string        `json:"object"`
 	Data   []OpenAIModel `json:"data"`
+
+	// HasMore is set when a paginated request has further pages
+	HasMore bool `json:"has_more,omitempty"` //nolint:tagliatelle
+
+	// NextPage is a plugin extension carrying the cursor to pass as `after` for the next page
+	NextPage string `json:"oci_next_page,omitempty"` //nolint:tagliatelle
 }
 
 // CompatibleDedicatedAiClusterShape represents a shape configuration for dedicated AI clusters.
@@ -268,3 +615,24 @@ type OCIModel struct {
 type OCIModelsResponse struct {
 	Items []OCIModel `json:"items"`
 }
+
+// ErrorResponse represents an OpenAI API error response.
+type ErrorResponse struct {
+	// Error describes what went wrong
+	Error APIError `json:"error"`
+}
+
+// APIError represents the error object of an OpenAI API error response.
+type APIError struct {
+	// Message is a human-readable description of the error
+	Message string `json:"message"`
+
+	// Type is the error category (e.g., "invalid_request_error")
+	Type string `json:"type"`
+
+	// Param is the request parameter the error relates to, if any
+	Param string `json:"param,omitempty"`
+
+	// Code is a machine-readable error code, if any
+	Code string `json:"code,omitempty"`
+}
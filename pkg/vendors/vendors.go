@@ -0,0 +1,54 @@
+// Package vendors lets forks of the plugin add support for OCI API formats it does not know,
+// without modifying its internal packages. A fork registers a Vendor under a name, typically from
+// an init function in its own file, and the customVendors setting maps model name prefixes to
+// registered vendors.
+package vendors
+
+import (
+	"sync"
+
+	"github.com/zalbiraw/ociaitoopenai/pkg/types"
+)
+
+// RequestFormatter builds the OCI chatRequest of an OpenAI chat completion request, which has
+// already been validated and normalized. The plugin adds the compartment and serving mode.
+type RequestFormatter interface {
+	FormatRequest(req types.ChatCompletionRequest) types.ChatRequest
+}
+
+// ResponseParser converts the body of an OCI chat response, decompressed, into the plugin's model
+// of a response, which is then converted to OpenAI's format like COHERE and GENERIC responses.
+type ResponseParser interface {
+	ParseResponse(body []byte) (types.OracleCloudResponse, error)
+}
+
+// Vendor is the support for a custom API format.
+type Vendor struct {
+	// Formatter builds the requests of the vendor's models.
+	Formatter RequestFormatter
+
+	// Parser parses the responses of the vendor's models. When nil, responses are parsed as
+	// COHERE and GENERIC responses are.
+	Parser ResponseParser
+}
+
+var (
+	mu       sync.RWMutex
+	registry = make(map[string]Vendor)
+)
+
+// Register makes a vendor available to the customVendors setting under name, replacing any
+// vendor registered under the same name.
+func Register(name string, vendor Vendor) {
+	mu.Lock()
+	defer mu.Unlock()
+	registry[name] = vendor
+}
+
+// Lookup returns the vendor registered under name.
+func Lookup(name string) (Vendor, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	vendor, ok := registry[name]
+	return vendor, ok
+}
@@ -0,0 +1,29 @@
+package vendors_test
+
+import (
+	"testing"
+
+	"github.com/zalbiraw/ociaitoopenai/pkg/types"
+	"github.com/zalbiraw/ociaitoopenai/pkg/vendors"
+)
+
+type formatter struct{}
+
+func (formatter) FormatRequest(req types.ChatCompletionRequest) types.ChatRequest {
+	return types.ChatRequest{APIFormat: "ACME", MaxTokens: req.MaxTokens}
+}
+
+func TestRegisterAndLookup(t *testing.T) {
+	if _, ok := vendors.Lookup("vendors-test"); ok {
+		t.Fatal("expected no vendor before registration")
+	}
+
+	vendors.Register("vendors-test", vendors.Vendor{Formatter: formatter{}})
+	vendor, ok := vendors.Lookup("vendors-test")
+	if !ok || vendor.Parser != nil {
+		t.Fatalf("expected the registered vendor, got %+v", vendor)
+	}
+	if req := vendor.Formatter.FormatRequest(types.ChatCompletionRequest{MaxTokens: 5}); req.APIFormat != "ACME" || req.MaxTokens != 5 {
+		t.Errorf("unexpected request %+v", req)
+	}
+}
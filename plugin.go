@@ -12,20 +12,47 @@
 package ociaitoopenai
 
 import (
+	"bufio"
 	"bytes"
 	"compress/flate"
-	"compress/gzip"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
+	"net"
 	"net/http"
 	"net/url"
+	"runtime/debug"
+	"strconv"
 	"strings"
-
+	"sync/atomic"
+	"time"
+
+	"github.com/zalbiraw/ociaitoopenai/internal/admission"
+	"github.com/zalbiraw/ociaitoopenai/internal/agents"
+	"github.com/zalbiraw/ociaitoopenai/internal/assistants"
+	"github.com/zalbiraw/ociaitoopenai/internal/audit"
+	"github.com/zalbiraw/ociaitoopenai/internal/auth"
+	"github.com/zalbiraw/ociaitoopenai/internal/balancer"
+	"github.com/zalbiraw/ociaitoopenai/internal/catalog"
+	"github.com/zalbiraw/ociaitoopenai/internal/chaos"
+	"github.com/zalbiraw/ociaitoopenai/internal/clientip"
 	"github.com/zalbiraw/ociaitoopenai/internal/config"
+	"github.com/zalbiraw/ociaitoopenai/internal/degrade"
+	"github.com/zalbiraw/ociaitoopenai/internal/egress"
+	"github.com/zalbiraw/ociaitoopenai/internal/fixture"
+	"github.com/zalbiraw/ociaitoopenai/internal/metrics"
+	"github.com/zalbiraw/ociaitoopenai/internal/mirror"
+	"github.com/zalbiraw/ociaitoopenai/internal/prompt"
+	"github.com/zalbiraw/ociaitoopenai/internal/schedule"
+	"github.com/zalbiraw/ociaitoopenai/internal/slo"
+	"github.com/zalbiraw/ociaitoopenai/internal/tenants"
 	"github.com/zalbiraw/ociaitoopenai/internal/transform"
+	"github.com/zalbiraw/ociaitoopenai/internal/usage"
+	"github.com/zalbiraw/ociaitoopenai/internal/vision"
+	"github.com/zalbiraw/ociaitoopenai/internal/warmup"
 	"github.com/zalbiraw/ociaitoopenai/pkg/types"
 )
 
@@ -34,6 +61,7 @@ type responseWriter struct {
 	http.ResponseWriter
 	statusCode int
 	body       *bytes.Buffer
+	responded  time.Time // Time the upstream response started
 }
 
 // newResponseWriter creates a new response writer wrapper
@@ -47,20 +75,219 @@ func newResponseWriter(w http.ResponseWriter) *responseWriter {
 
 func (rw *responseWriter) WriteHeader(code int) {
 	rw.statusCode = code
+	if rw.responded.IsZero() {
+		rw.responded = time.Now()
+	}
 }
 
 func (rw *responseWriter) Write(b []byte) (int, error) {
+	if rw.responded.IsZero() {
+		rw.responded = time.Now()
+	}
 	rw.body.Write(b)
 	return len(b), nil
 }
 
+// statusWriter passes a response through unbuffered, recording its status and whether any of
+// it was sent.
+type statusWriter struct {
+	http.ResponseWriter
+	statusCode  int
+	wroteHeader bool
+	responded   time.Time // Time the upstream response started
+}
+
+// newStatusWriter creates a status recording writer. The status is 200 until one is written.
+func newStatusWriter(w http.ResponseWriter) *statusWriter {
+	return &statusWriter{ResponseWriter: w, statusCode: http.StatusOK}
+}
+
+func (sw *statusWriter) WriteHeader(code int) {
+	if !sw.wroteHeader {
+		sw.wroteHeader = true
+		sw.statusCode = code
+		sw.responded = time.Now()
+	}
+	sw.ResponseWriter.WriteHeader(code)
+}
+
+func (sw *statusWriter) Write(b []byte) (int, error) {
+	if !sw.wroteHeader {
+		sw.WriteHeader(http.StatusOK)
+	}
+	return sw.ResponseWriter.Write(b)
+}
+
+// Flush sends buffered data to the client, if the underlying writer supports it.
+func (sw *statusWriter) Flush() {
+	if flusher, ok := sw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack lets the next handler take over the connection, for example for websocket upgrades.
+func (sw *statusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := sw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("response writer does not support hijacking")
+	}
+	conn, buf, err := hijacker.Hijack()
+	if err == nil {
+		// The connection no longer carries a response the plugin could write to
+		sw.wroteHeader = true
+	}
+	return conn, buf, err
+}
+
 // Proxy represents the main plugin instance that handles request transformation.
 // It contains all the necessary components for transforming requests and responses.
 type Proxy struct {
+	inFlight    int64                  // Chat requests being handled, updated atomically; first for 64-bit alignment
 	next        http.Handler           // Next handler in the middleware chain
 	config      *config.Config         // Plugin configuration
 	name        string                 // Plugin instance name
+	ctx         context.Context        // Plugin lifetime, cancelled when the plugin shuts down
 	transformer *transform.Transformer // Request transformer
+	metrics     *metrics.Registry      // Failure counters by stage
+	signer      *auth.Signer           // Built-in request signer, nil when signing is done downstream
+	client      *http.Client           // Egress client for the plugin's own calls to OCI services other than Generative AI
+	mirror      *mirror.Mirror         // Request/response mirror, nil when disabled
+	audit       *audit.Logger          // Audit logger, nil when disabled
+	images      *vision.Fetcher        // Remote image fetcher, nil when disabled
+	catalog     *catalog.Catalog       // Cached model catalog, nil when model validation is disabled
+	admission   *admission.Controller  // Load shedding controller, nil when disabled
+	balancer    *balancer.Balancer     // Endpoint load balancer, nil when no endpoints are configured
+	usage       *usage.Ledger          // Token usage ledger, nil when disabled
+	prompts     *prompt.Renderer       // Prompt template renderer, nil when no templates are configured
+	clientIPs   *clientip.Resolver     // Client IP resolver honouring trusted proxies
+	ipFilter    *clientip.Filter       // Client IP allow and deny lists, nil when not configured
+	overriders  []*net.IPNet           // Networks whose model override header is honoured, empty to trust every client
+	fixtures    *fixture.Store         // Recorded OCI responses, nil when fixtures are disabled
+	tenants     *tenants.Table         // Reloadable tenant table, nil when no tenant source is configured
+	schedule    *schedule.Schedule     // Time-based routing rules, nil when none are configured
+	sessions    *agents.Sessions       // Reused agent sessions, nil when the agents bridge is disabled
+	assistants  *assistants.Store      // Assistants API objects, nil when the emulation is disabled
+	chaos       *chaos.Injector        // Fault injection, nil when chaos mode is disabled
+	slo         *slo.Tracker           // Per-model latency and error rates, nil when SLO tracking is disabled
+	warmUp      *warmup.Pinger         // Warm-up pinger, nil when warm-up is disabled
+	degrade     *degrade.Guard         // Turns off failing optional features, nil when degradation is disabled
+}
+
+// chatExchange carries the state of a single chat completion request through the plugin.
+type chatExchange struct {
+	model           string                       // Model requested by the client, or by an operator's override header
+	responseModel   string                       // Model reported in responses, which ignores operator overrides
+	compartmentID   string                       // Compartment the model is in, which differs for federated models
+	requestBody     []byte                       // Original OpenAI request body
+	status          int                          // Status code returned to the client
+	responseBody    []byte                       // Uncompressed response body returned to the client
+	started         time.Time                    // Time the request was received
+	responded       time.Time                    // Time the OCI response headers arrived, zero until then
+	serviceTier     string                       // Requested OpenAI service tier
+	priority        string                       // Priority class the request was admitted with
+	store           *bool                        // Client store flag, recorded for auditing only
+	metadata        map[string]string            // Client-supplied metadata, recorded for auditing only
+	stream          bool                         // Client requested a streamed response
+	includeUsage    bool                         // Client requested a final usage chunk when streaming
+	tenant          string                       // Tenant identified by the configured tenant header
+	requestID       string                       // Gateway request ID sent to OCI, empty when request metadata is disabled
+	acceptEncoding  string                       // Client's Accept-Encoding, replaced upstream by the encodings the plugin decodes
+	clientIP        string                       // Client address behind any trusted proxies
+	adjustments     []string                     // Parameters clamped or truncated before forwarding
+	endpoint        *balancer.Endpoint           // Balanced endpoint the request was sent to, if load balancing is configured
+	usage           *types.ChatCompletionUsage   // Token usage of a successful response
+	emulation       *transform.ToolEmulation     // Tool emulation state, nil when tools are not emulated
+	jsonMode        bool                         // Client requested response_format json_object
+	legacyFunctions bool                         // Client used the deprecated functions fields, so calls are returned as function_call
+	request         *types.ChatCompletionRequest // Normalized OpenAI request, re-sent when an emulated tool call is malformed
+	agentEndpoint   string                       // Agent endpoint OCID of an "agent:" model, answered by OCI Generative AI Agents
+}
+
+// clientError marks a request rejected because of the client, whose error response has already been written.
+type clientError struct {
+	err error
+}
+
+func (e *clientError) Error() string { return e.err.Error() }
+
+func (e *clientError) Unwrap() error { return e.err }
+
+// requestFailure is a server-side failure to prepare a request for OCI, answered with a
+// server_error whose code names the failure.
+type requestFailure struct {
+	code string
+	err  error
+}
+
+func (e *requestFailure) Error() string { return e.err.Error() }
+
+func (e *requestFailure) Unwrap() error { return e.err }
+
+// Metric names for transformation failures, labelled by model and HTTP status.
+const (
+	metricParseErrors        = "ociai_parse_errors_total"
+	metricTransformErrors    = "ociai_transform_errors_total"
+	metricUpstreamFailures   = "ociai_upstream_failures_total"
+	metricDecompressFailures = "ociai_decompress_failures_total"
+	metricMarshalFailures    = "ociai_marshal_failures_total"
+	metricHandlerPanics      = "ociai_handler_panics_total"
+	metricShedRequests       = "ociai_shed_requests_total"
+	metricClientRequests     = "ociai_client_requests_total"
+	metricChaosFaults        = "ociai_chaos_faults_total"
+	metricTransformBypasses  = "ociai_transform_bypasses_total"
+	metricWarmUpFailures     = "ociai_warmup_failures_total"
+)
+
+// Metric names for streamed response latency, labelled by model.
+const (
+	metricTimeToFirstToken = "ociai_time_to_first_token_seconds"
+	metricTokensPerSecond  = "ociai_tokens_per_second"
+)
+
+// newMetrics creates the metrics registry with all failure counters registered.
+func newMetrics() *metrics.Registry {
+	registry := metrics.NewRegistry()
+	registry.NewCounter(metricParseErrors, "Client requests that could not be parsed as OpenAI requests.")
+	registry.NewCounter(metricTransformErrors, "OCI GenAI responses that could not be transformed to OpenAI format.")
+	registry.NewCounter(metricUpstreamFailures, "Non-200 responses returned by OCI GenAI.")
+	registry.NewCounter(metricDecompressFailures, "OCI GenAI responses that could not be decompressed.")
+	registry.NewCounter(metricMarshalFailures, "Requests or responses that could not be marshalled.")
+	registry.NewCounter(metricHandlerPanics, "Panics recovered from the next handler in the chain.")
+	registry.NewCounter(metricShedRequests, "Requests rejected by the admission controller.")
+	registry.NewCounter(metricChaosFaults, "Faults injected by chaos mode.")
+	registry.NewCounter(metricTransformBypasses, "Responses returned untransformed because they exceed transformMaxBytes.")
+	registry.NewCounter(metricWarmUpFailures, "Failed warm-up pings, by target.")
+	registry.NewCounter(metricClientRequests, "Chat requests by client IP, when client labels are enabled.")
+	registry.NewHistogram(metricTimeToFirstToken, "Time from receiving a streamed request to sending its first token.",
+		[]float64{0.1, 0.25, 0.5, 1, 2, 5, 10, 30})
+	registry.NewHistogram(metricTokensPerSecond, "Completion tokens per second after the first token of a streamed response.",
+		[]float64{1, 5, 10, 25, 50, 100, 200, 500})
+	return registry
+}
+
+// recordFailure increments the failure counter for the given stage.
+func (p *Proxy) recordFailure(stage, model string, status int) {
+	p.metrics.Inc(stage, metrics.Labels{
+		"model":  p.modelLabel(model),
+		"status": fmt.Sprintf("%d", status),
+	})
+}
+
+// modelLabel returns the metric label for a model: its name when it is listed in metrics.models
+// or in the cached catalog, and "other" otherwise, since the model name comes from the client.
+func (p *Proxy) modelLabel(model string) string {
+	if model == "" {
+		return ""
+	}
+	for _, known := range p.config.Metrics.Models {
+		if model == known {
+			return model
+		}
+	}
+	if p.catalog != nil && p.catalog.Known(model) {
+		return model
+	}
+	return "other"
 }
 
 // New creates a new Proxy plugin instance.
@@ -81,12 +308,153 @@ func New(ctx context.Context, next http.Handler, cfg *config.Config, name string
 	// Initialize transformer
 	transformer := transform.New(cfg)
 
-	return &Proxy{
+	// Initialize the built-in signer, if configured
+	signer, err := auth.NewSigner(ctx, cfg, func(err error) {
+		log.Printf("[%s] ERROR: Failed to refresh signing credentials: %v", name, err)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize %s signer: %w", cfg.AuthType, err)
+	}
+
+	// Object Storage, Speech and agent requests are not for the next handler, so they go to OCI directly
+	client, err := egress.NewClient(cfg.Egress, 5*time.Minute)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize egress client: %w", err)
+	}
+
+	// Turn off optional features that keep failing, if configured
+	guard := degrade.New(cfg.Degradation, func(feature string, disabled bool, err error) {
+		if disabled {
+			log.Printf("[%s] WARNING: Turning %s off after repeated failures, serving requests without it: %v", name, feature, err)
+		} else {
+			log.Printf("[%s] WARNING: Turning %s back on", name, feature)
+		}
+	})
+
+	// Initialize request mirroring, if configured
+	requestMirror, err := mirror.New(ctx, cfg.Mirror, featureFailed(guard, featureMirror, func(err error) {
+		log.Printf("[%s] ERROR: Failed to mirror request: %v", name, err)
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize request mirroring: %w", err)
+	}
+
+	// Initialize audit logging, if configured
+	auditLogger := audit.New(ctx, cfg.Audit, name, featureFailed(guard, featureAudit, func(err error) {
+		log.Printf("[%s] ERROR: Failed to write audit record: %v", name, err)
+	}))
+
+	// Initialize the usage ledger, if configured
+	usageLedger, err := usage.New(ctx, cfg.Usage, featureFailed(guard, featureUsage, func(err error) {
+		log.Printf("[%s] ERROR: Failed to flush usage ledger: %v", name, err)
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize usage ledger: %w", err)
+	}
+	if usageLedger != nil && cfg.Usage.ReportURL != "" {
+		reportInterval, _ := time.ParseDuration(cfg.Usage.ReportInterval)
+		reporter := usage.NewReporter(usageLedger, cfg.Usage.ReportURL, reportInterval)
+		go reporter.Run(ctx, func(err error) {
+			log.Printf("[%s] ERROR: Failed to send usage report: %v", name, err)
+		})
+	}
+
+	clientIPs, err := clientip.New(cfg.ClientIP)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize client IP resolver: %w", err)
+	}
+	ipFilter, err := clientip.NewFilter(cfg.IPFilter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize IP filter: %w", err)
+	}
+	overriders, err := clientip.ParseNetworks(cfg.ModelOverride.TrustedNetworks)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse model override networks: %w", err)
+	}
+
+	// Load the tenant table, if a tenant source is configured
+	tenantTable, err := tenants.New(ctx, cfg, func(err error) {
+		log.Printf("[%s] ERROR: Failed to reload tenants, keeping the current table: %v", name, err)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize tenant table: %w", err)
+	}
+
+	// Parse prompt templates, if configured
+	prompts, err := prompt.New(cfg.PromptTemplates)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize prompt templates: %w", err)
+	}
+
+	// Track per-model latency and error rates, if configured
+	sloTracker := slo.New(cfg.SLO, func(model string, stats slo.Stats) {
+		log.Printf("[%s] WARNING: Model %s breaches its SLO: p95 latency %v, error rate %.1f%% over %d requests",
+			name, model, stats.P95Latency, stats.ErrorRate*100, stats.Requests)
+	})
+
+	proxy := &Proxy{
 		next:        next,
 		config:      cfg,
 		name:        name,
+		ctx:         ctx,
 		transformer: transformer,
-	}, nil
+		metrics:     newMetrics(),
+		signer:      signer,
+		client:      client,
+		mirror:      requestMirror,
+		audit:       auditLogger,
+		images:      vision.NewFetcher(cfg.ImageFetch, cfg.ImageLimits),
+		admission:   admission.New(cfg.Admission),
+		chaos:       chaos.New(cfg.Chaos),
+		slo:         sloTracker,
+		balancer:    balancer.New(cfg.LoadBalancing),
+		usage:       usageLedger,
+		prompts:     prompts,
+		clientIPs:   clientIPs,
+		ipFilter:    ipFilter,
+		overriders:  overriders,
+		fixtures:    fixture.New(cfg.Fixtures, cfg.CompartmentID, cfg.TenancyID, cfg.UserID),
+		tenants:     tenantTable,
+		schedule:    schedule.New(cfg.Schedules),
+		degrade:     guard,
+	}
+
+	// Initialize the model catalog, if model validation is configured
+	if cfg.ModelValidation.Enabled {
+		ttl, _ := time.ParseDuration(cfg.ModelValidation.CacheTTL)
+		negativeTTL, _ := time.ParseDuration(cfg.ModelValidation.NegativeCacheTTL)
+		maxStale, _ := time.ParseDuration(cfg.ModelValidation.MaxStaleness)
+		proxy.catalog = catalog.New(proxy.federatedModels, ttl, negativeTTL, maxStale)
+	}
+
+	// Track agent sessions, if the agents bridge is enabled
+	if cfg.Agents.Enabled {
+		sessionTTL, _ := time.ParseDuration(cfg.Agents.SessionTTL)
+		proxy.sessions = agents.NewSessions(sessionTTL)
+	}
+
+	// Load the Assistants API objects, if the emulation is enabled
+	if cfg.Assistants.Enabled {
+		store, err := assistants.New(cfg.Assistants.File, assistants.Limits{
+			Objects:  cfg.Assistants.MaxObjects,
+			Messages: cfg.Assistants.MaxMessages,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize assistants store: %w", err)
+		}
+		proxy.assistants = store
+	}
+
+	// Keep dedicated endpoints warm, if configured
+	proxy.warmUp = warmup.New(cfg.WarmUp, proxy.warmUpPing, func(target string, err error) {
+		log.Printf("[%s] WARNING: Warm-up ping of %s failed: %v", name, target, err)
+		proxy.metrics.Inc(metricWarmUpFailures, metrics.Labels{"target": target})
+	})
+	if proxy.warmUp != nil {
+		proxy.warmUp.Start(ctx)
+	}
+
+	return proxy, nil
 }
 
 // ServeHTTP implements the http.Handler interface and processes incoming requests.
@@ -103,31 +471,187 @@ func New(ctx context.Context, next http.Handler, cfg *config.Config, name string
 func (p *Proxy) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 	log.Printf("[%s] ServeHTTP: method=%s, path=%s", p.name, req.Method, req.URL.Path)
 
-	// Handle different request types
-	if req.Method == http.MethodGet && strings.HasSuffix(req.URL.Path, "/models") {
-		log.Printf("[%s] ServeHTTP: Handling /models endpoint", p.name)
-		// Handle models endpoint
-		if err := p.processModelsRequest(rw, req); err != nil {
-			log.Printf("[%s] ServeHTTP: processModelsRequest error: %v", p.name, err)
-			log.Printf("[%s] ERROR: Failed to process models request: %v", p.name, err)
-			http.Error(rw, err.Error(), http.StatusInternalServerError)
+	// Block disallowed sources before anything else
+	if p.ipFilter != nil {
+		if clientIP := p.clientIPs.ClientIP(req); !p.ipFilter.Allowed(clientIP) {
+			log.Printf("[%s] ServeHTTP: Blocked request from %s", p.name, clientIP)
+			writeError(rw, http.StatusForbidden, "Requests from your IP address are not allowed", "", "ip_not_allowed")
+			return
 		}
+	}
+
+	// Handle different request types, matching paths regardless of repeated or trailing slashes and case
+	route := routePath(req.URL.Path)
+	if p.config.Metrics.Enabled && req.Method == http.MethodGet && route == p.config.Metrics.Path {
+		p.metrics.ServeHTTP(rw, req)
 		return
-	} else if req.Method == http.MethodPost && strings.HasSuffix(req.URL.Path, "/chat/completions") {
+	}
+	if p.config.Status.Enabled && req.Method == http.MethodGet && route == p.config.Status.Path {
+		p.serveStatus(rw, req)
+		return
+	}
+	if p.config.Introspection.Enabled && req.Method == http.MethodGet && route == p.config.Introspection.Path {
+		p.serveIntrospection(rw, req)
+		return
+	}
+
+	if p.servePreflight(rw, req, route) {
+		log.Printf("[%s] ServeHTTP: Answered CORS preflight", p.name)
+		return
+	}
+	handler := p.aiHandler(req, route)
+	if handler == nil {
+		// Pass through non-matching requests to the next handler
+		log.Printf("[%s] ServeHTTP: Passing through unmatched request", p.name)
+		p.serveNext(newStatusWriter(rw), req)
+		return
+	}
+	p.setCORSHeaders(rw.Header(), req)
+	if p.config.Maintenance.Enabled {
+		p.serveMaintenance(rw)
+		return
+	}
+	if rejectPreconditions(rw, req) {
+		log.Printf("[%s] ServeHTTP: Rejected range or conditional request", p.name)
+		return
+	}
+	handler(rw, req)
+}
+
+// aiHandler returns the handler of an OpenAI endpoint served by the plugin, or nil for requests
+// passed through to the next handler.
+func (p *Proxy) aiHandler(req *http.Request, route string) http.HandlerFunc {
+	lowerRoute := strings.ToLower(route)
+	switch {
+	case p.config.EnableModelsEndpoint && req.Method == http.MethodGet && strings.HasSuffix(lowerRoute, "/models"):
+		log.Printf("[%s] ServeHTTP: Handling /models endpoint", p.name)
+		return func(rw http.ResponseWriter, req *http.Request) {
+			if err := p.processModelsRequest(rw, req); err != nil {
+				log.Printf("[%s] ServeHTTP: processModelsRequest error: %v", p.name, err)
+				log.Printf("[%s] ERROR: Failed to process models request: %v", p.name, err)
+				writeFailure(rw, err)
+			}
+		}
+	case p.prompts != nil && req.Method == http.MethodPost && promptName(req.URL.Path) != "":
+		log.Printf("[%s] ServeHTTP: Handling prompt template endpoint", p.name)
+		return p.servePrompt
+	case p.config.Speech.Enabled && req.Method == http.MethodPost && strings.HasSuffix(lowerRoute, "/audio/speech"):
+		log.Printf("[%s] ServeHTTP: Handling /audio/speech endpoint", p.name)
+		return p.serveSpeech
+	case p.config.Transcription.Enabled && req.Method == http.MethodPost && strings.HasSuffix(lowerRoute, "/audio/transcriptions"):
+		log.Printf("[%s] ServeHTTP: Handling /audio/transcriptions endpoint", p.name)
+		return p.serveTranscription
+	case p.assistants != nil && assistantsPath(route) != nil:
+		log.Printf("[%s] ServeHTTP: Handling Assistants API endpoint", p.name)
+		return func(rw http.ResponseWriter, req *http.Request) {
+			p.serveAssistants(rw, req, assistantsPath(route))
+		}
+	case p.config.RejectRealtime && realtimePath(lowerRoute):
+		log.Printf("[%s] ServeHTTP: Handling Realtime API endpoint", p.name)
+		return p.rejectRealtime
+	case p.config.EnableChatEndpoint && req.Method == http.MethodPost && strings.HasSuffix(lowerRoute, "/chat/completions"):
 		log.Printf("[%s] ServeHTTP: Handling /chat/completions endpoint", p.name)
-		log.Printf("[%s] ServeHTTP: Calling processOpenAIRequest", p.name)
-		originalModel, err := p.processOpenAIRequest(rw, req)
-		if err != nil {
-			log.Printf("[%s] ERROR: Failed to process OpenAI request: %v", p.name, err)
-			http.Error(rw, err.Error(), http.StatusInternalServerError)
+		if p.chaos != nil {
+			return p.serveChaos
+		}
+		return p.serveChat
+	}
+	return nil
+}
+
+// routePath normalizes a request path for routing: repeated slashes are collapsed and a trailing
+// slash is removed, so /v1//chat/completions/ routes like /v1/chat/completions.
+func routePath(path string) string {
+	var b strings.Builder
+	b.Grow(len(path))
+	for i := 0; i < len(path); i++ {
+		if path[i] == '/' && i > 0 && path[i-1] == '/' {
+			continue
+		}
+		b.WriteByte(path[i])
+	}
+	route := b.String()
+	if len(route) > 1 {
+		route = strings.TrimSuffix(route, "/")
+	}
+	return route
+}
+
+// serveChat handles a chat completion request: it transforms the request, forwards it to OCI
+// GenAI, and transforms the response back to OpenAI format.
+func (p *Proxy) serveChat(rw http.ResponseWriter, req *http.Request) {
+	atomic.AddInt64(&p.inFlight, 1)
+	defer atomic.AddInt64(&p.inFlight, -1)
+
+	// Shed low-priority requests while the upstream is saturated, before doing any work for them
+	priority := p.admissionPriority(req)
+	if p.admission != nil {
+		if !p.admission.Admit(priority) {
+			p.shed(rw, priority)
 			return
 		}
+		defer p.admission.Done()
+	}
+
+	log.Printf("[%s] ServeHTTP: Calling processOpenAIRequest", p.name)
+	exchange, err := p.processOpenAIRequest(rw, req)
+	if err != nil {
+		log.Printf("[%s] ERROR: Failed to process OpenAI request: %v", p.name, err)
+		var rejected *clientError
+		if !errors.As(err, &rejected) {
+			writeFailure(rw, err)
+		}
+		return
+	}
+	exchange.priority = priority
+
+	// Shed on upstream latency alone, not on streamed generation time or local rejections
+	if p.admission != nil {
+		defer func() {
+			if !exchange.responded.IsZero() {
+				p.admission.Observe(exchange.responded.Sub(exchange.started))
+			}
+		}()
+	}
+
+	// Track the outcome against the model's objectives
+	if p.slo != nil {
+		defer func() {
+			if exchange.status != 0 {
+				p.slo.Record(exchange.model, exchange.status, time.Since(exchange.started))
+			}
+		}()
+	}
 
+	// Feed the outcome back into endpoint health and latency tracking
+	if exchange.endpoint != nil {
+		defer func() {
+			if exchange.status != 0 {
+				p.balancer.Report(exchange.endpoint, exchange.status, time.Since(exchange.started))
+			}
+		}()
+	}
+
+	if !p.config.TransformResponses && exchange.agentEndpoint == "" {
+		// Let a downstream component handle the response conversion, recording only the status
+		statusWriter := newStatusWriter(rw)
+		p.serveNext(statusWriter, req)
+		exchange.status = statusWriter.statusCode
+		exchange.responded = statusWriter.responded
+	} else if exchange.agentEndpoint != "" {
+		p.serveAgent(rw, req, exchange)
+	} else if exchange.stream {
+		p.processStream(rw, req, exchange)
+	} else {
 		// Create a response writer wrapper to capture the response
 		wrappedWriter := newResponseWriter(rw)
 
 		// Forward to next handler with wrapped writer
-		p.next.ServeHTTP(wrappedWriter, req)
+		p.serveNext(wrappedWriter, req)
+		exchange.responded = wrappedWriter.responded
+		if p.retryBudget(exchange) > 0 && !p.transformBypassed(wrappedWriter) {
+			wrappedWriter = p.retryMalformedAnswers(rw, req, exchange, wrappedWriter)
+		}
 
 		// Print OCI downstream status and result body (snippet)
 		log.Printf("[%s] OCI downstream status: %d", p.name, wrappedWriter.statusCode)
@@ -140,54 +664,295 @@ func (p *Proxy) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 
 		// Transform the response back to OpenAI format
 		log.Printf("[%s] ServeHTTP: Transforming downstream response", p.name)
-		if err := p.processResponse(rw, wrappedWriter, originalModel); err != nil {
+		if err := p.processResponse(rw, wrappedWriter, exchange); err != nil {
 			log.Printf("[%s] ERROR: Failed to transform response: %v", p.name, err)
 			// If transformation fails, write the original response
 			rw.WriteHeader(wrappedWriter.statusCode)
 			_, _ = rw.Write(wrappedWriter.body.Bytes())
+			exchange.status = wrappedWriter.statusCode
+			exchange.responseBody = wrappedWriter.body.Bytes()
 		}
-	} else {
-		// Pass through non-matching requests to the next handler
-		log.Printf("[%s] ServeHTTP: Passing through unmatched request", p.name)
-		p.next.ServeHTTP(rw, req)
+	}
+
+	if p.mirror != nil && p.featureEnabled(featureMirror) {
+		p.mirror.Record(exchange.model, exchange.status, exchange.requestBody, exchange.responseBody)
+	}
+
+	if p.audit != nil && p.featureEnabled(featureAudit) {
+		p.audit.Log(audit.Record{
+			Time:        exchange.started,
+			RequestID:   exchange.requestID,
+			Model:       exchange.model,
+			Tenant:      exchange.tenant,
+			ClientIP:    exchange.clientIP,
+			Status:      exchange.status,
+			DurationMs:  time.Since(exchange.started).Milliseconds(),
+			ServiceTier: exchange.serviceTier,
+			Priority:    exchange.priority,
+			Store:       exchange.store,
+			Metadata:    exchange.metadata,
+		})
+	}
+
+	if p.usage != nil && exchange.usage != nil && p.featureEnabled(featureUsage) {
+		p.usage.Record(exchange.tenant, exchange.model, *exchange.usage)
+	}
+
+	if p.config.Metrics.ClientLabels {
+		p.metrics.Inc(metricClientRequests, metrics.Labels{
+			"client": exchange.clientIP,
+			"model":  p.modelLabel(exchange.model),
+			"status": strconv.Itoa(exchange.status),
+		})
 	}
 }
 
 // processOpenAIRequest handles the transformation of OpenAI requests to OCI GenAI format.
-func (p *Proxy) processOpenAIRequest(rw http.ResponseWriter, req *http.Request) (string, error) {
+func (p *Proxy) processOpenAIRequest(rw http.ResponseWriter, req *http.Request) (*chatExchange, error) {
+	started := time.Now()
+
 	// Read the request body
-	body, err := io.ReadAll(req.Body)
+	body, err := readRequestBody(req)
 	if err != nil {
 		log.Printf("[%s] Failed to read request body: %v", p.name, err)
-		return "", fmt.Errorf("failed to read request body: %w", err)
+		writeBodyError(rw, err)
+		return nil, &clientError{fmt.Errorf("failed to read request body: %w", err)}
 	}
 
 	// Close the original body
 	if closeErr := req.Body.Close(); closeErr != nil {
-		return "", fmt.Errorf("failed to close request body: %w", closeErr)
+		return nil, &requestFailure{"request_body_close_failed", fmt.Errorf("failed to close request body: %w", closeErr)}
+	}
+
+	body, err = jsonRequestBody(req.Header.Get("Content-Type"), body)
+	if err != nil {
+		p.recordFailure(metricParseErrors, "", http.StatusUnsupportedMediaType)
+		writeError(rw, http.StatusUnsupportedMediaType, err.Error(), "", "unsupported_media_type")
+		return nil, &clientError{err}
 	}
 
 	// Parse OpenAI ChatCompletion request
 	var openAIReq types.ChatCompletionRequest
-	if unmarshalErr := json.Unmarshal(body, &openAIReq); unmarshalErr != nil {
-		http.Error(rw, "Failed to parse OpenAI request", http.StatusBadRequest)
-		return "", unmarshalErr
+	if invalid := decodeChatRequest(body, &openAIReq); invalid != nil {
+		p.recordFailure(metricParseErrors, "", http.StatusBadRequest)
+		writeError(rw, http.StatusBadRequest, invalid.message, invalid.param, invalid.code)
+		return nil, &clientError{errors.New(invalid.message)}
+	}
+	if invalid := validateChatRequest(openAIReq, p.config.AllowEmptyMessages); invalid != nil {
+		writeError(rw, http.StatusBadRequest, invalid.message, invalid.param, invalid.code)
+		return nil, &clientError{errors.New(invalid.message)}
 	}
+	openAIReq.Extra = extraBody(body, p.config.ExtraBodyPassthrough)
 
-	log.Printf("[%s] processOpenAIRequest: Raw request body: %s", p.name, string(body))
-	log.Printf("[%s] processOpenAIRequest: Unmarshalled OpenAI request: %+v", p.name, openAIReq)
+	// Prompt debugging may also be requested through a header
+	if openAIReq.DebugPrompt == "" {
+		openAIReq.DebugPrompt = req.Header.Get("X-Oci-Debug-Prompt")
+	}
+
+	// So may a server-side Cohere conversation
+	if openAIReq.ConversationID == "" {
+		openAIReq.ConversationID = req.Header.Get("X-Oci-Conversation-Id")
+	}
+
+	// Reject audio up front rather than dropping it in the transformation
+	if unsupported := unsupportedAudio(openAIReq); unsupported != nil {
+		writeError(rw, http.StatusBadRequest, unsupported.message, unsupported.param, "unsupported_audio")
+		return nil, &clientError{fmt.Errorf("unsupported audio in %s", unsupported.param)}
+	}
+	if unsupported := unsupportedModality(openAIReq); unsupported != nil {
+		writeError(rw, http.StatusBadRequest, unsupported.message, unsupported.param, "unsupported_modality")
+		return nil, &clientError{fmt.Errorf("unsupported modality in %s", unsupported.param)}
+	}
+
+	// Reject too many images, and oversized or unsupported inline ones, before anything is
+	// downloaded; the fetcher applies the same size and type limits to remote images
+	if err := vision.Validate(openAIReq.Messages, p.config.ImageLimits); err != nil {
+		var validationErr *vision.ValidationError
+		if errors.As(err, &validationErr) {
+			writeError(rw, http.StatusBadRequest, validationErr.Message, validationErr.Param, "invalid_image")
+			return nil, &clientError{err}
+		}
+		return nil, err
+	}
+
+	// OCI only accepts inline image data, so download remote images
+	if p.images != nil {
+		if err := p.images.InlineImages(req.Context(), openAIReq.Messages); err != nil {
+			writeError(rw, http.StatusBadRequest, fmt.Sprintf("Failed to fetch image: %v", err), "", "image_fetch_failed")
+			return nil, &clientError{err}
+		}
+	}
+
+	// Reject parameters OCI cannot honour rather than silently dropping them
+	if p.config.StrictParameters && len(openAIReq.LogitBias) > 0 {
+		writeError(rw, http.StatusBadRequest,
+			"logit_bias is not supported by OCI Generative AI; remove it from the request",
+			"logit_bias", "unsupported_parameter")
+		return nil, &clientError{fmt.Errorf("unsupported parameter logit_bias")}
+	}
+	if p.config.StrictParameters && len(openAIReq.Prediction) > 0 && string(openAIReq.Prediction) != "null" {
+		writeError(rw, http.StatusBadRequest,
+			"prediction is not supported by OCI Generative AI; remove it from the request",
+			"prediction", "unsupported_parameter")
+		return nil, &clientError{fmt.Errorf("unsupported parameter prediction")}
+	}
+
+	if p.config.ReasoningEffort == config.ReasoningEffortReject && openAIReq.ReasoningEffort != "" {
+		writeError(rw, http.StatusBadRequest,
+			"reasoning_effort is not supported by this gateway; remove it from the request",
+			"reasoning_effort", "unsupported_parameter")
+		return nil, &clientError{fmt.Errorf("unsupported parameter reasoning_effort")}
+	}
+
+	// Route the tenant's model alias to its configured model and endpoint
+	tenant := p.tenant(req)
+	route, routed := p.modelRoute(tenant, openAIReq.Model)
+	if routed && route.Model != "" {
+		log.Printf("[%s] processOpenAIRequest: Routing model %s to %s for tenant %s", p.name, openAIReq.Model, route.Model, tenant)
+		openAIReq.Model = route.Model
+	}
+
+	// Scheduled rules move models elsewhere during their time windows
+	if scheduled, rule, ok := p.schedule.Route(openAIReq.Model, started); ok {
+		log.Printf("[%s] processOpenAIRequest: Schedule %s routes model %s", p.name, rule, openAIReq.Model)
+		if scheduled.Model != "" {
+			openAIReq.Model = scheduled.Model
+		}
+		if scheduled.Host != "" || scheduled.Region != "" {
+			route.Host, route.Region = scheduled.Host, scheduled.Region
+		}
+	}
+
+	// Let trusted operators replace the model, while responses keep reporting the client's
+	responseModel := openAIReq.Model
+	if override := p.modelOverride(req); override != "" {
+		log.Printf("[%s] processOpenAIRequest: Overriding model %s with %s", p.name, openAIReq.Model, override)
+		openAIReq.Model = override
+	}
+
+	// Agent models are answered by an OCI Generative AI agent rather than sent to a model
+	if endpointID, ok := agentEndpointID(openAIReq.Model); ok && p.sessions != nil {
+		return &chatExchange{
+			model:         openAIReq.Model,
+			responseModel: responseModel,
+			requestBody:   body,
+			started:       started,
+			serviceTier:   openAIReq.ServiceTier,
+			store:         openAIReq.Store,
+			metadata:      openAIReq.Metadata,
+			stream:        openAIReq.Stream,
+			includeUsage:  openAIReq.StreamOptions != nil && openAIReq.StreamOptions.IncludeUsage,
+			tenant:        tenant,
+			clientIP:      p.clientIPs.ClientIP(req),
+			request:       &openAIReq,
+			agentEndpoint: endpointID,
+		}, nil
+	}
+
+	// Reject unknown models, and images for models without image input, before they reach OCI
+	if p.catalog != nil && p.featureEnabled(featureModelValidation) {
+		if model, found, err := p.catalog.Resolve(req.Context(), openAIReq.Model); err != nil {
+			// Let OCI decide when the catalog is unavailable
+			log.Printf("[%s] ERROR: Failed to resolve model %s, forwarding anyway: %v", p.name, openAIReq.Model, err)
+			if p.degrade != nil {
+				p.degrade.Failure(featureModelValidation, err)
+			}
+		} else if !found {
+			writeError(rw, http.StatusNotFound, fmt.Sprintf("The model `%s` does not exist or you do not have access to it.", openAIReq.Model), "model", "model_not_found")
+			return nil, &clientError{fmt.Errorf("model %s not found", openAIReq.Model)}
+		} else if !model.IsImageTextToTextSupported && vision.HasImages(openAIReq.Messages) {
+			fallback := p.config.ModelValidation.VisionFallbackModel
+			if fallback != "" {
+				// The fallback is checked like a requested model, so a misconfigured one fails here rather than at OCI
+				fallbackModel, found, err := p.catalog.Resolve(req.Context(), fallback)
+				switch {
+				case err != nil:
+					log.Printf("[%s] ERROR: Failed to resolve vision fallback model %s, forwarding anyway: %v", p.name, fallback, err)
+				case !found:
+					log.Printf("[%s] ERROR: Vision fallback model %s is not in the catalog", p.name, fallback)
+					writeError(rw, http.StatusNotFound, fmt.Sprintf("The vision fallback model `%s` does not exist or you do not have access to it.", fallback), "model", "model_not_found")
+					return nil, &clientError{fmt.Errorf("vision fallback model %s not found", fallback)}
+				case !fallbackModel.IsImageTextToTextSupported:
+					log.Printf("[%s] ERROR: Vision fallback model %s does not support image input", p.name, fallback)
+					fallback = ""
+				}
+			}
+			if fallback != "" {
+				log.Printf("[%s] processOpenAIRequest: Routing request with images from %s to %s", p.name, openAIReq.Model, fallback)
+				openAIReq.Model = fallback
+			} else {
+				writeError(rw, http.StatusBadRequest, fmt.Sprintf("The model `%s` does not support image input. Use a vision model or remove the images.", openAIReq.Model), "messages", "image_input_not_supported")
+				return nil, &clientError{fmt.Errorf("model %s does not support image input", openAIReq.Model)}
+			}
+		}
+	}
+
+	// Send namespaced models to their compartment under the name OCI knows them by
+	compartmentID, model := p.federatedModel(openAIReq.Model)
+	openAIReq.Model = model
+
+	// Translate the deprecated functions fields to tools
+	hasFunctions := len(openAIReq.Functions) > 0
+	legacyFunctions := transform.FromLegacyFunctions(&openAIReq)
+
+	// Describe tools in the prompt for models without native tool support
+	var emulation *transform.ToolEmulation
+	if !openAIReq.Stream {
+		emulation = p.transformer.EmulateTools(&openAIReq)
+	}
+
+	// Tools and functions only reach the model through tool emulation, so reject rather than drop
+	// them; a tool_choice of "none" asks for no tool calls, which dropping the tools honours
+	choice := transform.ToolChoice(openAIReq.ToolChoice)
+	if emulation == nil && choice != "none" && (len(openAIReq.Tools) > 0 || (choice != "" && choice != "auto")) {
+		param := "tools"
+		switch {
+		case hasFunctions:
+			param = "functions"
+		case len(openAIReq.Tools) == 0:
+			param = "tool_choice"
+		}
+		writeError(rw, http.StatusBadRequest,
+			param+" is only supported through tool emulation, which is not enabled for this model or for streamed requests",
+			param, "unsupported_parameter")
+		return nil, &clientError{fmt.Errorf("unsupported parameter %s", param)}
+	}
+
+	// Normalize rewrites roles in place, so debug summaries keep the messages as received
+	var received []types.ChatCompletionMessage
+	if p.config.Debug {
+		received = append(received, openAIReq.Messages...)
+	}
+
+	// Clamp parameters OCI would reject and truncate long histories
+	adjustments := p.transformer.Normalize(&openAIReq)
+	if len(adjustments) > 0 {
+		log.Printf("[%s] processOpenAIRequest: Adjusted parameters: %s", p.name, strings.Join(adjustments, "; "))
+	}
+
+	if p.config.Debug {
+		log.Printf("[%s] processOpenAIRequest: Raw request body: %s", p.name, string(body))
+		log.Printf("[%s] processOpenAIRequest: Unmarshalled OpenAI request: %+v", p.name, openAIReq)
+	}
 
 	// Transform to OCI GenAI format
 	log.Printf("[%s] processOpenAIRequest: Transforming to OCI GenAI format", p.name)
 	ociReq := p.transformer.ToOracleCloudRequest(openAIReq)
+	ociReq.CompartmentID = compartmentID
+	if p.config.Debug {
+		p.logTransformation(received, adjustments, ociReq)
+	}
 
 	// Marshal the OCI GenAI request
 	ociBody, err := json.Marshal(ociReq)
 	if err != nil {
 		log.Printf("[%s] processOpenAIRequest: Failed to marshal OCI GenAI request: %v", p.name, err)
-		return "", fmt.Errorf("failed to marshal OCI GenAI request: %w", err)
+		p.recordFailure(metricMarshalFailures, openAIReq.Model, http.StatusInternalServerError)
+		return nil, fmt.Errorf("failed to marshal OCI GenAI request: %w", err)
+	}
+	if p.config.Debug {
+		log.Printf("[%s] processOpenAIRequest: Marshalled OCI GenAI request: %s", p.name, string(ociBody))
 	}
-	log.Printf("[%s] processOpenAIRequest: Marshalled OCI GenAI request: %s", p.name, string(ociBody))
 
 	// Replace request body with transformed content
 	log.Printf("[%s] processOpenAIRequest: Replacing request body and updating Content-Length", p.name)
@@ -197,55 +962,161 @@ func (p *Proxy) processOpenAIRequest(rw http.ResponseWriter, req *http.Request)
 	// Update the request to point to the OCI GenAI endpoint
 	log.Printf("[%s] processOpenAIRequest: Setting OCI GenAI endpoint details", p.name)
 	req.RequestURI = ""
-	req.URL.Scheme = "https"
-
-	req.URL.Host = fmt.Sprintf("generativeai.%s.oci.oraclecloud.com", p.config.Region)
+	var endpoint *balancer.Endpoint
+	if p.config.RewriteHost {
+		req.URL.Scheme = "https"
+		req.URL.Host = fmt.Sprintf("generativeai.%s.oci.oraclecloud.com", p.config.Region)
+		switch {
+		case route.Host != "":
+			req.URL.Host = route.Host
+		case route.Region != "":
+			req.URL.Host = fmt.Sprintf("generativeai.%s.oci.oraclecloud.com", route.Region)
+		case p.balancer != nil:
+			endpoint = p.balancer.Pick()
+			req.URL.Host = endpoint.Host
+		}
+	}
 	req.URL.Path = "/20231130/actions/chat"
 	req.URL.RawQuery = ""
 	req.Header.Set("Content-Type", "application/json")
+	acceptEncoding := req.Header.Get("Accept-Encoding")
+	if p.config.TransformResponses {
+		req.Header.Set("Accept-Encoding", upstreamAcceptEncoding)
+	}
 
-	// Print outgoing request after all modifications
-	log.Printf("[%s] Outgoing OCI request: method=%s url=%s://%s%s headers=%v body=%s", p.name, req.Method, req.URL.Scheme, req.URL.Host, req.URL.Path, req.Header, string(ociBody))
+	// Identical resends, such as those of a downstream retry middleware, carry the same token,
+	// so OCI does not process them twice. A token supplied by the client is namespaced, so
+	// clients choosing the same token cannot be answered with each other's responses.
+	if token := req.Header.Get(retryTokenHeader); token != "" {
+		req.Header.Set(retryTokenHeader, clientRetryToken(token, tenant, p.clientIPs.ClientIP(req)))
+	} else {
+		req.Header.Set(retryTokenHeader, newRetryToken())
+	}
+
+	var requestID string
+	if p.config.RequestMetadata.Enabled {
+		requestID = p.requestID(req)
+		req.Header.Set(opcRequestIDHeader, p.opcRequestID(requestID, tenant, openAIReq.User))
+	}
+
+	if err := p.sign(req, ociBody); err != nil {
+		return nil, err
+	}
+
+	// Print outgoing request after all modifications, never with its signature or session token
+	if p.config.Debug {
+		log.Printf("[%s] Outgoing OCI request: method=%s url=%s://%s%s headers=%v body=%s", p.name, req.Method, req.URL.Scheme, req.URL.Host, req.URL.Path, redactedHeaders(req.Header), string(ociBody))
+	} else {
+		log.Printf("[%s] Outgoing OCI request: method=%s url=%s://%s%s", p.name, req.Method, req.URL.Scheme, req.URL.Host, req.URL.Path)
+	}
 
 	log.Printf("[%s] processOpenAIRequest: Complete, returning model=%s", p.name, openAIReq.Model)
-	return openAIReq.Model, nil
+	return &chatExchange{
+		model:           openAIReq.Model,
+		responseModel:   responseModel,
+		compartmentID:   compartmentID,
+		requestBody:     body,
+		started:         started,
+		serviceTier:     openAIReq.ServiceTier,
+		store:           openAIReq.Store,
+		metadata:        openAIReq.Metadata,
+		stream:          openAIReq.Stream,
+		includeUsage:    openAIReq.StreamOptions != nil && openAIReq.StreamOptions.IncludeUsage,
+		tenant:          tenant,
+		requestID:       requestID,
+		acceptEncoding:  acceptEncoding,
+		clientIP:        p.clientIPs.ClientIP(req),
+		adjustments:     adjustments,
+		endpoint:        endpoint,
+		emulation:       emulation,
+		legacyFunctions: legacyFunctions,
+		jsonMode:        !openAIReq.Stream && openAIReq.ResponseFormat != nil && openAIReq.ResponseFormat.Type == "json_object",
+		request:         &openAIReq,
+	}, nil
 }
 
 // processModelsRequest handles the transformation of models requests.
 func (p *Proxy) processModelsRequest(rw http.ResponseWriter, req *http.Request) error {
 	log.Printf("[%s] processModelsRequest: called", p.name)
 
+	if !acceptsJSON(req.Header.Get("Accept")) {
+		writeError(rw, http.StatusNotAcceptable, "The models endpoint only returns application/json", "", "not_acceptable")
+		return nil
+	}
+	modelsQuery, ok := parseModelsQuery(rw, req)
+	if !ok {
+		return nil
+	}
+
+	if len(p.config.ModelCompartments) > 0 && p.config.TransformResponses {
+		return p.serveFederatedModels(rw, req, modelsQuery)
+	}
+
+	// OpenAI-style cursor pagination maps onto OCI limit/page tokens, and owners onto OCI vendors
+	query := url.Values{}
+	query.Set("compartmentId", p.config.CompartmentID)
+	query.Set("capability", "CHAT")
+	if modelsQuery.limit > 0 {
+		query.Set("limit", strconv.Itoa(modelsQuery.limit))
+	}
+	if modelsQuery.ownedBy != "" {
+		if vendor, ok := p.transformer.ModelVendor(modelsQuery.ownedBy); ok {
+			query.Set("vendor", vendor)
+		}
+	}
+	if after := req.URL.Query().Get("after"); after != "" {
+		query.Set("page", after)
+	}
+
 	req.RequestURI = ""
-	req.URL.Scheme = "https"
-	req.URL.Host = fmt.Sprintf("generativeai.%s.oci.oraclecloud.com", p.config.Region)
+	if p.config.RewriteHost {
+		req.URL.Scheme = "https"
+		req.URL.Host = fmt.Sprintf("generativeai.%s.oci.oraclecloud.com", p.config.Region)
+	}
 	req.URL.Path = "/20231130/models"
-	req.URL.RawQuery = "compartmentId=" + url.QueryEscape(p.config.CompartmentID) + "&capability=CHAT"
+	req.URL.RawQuery = query.Encode()
 	req.Header.Set("Content-Type", "application/json")
+	acceptEncoding := req.Header.Get("Accept-Encoding")
+	if p.config.TransformResponses {
+		req.Header.Set("Accept-Encoding", upstreamAcceptEncoding)
+	}
+
+	if err := p.sign(req, nil); err != nil {
+		return err
+	}
+
+	// Let a downstream component handle the response conversion
+	if !p.config.TransformResponses {
+		p.serveNext(newStatusWriter(rw), req)
+		return nil
+	}
 
 	// Create a response writer wrapper to capture the response
 	wrappedWriter := newResponseWriter(rw)
 
 	// Forward to next handler
-	p.next.ServeHTTP(wrappedWriter, req)
+	p.serveNext(wrappedWriter, req)
 
 	if wrappedWriter.statusCode != http.StatusOK {
+		p.recordFailure(metricUpstreamFailures, "", wrappedWriter.statusCode)
+		body := p.clientEncodedBody(wrappedWriter.body.Bytes(), wrappedWriter.Header(), acceptEncoding)
 		rw.WriteHeader(wrappedWriter.statusCode)
-		_, _ = rw.Write(wrappedWriter.body.Bytes())
+		_, _ = rw.Write(body)
 		return nil
 	}
 
-	// Get response body, handling compression
-	responseBody, err := p.decompressResponse(wrappedWriter.body.Bytes(), wrappedWriter.Header())
-	if err != nil {
-		log.Printf("[%s] ERROR: Failed to decompress response: %v", p.name, err)
-		return fmt.Errorf("failed to decompress response: %w", err)
-	}
-
-	// Parse OCI models response
-	log.Printf("[%s] processModelsRequest: Unmarshalling OCI models response", p.name)
+	// Parse OCI models response, decompressing as it is decoded
+	log.Printf("[%s] processModelsRequest: Decoding OCI models response", p.name)
 	var ociResp types.OCIModelsResponse
-	if err := json.Unmarshal(responseBody, &ociResp); err != nil {
+	if err := p.decodeResponse(wrappedWriter.body.Bytes(), wrappedWriter.Header(), &ociResp); err != nil {
+		responseBody, decompressErr := p.decompressResponse(wrappedWriter.body.Bytes(), wrappedWriter.Header())
+		if decompressErr != nil {
+			log.Printf("[%s] ERROR: Failed to decompress response: %v", p.name, decompressErr)
+			p.recordFailure(metricDecompressFailures, "", wrappedWriter.statusCode)
+			return fmt.Errorf("failed to decompress response: %w", decompressErr)
+		}
 		log.Printf("[%s] ERROR: Failed to parse OCI models response: %v", p.name, err)
+		p.recordFailure(metricTransformErrors, "", wrappedWriter.statusCode)
 		log.Printf("[%s] Response body: %s", p.name, string(responseBody))
 		return fmt.Errorf("failed to parse OCI models response: %w", err)
 	}
@@ -253,15 +1124,24 @@ func (p *Proxy) processModelsRequest(rw http.ResponseWriter, req *http.Request)
 	// Transform to OpenAI format
 	log.Printf("[%s] processModelsRequest: Transforming OCI models response to OpenAI format", p.name)
 	openAIResp := p.transformer.ToOpenAIModelsResponse(ociResp)
+	modelsQuery.filter(&openAIResp)
+	if nextPage := wrappedWriter.Header().Get("opc-next-page"); nextPage != "" {
+		openAIResp.HasMore = true
+		openAIResp.NextPage = nextPage
+	}
 
 	// Marshal the response
 	openAIBody, err := json.Marshal(openAIResp)
 	if err != nil {
 		log.Printf("[%s] ERROR: Failed to marshal OpenAI models response: %v", p.name, err)
+		p.recordFailure(metricMarshalFailures, "", wrappedWriter.statusCode)
 		return fmt.Errorf("failed to marshal OpenAI models response: %w", err)
 	}
 
-	// Compress response if original was compressed
+	// Compress response if original was compressed and the client accepts it
+	if !acceptsEncoding(acceptEncoding, wrappedWriter.Header().Get("Content-Encoding")) {
+		wrappedWriter.Header().Del("Content-Encoding")
+	}
 	finalBody, err := p.compressResponse(openAIBody, wrappedWriter.Header())
 	if err != nil {
 		log.Printf("[%s] ERROR: Failed to compress response: %v", p.name, err)
@@ -269,17 +1149,11 @@ func (p *Proxy) processModelsRequest(rw http.ResponseWriter, req *http.Request)
 	}
 
 	// Copy headers from original response
-	for key, values := range wrappedWriter.Header() {
-		for _, value := range values {
-			rw.Header().Set(key, value)
-		}
-	}
+	copyUpstreamHeader(rw.Header(), wrappedWriter.Header())
 
 	// Update content headers
+	setTransformedEntityHeaders(rw.Header(), finalBody, wrappedWriter.Header().Get("Content-Encoding"))
 	rw.Header().Set("Content-Type", "application/json")
-	rw.Header().Set("Content-Length", fmt.Sprintf("%d", len(finalBody)))
-	// Add CORS header for actual response
-	rw.Header().Set("Access-Control-Allow-Origin", "*")
 	log.Printf("[%s] processModelsRequest: Writing transformed models response, length=%d", p.name, len(finalBody))
 	rw.WriteHeader(http.StatusOK)
 	_, _ = rw.Write(finalBody)
@@ -287,44 +1161,131 @@ func (p *Proxy) processModelsRequest(rw http.ResponseWriter, req *http.Request)
 	return nil
 }
 
+// processStream forwards a streamed request and converts the OCI events to OpenAI chunks as they arrive.
+// Latency metrics are recorded once the stream completes.
+func (p *Proxy) processStream(rw http.ResponseWriter, req *http.Request, exchange *chatExchange) {
+	log.Printf("[%s] processStream: called", p.name)
+
+	stream := p.transformer.NewStream(exchange.responseModel, exchange.includeUsage)
+	streamWriter := newStreamWriter(rw, stream, func(err error) {
+		log.Printf("[%s] ERROR: %v", p.name, err)
+		p.recordFailure(metricTransformErrors, exchange.model, http.StatusOK)
+	})
+
+	// Usage and finish reason are only known after the headers are sent, so they can only be trailers
+	p.setAccessLogHeaders(streamWriter.Header(), exchange, nil)
+	p.declareUsageTrailers(streamWriter.Header())
+	setAdjustedHeader(streamWriter.Header(), exchange)
+
+	p.serveNext(streamWriter, req)
+	exchange.responded = streamWriter.responded
+
+	if err := streamWriter.finish(); err != nil {
+		log.Printf("[%s] ERROR: Failed to finish stream: %v", p.name, err)
+	}
+
+	exchange.status = streamWriter.status
+	exchange.responseBody = streamWriter.output.Bytes()
+	if streamWriter.status != http.StatusOK {
+		p.recordFailure(metricUpstreamFailures, exchange.model, streamWriter.status)
+		return
+	}
+
+	// Only usage reported by OCI is recorded, so ledgers and reports never count invented tokens
+	streamUsage, reported := stream.Usage()
+	if reported {
+		exchange.usage = &streamUsage
+	}
+	p.setUsageTrailers(rw.Header(), exchange.usage, stream.FinishReason())
+
+	if streamWriter.firstToken.IsZero() {
+		return
+	}
+	labels := metrics.Labels{"model": p.modelLabel(exchange.model)}
+	p.metrics.Observe(metricTimeToFirstToken, labels, streamWriter.firstToken.Sub(exchange.started).Seconds())
+	if elapsed := time.Since(streamWriter.firstToken).Seconds(); elapsed > 0 && reported {
+		p.metrics.Observe(metricTokensPerSecond, labels, float64(streamUsage.CompletionTokens)/elapsed)
+	}
+}
+
 // processResponse handles the transformation of responses from OCI GenAI back to OpenAI format.
-func (p *Proxy) processResponse(originalWriter http.ResponseWriter, wrappedWriter *responseWriter, originalModel string) error {
+func (p *Proxy) processResponse(originalWriter http.ResponseWriter, wrappedWriter *responseWriter, exchange *chatExchange) error {
 	log.Printf("[%s] processResponse: called", p.name)
+	originalModel := exchange.model
 
 	// Only transform successful responses
 	if wrappedWriter.statusCode != http.StatusOK {
+		p.recordFailure(metricUpstreamFailures, originalModel, wrappedWriter.statusCode)
+		p.setAccessLogHeaders(originalWriter.Header(), exchange, nil)
+		setAdjustedHeader(originalWriter.Header(), exchange)
+		body := p.clientEncodedBody(wrappedWriter.body.Bytes(), wrappedWriter.Header(), exchange.acceptEncoding)
 		originalWriter.WriteHeader(wrappedWriter.statusCode)
-		_, _ = originalWriter.Write(wrappedWriter.body.Bytes())
+		_, _ = originalWriter.Write(body)
+
+		exchange.status = wrappedWriter.statusCode
+		exchange.responseBody = body
+		if decompressed, err := p.decompressResponse(exchange.responseBody, wrappedWriter.Header()); err == nil {
+			exchange.responseBody = decompressed
+		}
 		return nil
 	}
 
-	// Get response body, handling compression
-	responseBody, err := p.decompressResponse(wrappedWriter.body.Bytes(), wrappedWriter.Header())
-	if err != nil {
-		log.Printf("[%s] ERROR: Failed to decompress response: %v", p.name, err)
-		return fmt.Errorf("failed to decompress response: %w", err)
+	// Relieve the plugin of pathological payloads
+	if p.transformBypassed(wrappedWriter) {
+		p.bypassTransform(originalWriter, wrappedWriter, exchange)
+		return nil
 	}
 
-	// Parse the OCI GenAI response
-	log.Printf("[%s] processResponse: Unmarshalling OCI GenAI response for chat/completions", p.name)
-	var ociResp types.OracleCloudResponse
-	if err := json.Unmarshal(responseBody, &ociResp); err != nil {
+	// Parse the OCI GenAI response, decompressing as it is decoded
+	log.Printf("[%s] processResponse: Decoding OCI GenAI response for chat/completions", p.name)
+	ociResp, err := p.decodeChatResponse(wrappedWriter.body.Bytes(), wrappedWriter.Header(), originalModel)
+	if err != nil {
+		responseBody, decompressErr := p.decompressResponse(wrappedWriter.body.Bytes(), wrappedWriter.Header())
+		if decompressErr != nil {
+			log.Printf("[%s] ERROR: Failed to decompress response: %v", p.name, decompressErr)
+			p.recordFailure(metricDecompressFailures, originalModel, wrappedWriter.statusCode)
+			return fmt.Errorf("failed to decompress response: %w", decompressErr)
+		}
 		log.Printf("[%s] Failed to parse OCI response as JSON: %v", p.name, err)
+		p.recordFailure(metricTransformErrors, originalModel, wrappedWriter.statusCode)
 		log.Printf("[%s] Response body: %s", p.name, string(responseBody))
 		return fmt.Errorf("failed to parse OCI GenAI response: %w", err)
 	}
+	ociResp.RequestID = wrappedWriter.Header().Get("Opc-Request-Id")
 
 	// Transform to OpenAI format
 	log.Printf("[%s] processResponse: Transforming OCI GenAI response to OpenAI format", p.name)
-	openAIResp := p.transformer.ToOpenAIResponse(ociResp, originalModel)
+	openAIResp := p.transformer.ToOpenAIResponse(ociResp, exchange.responseModel)
+	if exchange.emulation != nil {
+		exchange.emulation.Apply(&openAIResp)
+	}
+	if exchange.legacyFunctions {
+		transform.ToLegacyFunctionCall(&openAIResp)
+	}
+	if exchange.jsonMode && p.config.JSONRepair.Enabled {
+		if err := transform.RepairJSONResponse(&openAIResp); err != nil {
+			log.Printf("[%s] Returning invalid JSON from model %s: %v", p.name, originalModel, err)
+		}
+	}
 
 	// Marshal the OpenAI response
 	openAIBody, err := json.Marshal(openAIResp)
 	if err != nil {
+		p.recordFailure(metricMarshalFailures, originalModel, wrappedWriter.statusCode)
 		return fmt.Errorf("failed to marshal OpenAI response: %w", err)
 	}
 
-	// Compress response if original was compressed
+	// Minify and prune the response, if configured
+	openAIBody, err = p.transformer.ShapeResponse(openAIBody)
+	if err != nil {
+		p.recordFailure(metricMarshalFailures, originalModel, wrappedWriter.statusCode)
+		return fmt.Errorf("failed to shape OpenAI response: %w", err)
+	}
+
+	// Compress response if original was compressed and the client accepts it
+	if !acceptsEncoding(exchange.acceptEncoding, wrappedWriter.Header().Get("Content-Encoding")) {
+		wrappedWriter.Header().Del("Content-Encoding")
+	}
 	finalBody, err := p.compressResponse(openAIBody, wrappedWriter.Header())
 	if err != nil {
 		log.Printf("[%s] ERROR: Failed to compress response: %v", p.name, err)
@@ -332,17 +1293,13 @@ func (p *Proxy) processResponse(originalWriter http.ResponseWriter, wrappedWrite
 	}
 
 	// Copy headers from original response
-	for key, values := range wrappedWriter.Header() {
-		for _, value := range values {
-			originalWriter.Header().Set(key, value)
-		}
-	}
+	copyUpstreamHeader(originalWriter.Header(), wrappedWriter.Header())
 
 	// Update content headers
+	setTransformedEntityHeaders(originalWriter.Header(), finalBody, wrappedWriter.Header().Get("Content-Encoding"))
 	originalWriter.Header().Set("Content-Type", "application/json")
-	originalWriter.Header().Set("Content-Length", fmt.Sprintf("%d", len(finalBody)))
-	// Add CORS header for actual response
-	originalWriter.Header().Set("Access-Control-Allow-Origin", "*")
+	p.setAccessLogHeaders(originalWriter.Header(), exchange, &openAIResp)
+	setAdjustedHeader(originalWriter.Header(), exchange)
 
 	// Write the status code
 	log.Printf("[%s] processResponse: Writing transformed chat/completions response, length=%d", p.name, len(finalBody))
@@ -351,52 +1308,267 @@ func (p *Proxy) processResponse(originalWriter http.ResponseWriter, wrappedWrite
 	// Write the transformed response
 	_, _ = originalWriter.Write(finalBody)
 
+	exchange.status = http.StatusOK
+	exchange.responseBody = openAIBody
+	exchange.usage = &openAIResp.Usage
 	return nil
 }
 
-// compressResponse compresses the response body if the original response was compressed
-func (p *Proxy) compressResponse(body []byte, originalHeaders http.Header) ([]byte, error) {
-	contentEncoding := originalHeaders.Get("Content-Encoding")
+// serviceTierHeader carries the service tier a chat request is admitted with, since admission
+// is decided before the request body is read.
+const serviceTierHeader = "X-Oci-Service-Tier"
 
-	// Only compress if original response was compressed
-	if contentEncoding == "" {
-		return body, nil
+// admissionPriority returns the priority class of a chat request: that of its service tier
+// header, or else that configured for its tenant, or else normal.
+func (p *Proxy) admissionPriority(req *http.Request) string {
+	if tier := req.Header.Get(serviceTierHeader); tier != "" {
+		return p.config.ServiceTierPriority(tier)
 	}
+	tenant := p.tenant(req)
+	priority := p.config.TenantPriority(tenant)
+	if p.tenants != nil {
+		priority = p.tenants.Priority(tenant)
+	}
+	if priority == "" {
+		return config.PriorityNormal
+	}
+	return priority
+}
 
-	switch contentEncoding {
-	case "gzip":
-		var buf bytes.Buffer
-		gzipWriter := gzip.NewWriter(&buf)
+// shed rejects a request with 503 and Retry-After because the upstream is saturated.
+func (p *Proxy) shed(rw http.ResponseWriter, priority string) {
+	log.Printf("[%s] Shedding %s priority request", p.name, priority)
+	p.metrics.Inc(metricShedRequests, metrics.Labels{"priority": priority})
 
-		if _, err := gzipWriter.Write(body); err != nil {
-			return nil, fmt.Errorf("failed to write gzip compressed data: %w", err)
-		}
+	rw.Header().Set("Retry-After", fmt.Sprintf("%d", int(p.admission.RetryAfter().Seconds()+0.5)))
+	writeError(rw, http.StatusServiceUnavailable, "The server is overloaded, please retry later", "", "overloaded")
+}
 
-		if err := gzipWriter.Close(); err != nil {
-			return nil, fmt.Errorf("failed to close gzip writer: %w", err)
+// serveNext calls the next handler. A missing handler or a panic in the chain is logged
+// and turned into an OpenAI-format 500 error, so a misbehaving downstream middleware
+// cannot take out the entrypoint.
+func (p *Proxy) serveNext(rw http.ResponseWriter, req *http.Request) {
+	if p.next == nil {
+		log.Printf("[%s] ERROR: No next handler configured", p.name)
+		writeError(rw, http.StatusInternalServerError, "The gateway is misconfigured", "", "no_next_handler")
+		return
+	}
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			if recovered == http.ErrAbortHandler {
+				panic(recovered)
+			}
+			log.Printf("[%s] ERROR: Next handler panicked: %v\n%s", p.name, recovered, debug.Stack())
+			p.metrics.Inc(metricHandlerPanics, nil)
+
+			// Drop any partial response captured before the panic. Once part of the response has
+			// reached the client, an error cannot be sent cleanly, so the connection is aborted.
+			switch wrapped := rw.(type) {
+			case *responseWriter:
+				wrapped.body.Reset()
+			case *streamWriter:
+				if wrapped.wroteHeader {
+					wrapped.abort()
+					panic(http.ErrAbortHandler)
+				}
+			case *statusWriter:
+				if wrapped.wroteHeader {
+					panic(http.ErrAbortHandler)
+				}
+			}
+			writeError(rw, http.StatusInternalServerError, "The server had an error while processing your request", "", "internal_error")
 		}
+	}()
 
-		return buf.Bytes(), nil
+	if p.fixtures != nil && isOCIRequest(req) {
+		p.serveFixture(rw, req)
+		return
+	}
+	p.next.ServeHTTP(rw, req)
+}
 
-	case "deflate":
-		var buf bytes.Buffer
-		deflateWriter, err := flate.NewWriter(&buf, flate.DefaultCompression)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create deflate writer: %w", err)
-		}
+// writeError writes an OpenAI-format error response. Server errors are of type "server_error",
+// everything else is an "invalid_request_error".
+func writeError(rw http.ResponseWriter, status int, message, param, code string) {
+	errorType := "invalid_request_error"
+	if status >= http.StatusInternalServerError {
+		errorType = "server_error"
+	}
 
-		if _, err := deflateWriter.Write(body); err != nil {
-			return nil, fmt.Errorf("failed to write deflate compressed data: %w", err)
-		}
+	body, _ := json.Marshal(types.ErrorResponse{Error: types.APIError{
+		Message: message,
+		Type:    errorType,
+		Param:   param,
+		Code:    code,
+	}})
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(status)
+	_, _ = rw.Write(body)
+}
+
+// writeFailure answers a request that failed on the server's side with a server_error. Its code
+// is that of the requestFailure in err, or internal_error.
+func writeFailure(rw http.ResponseWriter, err error) {
+	code := "internal_error"
+	var failure *requestFailure
+	if errors.As(err, &failure) {
+		code = failure.code
+	}
+	writeError(rw, http.StatusInternalServerError, fmt.Sprintf("The server failed to process the request: %v", err), "", code)
+}
+
+// writeBodyError answers a request whose body could not be read: a body above a size limit gets
+// a 413, and a body the client failed to send a 400.
+func writeBodyError(rw http.ResponseWriter, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		writeError(rw, http.StatusRequestEntityTooLarge,
+			fmt.Sprintf("The request body must be at most %d bytes", tooLarge.Limit), "", "request_too_large")
+		return
+	}
+	writeError(rw, http.StatusBadRequest, fmt.Sprintf("Failed to read request body: %v", err), "", "request_body_unreadable")
+}
+
+// tenant returns the tenant named by the configured tenant header, if any.
+func (p *Proxy) tenant(req *http.Request) string {
+	if p.config.TenantHeader == "" {
+		return ""
+	}
+	return req.Header.Get(p.config.TenantHeader)
+}
+
+// modelRoute returns the route for a tenant's model alias, from the reloadable tenant table
+// when one is configured.
+func (p *Proxy) modelRoute(tenant, model string) (config.ModelRoute, bool) {
+	if p.tenants != nil {
+		return p.tenants.ModelRoute(tenant, model)
+	}
+	return p.config.ModelRoute(tenant, model)
+}
+
+// setAccessLogHeaders adds response headers carrying the model, tenant, and, when the response
+// is known, token usage and finish reason, so Traefik access logs can capture them.
+func (p *Proxy) setAccessLogHeaders(header http.Header, exchange *chatExchange, resp *types.ChatCompletionResponse) {
+	if !p.config.AccessLog.Enabled {
+		return
+	}
+
+	prefix := p.config.AccessLog.Prefix
+	header.Set(prefix+"Model", exchange.model)
+	if exchange.tenant != "" {
+		header.Set(prefix+"Tenant", exchange.tenant)
+	}
+	if resp == nil {
+		return
+	}
+
+	finishReason := ""
+	if len(resp.Choices) > 0 {
+		finishReason = resp.Choices[0].FinishReason
+	}
+	setUsageFields(header, prefix, resp.Usage, finishReason)
+}
+
+// declareUsageTrailers announces the trailers carrying the token usage and finish reason of a
+// streamed response, which must be declared before the headers are sent.
+func (p *Proxy) declareUsageTrailers(header http.Header) {
+	if !p.config.AccessLog.Enabled || !p.config.AccessLog.Trailers {
+		return
+	}
+
+	prefix := p.config.AccessLog.Prefix
+	header.Set("Trailer", strings.Join([]string{
+		prefix + "Prompt-Tokens", prefix + "Completion-Tokens", prefix + "Total-Tokens", prefix + "Finish-Reason",
+	}, ", "))
+}
 
-		if err := deflateWriter.Close(); err != nil {
-			return nil, fmt.Errorf("failed to close deflate writer: %w", err)
+// setUsageTrailers sets the trailers declared by declareUsageTrailers, once the stream has ended.
+func (p *Proxy) setUsageTrailers(header http.Header, usage *types.ChatCompletionUsage, finishReason string) {
+	if !p.config.AccessLog.Enabled || !p.config.AccessLog.Trailers {
+		return
+	}
+	if usage == nil {
+		// Without usage from OCI, only the finish reason is known
+		if finishReason != "" {
+			header.Set(p.config.AccessLog.Prefix+"Finish-Reason", finishReason)
 		}
+		return
+	}
+	setUsageFields(header, p.config.AccessLog.Prefix, *usage, finishReason)
+}
 
-		return buf.Bytes(), nil
+// setUsageFields sets the access log fields carrying token usage and finish reason.
+func setUsageFields(header http.Header, prefix string, usage types.ChatCompletionUsage, finishReason string) {
+	header.Set(prefix+"Prompt-Tokens", fmt.Sprintf("%d", usage.PromptTokens))
+	header.Set(prefix+"Completion-Tokens", fmt.Sprintf("%d", usage.CompletionTokens))
+	header.Set(prefix+"Total-Tokens", fmt.Sprintf("%d", usage.TotalTokens))
+	if finishReason != "" {
+		header.Set(prefix+"Finish-Reason", finishReason)
+	}
+}
+
+// setAdjustedHeader lists the parameters the plugin changed in the x-params-adjusted response header.
+func setAdjustedHeader(header http.Header, exchange *chatExchange) {
+	if len(exchange.adjustments) > 0 {
+		header.Set("X-Params-Adjusted", strings.Join(exchange.adjustments, "; "))
+	}
+}
+
+// logTransformation logs a summary of how a chat request was transformed, in debug mode.
+func (p *Proxy) logTransformation(received []types.ChatCompletionMessage, adjustments []string, ociReq types.OracleCloudRequest) {
+	summary, err := json.Marshal(p.transformer.Summarize(received, adjustments, ociReq))
+	if err != nil {
+		log.Printf("[%s] WARNING: Failed to marshal transformation summary: %v", p.name, err)
+		return
+	}
+	log.Printf("[%s] processOpenAIRequest: Transformation: %s", p.name, summary)
+}
+
+// sign prepares a rewritten request for OCI, which every request to OCI passes through: it
+// strips credentials meant for the gateway and hop-by-hop headers, then signs the request with
+// the built-in signer, if one is configured.
+func (p *Proxy) sign(req *http.Request, body []byte) error {
+	if stripped := stripRequestHeaders(req.Header, p.config.ForwardClientHeaders); len(stripped) > 0 {
+		log.Printf("[%s] sign: Stripped request headers: %s", p.name, strings.Join(stripped, ", "))
+	}
+	if p.signer == nil {
+		return nil
+	}
+
+	if err := p.signer.Sign(req, body); err != nil {
+		log.Printf("[%s] ERROR: Failed to sign request: %v", p.name, err)
+		return &requestFailure{"signing_failed", fmt.Errorf("failed to sign request: %w", err)}
+	}
+	return nil
+}
+
+// compressResponse compresses the response body if the original response was compressed.
+// Bodies smaller than compression.minBytes are sent uncompressed, and Content-Encoding is
+// removed from the headers, since compressing tiny JSON wastes CPU.
+func (p *Proxy) compressResponse(body []byte, originalHeaders http.Header) ([]byte, error) {
+	contentEncoding := originalHeaders.Get("Content-Encoding")
+
+	// Only compress if original response was compressed
+	if contentEncoding == "" {
+		return body, nil
+	}
+
+	if len(body) < p.config.Compression.MinBytes {
+		originalHeaders.Del("Content-Encoding")
+		return body, nil
+	}
+
+	switch contentEncoding {
+	case "gzip":
+		return gzipCompress(body, p.config.Compression.Level)
+
+	case "deflate":
+		return deflateCompress(body, p.config.Compression.Level)
 
 	default:
 		log.Printf("[%s] Unknown Content-Encoding: %s, returning body uncompressed", p.name, contentEncoding)
+		originalHeaders.Del("Content-Encoding")
 		return body, nil
 	}
 }
@@ -415,13 +1587,13 @@ func (p *Proxy) decompressResponse(body []byte, headers http.Header) ([]byte, er
 		if len(body) < 2 {
 			return body, nil
 		}
-		gzipReader, err := gzip.NewReader(bytes.NewReader(body))
+		gzipReader, err := getGzipReader(bytes.NewReader(body))
 		if err != nil {
 			return nil, fmt.Errorf("failed to create gzip reader: %w", err)
 		}
-		defer gzipReader.Close()
+		defer gzipReaderPool.Put(gzipReader)
 
-		decompressed, err := io.ReadAll(gzipReader)
+		decompressed, err := readAllPooled(gzipReader)
 		if err != nil {
 			return nil, fmt.Errorf("failed to decompress gzip response: %w", err)
 		}
@@ -431,7 +1603,7 @@ func (p *Proxy) decompressResponse(body []byte, headers http.Header) ([]byte, er
 		deflateReader := flate.NewReader(bytes.NewReader(body))
 		defer deflateReader.Close()
 
-		decompressed, err := io.ReadAll(deflateReader)
+		decompressed, err := readAllPooled(deflateReader)
 		if err != nil {
 			return nil, fmt.Errorf("failed to decompress deflate response: %w", err)
 		}
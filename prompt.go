@@ -0,0 +1,74 @@
+package ociaitoopenai
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/zalbiraw/ociaitoopenai/internal/prompt"
+)
+
+// promptName returns the template name of a */prompts/{name}/completions path, or "" for other paths.
+// The path is matched like other routes, but the name keeps its case.
+func promptName(path string) string {
+	path = routePath(path)
+	lower := strings.ToLower(path)
+	idx := strings.LastIndex(lower, "/prompts/")
+	if idx < 0 || !strings.HasSuffix(lower, "/completions") {
+		return ""
+	}
+	start, end := idx+len("/prompts/"), len(path)-len("/completions")
+	if start >= end {
+		return ""
+	}
+	name := path[start:end]
+	if strings.Contains(name, "/") {
+		return ""
+	}
+	return name
+}
+
+// servePrompt renders a prompt template into a chat completion request and serves it like any other.
+func (p *Proxy) servePrompt(rw http.ResponseWriter, req *http.Request) {
+	name := promptName(req.URL.Path)
+
+	body, err := readRequestBody(req)
+	if err != nil {
+		log.Printf("[%s] ERROR: Failed to read prompt request body: %v", p.name, err)
+		writeBodyError(rw, err)
+		return
+	}
+	_ = req.Body.Close()
+
+	body, err = jsonRequestBody(req.Header.Get("Content-Type"), body)
+	if err != nil {
+		writeError(rw, http.StatusUnsupportedMediaType, err.Error(), "", "unsupported_media_type")
+		return
+	}
+
+	rendered, err := p.prompts.Render(name, body)
+	if err != nil {
+		var renderErr *prompt.RenderError
+		switch {
+		case errors.Is(err, prompt.ErrNotFound):
+			writeError(rw, http.StatusNotFound, fmt.Sprintf("The prompt template %s does not exist", name), "", "prompt_not_found")
+		case errors.As(err, &renderErr):
+			writeError(rw, http.StatusBadRequest, renderErr.Message, renderErr.Param, "invalid_prompt_request")
+		default:
+			log.Printf("[%s] ERROR: Failed to render prompt template %s: %v", p.name, name, err)
+			writeFailure(rw, err)
+		}
+		return
+	}
+
+	req.Body = io.NopCloser(bytes.NewReader(rendered))
+	req.ContentLength = int64(len(rendered))
+	req.Header.Set("Content-Type", "application/json")
+	path := routePath(req.URL.Path)
+	req.URL.Path = path[:strings.LastIndex(strings.ToLower(path), "/prompts/")] + "/chat/completions"
+	p.serveChat(rw, req)
+}
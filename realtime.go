@@ -0,0 +1,24 @@
+package ociaitoopenai
+
+import (
+	"log"
+	"net/http"
+	"strings"
+)
+
+// realtimePath reports whether a lowercased route is an OpenAI Realtime API endpoint: the
+// websocket at */realtime, or a resource below it such as */realtime/sessions.
+func realtimePath(lowerRoute string) bool {
+	return strings.HasSuffix(lowerRoute, "/realtime") || strings.Contains(lowerRoute, "/realtime/")
+}
+
+// rejectRealtime answers a Realtime API request, which OCI GenAI has no counterpart for, with an
+// error clients can act on rather than the confusing failure of a passthrough. Websocket upgrades
+// get the same response, which fails the handshake.
+func (p *Proxy) rejectRealtime(rw http.ResponseWriter, req *http.Request) {
+	upgrade := strings.EqualFold(req.Header.Get("Upgrade"), "websocket")
+	log.Printf("[%s] rejectRealtime: Rejecting Realtime API request, websocket upgrade=%t", p.name, upgrade)
+	writeError(rw, http.StatusBadRequest,
+		"The Realtime API is not supported by this gateway. Use POST /v1/chat/completions with \"stream\": true for incremental responses.",
+		"", "realtime_not_supported")
+}
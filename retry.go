@@ -0,0 +1,120 @@
+package ociaitoopenai
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/zalbiraw/ociaitoopenai/internal/transform"
+)
+
+// retryTokenHeader makes OCI treat requests carrying the same token as one.
+const retryTokenHeader = "Opc-Retry-Token"
+
+// newRetryToken generates a random opc-retry-token.
+func newRetryToken() string {
+	return randomID()
+}
+
+// clientRetryToken derives the opc-retry-token sent to OCI from one supplied by a client. The
+// token is hashed with the tenant and client IP, so a client's own resends still match while
+// the same token sent by another tenant or client is a distinct request.
+func clientRetryToken(token, tenant, clientIP string) string {
+	sum := sha256.Sum256([]byte(tenant + "\x00" + clientIP + "\x00" + token))
+	return hex.EncodeToString(sum[:16])
+}
+
+// randomID generates a random 32 character hex ID.
+func randomID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// retryBudget is the number of times a request may be re-asked after a malformed answer.
+func (p *Proxy) retryBudget(exchange *chatExchange) int {
+	budget := 0
+	if exchange.emulation != nil {
+		budget = p.config.ToolEmulation.MaxRetries
+	}
+	if exchange.jsonMode && p.config.JSONRepair.Enabled && p.config.JSONRepair.Reask && budget < 1 {
+		budget = 1
+	}
+	return budget
+}
+
+// retryMalformedAnswers asks the model again, within the retry budget, while its answer attempts
+// an emulated tool call that cannot be parsed, or is not valid JSON for a json_object request
+// and cannot be repaired. It returns the captured response to transform, which is the last one received.
+func (p *Proxy) retryMalformedAnswers(rw http.ResponseWriter, req *http.Request, exchange *chatExchange, captured *responseWriter) *responseWriter {
+	budget := p.retryBudget(exchange)
+	for attempt := 0; attempt < budget; attempt++ {
+		if captured.statusCode != http.StatusOK {
+			return captured
+		}
+
+		ociResp, err := p.decodeChatResponse(captured.body.Bytes(), captured.Header(), exchange.model)
+		if err != nil {
+			return captured
+		}
+		answer := p.transformer.ToOpenAIResponse(ociResp, exchange.model).Choices[0].Message.Content
+
+		if !p.reask(exchange, answer) {
+			return captured
+		}
+
+		ociReq := p.transformer.ToOracleCloudRequest(*exchange.request)
+		ociReq.CompartmentID = exchange.compartmentID
+		ociBody, err := json.Marshal(ociReq)
+		if err != nil {
+			p.recordFailure(metricMarshalFailures, exchange.model, http.StatusInternalServerError)
+			return captured
+		}
+
+		retry := req.Clone(req.Context())
+		retry.Body = io.NopCloser(bytes.NewReader(ociBody))
+		retry.ContentLength = int64(len(ociBody))
+		// A re-ask is a new request, which OCI would reject under the original's token
+		retry.Header.Set(retryTokenHeader, newRetryToken())
+		if err := p.sign(retry, ociBody); err != nil {
+			return captured
+		}
+
+		// The retry's response headers replace those of the malformed answer
+		rw.Header().Del("Content-Encoding")
+		rw.Header().Del("Content-Length")
+		captured = newResponseWriter(rw)
+		p.serveNext(captured, retry)
+	}
+	return captured
+}
+
+// reask extends the exchange's request with a correction when the answer is malformed,
+// and reports whether it did.
+func (p *Proxy) reask(exchange *chatExchange, answer string) bool {
+	if exchange.emulation != nil {
+		calls, err := exchange.emulation.Parse(answer)
+		if err != nil {
+			log.Printf("[%s] Malformed emulated tool call from model %s, asking again: %v", p.name, exchange.model, err)
+			exchange.emulation.Reask(exchange.request, answer, err)
+			return true
+		}
+		if len(calls) > 0 {
+			return false
+		}
+	}
+
+	if exchange.jsonMode && p.config.JSONRepair.Reask {
+		if _, ok := transform.RepairJSON(answer); !ok {
+			log.Printf("[%s] Invalid JSON from model %s, asking again", p.name, exchange.model)
+			transform.ReaskJSON(exchange.request, answer)
+			return true
+		}
+	}
+	return false
+}
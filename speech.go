@@ -0,0 +1,192 @@
+package ociaitoopenai
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/zalbiraw/ociaitoopenai/pkg/types"
+)
+
+// maxSpeechInput is the longest input OpenAI accepts, in characters.
+const maxSpeechInput = 4096
+
+// speechSampleRate matches OpenAI's 24kHz audio, which pcm clients rely on.
+const speechSampleRate = 24000
+
+// speechOutputFormats maps OpenAI response formats to OCI output formats.
+var speechOutputFormats = map[string]string{
+	"mp3":  "MP3",
+	"opus": "OGG",
+	"pcm":  "PCM",
+}
+
+// serveSpeech translates an OpenAI text-to-speech request to OCI AI Speech and streams the
+// synthesized audio back unchanged.
+func (p *Proxy) serveSpeech(rw http.ResponseWriter, req *http.Request) {
+	body, err := readRequestBody(req)
+	if err != nil {
+		log.Printf("[%s] ERROR: Failed to read speech request body: %v", p.name, err)
+		writeBodyError(rw, err)
+		return
+	}
+	_ = req.Body.Close()
+
+	body, err = jsonRequestBody(req.Header.Get("Content-Type"), body)
+	if err != nil {
+		writeError(rw, http.StatusUnsupportedMediaType, err.Error(), "", "unsupported_media_type")
+		return
+	}
+
+	var speechReq types.SpeechRequest
+	if err := json.Unmarshal(body, &speechReq); err != nil {
+		writeError(rw, http.StatusBadRequest, "Failed to parse speech request", "", "invalid_request")
+		return
+	}
+
+	ociReq, param, message := p.toOCISpeechRequest(speechReq)
+	if message != "" {
+		writeError(rw, http.StatusBadRequest, message, param, "invalid_speech_request")
+		return
+	}
+	ociBody, err := json.Marshal(ociReq)
+	if err != nil {
+		log.Printf("[%s] ERROR: Failed to marshal OCI speech request: %v", p.name, err)
+		writeFailure(rw, err)
+		return
+	}
+
+	// Speech requests are not for the next handler, which fronts Generative AI
+	endpoint := "https://" + p.speechHost() + "/20220101/actions/synthesizeSpeech"
+	ociHTTPReq, err := p.newOCIRequest(req.Context(), http.MethodPost, endpoint, ociBody, "application/json")
+	if err != nil {
+		log.Printf("[%s] ERROR: Failed to create speech request: %v", p.name, err)
+		writeFailure(rw, err)
+		return
+	}
+	// Audio is already compressed
+	ociHTTPReq.Header.Del("Accept-Encoding")
+
+	log.Printf("[%s] serveSpeech: Synthesizing %d characters with %s", p.name, len(speechReq.Input), ociReq.Configuration.ModelDetails.ModelName)
+	resp, err := p.client.Do(ociHTTPReq)
+	if err != nil {
+		log.Printf("[%s] ERROR: Failed to synthesize speech: %v", p.name, err)
+		writeError(rw, http.StatusBadGateway, fmt.Sprintf("The speech synthesis failed: %v", err), "", "speech_failed")
+		return
+	}
+	defer resp.Body.Close()
+
+	for key, values := range resp.Header {
+		rw.Header()[key] = append([]string(nil), values...)
+	}
+	rw.WriteHeader(resp.StatusCode)
+	if err := streamBody(rw, resp.Body); err != nil {
+		log.Printf("[%s] ERROR: Failed to stream speech audio: %v", p.name, err)
+	}
+}
+
+// streamBody copies body to rw, flushing after each read so audio reaches the client as OCI
+// synthesizes it.
+func streamBody(rw http.ResponseWriter, body io.Reader) error {
+	flusher, _ := rw.(http.Flusher)
+	buf := make([]byte, 32<<10)
+	for {
+		n, err := body.Read(buf)
+		if n > 0 {
+			if _, writeErr := rw.Write(buf[:n]); writeErr != nil {
+				return writeErr
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// toOCISpeechRequest converts an OpenAI speech request. For invalid requests, it returns the
+// offending parameter and a message for the client.
+func (p *Proxy) toOCISpeechRequest(speechReq types.SpeechRequest) (types.OCISpeechRequest, string, string) {
+	if speechReq.Input == "" {
+		return types.OCISpeechRequest{}, "input", "input is required"
+	}
+	if len([]rune(speechReq.Input)) > maxSpeechInput {
+		return types.OCISpeechRequest{}, "input", fmt.Sprintf("input must be at most %d characters", maxSpeechInput)
+	}
+	if speechReq.Model == "" {
+		return types.OCISpeechRequest{}, "model", "model is required"
+	}
+	if speechReq.Voice == "" {
+		return types.OCISpeechRequest{}, "voice", "voice is required"
+	}
+	if speechReq.Speed != 0 && (speechReq.Speed < 0.25 || speechReq.Speed > 4) {
+		return types.OCISpeechRequest{}, "speed", "speed must be between 0.25 and 4.0"
+	}
+
+	responseFormat := speechReq.ResponseFormat
+	if responseFormat == "" {
+		responseFormat = "mp3"
+	}
+	outputFormat, ok := speechOutputFormats[responseFormat]
+	if !ok {
+		return types.OCISpeechRequest{}, "response_format",
+			fmt.Sprintf("response_format %q is not supported by OCI AI Speech; use mp3, opus or pcm", responseFormat)
+	}
+
+	model := speechReq.Model
+	if mapped, ok := p.config.Speech.Models[model]; ok {
+		model = mapped
+	}
+	voice := speechReq.Voice
+	if mapped, ok := p.config.Speech.Voices[voice]; ok {
+		voice = mapped
+	}
+
+	// OCI has no speed setting, but honours SSML prosody
+	text, textType := speechReq.Input, "TEXT"
+	if speechReq.Speed != 0 && speechReq.Speed != 1 {
+		var escaped strings.Builder
+		_ = xml.EscapeText(&escaped, []byte(speechReq.Input))
+		text = fmt.Sprintf(`<speak><prosody rate="%.0f%%">%s</prosody></speak>`, speechReq.Speed*100, escaped.String())
+		textType = "SSML"
+	}
+
+	return types.OCISpeechRequest{
+		Text:            text,
+		IsStreamEnabled: true,
+		CompartmentID:   p.config.CompartmentID,
+		Configuration: types.OCISpeechConfiguration{
+			ModelFamily: "ORACLE",
+			ModelDetails: types.OCISpeechModelDetails{
+				ModelName: model,
+				VoiceID:   voice,
+			},
+			SpeechSettings: types.OCISpeechSettings{
+				TextType:       textType,
+				SampleRateInHz: speechSampleRate,
+				OutputFormat:   outputFormat,
+			},
+		},
+	}, "", ""
+}
+
+// speechHost returns the OCI AI Speech endpoint host.
+func (p *Proxy) speechHost() string {
+	if p.config.Speech.Host != "" {
+		return p.config.Speech.Host
+	}
+	region := p.config.Speech.Region
+	if region == "" {
+		region = p.config.Region
+	}
+	return fmt.Sprintf("speech.aiservice.%s.oci.oraclecloud.com", region)
+}
@@ -0,0 +1,163 @@
+package ociaitoopenai
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// statusResponse is the body of the status endpoint.
+type statusResponse struct {
+	InFlight    int64                `json:"inFlight"`
+	Maintenance bool                 `json:"maintenance,omitempty"`
+	Queues      map[string]int       `json:"queues"`
+	Admission   *admissionStatus     `json:"admission,omitempty"`
+	Endpoints   []endpointStatus     `json:"endpoints,omitempty"`
+	ModelCache  *cacheStatus         `json:"modelCache,omitempty"`
+	SLO         map[string]sloStatus `json:"slo,omitempty"`
+	WarmUp      []warmUpStatus       `json:"warmUp,omitempty"`
+	Degraded    []featureStatus      `json:"degraded,omitempty"`
+}
+
+// admissionStatus reports the admission controller state.
+type admissionStatus struct {
+	InFlight     int   `json:"inFlight"`
+	P95LatencyMs int64 `json:"p95LatencyMs"`
+	Shedding     bool  `json:"shedding"`
+}
+
+// endpointStatus reports the circuit state of a load-balanced endpoint.
+type endpointStatus struct {
+	Host      string `json:"host"`
+	State     string `json:"state"`
+	Failures  int    `json:"failures"`
+	LatencyMs int64  `json:"latencyMs"`
+}
+
+// sloStatus reports a model's rolling latency and error rate.
+type sloStatus struct {
+	Requests     int     `json:"requests"`
+	P50LatencyMs int64   `json:"p50LatencyMs"`
+	P95LatencyMs int64   `json:"p95LatencyMs"`
+	ErrorRate    float64 `json:"errorRate"`
+	Breached     bool    `json:"breached"`
+}
+
+// warmUpStatus reports the latest warm-up ping of a target.
+type warmUpStatus struct {
+	Target    string `json:"target"`
+	Healthy   bool   `json:"healthy"`
+	LastPing  string `json:"lastPing,omitempty"`
+	LatencyMs int64  `json:"latencyMs"`
+	Failures  int    `json:"failures"`
+	LastError string `json:"lastError,omitempty"`
+}
+
+// featureStatus reports the failures of an optional feature, and whether it is turned off.
+type featureStatus struct {
+	Feature       string `json:"feature"`
+	Disabled      bool   `json:"disabled"`
+	DisabledUntil string `json:"disabledUntil,omitempty"`
+	Failures      int    `json:"failures"`
+	LastError     string `json:"lastError,omitempty"`
+}
+
+// cacheStatus reports the hit rate of a cache.
+type cacheStatus struct {
+	Lookups int64   `json:"lookups"`
+	Hits    int64   `json:"hits"`
+	HitRate float64 `json:"hitRate"`
+}
+
+// serveStatus writes a JSON snapshot of the plugin's operational state.
+func (p *Proxy) serveStatus(rw http.ResponseWriter, _ *http.Request) {
+	status := statusResponse{
+		InFlight:    atomic.LoadInt64(&p.inFlight),
+		Maintenance: p.config.Maintenance.Enabled,
+		Queues:      map[string]int{},
+	}
+
+	if p.mirror != nil {
+		status.Queues["mirror"] = p.mirror.QueueDepth()
+	}
+	if p.audit != nil {
+		status.Queues["audit"] = p.audit.QueueDepth()
+	}
+
+	if p.admission != nil {
+		stats := p.admission.Stats()
+		status.Admission = &admissionStatus{
+			InFlight:     stats.InFlight,
+			P95LatencyMs: stats.P95Latency.Milliseconds(),
+			Shedding:     stats.Overloaded,
+		}
+	}
+
+	if p.balancer != nil {
+		for _, endpoint := range p.balancer.Status() {
+			status.Endpoints = append(status.Endpoints, endpointStatus{
+				Host:      endpoint.Host,
+				State:     endpoint.State,
+				Failures:  endpoint.Failures,
+				LatencyMs: endpoint.Latency.Milliseconds(),
+			})
+		}
+	}
+
+	if p.catalog != nil {
+		lookups, hits := p.catalog.Stats()
+		status.ModelCache = &cacheStatus{Lookups: lookups, Hits: hits}
+		if lookups > 0 {
+			status.ModelCache.HitRate = float64(hits) / float64(lookups)
+		}
+	}
+
+	if p.slo != nil {
+		status.SLO = map[string]sloStatus{}
+		for model, stats := range p.slo.Stats() {
+			status.SLO[model] = sloStatus{
+				Requests:     stats.Requests,
+				P50LatencyMs: stats.P50Latency.Milliseconds(),
+				P95LatencyMs: stats.P95Latency.Milliseconds(),
+				ErrorRate:    stats.ErrorRate,
+				Breached:     stats.Breached,
+			}
+		}
+	}
+
+	if p.warmUp != nil {
+		for _, target := range p.warmUp.Status() {
+			warmUp := warmUpStatus{
+				Target:    target.Target,
+				Healthy:   target.Healthy,
+				LatencyMs: target.Latency.Milliseconds(),
+				Failures:  target.Failures,
+				LastError: target.LastError,
+			}
+			if !target.LastPing.IsZero() {
+				warmUp.LastPing = target.LastPing.UTC().Format(time.RFC3339)
+			}
+			status.WarmUp = append(status.WarmUp, warmUp)
+		}
+	}
+
+	if p.degrade != nil {
+		for _, feature := range p.degrade.Status() {
+			degraded := featureStatus{
+				Feature:   feature.Feature,
+				Disabled:  feature.Disabled,
+				Failures:  feature.Failures,
+				LastError: feature.LastError,
+			}
+			if feature.Disabled {
+				degraded.DisabledUntil = feature.DisabledUntil.UTC().Format(time.RFC3339)
+			}
+			status.Degraded = append(status.Degraded, degraded)
+		}
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(rw).Encode(status)
+}
@@ -0,0 +1,273 @@
+package ociaitoopenai
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zalbiraw/ociaitoopenai/internal/transform"
+	"github.com/zalbiraw/ociaitoopenai/pkg/types"
+)
+
+// streamWriter converts a streamed OCI GenAI response into OpenAI server-sent events as it arrives,
+// flushing each chunk to the client. gzip and deflate streams are decompressed on the fly and sent
+// uncompressed; non-200 responses and other encodings are passed through unchanged.
+type streamWriter struct {
+	rw          http.ResponseWriter
+	header      http.Header
+	stream      *transform.Stream
+	onError     func(error)
+	status      int
+	wroteHeader bool
+	passthrough bool
+	pending     []byte       // Incomplete line carried over to the next write
+	output      bytes.Buffer // Everything written to the client, for mirroring
+	firstToken  time.Time    // Time the first content chunk was written
+	responded   time.Time    // Time the upstream response headers arrived
+	decoder     *io.PipeWriter
+	decoded     chan error // Receives the result of the decoding goroutine
+	mu          sync.Mutex // Serializes writes and flushes from the decoding goroutine and the caller
+}
+
+// newStreamWriter creates a stream writer converting events with the given stream.
+func newStreamWriter(rw http.ResponseWriter, stream *transform.Stream, onError func(error)) *streamWriter {
+	return &streamWriter{
+		rw:      rw,
+		header:  make(http.Header),
+		stream:  stream,
+		onError: onError,
+		status:  http.StatusOK,
+	}
+}
+
+func (sw *streamWriter) Header() http.Header {
+	return sw.header
+}
+
+func (sw *streamWriter) WriteHeader(code int) {
+	if sw.wroteHeader {
+		return
+	}
+	sw.wroteHeader = true
+	sw.status = code
+	sw.responded = time.Now()
+	sw.stream.Start(sw.header.Get("Opc-Request-Id"))
+	encoding := strings.ToLower(sw.header.Get("Content-Encoding"))
+	sw.passthrough = code != http.StatusOK || (encoding != "" && encoding != "gzip" && encoding != "deflate")
+	if !sw.passthrough && encoding != "" {
+		sw.startDecoder(encoding)
+	}
+
+	for key, values := range sw.header {
+		// SSE output is never re-compressed, and the upstream entity headers describe the OCI stream
+		if !sw.passthrough && upstreamEntityHeader(key) {
+			continue
+		}
+		for _, value := range values {
+			sw.rw.Header().Add(key, value)
+		}
+	}
+	if !sw.passthrough {
+		// Keep intermediate proxies, such as nginx, from caching or buffering the stream, and
+		// leave the length unset so it is sent chunked
+		sw.rw.Header().Set("Content-Type", "text/event-stream")
+		sw.rw.Header().Set("Cache-Control", "no-cache")
+		sw.rw.Header().Set("X-Accel-Buffering", "no")
+		sw.rw.Header().Del("Content-Length")
+	}
+	sw.rw.WriteHeader(code)
+}
+
+func (sw *streamWriter) Write(b []byte) (int, error) {
+	if !sw.wroteHeader {
+		sw.WriteHeader(http.StatusOK)
+	}
+	if sw.passthrough {
+		sw.output.Write(b)
+		return sw.rw.Write(b)
+	}
+	if sw.decoder != nil {
+		return sw.decoder.Write(b)
+	}
+
+	if err := sw.process(b); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// process converts every complete line in b, keeping any incomplete line for the next call.
+func (sw *streamWriter) process(b []byte) error {
+	sw.pending = append(sw.pending, b...)
+	for {
+		i := bytes.IndexByte(sw.pending, '\n')
+		if i < 0 {
+			break
+		}
+		line := string(sw.pending[:i])
+		sw.pending = sw.pending[i+1:]
+		if err := sw.handleLine(line); err != nil {
+			return err
+		}
+	}
+	sw.Flush()
+	return nil
+}
+
+// startDecoder decompresses the stream in a goroutine fed through a pipe,
+// so events are converted as they arrive rather than after the whole body is buffered.
+func (sw *streamWriter) startDecoder(encoding string) {
+	reader, writer := io.Pipe()
+	sw.decoder = writer
+	sw.decoded = make(chan error, 1)
+
+	go func() {
+		sw.decoded <- sw.decode(encoding, reader)
+	}()
+}
+
+// decode reads the compressed stream and converts the decompressed events.
+func (sw *streamWriter) decode(encoding string, reader *io.PipeReader) error {
+	var source io.Reader
+	if encoding == "gzip" {
+		gzipReader, err := getGzipReader(reader)
+		if err != nil {
+			err = fmt.Errorf("failed to create gzip reader: %w", err)
+			reader.CloseWithError(err)
+			return err
+		}
+		defer gzipReaderPool.Put(gzipReader)
+		source = gzipReader
+	} else {
+		deflateReader := flate.NewReader(reader)
+		defer deflateReader.Close()
+		source = deflateReader
+	}
+
+	buf := make([]byte, 4096)
+	for {
+		n, err := source.Read(buf)
+		if n > 0 {
+			if processErr := sw.process(buf[:n]); processErr != nil {
+				reader.CloseWithError(processErr)
+				return processErr
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			err = fmt.Errorf("failed to decompress stream: %w", err)
+			reader.CloseWithError(err)
+			return err
+		}
+	}
+}
+
+// Flush sends buffered data to the client, if the underlying writer supports it.
+// It may be called by the next handler while the decoding goroutine is writing.
+func (sw *streamWriter) Flush() {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	if flusher, ok := sw.rw.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// abort stops the decoding goroutine, if any, without converting the rest of the stream. It is
+// used when the response cannot be finished.
+func (sw *streamWriter) abort() {
+	if sw.decoder == nil {
+		return
+	}
+	_ = sw.decoder.CloseWithError(http.ErrAbortHandler)
+	<-sw.decoded
+	sw.decoder = nil
+}
+
+// handleLine converts a single SSE line. Only data lines carry events.
+func (sw *streamWriter) handleLine(line string) error {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "data:") {
+		return nil
+	}
+	payload := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+	if payload == "" || payload == "[DONE]" {
+		return nil
+	}
+
+	var event types.OracleCloudStreamEvent
+	if err := json.Unmarshal([]byte(payload), &event); err != nil {
+		sw.onError(fmt.Errorf("failed to parse OCI stream event: %w", err))
+		return nil
+	}
+
+	for _, chunk := range sw.stream.Convert(event) {
+		if sw.firstToken.IsZero() && len(chunk.Choices) > 0 && chunk.Choices[0].Delta.Content != "" {
+			sw.firstToken = time.Now()
+		}
+		if err := sw.writeEvent(chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeEvent writes a chunk as an SSE data event.
+func (sw *streamWriter) writeEvent(chunk types.ChatCompletionChunk) error {
+	data, err := json.Marshal(chunk)
+	if err != nil {
+		return fmt.Errorf("failed to marshal OpenAI stream chunk: %w", err)
+	}
+	return sw.writeData(data)
+}
+
+func (sw *streamWriter) writeData(data []byte) error {
+	event := append(append([]byte("data: "), data...), '\n', '\n')
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	sw.output.Write(event)
+	_, err := sw.rw.Write(event)
+	return err
+}
+
+// finish converts any trailing event, then writes the usage chunk and the [DONE] marker.
+func (sw *streamWriter) finish() error {
+	if !sw.wroteHeader {
+		sw.WriteHeader(http.StatusOK)
+	}
+	if sw.passthrough {
+		return nil
+	}
+
+	if sw.decoder != nil {
+		_ = sw.decoder.Close()
+		if err := <-sw.decoded; err != nil {
+			sw.onError(err)
+		}
+	}
+
+	if len(sw.pending) > 0 {
+		line := string(sw.pending)
+		sw.pending = nil
+		if err := sw.handleLine(line); err != nil {
+			return err
+		}
+	}
+	for _, chunk := range sw.stream.Finish() {
+		if err := sw.writeEvent(chunk); err != nil {
+			return err
+		}
+	}
+	if err := sw.writeData([]byte("[DONE]")); err != nil {
+		return err
+	}
+	sw.Flush()
+	return nil
+}
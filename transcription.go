@@ -0,0 +1,323 @@
+package ociaitoopenai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/zalbiraw/ociaitoopenai/pkg/types"
+)
+
+// Transcription upload limits beyond the audio file itself.
+const (
+	multipartOverhead = 1 << 20  // Other form fields and part headers
+	multipartMemory   = 32 << 20 // Parts held in memory before spilling to disk
+)
+
+// serveTranscription stages the uploaded audio in Object Storage, transcribes it with an OCI AI
+// Speech job, and returns the transcript in the requested OpenAI format.
+func (p *Proxy) serveTranscription(rw http.ResponseWriter, req *http.Request) {
+	cfg := p.config.Transcription
+
+	if mediaType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type")); mediaType != "multipart/form-data" {
+		writeError(rw, http.StatusUnsupportedMediaType, "Transcription requests must be multipart/form-data", "", "unsupported_media_type")
+		return
+	}
+	req.Body = http.MaxBytesReader(rw, req.Body, cfg.MaxBytes+multipartOverhead)
+	if err := req.ParseMultipartForm(multipartMemory); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeBodyError(rw, err)
+			return
+		}
+		writeError(rw, http.StatusBadRequest, fmt.Sprintf("Failed to parse multipart form: %v", err), "", "invalid_request")
+		return
+	}
+	defer func() { _ = req.MultipartForm.RemoveAll() }()
+
+	file, header, err := req.FormFile("file")
+	if err != nil {
+		writeError(rw, http.StatusBadRequest, "file is required", "file", "invalid_request")
+		return
+	}
+	defer file.Close()
+	audio, err := io.ReadAll(io.LimitReader(file, cfg.MaxBytes+1))
+	if err != nil {
+		writeError(rw, http.StatusBadRequest, fmt.Sprintf("Failed to read file: %v", err), "file", "invalid_request")
+		return
+	}
+	if int64(len(audio)) > cfg.MaxBytes {
+		writeError(rw, http.StatusRequestEntityTooLarge, fmt.Sprintf("file must be at most %d bytes", cfg.MaxBytes), "file", "file_too_large")
+		return
+	}
+
+	model := req.FormValue("model")
+	if model == "" {
+		writeError(rw, http.StatusBadRequest, "model is required", "model", "invalid_request")
+		return
+	}
+	if mapped, ok := cfg.Models[model]; ok {
+		model = mapped
+	}
+	responseFormat := req.FormValue("response_format")
+	if responseFormat == "" {
+		responseFormat = "json"
+	}
+	if responseFormat != "json" && responseFormat != "text" {
+		writeError(rw, http.StatusBadRequest,
+			fmt.Sprintf("response_format %q is not supported with OCI AI Speech; use json or text", responseFormat),
+			"response_format", "invalid_request")
+		return
+	}
+
+	contentType := header.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	timeout, _ := time.ParseDuration(cfg.Timeout)
+	ctx, cancel := context.WithTimeout(req.Context(), timeout)
+	defer cancel()
+
+	log.Printf("[%s] serveTranscription: Transcribing %d bytes with %s", p.name, len(audio), model)
+	text, err := p.transcribe(ctx, audio, objectFileName(header.Filename), contentType, model, req.FormValue("language"))
+	if err != nil {
+		log.Printf("[%s] ERROR: Failed to transcribe audio: %v", p.name, err)
+		if errors.Is(err, context.DeadlineExceeded) {
+			writeError(rw, http.StatusGatewayTimeout, "The transcription did not finish in time", "", "transcription_timeout")
+		} else {
+			writeError(rw, http.StatusBadGateway, fmt.Sprintf("The transcription failed: %v", err), "", "transcription_failed")
+		}
+		return
+	}
+
+	if responseFormat == "text" {
+		rw.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = rw.Write([]byte(text))
+		return
+	}
+	body, _ := json.Marshal(types.TranscriptionResponse{Text: text})
+	rw.Header().Set("Content-Type", "application/json")
+	_, _ = rw.Write(body)
+}
+
+// transcribe uploads the audio, runs a transcription job on it and returns the transcript. The
+// objects written are deleted afterwards.
+func (p *Proxy) transcribe(ctx context.Context, audio []byte, fileName, contentType, model, language string) (string, error) {
+	cfg := p.config.Transcription
+	prefix := cfg.Prefix + randomID() + "/"
+
+	input := prefix + fileName
+	if _, err := p.serviceCall(ctx, http.MethodPut, p.objectURL(input), audio, contentType); err != nil {
+		return "", fmt.Errorf("failed to upload audio: %w", err)
+	}
+	defer p.deleteObject(input)
+
+	var job types.OCITranscriptionJob
+	if err := p.speechJSON(ctx, http.MethodPost, "/20220101/transcriptionJobs", types.OCITranscriptionJobRequest{
+		CompartmentID: p.config.CompartmentID,
+		DisplayName:   "ociai-" + path.Base(strings.TrimSuffix(prefix, "/")),
+		ModelDetails:  types.OCITranscriptionModelDetails{ModelType: model, LanguageCode: language},
+		InputLocation: types.OCITranscriptionInputLocation{
+			LocationType: "OBJECT_LIST_INLINE_INPUT_LOCATION",
+			ObjectLocations: []types.OCIObjectLocation{{
+				NamespaceName: cfg.Namespace,
+				BucketName:    cfg.Bucket,
+				ObjectNames:   []string{input},
+			}},
+		},
+		OutputLocation: types.OCIObjectPrefix{NamespaceName: cfg.Namespace, BucketName: cfg.Bucket, Prefix: prefix},
+	}, &job); err != nil {
+		return "", fmt.Errorf("failed to create transcription job: %w", err)
+	}
+
+	interval, _ := time.ParseDuration(cfg.PollInterval)
+	for job.LifecycleState != "SUCCEEDED" {
+		switch job.LifecycleState {
+		case "FAILED", "CANCELING", "CANCELED":
+			return "", fmt.Errorf("transcription job %s %s: %s", job.ID, strings.ToLower(job.LifecycleState), job.LifecycleDetails)
+		}
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(interval):
+		}
+		if err := p.speechJSON(ctx, http.MethodGet, "/20220101/transcriptionJobs/"+url.PathEscape(job.ID), nil, &job); err != nil {
+			return "", fmt.Errorf("failed to get transcription job: %w", err)
+		}
+	}
+
+	var tasks types.OCITranscriptionTasks
+	jobPath := "/20220101/transcriptionJobs/" + url.PathEscape(job.ID) + "/transcriptionTasks"
+	if err := p.speechJSON(ctx, http.MethodGet, jobPath, nil, &tasks); err != nil {
+		return "", fmt.Errorf("failed to list transcription tasks: %w", err)
+	}
+	if len(tasks.Items) == 0 {
+		return "", fmt.Errorf("transcription job %s has no tasks", job.ID)
+	}
+	var task types.OCITranscriptionTask
+	if err := p.speechJSON(ctx, http.MethodGet, jobPath+"/"+url.PathEscape(tasks.Items[0].ID), nil, &task); err != nil {
+		return "", fmt.Errorf("failed to get transcription task: %w", err)
+	}
+
+	var texts []string
+	for _, object := range task.OutputLocation.ObjectNames {
+		defer p.deleteObject(object)
+		capture, err := p.serviceCall(ctx, http.MethodGet, p.objectURL(object), nil, "")
+		if err != nil {
+			return "", fmt.Errorf("failed to read transcription result: %w", err)
+		}
+		var result types.OCITranscriptionResult
+		if err := p.decodeResponse(capture.body.Bytes(), capture.header, &result); err != nil {
+			return "", fmt.Errorf("failed to parse transcription result: %w", err)
+		}
+		for _, transcription := range result.Transcriptions {
+			texts = append(texts, transcription.Transcription)
+		}
+	}
+	return strings.Join(texts, "\n"), nil
+}
+
+// speechJSON calls the OCI AI Speech API, sending in and decoding the response into out as JSON.
+func (p *Proxy) speechJSON(ctx context.Context, method, apiPath string, in, out interface{}) error {
+	var body []byte
+	if in != nil {
+		var err error
+		if body, err = json.Marshal(in); err != nil {
+			return err
+		}
+	}
+	host := p.config.Transcription.SpeechHost
+	if host == "" {
+		host = fmt.Sprintf("speech.aiservice.%s.oci.oraclecloud.com", p.transcriptionRegion())
+	}
+	endpoint := "https://" + host + apiPath
+	capture, err := p.serviceCall(ctx, method, endpoint, body, "application/json")
+	if err != nil {
+		return err
+	}
+	return p.decodeResponse(capture.body.Bytes(), capture.header, out)
+}
+
+// ociCall issues a request of the plugin's own to OCI Generative AI through the next handler, like
+// the catalog does, so downstream authentication applies. Responses other than 2xx are errors.
+func (p *Proxy) ociCall(ctx context.Context, method, endpoint string, body []byte, contentType string) (*captureWriter, error) {
+	req, err := p.newOCIRequest(ctx, method, endpoint, body, contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	capture := newCaptureWriter()
+	p.serveNext(capture, req)
+	if capture.statusCode < http.StatusOK || capture.statusCode >= http.StatusMultipleChoices {
+		return nil, &ociStatusError{method: method, path: req.URL.Path, status: capture.statusCode}
+	}
+	return capture, nil
+}
+
+// serviceCall issues a request of the plugin's own to an OCI service other than Generative AI,
+// such as Object Storage, which the next handler does not reach. It is sent with the egress
+// client and signed by the built-in signer. Responses other than 2xx are errors.
+func (p *Proxy) serviceCall(ctx context.Context, method, endpoint string, body []byte, contentType string) (*captureWriter, error) {
+	req, err := p.newOCIRequest(ctx, method, endpoint, body, contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	capture := newCaptureWriter()
+	capture.statusCode = resp.StatusCode
+	capture.header = resp.Header
+	if _, err := io.Copy(&capture.body, resp.Body); err != nil {
+		return nil, fmt.Errorf("failed to read %s %s response: %w", method, req.URL.Path, err)
+	}
+	if capture.statusCode < http.StatusOK || capture.statusCode >= http.StatusMultipleChoices {
+		return nil, &ociStatusError{method: method, path: req.URL.Path, status: capture.statusCode}
+	}
+	return capture, nil
+}
+
+// newOCIRequest creates a signed request of the plugin's own to OCI.
+func (p *Proxy) newOCIRequest(ctx context.Context, method, endpoint string, body []byte, contentType string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set("Accept-Encoding", upstreamAcceptEncoding)
+	if err := p.sign(req, body); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// ociStatusError reports an OCI call answered with a status other than 2xx.
+type ociStatusError struct {
+	method string
+	path   string
+	status int
+}
+
+func (e *ociStatusError) Error() string {
+	return fmt.Sprintf("%s %s returned status %d", e.method, e.path, e.status)
+}
+
+// deleteObject removes a staged object, logging failures. It runs even when the request was cancelled.
+func (p *Proxy) deleteObject(object string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if _, err := p.serviceCall(ctx, http.MethodDelete, p.objectURL(object), nil, ""); err != nil {
+		log.Printf("[%s] ERROR: Failed to delete transcription object %s: %v", p.name, object, err)
+	}
+}
+
+// objectURL returns the Object Storage URL of an object in the transcription bucket.
+func (p *Proxy) objectURL(object string) string {
+	cfg := p.config.Transcription
+	host := cfg.ObjectStorageHost
+	if host == "" {
+		host = fmt.Sprintf("objectstorage.%s.oraclecloud.com", p.transcriptionRegion())
+	}
+	return fmt.Sprintf("https://%s/n/%s/b/%s/o/%s",
+		host, url.PathEscape(cfg.Namespace), url.PathEscape(cfg.Bucket), url.PathEscape(object))
+}
+
+func (p *Proxy) transcriptionRegion() string {
+	if p.config.Transcription.Region != "" {
+		return p.config.Transcription.Region
+	}
+	return p.config.Region
+}
+
+// objectFileName reduces an uploaded file name to characters safe in object names.
+func objectFileName(name string) string {
+	name = path.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '_', r == '-':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+	if name == "" || name == "." || name == ".." {
+		return "audio"
+	}
+	return name
+}
@@ -0,0 +1,199 @@
+package ociaitoopenai
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/zalbiraw/ociaitoopenai/pkg/types"
+)
+
+// OpenAI ranges of the sampling parameters, enforced before OCI ranges are applied.
+const (
+	maxRequestTemperature = 2.0
+	maxRequestTopP        = 1.0
+	maxRequestPenalty     = 2.0
+)
+
+// messageRoles are the message roles OpenAI accepts.
+var messageRoles = map[string]bool{
+	"system": true, "developer": true, "user": true, "assistant": true, "tool": true, "function": true,
+}
+
+// invalidRequest describes why an OpenAI request is invalid, naming the offending parameter.
+type invalidRequest struct {
+	param   string
+	message string
+	code    string
+}
+
+// decodeChatRequest parses an OpenAI chat completion request. Malformed JSON and fields of the
+// wrong type are described with the parameter at fault rather than a generic parse error.
+func decodeChatRequest(body []byte, req *types.ChatCompletionRequest) *invalidRequest {
+	if !json.Valid(body) {
+		return &invalidRequest{
+			message: "We could not parse the JSON body of your request. The body must be valid JSON.",
+			code:    "invalid_json",
+		}
+	}
+	if trimmed := bytes.TrimSpace(body); trimmed[0] != '{' {
+		return &invalidRequest{message: "The request body must be a JSON object.", code: "invalid_type"}
+	}
+	err := json.Unmarshal(body, req)
+	if err == nil {
+		return nil
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if !errors.As(err, &typeErr) {
+		return &invalidRequest{message: fmt.Sprintf("Invalid request: %v", err), code: "invalid_request"}
+	}
+	param := typeErr.Field
+	if param == "messages" || typeErr.Struct == "" || typeErr.Struct == "ChatCompletionMessage" || typeErr.Struct == "ContentPart" {
+		// Messages decode themselves, so the error lacks the message index
+		param = messageTypeParam(body, param)
+	}
+	return &invalidRequest{
+		param:   param,
+		message: fmt.Sprintf("Invalid type for '%s': expected %s, but got %s instead.", param, jsonType(typeErr.Type), typeErr.Value),
+		code:    "invalid_type",
+	}
+}
+
+// messageTypeParam locates a field of the wrong type in the messages of a request body, such
+// as "messages[2].role". field is the field reported by the decoder, relative to the message.
+func messageTypeParam(body []byte, field string) string {
+	var raw struct {
+		Messages []json.RawMessage `json:"messages"`
+	}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return "messages"
+	}
+	for i, data := range raw.Messages {
+		var msg types.ChatCompletionMessage
+		err := json.Unmarshal(data, &msg)
+		if err == nil {
+			continue
+		}
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) && typeErr.Struct != "ContentPart" && typeErr.Field != "" {
+			return fmt.Sprintf("messages[%d].%s", i, typeErr.Field)
+		}
+		// Content is decoded on its own, as a string or an array of parts
+		return fmt.Sprintf("messages[%d].content", i)
+	}
+	return field
+}
+
+// jsonType names the JSON type a Go type is decoded from.
+func jsonType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	case reflect.Ptr:
+		return jsonType(t.Elem())
+	default:
+		return "an object"
+	}
+}
+
+// validateChatRequest checks the required fields, message roles and numeric ranges of an OpenAI
+// chat completion request. Empty messages are accepted when allowEmptyMessages is set.
+func validateChatRequest(req types.ChatCompletionRequest, allowEmptyMessages bool) *invalidRequest {
+	if req.Model == "" {
+		return missingParameter("model")
+	}
+	if req.Messages == nil && !allowEmptyMessages {
+		return missingParameter("messages")
+	}
+	if len(req.Messages) == 0 && !allowEmptyMessages {
+		return &invalidRequest{
+			param:   "messages",
+			message: "Invalid 'messages': empty array. Expected an array with minimum length 1, but got an empty array instead.",
+			code:    "empty_array",
+		}
+	}
+	for i, msg := range req.Messages {
+		param := fmt.Sprintf("messages[%d].role", i)
+		if msg.Role == "" {
+			return missingParameter(param)
+		}
+		if !messageRoles[msg.Role] {
+			return &invalidRequest{
+				param:   param,
+				message: fmt.Sprintf("Invalid value: '%s'. Supported values are: 'system', 'developer', 'user', 'assistant', 'tool', and 'function'.", msg.Role),
+				code:    "invalid_value",
+			}
+		}
+	}
+
+	if req.Temperature != nil {
+		if invalid := outOfRange("temperature", *req.Temperature, 0, maxRequestTemperature); invalid != nil {
+			return invalid
+		}
+	}
+	if invalid := outOfRange("top_p", req.TopP, 0, maxRequestTopP); invalid != nil {
+		return invalid
+	}
+	if invalid := outOfRange("frequency_penalty", req.FrequencyPenalty, -maxRequestPenalty, maxRequestPenalty); invalid != nil {
+		return invalid
+	}
+	if invalid := outOfRange("presence_penalty", req.PresencePenalty, -maxRequestPenalty, maxRequestPenalty); invalid != nil {
+		return invalid
+	}
+	if req.MaxTokens < 0 {
+		return belowMinimum("max_tokens", req.MaxTokens)
+	}
+	if req.MaxCompletionTokens < 0 {
+		return belowMinimum("max_completion_tokens", req.MaxCompletionTokens)
+	}
+	return nil
+}
+
+// missingParameter reports a required parameter that was not sent.
+func missingParameter(param string) *invalidRequest {
+	return &invalidRequest{
+		param:   param,
+		message: fmt.Sprintf("Missing required parameter: '%s'.", param),
+		code:    "missing_required_parameter",
+	}
+}
+
+// outOfRange reports a number outside [low, high], or returns nil.
+func outOfRange(param string, value, low, high float64) *invalidRequest {
+	switch {
+	case value < low:
+		return &invalidRequest{
+			param:   param,
+			message: fmt.Sprintf("Invalid '%s': decimal below minimum value. Expected a value >= %v, but got %v instead.", param, low, value),
+			code:    "decimal_below_min_value",
+		}
+	case value > high:
+		return &invalidRequest{
+			param:   param,
+			message: fmt.Sprintf("Invalid '%s': decimal above maximum value. Expected a value <= %v, but got %v instead.", param, high, value),
+			code:    "decimal_above_max_value",
+		}
+	}
+	return nil
+}
+
+// belowMinimum reports a token limit below 1. Zero is indistinguishable from an unset limit.
+func belowMinimum(param string, value int) *invalidRequest {
+	return &invalidRequest{
+		param:   param,
+		message: fmt.Sprintf("Invalid '%s': integer below minimum value. Expected a value >= 1, but got %d instead.", param, value),
+		code:    "integer_below_min_value",
+	}
+}
@@ -0,0 +1,25 @@
+package ociaitoopenai
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/zalbiraw/ociaitoopenai/pkg/types"
+)
+
+// decodeChatResponse decodes an OCI chat response of a model, with the parser of its custom
+// vendor, if it has one.
+func (p *Proxy) decodeChatResponse(body []byte, header http.Header, model string) (types.OracleCloudResponse, error) {
+	var ociResp types.OracleCloudResponse
+	vendor, ok := p.transformer.CustomVendor(model)
+	if !ok || vendor.Parser == nil {
+		err := p.decodeResponse(body, header, &ociResp)
+		return ociResp, err
+	}
+
+	var raw json.RawMessage
+	if err := p.decodeResponse(body, header, &raw); err != nil {
+		return ociResp, err
+	}
+	return vendor.Parser.ParseResponse(raw)
+}
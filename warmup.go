@@ -0,0 +1,39 @@
+package ociaitoopenai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/zalbiraw/ociaitoopenai/internal/config"
+	"github.com/zalbiraw/ociaitoopenai/pkg/types"
+)
+
+// warmUpPing sends a one-token chat request to a warm-up target. Like client requests, it goes
+// through the next handler, so a successful ping also proves authentication works.
+func (p *Proxy) warmUpPing(ctx context.Context, target config.WarmUpTarget) error {
+	ociReq := p.transformer.ToOracleCloudRequest(types.ChatCompletionRequest{
+		Model:     target.Model,
+		MaxTokens: 1,
+		Messages:  []types.ChatCompletionMessage{{Role: "user", Content: "ping"}},
+	})
+	if target.Endpoint != "" {
+		ociReq.ServingMode = types.ServingMode{EndpointID: target.Endpoint, ServingType: "DEDICATED"}
+	}
+	body, err := json.Marshal(ociReq)
+	if err != nil {
+		return err
+	}
+
+	host := target.Host
+	if host == "" {
+		region := target.Region
+		if region == "" {
+			region = p.config.Region
+		}
+		host = fmt.Sprintf("generativeai.%s.oci.oraclecloud.com", region)
+	}
+	_, err = p.ociCall(ctx, http.MethodPost, "https://"+host+"/20231130/actions/chat", body, "application/json")
+	return err
+}